@@ -0,0 +1,129 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1
+
+const (
+	// DefaultDigestMemberUser is the digest user the members authenticate as
+	DefaultDigestMemberUser = "zookeeper"
+
+	// DefaultKerberosKeytabKey is the key of the keytab in its Secret
+	DefaultKerberosKeytabKey = "krb5.keytab"
+
+	// Krb5ConfKey is the key of the Kerberos configuration in its ConfigMap
+	Krb5ConfKey = "krb5.conf"
+
+	// KerberosHostPlaceholder stands for the fully qualified domain name of
+	// each member in a Kerberos principal
+	KerberosHostPlaceholder = "_HOST"
+)
+
+// AuthPolicy configures the SASL authentication of the clients and of the
+// members. At least one of digest and kerberos has to be configured.
+type AuthPolicy struct {
+	// Digest authenticates users with DIGEST-MD5
+	// +optional
+	Digest *DigestAuth `json:"digest,omitempty"`
+
+	// Kerberos authenticates the members with a keytab, and the clients
+	// through the realm of the members
+	// +optional
+	Kerberos *KerberosAuth `json:"kerberos,omitempty"`
+
+	// RequireClientAuth rejects the sessions of clients which do not
+	// authenticate through SASL. The digest credentials of the operator
+	// client are accepted as well.
+	// +optional
+	RequireClientAuth bool `json:"requireClientAuth,omitempty"`
+
+	// Quorum authenticates the members to each other, through Kerberos when
+	// configured and DIGEST-MD5 otherwise. It can only be set when the
+	// cluster is created.
+	// +optional
+	Quorum bool `json:"quorum,omitempty"`
+}
+
+// DigestAuth configures the DIGEST-MD5 users
+type DigestAuth struct {
+	// SecretName is the name of a Secret whose keys are the users and whose
+	// values are their passwords. Updating the Secret restarts the members.
+	SecretName string `json:"secretName"`
+
+	// MemberUser is the user of the Secret the members authenticate as, to
+	// each other and to register with the ensemble. Default is zookeeper.
+	// +optional
+	MemberUser string `json:"memberUser,omitempty"`
+}
+
+// KerberosAuth configures the Kerberos identity of the members
+type KerberosAuth struct {
+	// KeytabSecretName is the name of the Secret holding the keytab of the
+	// members. Updating the Secret restarts the members.
+	KeytabSecretName string `json:"keytabSecretName"`
+
+	// KeytabKey is the key of the keytab in the Secret. Default is
+	// krb5.keytab.
+	// +optional
+	KeytabKey string `json:"keytabKey,omitempty"`
+
+	// Principal is the principal of the members, in which _HOST stands for
+	// the fully qualified domain name of each member, e.g.
+	// zookeeper/_HOST@EXAMPLE.COM
+	Principal string `json:"principal"`
+
+	// Krb5ConfigMapName is the name of a ConfigMap holding the Kerberos
+	// configuration in its krb5.conf key. Default is the configuration of the
+	// image.
+	// +optional
+	Krb5ConfigMapName string `json:"krb5ConfigMapName,omitempty"`
+}
+
+func (a *AuthPolicy) withDefaults() (changed bool) {
+	if a.Digest != nil && a.Digest.MemberUser == "" {
+		a.Digest.MemberUser = DefaultDigestMemberUser
+		changed = true
+	}
+	if a.Kerberos != nil && a.Kerberos.KeytabKey == "" {
+		a.Kerberos.KeytabKey = DefaultKerberosKeytabKey
+		changed = true
+	}
+	return changed
+}
+
+// Enabled reports whether the members authenticate through SASL
+func (a *AuthPolicy) Enabled() bool {
+	return a != nil && (a.Digest != nil || a.Kerberos != nil)
+}
+
+func (a *AuthPolicy) quorumEnabled() bool {
+	return a.Enabled() && a.Quorum
+}
+
+// ACLPolicy configures the access control of the znodes
+type ACLPolicy struct {
+	// Enforce makes the members check the ACLs of the znodes, which they
+	// skip otherwise.
+	// +optional
+	Enforce bool `json:"enforce,omitempty"`
+
+	// SuperUserSecretName is the name of a Secret holding the username and
+	// the password of the super user in its username and password keys. The
+	// super user may access every znode regardless of its ACL, and is used by
+	// the operator and the scripts of the image to manage the ensemble.
+	// Required when ACLs are enforced. Updating the Secret restarts the
+	// members.
+	// +optional
+	SuperUserSecretName string `json:"superUserSecretName,omitempty"`
+}
+
+// Enforced reports whether the members check the ACLs of the znodes
+func (p *ACLPolicy) Enforced() bool {
+	return p != nil && p.Enforce
+}
@@ -0,0 +1,243 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxReconfigSteps bounds the history of reconfiguration steps in the status
+const maxReconfigSteps = 10
+
+// ReconfigAction is the change a reconfiguration step makes to the
+// membership of the ensemble
+// +kubebuilder:validation:Enum=Add;Demote;Promote;Remove;Update
+type ReconfigAction string
+
+const (
+	// ReconfigActionAdd adds a member which is not in the dynamic config
+	ReconfigActionAdd ReconfigAction = "Add"
+	// ReconfigActionDemote turns a voting member into an observer, which
+	// makes the leader hand the leadership over to a follower
+	ReconfigActionDemote ReconfigAction = "Demote"
+	// ReconfigActionPromote turns an observer into a voting member
+	ReconfigActionPromote ReconfigAction = "Promote"
+	// ReconfigActionRemove removes a member from the dynamic config
+	ReconfigActionRemove ReconfigAction = "Remove"
+	// ReconfigActionUpdate changes the client address of a member, e.g. once
+	// it only serves plaintext clients on its loopback interface
+	ReconfigActionUpdate ReconfigAction = "Update"
+)
+
+// ReconfigStepState is the outcome of a reconfiguration step
+// +kubebuilder:validation:Enum=Pending;Succeeded
+type ReconfigStepState string
+
+const (
+	// ReconfigStepPending steps have not been committed yet. They are
+	// retried on every reconciliation until they are.
+	ReconfigStepPending ReconfigStepState = "Pending"
+	// ReconfigStepSucceeded steps have been committed by the ensemble
+	ReconfigStepSucceeded ReconfigStepState = "Succeeded"
+)
+
+// ScaleDownPhase is the phase of a scale down of the ensemble
+// +kubebuilder:validation:Enum=RemovingMembers;VerifyingQuorum;Completed
+type ScaleDownPhase string
+
+const (
+	// ScaleDownRemovingMembers reconfigures the departing members out of the
+	// ensemble
+	ScaleDownRemovingMembers ScaleDownPhase = "RemovingMembers"
+	// ScaleDownVerifyingQuorum waits for the ensemble to commit the new
+	// config and for a quorum of the remaining members to serve
+	ScaleDownVerifyingQuorum ScaleDownPhase = "VerifyingQuorum"
+	// ScaleDownCompleted scale downs have shrunk the stateful set
+	ScaleDownCompleted ScaleDownPhase = "Completed"
+)
+
+// ScaleDownStatus tracks a scale down of the ensemble. The pods of the
+// departing members are only deleted once the ensemble runs without them.
+type ScaleDownStatus struct {
+	// FromReplicas is the number of members before the scale down
+	FromReplicas int32 `json:"fromReplicas"`
+
+	// ToReplicas is the number of members after the scale down
+	ToReplicas int32 `json:"toReplicas"`
+
+	// Phase is the phase the scale down is in
+	Phase ScaleDownPhase `json:"phase"`
+
+	// Message explains why the phase did not complete yet
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime is the time the scale down started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// PhaseStartTime is the time the scale down entered its phase. A phase
+	// which does not complete in time fails the scale down.
+	// +optional
+	PhaseStartTime *metav1.Time `json:"phaseStartTime,omitempty"`
+
+	// CompletionTime is the time the stateful set was shrunk
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// MembershipStatus is the membership of the ensemble, which the operator
+// changes through dynamic reconfiguration
+type MembershipStatus struct {
+	// ConfigVersion is the version of the dynamic config the operator last
+	// read, in hexadecimal
+	// +optional
+	ConfigVersion string `json:"configVersion,omitempty"`
+
+	// Participants is the number of voting members in that config
+	// +optional
+	Participants int32 `json:"participants,omitempty"`
+
+	// Observers is the number of non-voting members in that config
+	// +optional
+	Observers int32 `json:"observers,omitempty"`
+
+	// Steps are the latest reconfiguration steps, the oldest first
+	// +optional
+	Steps []ReconfigStep `json:"steps,omitempty"`
+
+	// ScaleDown is the last scale down of the ensemble
+	// +optional
+	ScaleDown *ScaleDownStatus `json:"scaleDown,omitempty"`
+}
+
+// ReconfigStep is a change to the membership of a single member
+type ReconfigStep struct {
+	// Action is the change made to the membership
+	Action ReconfigAction `json:"action"`
+
+	// Member is the name of the pod of the member
+	Member string `json:"member"`
+
+	// ServerID is the id of the member in the dynamic config
+	ServerID int32 `json:"serverId"`
+
+	// State is the outcome of the step
+	State ReconfigStepState `json:"state"`
+
+	// Attempts is the number of times the reconfiguration was sent to the
+	// ensemble
+	// +optional
+	Attempts int32 `json:"attempts,omitempty"`
+
+	// Message is the error of the last attempt, if it failed
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime is the time of the first attempt
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// LastAttemptTime is the time of the last attempt
+	// +optional
+	LastAttemptTime *metav1.Time `json:"lastAttemptTime,omitempty"`
+}
+
+// StartReconfigStep records an attempt of a reconfiguration step, resuming
+// the pending step of the same action on the member if there is one
+func (ms *MembershipStatus) StartReconfigStep(action ReconfigAction, member string, serverID int32) *ReconfigStep {
+	now := metav1.Now()
+	step := ms.PendingReconfigStep(member)
+	if step == nil || step.Action != action {
+		// a step the spec changed its mind about is dropped
+		steps := []ReconfigStep{}
+		for _, s := range ms.Steps {
+			if s.Member != member || s.State != ReconfigStepPending {
+				steps = append(steps, s)
+			}
+		}
+		ms.Steps = append(steps, ReconfigStep{
+			Action:    action,
+			Member:    member,
+			ServerID:  serverID,
+			State:     ReconfigStepPending,
+			StartTime: &now,
+		})
+		if len(ms.Steps) > maxReconfigSteps {
+			ms.Steps = ms.Steps[len(ms.Steps)-maxReconfigSteps:]
+		}
+		step = &ms.Steps[len(ms.Steps)-1]
+	}
+	step.Attempts++
+	step.LastAttemptTime = &now
+	return step
+}
+
+// PendingReconfigStep returns the step of the member which has not been
+// committed yet, if any
+func (ms *MembershipStatus) PendingReconfigStep(member string) *ReconfigStep {
+	for i := range ms.Steps {
+		if ms.Steps[i].Member == member && ms.Steps[i].State == ReconfigStepPending {
+			return &ms.Steps[i]
+		}
+	}
+	return nil
+}
+
+// Succeed records that the ensemble committed the step
+func (s *ReconfigStep) Succeed() {
+	s.State = ReconfigStepSucceeded
+	s.Message = ""
+}
+
+// Fail records the error of the last attempt, the step stays pending
+func (s *ReconfigStep) Fail(err error) {
+	s.Message = err.Error()
+}
+
+// StartScaleDown returns the scale down to the number of replicas, starting
+// it unless it is in progress already
+func (ms *MembershipStatus) StartScaleDown(from int32, to int32) *ScaleDownStatus {
+	if s := ms.ScaleDown; s != nil && s.Phase != ScaleDownCompleted && s.ToReplicas == to {
+		return s
+	}
+	now := metav1.Now()
+	ms.ScaleDown = &ScaleDownStatus{
+		FromReplicas:   from,
+		ToReplicas:     to,
+		Phase:          ScaleDownRemovingMembers,
+		StartTime:      &now,
+		PhaseStartTime: &now,
+	}
+	return ms.ScaleDown
+}
+
+// SetPhase moves the scale down to the phase
+func (s *ScaleDownStatus) SetPhase(phase ScaleDownPhase) {
+	if s.Phase == phase {
+		return
+	}
+	now := metav1.Now()
+	s.Phase = phase
+	s.PhaseStartTime = &now
+	s.Message = ""
+	if phase == ScaleDownCompleted {
+		s.CompletionTime = &now
+	}
+}
+
+// PhaseExceeded reports whether the scale down has been in its phase for
+// longer than the timeout
+func (s *ScaleDownStatus) PhaseExceeded(timeout time.Duration) bool {
+	return s.PhaseStartTime != nil && time.Since(s.PhaseStartTime.Time) > timeout
+}
@@ -0,0 +1,112 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ObserverPolicy configures a pool of observers next to the voting members.
+// Observers serve reads and forward writes to the leader without voting, so
+// they scale independently of the quorum. They run in a StatefulSet of their
+// own and share the configuration and the services of the voting members.
+type ObserverPolicy struct {
+	// Replicas is the number of observers
+	// +kubebuilder:validation:Minimum=0
+	Replicas int32 `json:"replicas"`
+
+	// ObserverMasterPort lets the followers serve the observers on this
+	// port, which takes the load of the observers off the leader. The
+	// observers connect to the leader when it is not set.
+	// +optional
+	ObserverMasterPort int32 `json:"observerMasterPort,omitempty"`
+
+	// Resources is the resource requirements of the observer container,
+	// those of spec.pod by default
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector constrains the nodes of the observers, the node selector
+	// of spec.pod is used when it is not set
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Affinity is the scheduling constraints of the observers, those of
+	// spec.pod by default
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// TopologySpreadConstraints of the observers, those of spec.pod by
+	// default
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// Tolerations of the observers, those of spec.pod by default
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// validate checks the observer master port against the ports of the members
+func (o *ObserverPolicy) validate(observersPath *field.Path, ports *Ports) field.ErrorList {
+	var errs field.ErrorList
+	if o.Replicas < 0 {
+		errs = append(errs, field.Invalid(observersPath.Child("replicas"), o.Replicas, "must not be negative"))
+	}
+	port := o.ObserverMasterPort
+	if port == 0 {
+		return errs
+	}
+	portPath := observersPath.Child("observerMasterPort")
+	if port < 1 || port > 65535 {
+		return append(errs, field.Invalid(portPath, port, "must be between 1 and 65535"))
+	}
+	// unset ports get their default value
+	defaulted := *ports
+	defaulted.withDefaults()
+	for _, p := range defaulted.ContainerPorts() {
+		if p.ContainerPort == port {
+			errs = append(errs, field.Invalid(portPath, port, fmt.Sprintf("is the %s port already", p.Name)))
+		}
+	}
+	return errs
+}
+
+// ObserversStatus is the status of the observer pool
+type ObserversStatus struct {
+	// Replicas is the number of observers
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// ReadyReplicas is the number of ready observers
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// Members are the ready and unready observers
+	// +optional
+	Members MembersStatus `json:"members,omitempty"`
+}
+
+// ObserverReplicas returns the number of observers of the cluster
+func (z *ZookeeperCluster) ObserverReplicas() int32 {
+	if z.Spec.Observers == nil {
+		return 0
+	}
+	return z.Spec.Observers.Replicas
+}
+
+// ObserverStatefulSetName returns the name of the StatefulSet of the
+// observers
+func (z *ZookeeperCluster) ObserverStatefulSetName() string {
+	return fmt.Sprintf("%s-observer", z.GetName())
+}
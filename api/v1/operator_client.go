@@ -0,0 +1,70 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1
+
+const (
+	// DigestAuthScheme authenticates a zookeeper session with a username and
+	// a password
+	DigestAuthScheme = "digest"
+
+	DigestUsernameKey = "username"
+	DigestPasswordKey = "password"
+)
+
+// OperatorClientPolicy configures how the operator itself connects to the
+// ensemble to manage the metadata of the cluster
+type OperatorClientPolicy struct {
+	// TLSSecretName is the name of a Secret holding the PEM encoded CA bundle
+	// the operator verifies the members with, in its ca.crt key, and the
+	// client certificate it presents, in its tls.crt and tls.key keys. It is
+	// only used when client TLS is enabled and defaults to the client TLS
+	// Secret of the members, which must then be in the PEM format.
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+
+	// Auth are the credentials the operator adds to its sessions
+	// +optional
+	Auth *ClientCredentials `json:"auth,omitempty"`
+}
+
+// ClientCredentials references the credentials of a zookeeper client
+type ClientCredentials struct {
+	// Scheme is the authentication scheme of the credentials. Only digest is
+	// supported, which reads the username and the password from the keys of
+	// the same name in the Secret. Default is digest.
+	// +kubebuilder:validation:Enum=digest
+	// +optional
+	Scheme string `json:"scheme,omitempty"`
+
+	// SecretName is the name of the Secret in the namespace of the cluster
+	SecretName string `json:"secretName"`
+}
+
+func (p *OperatorClientPolicy) withDefaults() (changed bool) {
+	if p.Auth != nil && p.Auth.Scheme == "" {
+		p.Auth.Scheme = DigestAuthScheme
+		changed = true
+	}
+	return changed
+}
+
+// OperatorTLSSecretName returns the Secret the operator reads its TLS key
+// material from, or an empty string when the members do not serve clients
+// over TLS
+func (z *ZookeeperCluster) OperatorTLSSecretName() string {
+	if !z.Spec.TLS.ClientEnabled() {
+		return ""
+	}
+	if p := z.Spec.OperatorClient; p != nil && p.TLSSecretName != "" {
+		return p.TLSSecretName
+	}
+	return z.Spec.TLS.Client.SecretName
+}
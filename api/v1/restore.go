@@ -0,0 +1,54 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// RestoreSource is the artifact of a ZookeeperBackup a new cluster is
+// restored from. Every member whose data volume is empty restores it before
+// it joins the ensemble.
+type RestoreSource struct {
+	// Storage is where the artifact is kept. Its prefix or path is not used,
+	// the artifact is found at path.
+	Storage BackupStorage `json:"storage"`
+
+	// Path is the path of the artifact in the bucket or on the volume, i.e.
+	// the status.location of the backup without its s3://<bucket>/ or
+	// pvc://<claim>/ part
+	Path string `json:"path"`
+
+	// Checksum is the status.checksum of the backup, as sha256:<hex>. The
+	// artifact is verified against it when set.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+}
+
+func (r *RestoreSource) withDefaults() (changed bool) {
+	return r.Storage.withDefaults()
+}
+
+func (r *RestoreSource) validate(restorePath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if err := r.Storage.Validate(); err != nil {
+		errs = append(errs, field.Invalid(restorePath.Child("storage"), "", err.Error()))
+	}
+	if r.Path == "" {
+		errs = append(errs, field.Required(restorePath.Child("path"), "the path of the artifact"))
+	}
+	if r.Checksum != "" && !strings.HasPrefix(r.Checksum, "sha256:") {
+		errs = append(errs, field.Invalid(restorePath.Child("checksum"), r.Checksum, "must be sha256:<hex>"))
+	}
+	return errs
+}
@@ -0,0 +1,486 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ClusterConditionPodsReady = "PodsReady"
+	ClusterConditionUpgrading = "Upgrading"
+	ClusterConditionError     = "Error"
+
+	// ClusterConditionQuorumAvailable is true while a majority of the voting
+	// members serves requests
+	ClusterConditionQuorumAvailable = "QuorumAvailable"
+	// ClusterConditionDegraded is true while the ensemble does not run with
+	// all of its voting members, or with another membership than requested
+	ClusterConditionDegraded = "Degraded"
+	// ClusterConditionLeaderElected is true while a member leads the ensemble
+	ClusterConditionLeaderElected = "LeaderElected"
+	// ClusterConditionRolledBack is true once the members run the last known
+	// good revision again after a failed upgrade
+	ClusterConditionRolledBack = "RolledBack"
+
+	// Reasons for cluster upgrading condition
+	UpdatingZookeeperReason = "UpdatingZookeeper"
+	UpgradeErrorReason      = "UpgradeError"
+	// UpgradePausedReason is set while spec.upgradeStrategy.paused holds the
+	// upgrade
+	UpgradePausedReason = "UpgradePaused"
+	// UnsupportedUpgradePathReason is set while the members are held on their
+	// version because the compatibility matrix does not support the upgrade
+	UnsupportedUpgradePathReason = "UnsupportedUpgradePath"
+	// BackingUpReason is set while the members wait for the backup taken
+	// before the upgrade
+	BackingUpReason = "BackingUp"
+	// PreUpgradeBackupFailedReason is set while the members are held on
+	// their version because the backup taken before the upgrade failed
+	PreUpgradeBackupFailedReason = "PreUpgradeBackupFailed"
+
+	// Reasons for cluster rolled back condition
+	RollingBackReason = "RollingBack"
+
+	// Reasons for cluster error condition
+	UpgradeFailedReason   = "UpgradeFailed"
+	ScaleDownFailedReason = "ScaleDownFailed"
+
+	// Reasons for cluster degraded condition
+	QuorumLostReason         = "QuorumLost"
+	NoRedundancyReason       = "NoRedundancy"
+	MembersUnavailableReason = "MembersUnavailable"
+	MembershipMismatchReason = "MembershipMismatch"
+)
+
+// MemberRole is the role a zookeeper member plays in the ensemble
+// +kubebuilder:validation:Enum=Leader;Follower;Observer;Standalone;Unknown
+type MemberRole string
+
+const (
+	MemberRoleLeader     MemberRole = "Leader"
+	MemberRoleFollower   MemberRole = "Follower"
+	MemberRoleObserver   MemberRole = "Observer"
+	MemberRoleStandalone MemberRole = "Standalone"
+	// MemberRoleUnknown is used for members which could not be queried or
+	// which do not serve requests at the moment, e.g. during leader election
+	MemberRoleUnknown MemberRole = "Unknown"
+)
+
+// ZookeeperClusterStatus defines the observed state of ZookeeperCluster
+type ZookeeperClusterStatus struct {
+	// ObservedGeneration is the generation of the spec the status was last
+	// computed for. The conditions carry the same generation.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Members is the zookeeper members in the cluster
+	Members MembersStatus `json:"members,omitempty"`
+
+	// Leader is the name of the member which currently leads the ensemble
+	// +optional
+	Leader string `json:"leader,omitempty"`
+
+	// MemberStatuses is the role and health of every zookeeper member, as
+	// last reported by the member itself
+	// +listType=map
+	// +listMapKey=name
+	// +optional
+	MemberStatuses []MemberStatus `json:"memberStatuses,omitempty"`
+
+	// Replicas is the number of number of desired replicas in the cluster
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// ReadyReplicas is the number of number of ready replicas in the cluster
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// Selector is the label selector of the pods of the voting members, in
+	// the string form expected by the scale subresource
+	Selector string `json:"selector,omitempty"`
+
+	// InternalClientEndpoint is the internal client IP and port
+	InternalClientEndpoint string `json:"internalClientEndpoint,omitempty"`
+
+	// ExternalClientEndpoint is the internal client IP and port
+	ExternalClientEndpoint string `json:"externalClientEndpoint,omitempty"`
+
+	MetaRootCreated bool `json:"metaRootCreated,omitempty"`
+
+	// CurrentVersion is the current cluster version
+	CurrentVersion string `json:"currentVersion,omitempty"`
+
+	TargetVersion string `json:"targetVersion,omitempty"`
+
+	// LastUpgradeProgressTime is the last time the upgrading condition changed
+	// its reason or message, which happens whenever another member has been
+	// updated. It is used to detect stalled upgrades.
+	// +optional
+	LastUpgradeProgressTime *metav1.Time `json:"lastUpgradeProgressTime,omitempty"`
+
+	// Upgrade is the progress of the last rollout of the pod template to the
+	// members
+	// +optional
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+
+	// LastKnownGood is the image and the config the members last ran with,
+	// all of them ready. Failed upgrades are rolled back to it.
+	// +optional
+	LastKnownGood *KnownGoodRevision `json:"lastKnownGood,omitempty"`
+
+	// Rollback is the rollback of the last failed upgrade. It is kept once
+	// completed, with the version of the failed upgrade.
+	// +optional
+	Rollback *RollbackStatus `json:"rollback,omitempty"`
+
+	// PreUpgradeBackup is the backup taken before the last upgrade of the
+	// image tag, see spec.upgradeStrategy.preUpgradeBackup
+	// +optional
+	PreUpgradeBackup *PreUpgradeBackupStatus `json:"preUpgradeBackup,omitempty"`
+
+	// QuorumTLS tracks the rolling restarts which turn TLS between the
+	// members on or off. It is left out while the members talk plaintext.
+	// +optional
+	QuorumTLS *QuorumTLSStatus `json:"quorumTLS,omitempty"`
+
+	// Membership is the membership of the ensemble and the latest steps the
+	// operator took to reconfigure it
+	// +optional
+	Membership MembershipStatus `json:"membership,omitempty"`
+
+	// Observers is the status of the observer pool, the observers are not
+	// part of the members above
+	// +optional
+	Observers ObserversStatus `json:"observers,omitempty"`
+
+	// Conditions list all the applied conditions
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// MembersStatus is the status of the members of the cluster with both
+// ready and unready node membership lists
+type MembersStatus struct {
+	//+nullable
+	Ready []string `json:"ready,omitempty"`
+	//+nullable
+	Unready []string `json:"unready,omitempty"`
+}
+
+// MemberStatus is the state of a single zookeeper member, as reported by the
+// srvr four letter word
+type MemberStatus struct {
+	// Name is the name of the pod of the member
+	Name string `json:"name"`
+
+	// Role is the role of the member in the ensemble
+	Role MemberRole `json:"role,omitempty"`
+
+	// Zxid is the last transaction id seen by the member, in hexadecimal
+	// +optional
+	Zxid string `json:"zxid,omitempty"`
+
+	// Epoch is the leader epoch, the upper 32 bits of the zxid
+	// +optional
+	Epoch int64 `json:"epoch,omitempty"`
+
+	// Connections is the number of client connections to the member
+	// +optional
+	Connections int32 `json:"connections,omitempty"`
+
+	// OutstandingRequests is the number of queued client requests
+	// +optional
+	OutstandingRequests int64 `json:"outstandingRequests,omitempty"`
+
+	// Latency is the min/avg/max request latency in milliseconds
+	// +optional
+	Latency string `json:"latency,omitempty"`
+
+	// LastSeenTime is the last time the member answered the operator. The
+	// other fields keep their last known values while the member does not
+	// answer.
+	// +optional
+	LastSeenTime *metav1.Time `json:"lastSeenTime,omitempty"`
+}
+
+// GetMemberStatus returns the last known status of a member
+func (zs *ZookeeperClusterStatus) GetMemberStatus(name string) *MemberStatus {
+	for i := range zs.MemberStatuses {
+		if zs.MemberStatuses[i].Name == name {
+			return &zs.MemberStatuses[i]
+		}
+	}
+	return nil
+}
+
+// DefaultConditionReason returns the reason used for a condition which has
+// been set without one, since every condition needs a reason in v1
+func DefaultConditionReason(conditionType string, status metav1.ConditionStatus) string {
+	switch status {
+	case metav1.ConditionTrue:
+		switch conditionType {
+		case ClusterConditionPodsReady:
+			return "AllPodsReady"
+		case ClusterConditionUpgrading:
+			return "UpgradeStarted"
+		case ClusterConditionQuorumAvailable:
+			return "QuorumReached"
+		}
+		return conditionType
+	case metav1.ConditionFalse:
+		switch conditionType {
+		case ClusterConditionPodsReady:
+			return "PodsNotReady"
+		case ClusterConditionUpgrading:
+			return "NotUpgrading"
+		case ClusterConditionError:
+			return "NoError"
+		case ClusterConditionQuorumAvailable:
+			return QuorumLostReason
+		case ClusterConditionDegraded:
+			return "AllMembersServing"
+		case ClusterConditionLeaderElected:
+			return "NoLeader"
+		}
+		return "Not" + conditionType
+	}
+	return "Unknown"
+}
+
+// ObserveGeneration records the generation of the spec the status is computed
+// for, on the status itself and on every condition
+func (zs *ZookeeperClusterStatus) ObserveGeneration(generation int64) {
+	zs.ObservedGeneration = generation
+	for i := range zs.Conditions {
+		zs.Conditions[i].ObservedGeneration = generation
+	}
+}
+
+func (zs *ZookeeperClusterStatus) Init() {
+	// Initialise conditions
+	conditionTypes := []string{
+		ClusterConditionPodsReady,
+		ClusterConditionUpgrading,
+		ClusterConditionError,
+	}
+	for _, conditionType := range conditionTypes {
+		if _, condition := zs.GetClusterCondition(conditionType); condition == nil {
+			zs.setClusterCondition(conditionType, metav1.ConditionFalse, "", "")
+		}
+	}
+}
+
+func (zs *ZookeeperClusterStatus) SetPodsReadyConditionTrue() {
+	zs.setClusterCondition(ClusterConditionPodsReady, metav1.ConditionTrue, "", "")
+}
+
+func (zs *ZookeeperClusterStatus) SetPodsReadyConditionFalse() {
+	zs.setClusterCondition(ClusterConditionPodsReady, metav1.ConditionFalse, "", "")
+}
+
+func (zs *ZookeeperClusterStatus) SetUpgradingConditionTrue(reason, message string) {
+	zs.setClusterCondition(ClusterConditionUpgrading, metav1.ConditionTrue, reason, message)
+}
+
+func (zs *ZookeeperClusterStatus) SetUpgradingConditionFalse() {
+	zs.setClusterCondition(ClusterConditionUpgrading, metav1.ConditionFalse, "", "")
+	zs.LastUpgradeProgressTime = nil
+}
+
+// RecordUpgradeProgress restarts the progress deadline of the upgrade while
+// the upgrading condition stays the same
+func (zs *ZookeeperClusterStatus) RecordUpgradeProgress() {
+	now := metav1.Now()
+	zs.LastUpgradeProgressTime = &now
+}
+
+// SetUpgradingConditionBlocked records why the members are held on their
+// version instead of being upgraded
+func (zs *ZookeeperClusterStatus) SetUpgradingConditionBlocked(message string) {
+	zs.SetUpgradingConditionHeld(UnsupportedUpgradePathReason, message)
+}
+
+// SetUpgradingConditionHeld records why the upgrade of the members does not
+// start yet
+func (zs *ZookeeperClusterStatus) SetUpgradingConditionHeld(reason, message string) {
+	zs.setClusterCondition(ClusterConditionUpgrading, metav1.ConditionFalse, reason, message)
+	zs.LastUpgradeProgressTime = nil
+}
+
+// IsUpgradeBlocked reports whether the members are held on their version
+// because of an unsupported upgrade path
+func (zs *ZookeeperClusterStatus) IsUpgradeBlocked() bool {
+	return zs.upgradeHeldReason() == UnsupportedUpgradePathReason
+}
+
+// IsUpgradeHeld reports whether the members are held on their version, for
+// an unsupported upgrade path or for the backup taken before the upgrade
+func (zs *ZookeeperClusterStatus) IsUpgradeHeld() bool {
+	switch zs.upgradeHeldReason() {
+	case UnsupportedUpgradePathReason, BackingUpReason, PreUpgradeBackupFailedReason:
+		return true
+	}
+	return false
+}
+
+// upgradeHeldReason returns the reason of the upgrading condition while it
+// is false
+func (zs *ZookeeperClusterStatus) upgradeHeldReason() string {
+	_, upgradeCondition := zs.GetClusterCondition(ClusterConditionUpgrading)
+	if upgradeCondition == nil || upgradeCondition.Status != metav1.ConditionFalse {
+		return ""
+	}
+	return upgradeCondition.Reason
+}
+
+func (zs *ZookeeperClusterStatus) SetErrorConditionTrue(reason, message string) {
+	zs.setClusterCondition(ClusterConditionError, metav1.ConditionTrue, reason, message)
+}
+
+func (zs *ZookeeperClusterStatus) SetErrorConditionFalse() {
+	zs.setClusterCondition(ClusterConditionError, metav1.ConditionFalse, "", "")
+}
+
+func (zs *ZookeeperClusterStatus) SetQuorumAvailableConditionTrue(message string) {
+	zs.setClusterCondition(ClusterConditionQuorumAvailable, metav1.ConditionTrue, "", message)
+}
+
+func (zs *ZookeeperClusterStatus) SetQuorumAvailableConditionFalse(message string) {
+	zs.setClusterCondition(ClusterConditionQuorumAvailable, metav1.ConditionFalse, "", message)
+}
+
+func (zs *ZookeeperClusterStatus) SetDegradedConditionTrue(reason, message string) {
+	zs.setClusterCondition(ClusterConditionDegraded, metav1.ConditionTrue, reason, message)
+}
+
+func (zs *ZookeeperClusterStatus) SetDegradedConditionFalse() {
+	zs.setClusterCondition(ClusterConditionDegraded, metav1.ConditionFalse, "", "")
+}
+
+func (zs *ZookeeperClusterStatus) SetLeaderElectedConditionTrue(leader string) {
+	zs.setClusterCondition(ClusterConditionLeaderElected, metav1.ConditionTrue, "",
+		fmt.Sprintf("%s leads the ensemble", leader))
+}
+
+func (zs *ZookeeperClusterStatus) SetLeaderElectedConditionFalse() {
+	zs.setClusterCondition(ClusterConditionLeaderElected, metav1.ConditionFalse, "", "")
+}
+
+func (zs *ZookeeperClusterStatus) SetRolledBackConditionTrue(message string) {
+	zs.setClusterCondition(ClusterConditionRolledBack, metav1.ConditionTrue, "", message)
+}
+
+func (zs *ZookeeperClusterStatus) SetRolledBackConditionFalse(reason, message string) {
+	zs.setClusterCondition(ClusterConditionRolledBack, metav1.ConditionFalse, reason, message)
+}
+
+func (zs *ZookeeperClusterStatus) GetClusterCondition(t string) (int, *metav1.Condition) {
+	for i, c := range zs.Conditions {
+		if t == c.Type {
+			return i, &c
+		}
+	}
+	return -1, nil
+}
+
+func (zs *ZookeeperClusterStatus) setClusterCondition(t string, status metav1.ConditionStatus, reason, message string) {
+	if reason == "" {
+		reason = DefaultConditionReason(t, status)
+	}
+	if t == ClusterConditionUpgrading && status == metav1.ConditionTrue {
+		_, existing := zs.GetClusterCondition(t)
+		if existing == nil || existing.Status != status || existing.Reason != reason || existing.Message != message {
+			now := metav1.Now()
+			zs.LastUpgradeProgressTime = &now
+		}
+	}
+	meta.SetStatusCondition(&zs.Conditions, metav1.Condition{
+		Type:               t,
+		Status:             status,
+		ObservedGeneration: zs.ObservedGeneration,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+func (zs *ZookeeperClusterStatus) IsClusterInUpgradeFailedState() bool {
+	_, errorCondition := zs.GetClusterCondition(ClusterConditionError)
+	if errorCondition == nil {
+		return false
+	}
+	if errorCondition.Status == metav1.ConditionTrue && errorCondition.Reason == UpgradeFailedReason {
+		return true
+	}
+	return false
+}
+
+// IsScaleDownFailed reports whether a scale down did not complete in time
+func (zs *ZookeeperClusterStatus) IsScaleDownFailed() bool {
+	_, errorCondition := zs.GetClusterCondition(ClusterConditionError)
+	return errorCondition != nil && errorCondition.Status == metav1.ConditionTrue &&
+		errorCondition.Reason == ScaleDownFailedReason
+}
+
+func (zs *ZookeeperClusterStatus) IsClusterInUpgradingState() bool {
+	_, upgradeCondition := zs.GetClusterCondition(ClusterConditionUpgrading)
+	if upgradeCondition == nil {
+		return false
+	}
+	if upgradeCondition.Status == metav1.ConditionTrue {
+		return true
+	}
+	return false
+}
+
+// IsQuorumAvailable reports whether the ensemble was serving requests when
+// its members were last queried
+func (zs *ZookeeperClusterStatus) IsQuorumAvailable() bool {
+	_, quorumCondition := zs.GetClusterCondition(ClusterConditionQuorumAvailable)
+	return quorumCondition != nil && quorumCondition.Status == metav1.ConditionTrue
+}
+
+func (zs *ZookeeperClusterStatus) IsClusterInReadyState() bool {
+	_, readyCondition := zs.GetClusterCondition(ClusterConditionPodsReady)
+	if readyCondition != nil && readyCondition.Status == metav1.ConditionTrue {
+		return true
+	}
+	return false
+}
+
+// isSafeToRestartMembers reports whether a change which restarts every member
+// of the ensemble can be rolled out without putting the quorum at risk
+func (zs *ZookeeperClusterStatus) isSafeToRestartMembers() bool {
+	if zs.IsClusterInUpgradingState() || zs.IsClusterInUpgradeFailedState() {
+		return false
+	}
+	// an ensemble which never became ready has no quorum to lose
+	return zs.IsClusterInReadyState() || zs.ReadyReplicas == 0
+}
+
+func (zs *ZookeeperClusterStatus) UpdateProgress(reason, updatedReplicas string) {
+	if zs.IsClusterInUpgradingState() {
+		// Set the upgrade condition reason to be UpgradingZookeeperReason, message to be the upgradedReplicas
+		zs.SetUpgradingConditionTrue(reason, updatedReplicas)
+	}
+}
+
+func (zs *ZookeeperClusterStatus) GetLastCondition() (lastCondition *metav1.Condition) {
+	if zs.IsClusterInUpgradingState() {
+		_, lastCondition := zs.GetClusterCondition(ClusterConditionUpgrading)
+		return lastCondition
+	}
+	// nothing to do if we are not upgrading
+	return nil
+}
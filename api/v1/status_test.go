@@ -0,0 +1,247 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1_test
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/pravega/zookeeper-operator/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ZookeeperCluster Status", func() {
+	var zs v1.ZookeeperClusterStatus
+
+	BeforeEach(func() {
+		zs = v1.ZookeeperClusterStatus{}
+	})
+
+	Context("with no conditions", func() {
+		It("should not be upgrading or failed", func() {
+			Ω(zs.IsClusterInUpgradingState()).To(BeFalse())
+			Ω(zs.IsClusterInUpgradeFailedState()).To(BeFalse())
+			Ω(zs.IsClusterInReadyState()).To(BeFalse())
+		})
+	})
+
+	Context("#Init", func() {
+		BeforeEach(func() {
+			zs.Init()
+		})
+
+		It("should set every condition to false with a reason", func() {
+			Ω(zs.Conditions).To(HaveLen(3))
+			for _, c := range zs.Conditions {
+				Ω(c.Status).To(Equal(metav1.ConditionFalse))
+				Ω(c.Reason).To(Equal(v1.DefaultConditionReason(c.Type, metav1.ConditionFalse)))
+				Ω(c.LastTransitionTime.IsZero()).To(BeFalse())
+			}
+		})
+
+		It("should keep existing conditions", func() {
+			zs.SetPodsReadyConditionTrue()
+			zs.Init()
+			Ω(zs.IsClusterInReadyState()).To(BeTrue())
+		})
+	})
+
+	Context("Upgrading condition", func() {
+		BeforeEach(func() {
+			zs.Init()
+			zs.SetUpgradingConditionTrue("", "")
+		})
+
+		It("should be upgrading and record the progress time", func() {
+			Ω(zs.IsClusterInUpgradingState()).To(BeTrue())
+			_, c := zs.GetClusterCondition(v1.ClusterConditionUpgrading)
+			Ω(c.Reason).To(Equal("UpgradeStarted"))
+			Ω(zs.LastUpgradeProgressTime).NotTo(BeNil())
+		})
+
+		It("should record progress when the message changes", func() {
+			zs.LastUpgradeProgressTime = &metav1.Time{}
+			zs.UpdateProgress(v1.UpdatingZookeeperReason, "1")
+			Ω(zs.LastUpgradeProgressTime.IsZero()).To(BeFalse())
+			Ω(zs.GetLastCondition().Message).To(Equal("1"))
+		})
+
+		It("should not record progress when nothing changed", func() {
+			zs.UpdateProgress(v1.UpdatingZookeeperReason, "1")
+			zs.LastUpgradeProgressTime = &metav1.Time{}
+			zs.UpdateProgress(v1.UpdatingZookeeperReason, "1")
+			Ω(zs.LastUpgradeProgressTime.IsZero()).To(BeTrue())
+		})
+
+		It("should clear the progress time when the upgrade ends", func() {
+			zs.SetUpgradingConditionFalse()
+			Ω(zs.IsClusterInUpgradingState()).To(BeFalse())
+			Ω(zs.LastUpgradeProgressTime).To(BeNil())
+			Ω(zs.GetLastCondition()).To(BeNil())
+		})
+	})
+
+	Context("#ObserveGeneration", func() {
+		It("should record the generation on the status and every condition", func() {
+			zs.Init()
+			zs.ObserveGeneration(4)
+			Ω(zs.ObservedGeneration).To(BeEquivalentTo(4))
+			for _, c := range zs.Conditions {
+				Ω(c.ObservedGeneration).To(BeEquivalentTo(4))
+			}
+			zs.SetQuorumAvailableConditionTrue("3 of 3 voting members are serving")
+			_, c := zs.GetClusterCondition(v1.ClusterConditionQuorumAvailable)
+			Ω(c.ObservedGeneration).To(BeEquivalentTo(4))
+			Ω(c.Reason).To(Equal("QuorumReached"))
+			Ω(zs.IsQuorumAvailable()).To(BeTrue())
+		})
+	})
+
+	Context("Error condition", func() {
+		BeforeEach(func() {
+			zs.Init()
+		})
+
+		It("should be failed only for the upgrade failed reason", func() {
+			zs.SetErrorConditionTrue("SomeError", "")
+			Ω(zs.IsClusterInUpgradeFailedState()).To(BeFalse())
+			zs.SetErrorConditionTrue(v1.UpgradeFailedReason, "failed")
+			Ω(zs.IsClusterInUpgradeFailedState()).To(BeTrue())
+			zs.SetErrorConditionFalse()
+			Ω(zs.IsClusterInUpgradeFailedState()).To(BeFalse())
+		})
+	})
+
+	Context("Reconfiguration steps", func() {
+		var ms *v1.MembershipStatus
+
+		BeforeEach(func() {
+			ms = &zs.Membership
+		})
+
+		It("should resume the pending step of a member", func() {
+			step := ms.StartReconfigStep(v1.ReconfigActionAdd, "example-3", 4)
+			step.Fail(fmt.Errorf("no quorum"))
+			step = ms.StartReconfigStep(v1.ReconfigActionAdd, "example-3", 4)
+			Ω(ms.Steps).To(HaveLen(1))
+			Ω(step.Attempts).To(BeEquivalentTo(2))
+			Ω(step.Message).To(Equal("no quorum"))
+			step.Succeed()
+			Ω(step.Message).To(BeEmpty())
+			Ω(ms.PendingReconfigStep("example-3")).To(BeNil())
+		})
+
+		It("should replace a pending step of another action", func() {
+			ms.StartReconfigStep(v1.ReconfigActionAdd, "example-3", 4)
+			ms.StartReconfigStep(v1.ReconfigActionRemove, "example-3", 4)
+			Ω(ms.Steps).To(HaveLen(1))
+			Ω(ms.PendingReconfigStep("example-3").Action).To(Equal(v1.ReconfigActionRemove))
+		})
+
+		It("should keep the latest steps only", func() {
+			for i := 0; i < 12; i++ {
+				ms.StartReconfigStep(v1.ReconfigActionAdd, fmt.Sprintf("example-%d", i), int32(i+1)).Succeed()
+			}
+			Ω(ms.Steps).To(HaveLen(10))
+			Ω(ms.Steps[0].Member).To(Equal("example-2"))
+		})
+	})
+
+	Context("Scale down", func() {
+		var ms *v1.MembershipStatus
+
+		BeforeEach(func() {
+			ms = &v1.MembershipStatus{}
+		})
+
+		It("should resume the scale down in progress", func() {
+			scale := ms.StartScaleDown(5, 3)
+			Ω(scale.Phase).To(Equal(v1.ScaleDownRemovingMembers))
+			scale.Message = "no quorum"
+			scale.SetPhase(v1.ScaleDownVerifyingQuorum)
+			Ω(scale.Message).To(BeEmpty())
+			Ω(ms.StartScaleDown(5, 3).Phase).To(Equal(v1.ScaleDownVerifyingQuorum))
+			Ω(scale.PhaseExceeded(time.Minute)).To(BeFalse())
+		})
+
+		It("should start over for another size or after completion", func() {
+			ms.StartScaleDown(5, 3).SetPhase(v1.ScaleDownVerifyingQuorum)
+			Ω(ms.StartScaleDown(5, 1).Phase).To(Equal(v1.ScaleDownRemovingMembers))
+			ms.ScaleDown.SetPhase(v1.ScaleDownCompleted)
+			Ω(ms.ScaleDown.CompletionTime).NotTo(BeNil())
+			Ω(ms.StartScaleDown(5, 1).CompletionTime).To(BeNil())
+		})
+	})
+
+	Context("Upgrade", func() {
+		var zs *v1.ZookeeperClusterStatus
+
+		BeforeEach(func() {
+			zs = &v1.ZookeeperClusterStatus{}
+		})
+
+		It("should resume the rollout of the revision", func() {
+			u := zs.StartUpgrade("rev-2", 2)
+			Ω(u.Phase).To(Equal(v1.UpgradeRollingOut))
+			Ω(u.StartTime).NotTo(BeNil())
+			u.Partition = 1
+			Ω(zs.StartUpgrade("rev-2", 2).Partition).To(BeEquivalentTo(1))
+			Ω(zs.StartUpgrade("rev-3", 2).Partition).To(BeEquivalentTo(2))
+		})
+
+		It("should keep the gates while verifying only", func() {
+			u := zs.StartUpgrade("rev-2", 2)
+			u.SetPhase(v1.UpgradeVerifying)
+			now := metav1.Now()
+			u.GateStartTime = &now
+			u.GateErrors = 3
+			u.Message = "soaking"
+			u.SetPhase(v1.UpgradeVerifying)
+			Ω(u.GateStartTime).NotTo(BeNil())
+			Ω(u.Message).To(Equal("soaking"))
+			u.SetPhase(v1.UpgradePaused)
+			Ω(u.GateStartTime).To(BeNil())
+			Ω(u.GateErrors).To(BeZero())
+			Ω(u.Message).To(BeEmpty())
+		})
+
+		It("should record a known good revision once", func() {
+			image := v1.ContainerImage{Repository: "pravega/zookeeper", Tag: "0.2.15"}
+			zs.RecordKnownGood("rev-1", image, v1.ZookeeperConfig{TickTime: 2000})
+			recorded := zs.LastKnownGood.RecordTime
+			zs.RecordKnownGood("rev-1", image, v1.ZookeeperConfig{TickTime: 2000})
+			Ω(zs.LastKnownGood.RecordTime).To(BeIdenticalTo(recorded))
+			zs.RecordKnownGood("rev-2", image, v1.ZookeeperConfig{TickTime: 3000})
+			Ω(zs.LastKnownGood.Conf.TickTime).To(Equal(3000))
+		})
+
+		It("should roll back to the known good revision", func() {
+			zs.RecordKnownGood("rev-1", v1.ContainerImage{Tag: "0.2.15"}, v1.ZookeeperConfig{})
+			rb := zs.StartRollback("0.2.16", v1.ContainerImage{Tag: "0.2.16"}, "hash", "progress deadline exceeded")
+			Ω(rb.Revision).To(Equal("rev-1"))
+			Ω(zs.IsRollingBack()).To(BeTrue())
+			rb.Complete()
+			Ω(zs.IsRollingBack()).To(BeFalse())
+			Ω(rb.CompletionTime).NotTo(BeNil())
+		})
+
+		It("should start over after completion", func() {
+			u := zs.StartUpgrade("rev-2", 2)
+			u.SetPhase(v1.UpgradeCompleted)
+			Ω(u.Partition).To(BeZero())
+			Ω(u.CompletionTime).NotTo(BeNil())
+			Ω(zs.StartUpgrade("rev-2", 2).CompletionTime).To(BeNil())
+		})
+	})
+})
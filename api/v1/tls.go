@@ -0,0 +1,229 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1
+
+// TLSFormat is the format of the key material in a TLS Secret
+// +kubebuilder:validation:Enum=PEM;PKCS12
+type TLSFormat string
+
+const (
+	// TLSFormatPEM reads the certificate, the private key and the CA bundle
+	// from the tls.crt, tls.key and ca.crt keys of the Secret, as found in
+	// the Secrets issued by cert-manager
+	TLSFormatPEM TLSFormat = "PEM"
+
+	// TLSFormatPKCS12 reads the keystore and the truststore from the
+	// keystore.p12 and truststore.p12 keys of the Secret, and the password
+	// of both from its password key
+	TLSFormatPKCS12 TLSFormat = "PKCS12"
+
+	TLSCertKey          = "tls.crt"
+	TLSPrivateKeyKey    = "tls.key"
+	TLSCAKey            = "ca.crt"
+	PKCS12KeystoreKey   = "keystore.p12"
+	PKCS12TruststoreKey = "truststore.p12"
+	PKCS12PasswordKey   = "password"
+
+	// DefaultSecureClientPort is the default port zookeeper serves TLS
+	// clients on
+	DefaultSecureClientPort = 2281
+)
+
+// TLSPolicy configures TLS for the connections to the zookeeper members
+type TLSPolicy struct {
+	// Client enables TLS for client connections, which are then served on
+	// the secure client port. The plaintext client port is only served on
+	// the loopback interface of the members, for their probes, unless
+	// plaintext clients are explicitly allowed.
+	// +optional
+	Client *ClientTLS `json:"client,omitempty"`
+
+	// Quorum enables TLS between the members, on the quorum and the leader
+	// election ports. Turning it on or off for a running ensemble takes three
+	// rolling restarts, which are tracked in the quorumTLS status.
+	// +optional
+	Quorum *TLSSecret `json:"quorum,omitempty"`
+}
+
+// TLSSecret references the Secret holding the key material of the members
+type TLSSecret struct {
+	// SecretName is the name of the Secret in the namespace of the cluster.
+	// Every member uses the same key material, so the certificate must be
+	// valid for the client service as well as for the member addresses.
+	// Updating the Secret restarts the members.
+	SecretName string `json:"secretName"`
+
+	// Format is the format of the key material, either PEM or PKCS12.
+	// Default is PEM.
+	// +optional
+	Format TLSFormat `json:"format,omitempty"`
+}
+
+// ClientTLS configures TLS for client connections
+type ClientTLS struct {
+	TLSSecret `json:",inline"`
+
+	// ClientAuth is whether clients have to present a certificate the CA
+	// bundle trusts, one of none, want or need. Zookeeper requires one unless
+	// configured otherwise.
+	// +kubebuilder:validation:Enum=none;want;need
+	// +optional
+	ClientAuth string `json:"clientAuth,omitempty"`
+
+	// AllowPlaintext keeps serving clients on the plaintext client port as
+	// well, e.g. while the clients are moved over to TLS.
+	// +optional
+	AllowPlaintext bool `json:"allowPlaintext,omitempty"`
+}
+
+// QuorumTLSPhase is the step of the rolling procedure which turns TLS
+// between the members on or off without losing the quorum. Members only
+// talk to peers in the same or an adjacent phase.
+// +kubebuilder:validation:Enum=Disabled;PortUnification;TLSWithPortUnification;Enabled
+type QuorumTLSPhase string
+
+const (
+	// QuorumTLSDisabled members only talk plaintext to each other
+	QuorumTLSDisabled QuorumTLSPhase = "Disabled"
+
+	// QuorumTLSPortUnification members accept TLS as well as plaintext
+	// connections from their peers, but still connect in plaintext
+	QuorumTLSPortUnification QuorumTLSPhase = "PortUnification"
+
+	// QuorumTLSWithPortUnification members connect to their peers over TLS,
+	// but still accept plaintext connections
+	QuorumTLSWithPortUnification QuorumTLSPhase = "TLSWithPortUnification"
+
+	// QuorumTLSEnabled members only talk TLS to each other
+	QuorumTLSEnabled QuorumTLSPhase = "Enabled"
+)
+
+var quorumTLSPhases = []QuorumTLSPhase{
+	QuorumTLSDisabled,
+	QuorumTLSPortUnification,
+	QuorumTLSWithPortUnification,
+	QuorumTLSEnabled,
+}
+
+// Next returns the phase which follows this one on the way to quorum TLS
+// being enabled or disabled
+func (p QuorumTLSPhase) Next(enable bool) QuorumTLSPhase {
+	for i, phase := range quorumTLSPhases {
+		if phase != p {
+			continue
+		}
+		if enable && i < len(quorumTLSPhases)-1 {
+			return quorumTLSPhases[i+1]
+		}
+		if !enable && i > 0 {
+			return quorumTLSPhases[i-1]
+		}
+		return p
+	}
+	// an unknown phase starts over from the end the members are leaving
+	if enable {
+		return QuorumTLSPortUnification
+	}
+	return QuorumTLSWithPortUnification
+}
+
+// QuorumTLSStatus is the state of TLS between the members
+type QuorumTLSStatus struct {
+	// Phase is the phase of the rolling procedure the members are in, or
+	// are being restarted into.
+	Phase QuorumTLSPhase `json:"phase,omitempty"`
+
+	// Secret is the Secret the members read their quorum key material from.
+	// It is kept until TLS is off on every member, even once the quorum TLS
+	// has been removed from the spec.
+	// +optional
+	Secret *TLSSecret `json:"secret,omitempty"`
+}
+
+func (t *TLSPolicy) withDefaults() (changed bool) {
+	if t.Client != nil && t.Client.withDefaults() {
+		changed = true
+	}
+	if t.Quorum != nil && t.Quorum.withDefaults() {
+		changed = true
+	}
+	return changed
+}
+
+func (s *TLSSecret) withDefaults() (changed bool) {
+	if s.Format == "" {
+		s.Format = TLSFormatPEM
+		changed = true
+	}
+	return changed
+}
+
+// ClientEnabled reports whether client connections are served over TLS
+func (t *TLSPolicy) ClientEnabled() bool {
+	return t != nil && t.Client != nil
+}
+
+// ServesPlaintextClients reports whether the members serve clients on the
+// plaintext client port beyond their loopback interface
+func (t *TLSPolicy) ServesPlaintextClients() bool {
+	return !t.ClientEnabled() || t.Client.AllowPlaintext
+}
+
+// QuorumEnabled reports whether the members should talk TLS to each other
+func (t *TLSPolicy) QuorumEnabled() bool {
+	return t != nil && t.Quorum != nil
+}
+
+// Secrets returns the TLS Secrets the members read their key material from
+func (t *TLSPolicy) Secrets() []TLSSecret {
+	var secrets []TLSSecret
+	if t.ClientEnabled() {
+		secrets = append(secrets, t.Client.TLSSecret)
+	}
+	if t.QuorumEnabled() {
+		secrets = append(secrets, *t.Quorum)
+	}
+	return secrets
+}
+
+// QuorumTLSPhase returns the quorum TLS phase the members are configured
+// for. Without a phase in the status, a new ensemble starts right away with
+// the quorum TLS of its spec.
+func (z *ZookeeperCluster) QuorumTLSPhase() QuorumTLSPhase {
+	if status := z.Status.QuorumTLS; status != nil && status.Phase != "" {
+		return status.Phase
+	}
+	if z.Spec.TLS.QuorumEnabled() {
+		return QuorumTLSEnabled
+	}
+	return QuorumTLSDisabled
+}
+
+// QuorumTLSSecret returns the Secret the members read their quorum key
+// material from, which outlives the quorum TLS in the spec while it is
+// being disabled
+func (z *ZookeeperCluster) QuorumTLSSecret() *TLSSecret {
+	if status := z.Status.QuorumTLS; status != nil && status.Secret != nil {
+		return status.Secret
+	}
+	if !z.Spec.TLS.QuorumEnabled() {
+		return nil
+	}
+	return z.Spec.TLS.Quorum
+}
+
+// RequiredKeys returns the keys the Secret must hold for its format
+func (s *TLSSecret) RequiredKeys() []string {
+	if s.Format == TLSFormatPKCS12 {
+		return []string{PKCS12KeystoreKey, PKCS12TruststoreKey, PKCS12PasswordKey}
+	}
+	return []string{TLSCertKey, TLSPrivateKeyKey, TLSCAKey}
+}
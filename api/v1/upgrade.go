@@ -0,0 +1,534 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// DefaultUpgradeBatchSize is the default number of members upgraded
+	// between two runs of the health gates
+	DefaultUpgradeBatchSize = 1
+
+	// DefaultUpgradeSoakSeconds is the default time the upgraded members
+	// have to pass the health gates for
+	DefaultUpgradeSoakSeconds = 60
+
+	// DefaultUpgradeProgressDeadlineSeconds is the default time an upgrade
+	// may go without progress before it fails
+	DefaultUpgradeProgressDeadlineSeconds = 600
+)
+
+// DefaultUpgradeErrorMetrics are the counters of the metrics of zookeeper
+// which the health gates watch by default
+var DefaultUpgradeErrorMetrics = []string{"unsuccessful_handshake", "digest_mismatches_count"}
+
+// UpgradeStrategy rolls a new pod template out to the members in stages. A
+// single canary member is upgraded first, the other members follow in batches
+// once the health gates passed on the members upgraded so far. The members
+// are still restarted one at a time, the followers first and the leader last.
+//
+// The StatefulSet of the members keeps the OnDelete strategy, and the operator
+// tracks the partition of the rollout in status.upgrade instead of setting
+// RollingUpdate.Partition. The StatefulSet controller would restart the pods
+// at or above the partition in the order of their ordinals, as soon as they
+// are ready, whereas the operator waits for every member to sync with the
+// leader, restarts the leader last and hands its leadership over first.
+type UpgradeStrategy struct {
+	// BatchSize is the number of members upgraded after the canary, and after
+	// every later batch, before the health gates run again. Default is 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	BatchSize int32 `json:"batchSize,omitempty"`
+
+	// Paused holds the upgrade once the member being restarted is back. The
+	// upgrade carries on where it stopped when it is unpaused.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// ProgressDeadlineSeconds is how long the upgrade may go without progress
+	// before it fails. A member which runs the new revision and catches up
+	// with the leader makes progress, a member crash looping on it fails the
+	// upgrade right away. Default is 600.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ProgressDeadlineSeconds int32 `json:"progressDeadlineSeconds,omitempty"`
+
+	// HealthGates are the checks the upgraded members have to pass before the
+	// next batch is upgraded
+	// +optional
+	HealthGates UpgradeHealthGates `json:"healthGates,omitempty"`
+
+	// AutoRollback reverts the members to the image and the config they last
+	// ran with, all of them ready, once an upgrade fails. The members stay on
+	// them until the image or the config of the spec changes again.
+	// +optional
+	AutoRollback bool `json:"autoRollback,omitempty"`
+
+	// VersionSkewPolicy is what happens to an upgrade which the compatibility
+	// matrix does not support, i.e. a downgrade of ZooKeeper or an upgrade
+	// across more than one minor release. Block holds the members on their
+	// version, Warn lets the upgrade through. Default is Block.
+	// +optional
+	VersionSkewPolicy VersionSkewPolicy `json:"versionSkewPolicy,omitempty"`
+
+	// ZookeeperVersions maps the tags of custom images to the ZooKeeper
+	// release they ship, extending the compatibility matrix of the operator
+	// +optional
+	ZookeeperVersions map[string]string `json:"zookeeperVersions,omitempty"`
+
+	// PreUpgradeBackup takes a backup of the ensemble before the members are
+	// upgraded to another image tag
+	// +optional
+	PreUpgradeBackup *PreUpgradeBackup `json:"preUpgradeBackup,omitempty"`
+}
+
+// PreUpgradeBackup is the backup taken before every upgrade of the image tag.
+// The members keep their version until the backup succeeded, an upgrade whose
+// backup failed does not start.
+type PreUpgradeBackup struct {
+	// Storage is where the artifacts of the backups are kept
+	Storage BackupStorage `json:"storage"`
+
+	// ReclaimPolicy is what happens to the artifact when its backup is
+	// deleted. It is kept with Retain, which is the default, and deleted
+	// from the storage with Delete.
+	// +kubebuilder:validation:Enum="Delete";"Retain"
+	// +optional
+	ReclaimPolicy VolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+}
+
+// UpgradeHealthGates are the checks run on the upgraded members. Every member
+// has to serve with a quorum and be caught up with the leader, and the
+// upgraded members must not count more errors than allowed, for the whole
+// soak time.
+type UpgradeHealthGates struct {
+	// SoakSeconds is how long the gates have to pass for. Default is 60.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	SoakSeconds int32 `json:"soakSeconds,omitempty"`
+
+	// ErrorMetrics are the counters of the Prometheus metrics of the members
+	// which count errors. Default are unsuccessful_handshake and
+	// digest_mismatches_count.
+	// +optional
+	ErrorMetrics []string `json:"errorMetrics,omitempty"`
+
+	// MaxErrorsPerMinute is the rate of errors, summed across the error
+	// metrics of the upgraded members, above which the gates fail. Default is
+	// 0, no error is tolerated.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxErrorsPerMinute int32 `json:"maxErrorsPerMinute,omitempty"`
+}
+
+func (u *UpgradeStrategy) withDefaults() (changed bool) {
+	if u.BatchSize < 1 {
+		u.BatchSize = DefaultUpgradeBatchSize
+		changed = true
+	}
+	if u.ProgressDeadlineSeconds < 1 {
+		u.ProgressDeadlineSeconds = DefaultUpgradeProgressDeadlineSeconds
+		changed = true
+	}
+	if u.HealthGates.SoakSeconds < 1 {
+		u.HealthGates.SoakSeconds = DefaultUpgradeSoakSeconds
+		changed = true
+	}
+	if len(u.HealthGates.ErrorMetrics) == 0 {
+		u.HealthGates.ErrorMetrics = append([]string{}, DefaultUpgradeErrorMetrics...)
+		changed = true
+	}
+	if b := u.PreUpgradeBackup; b != nil {
+		if !b.ReclaimPolicy.isValid() {
+			b.ReclaimPolicy = VolumeReclaimPolicyRetain
+			changed = true
+		}
+		if b.Storage.withDefaults() {
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (u *UpgradeStrategy) validate(upgradePath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if u.BatchSize < 0 {
+		errs = append(errs, field.Invalid(upgradePath.Child("batchSize"), u.BatchSize, "must not be negative"))
+	}
+	if u.ProgressDeadlineSeconds < 0 {
+		errs = append(errs, field.Invalid(upgradePath.Child("progressDeadlineSeconds"), u.ProgressDeadlineSeconds, "must not be negative"))
+	}
+	gatesPath := upgradePath.Child("healthGates")
+	if u.HealthGates.SoakSeconds < 0 {
+		errs = append(errs, field.Invalid(gatesPath.Child("soakSeconds"), u.HealthGates.SoakSeconds, "must not be negative"))
+	}
+	if u.HealthGates.MaxErrorsPerMinute < 0 {
+		errs = append(errs, field.Invalid(gatesPath.Child("maxErrorsPerMinute"), u.HealthGates.MaxErrorsPerMinute, "must not be negative"))
+	}
+	for i, metric := range u.HealthGates.ErrorMetrics {
+		if metric == "" {
+			errs = append(errs, field.Required(gatesPath.Child("errorMetrics").Index(i), "the name of a counter"))
+		}
+	}
+	errs = append(errs, u.validateVersions(upgradePath.Child("zookeeperVersions"))...)
+	if b := u.PreUpgradeBackup; b != nil {
+		if err := b.Storage.Validate(); err != nil {
+			errs = append(errs, field.Invalid(upgradePath.Child("preUpgradeBackup", "storage"), "", err.Error()))
+		}
+	}
+	return errs
+}
+
+// IsPaused reports whether the upgrades of the cluster are on hold
+func (u *UpgradeStrategy) IsPaused() bool {
+	return u != nil && u.Paused
+}
+
+// ProgressDeadline returns how long an upgrade may go without progress
+func (u *UpgradeStrategy) ProgressDeadline() time.Duration {
+	if u == nil || u.ProgressDeadlineSeconds < 1 {
+		return DefaultUpgradeProgressDeadlineSeconds * time.Second
+	}
+	return time.Duration(u.ProgressDeadlineSeconds) * time.Second
+}
+
+// BacksUp returns the backup taken before the upgrades, if any
+func (u *UpgradeStrategy) BacksUp() *PreUpgradeBackup {
+	if u == nil {
+		return nil
+	}
+	return u.PreUpgradeBackup
+}
+
+// RollsBack reports whether failed upgrades are rolled back
+func (u *UpgradeStrategy) RollsBack() bool {
+	return u != nil && u.AutoRollback
+}
+
+// UpgradePhase is the phase of the rollout of a pod template to the members
+// +kubebuilder:validation:Enum=RollingOut;Verifying;Paused;Completed
+type UpgradePhase string
+
+const (
+	// UpgradeRollingOut restarts the members up to the partition
+	UpgradeRollingOut UpgradePhase = "RollingOut"
+	// UpgradeVerifying runs the health gates on the upgraded members before
+	// the partition is lowered to the next batch
+	UpgradeVerifying UpgradePhase = "Verifying"
+	// UpgradePaused upgrades are held by spec.upgradeStrategy.paused
+	UpgradePaused UpgradePhase = "Paused"
+	// UpgradeCompleted upgrades run the revision on every member
+	UpgradeCompleted UpgradePhase = "Completed"
+)
+
+// UpgradeStatus is the progress of the rollout of a pod template to the
+// members
+type UpgradeStatus struct {
+	// Revision is the revision of the StatefulSet being rolled out
+	Revision string `json:"revision"`
+
+	// Phase is the phase the rollout is in
+	Phase UpgradePhase `json:"phase"`
+
+	// Partition is the lowest ordinal which may be upgraded. The members
+	// below it keep their revision until the health gates pass on the members
+	// above it.
+	Partition int32 `json:"partition"`
+
+	// UpdatedReplicas is the number of members which run the revision
+	// +optional
+	UpdatedReplicas int32 `json:"updatedReplicas,omitempty"`
+
+	// Message explains why the rollout does not progress
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime is the time the rollout started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// GateStartTime is the time the upgraded members started to pass the
+	// health gates
+	// +optional
+	GateStartTime *metav1.Time `json:"gateStartTime,omitempty"`
+
+	// GateErrors is the count of the error metrics of the upgraded members
+	// at the gate start time
+	// +optional
+	GateErrors int64 `json:"gateErrors,omitempty"`
+
+	// CompletionTime is the time every member ran the revision
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Members is the progress of every member towards the revision
+	// +optional
+	Members []MemberUpgradeStatus `json:"members,omitempty"`
+
+	// Restarting is the leader which handed its leadership over before its
+	// restart. It stays an observer until its pod runs the revision.
+	// +optional
+	Restarting string `json:"restarting,omitempty"`
+}
+
+// MemberUpgradePhase is the progress of a single member towards the revision
+// being rolled out
+// +kubebuilder:validation:Enum=Pending;Restarting;Syncing;Updated;Failed
+type MemberUpgradePhase string
+
+const (
+	// MemberUpgradePending members run the previous revision
+	MemberUpgradePending MemberUpgradePhase = "Pending"
+	// MemberUpgradeRestarting members are being restarted, zookeeper does not
+	// run yet
+	MemberUpgradeRestarting MemberUpgradePhase = "Restarting"
+	// MemberUpgradeSyncing members run the revision and catch up with the
+	// leader before they are ready
+	MemberUpgradeSyncing MemberUpgradePhase = "Syncing"
+	// MemberUpgradeUpdated members run the revision and are ready
+	MemberUpgradeUpdated MemberUpgradePhase = "Updated"
+	// MemberUpgradeFailed members crash loop on the revision
+	MemberUpgradeFailed MemberUpgradePhase = "Failed"
+)
+
+// MemberUpgradeStatus is the progress of a single member towards the revision
+// being rolled out
+type MemberUpgradeStatus struct {
+	// Name is the name of the pod of the member
+	Name string `json:"name"`
+
+	// Phase is the phase the member is in
+	Phase MemberUpgradePhase `json:"phase"`
+
+	// Restarts is the number of restarts of the zookeeper container of the
+	// member on the revision
+	// +optional
+	Restarts int32 `json:"restarts,omitempty"`
+
+	// Message explains why the member failed
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// StartUpgrade returns the rollout of the revision, starting it with the
+// partition unless it is in progress already
+func (zs *ZookeeperClusterStatus) StartUpgrade(revision string, partition int32) *UpgradeStatus {
+	if u := zs.Upgrade; u != nil && u.Revision == revision && u.Phase != UpgradeCompleted {
+		return u
+	}
+	now := metav1.Now()
+	zs.Upgrade = &UpgradeStatus{
+		Revision:  revision,
+		Phase:     UpgradeRollingOut,
+		Partition: partition,
+		StartTime: &now,
+	}
+	return zs.Upgrade
+}
+
+// SetPhase moves the rollout to the phase. The gates start over whenever the
+// rollout leaves the Verifying phase.
+func (u *UpgradeStatus) SetPhase(phase UpgradePhase) {
+	if u.Phase == phase {
+		return
+	}
+	u.Phase = phase
+	u.Message = ""
+	if phase != UpgradeVerifying {
+		u.ResetGates()
+	}
+	if phase == UpgradeCompleted {
+		now := metav1.Now()
+		u.Partition = 0
+		u.CompletionTime = &now
+	}
+}
+
+// ResetGates starts the health gates over
+func (u *UpgradeStatus) ResetGates() {
+	u.GateStartTime = nil
+	u.GateErrors = 0
+}
+
+// KnownGoodRevision is the image and the config the members last ran with,
+// every member running the same revision and ready
+type KnownGoodRevision struct {
+	// Revision is the revision of the StatefulSet the members ran
+	Revision string `json:"revision"`
+
+	// Image is the image the members ran
+	Image ContainerImage `json:"image"`
+
+	// Conf is the zookeeper configuration the members ran with
+	// +optional
+	Conf ZookeeperConfig `json:"config,omitempty"`
+
+	// RecordTime is the time the revision was recorded
+	// +optional
+	RecordTime *metav1.Time `json:"recordTime,omitempty"`
+}
+
+// RecordKnownGood records the revision as known good, unless it is already
+func (zs *ZookeeperClusterStatus) RecordKnownGood(revision string, image ContainerImage, conf ZookeeperConfig) {
+	if good := zs.LastKnownGood; good != nil && good.Revision == revision && good.Image == image {
+		return
+	}
+	now := metav1.Now()
+	zs.LastKnownGood = &KnownGoodRevision{
+		Revision:   revision,
+		Image:      image,
+		Conf:       *conf.DeepCopy(),
+		RecordTime: &now,
+	}
+}
+
+// RollbackPhase is the phase of the rollback of a failed upgrade
+// +kubebuilder:validation:Enum=RollingBack;RolledBack
+type RollbackPhase string
+
+const (
+	// RollbackRollingBack restarts the members back to the last known good
+	// revision
+	RollbackRollingBack RollbackPhase = "RollingBack"
+	// RollbackRolledBack members run the last known good revision again
+	RollbackRolledBack RollbackPhase = "RolledBack"
+)
+
+// RollbackStatus is the rollback of a failed upgrade to the last known good
+// revision
+type RollbackStatus struct {
+	// Phase is the phase the rollback is in
+	Phase RollbackPhase `json:"phase"`
+
+	// FailedVersion is the target version of the failed upgrade
+	// +optional
+	FailedVersion string `json:"failedVersion,omitempty"`
+
+	// FailedImage is the image of the failed upgrade
+	FailedImage ContainerImage `json:"failedImage"`
+
+	// FailedConfigHash is the hash of the zoo.cfg of the failed upgrade. The
+	// members are held on the last known good revision while the spec asks
+	// for the image and the config of the failed upgrade.
+	// +optional
+	FailedConfigHash string `json:"failedConfigHash,omitempty"`
+
+	// Reason is why the upgrade failed
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Revision is the known good revision the members are rolled back to
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// StartTime is the time the rollback started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time every member ran the known good revision
+	// again
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// StartRollback starts the rollback of the failed upgrade to the last known
+// good revision
+func (zs *ZookeeperClusterStatus) StartRollback(failedVersion string, failedImage ContainerImage, failedConfigHash string, reason string) *RollbackStatus {
+	now := metav1.Now()
+	zs.Rollback = &RollbackStatus{
+		Phase:            RollbackRollingBack,
+		FailedVersion:    failedVersion,
+		FailedImage:      failedImage,
+		FailedConfigHash: failedConfigHash,
+		Reason:           reason,
+		StartTime:        &now,
+	}
+	if zs.LastKnownGood != nil {
+		zs.Rollback.Revision = zs.LastKnownGood.Revision
+	}
+	return zs.Rollback
+}
+
+// IsRollingBack reports whether the members are being restarted back to the
+// last known good revision
+func (zs *ZookeeperClusterStatus) IsRollingBack() bool {
+	return zs.Rollback != nil && zs.Rollback.Phase == RollbackRollingBack
+}
+
+// Complete marks the rollback as completed
+func (rb *RollbackStatus) Complete() {
+	now := metav1.Now()
+	rb.Phase = RollbackRolledBack
+	rb.CompletionTime = &now
+}
+
+// PreUpgradeBackupStatus is the backup taken before the members are upgraded
+// to a new version. It is the restore point of the upgrade.
+type PreUpgradeBackupStatus struct {
+	// Name is the name of the ZookeeperBackup
+	Name string `json:"name"`
+
+	// FromVersion is the version the members ran when the backup was taken
+	// +optional
+	FromVersion string `json:"fromVersion,omitempty"`
+
+	// TargetVersion is the version the members are upgraded to once the
+	// backup succeeded
+	TargetVersion string `json:"targetVersion"`
+
+	// Phase is the phase of the backup
+	// +optional
+	Phase BackupPhase `json:"phase,omitempty"`
+
+	// Location is the URL of the artifact of the backup
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// Zxid is the last transaction held by the backup
+	// +optional
+	Zxid string `json:"zxid,omitempty"`
+
+	// Checksum is the SHA-256 checksum of the artifact, as sha256:<hex>
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+
+	// Message explains why the backup failed
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// CompletionTime is the time the backup succeeded or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// StartPreUpgradeBackup records the backup taken before the upgrade to the
+// target version
+func (zs *ZookeeperClusterStatus) StartPreUpgradeBackup(name, targetVersion string) *PreUpgradeBackupStatus {
+	zs.PreUpgradeBackup = &PreUpgradeBackupStatus{
+		Name:          name,
+		FromVersion:   zs.CurrentVersion,
+		TargetVersion: targetVersion,
+		Phase:         BackupPhasePending,
+	}
+	return zs.PreUpgradeBackup
+}
+
+// HasPreUpgradeBackup reports whether the backup taken before the upgrade to
+// the target version succeeded
+func (zs *ZookeeperClusterStatus) HasPreUpgradeBackup(targetVersion string) bool {
+	b := zs.PreUpgradeBackup
+	return b != nil && b.TargetVersion == targetVersion && b.Phase == BackupPhaseSucceeded
+}
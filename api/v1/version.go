@@ -0,0 +1,183 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ZookeeperVersions is the compatibility matrix of the operator. It maps the
+// tags of the zookeeper images to the ZooKeeper release they ship. Tags named
+// after a ZooKeeper release, e.g. 3.8.4 or 3.8.4-custom, need no entry.
+var ZookeeperVersions = map[string]string{
+	"0.2.15": "3.7.2",
+}
+
+// VersionSkewPolicy is what happens to an upgrade along a path the
+// compatibility matrix does not support
+// +kubebuilder:validation:Enum=Block;Warn
+type VersionSkewPolicy string
+
+const (
+	// VersionSkewBlock holds the members on their version
+	VersionSkewBlock VersionSkewPolicy = "Block"
+	// VersionSkewWarn lets the upgrade through with a warning
+	VersionSkewWarn VersionSkewPolicy = "Warn"
+)
+
+// zookeeperVersion is a ZooKeeper release as major, minor and patch
+type zookeeperVersion [3]int
+
+// parseZookeeperVersion reads a release such as 3.7.2, ignoring a suffix
+// after a dash. The patch may be left out.
+func parseZookeeperVersion(s string) (zookeeperVersion, error) {
+	var v zookeeperVersion
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.Index(s, "-"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return v, fmt.Errorf("%q is not a ZooKeeper release", s)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("%q is not a ZooKeeper release", s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+func (v zookeeperVersion) less(o zookeeperVersion) bool {
+	for i := range v {
+		if v[i] != o[i] {
+			return v[i] < o[i]
+		}
+	}
+	return false
+}
+
+func (v zookeeperVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// checkVersionSkew returns why the members cannot be upgraded from one
+// release to the other. A release only upgrades to its next minor release at
+// most, since a skipped release may change the format of the snapshots and of
+// the transaction logs.
+func checkVersionSkew(from, to zookeeperVersion) error {
+	switch {
+	case to.less(from):
+		return fmt.Errorf("downgrading ZooKeeper from %s to %s is not supported, the older release may not read the data of the newer one", from, to)
+	case to[0] != from[0]:
+		return fmt.Errorf("upgrading ZooKeeper from %s to %s crosses a major release, which is not supported", from, to)
+	case to[1] > from[1]+1:
+		return fmt.Errorf("upgrading ZooKeeper from %s to %s skips %d.%d, upgrade to it first", from, to, from[0], from[1]+1)
+	}
+	return nil
+}
+
+// ZookeeperVersion returns the ZooKeeper release shipped by the image tag,
+// and whether it is known. The versions of the upgrade strategy take
+// precedence over the compatibility matrix of the operator.
+func (z *ZookeeperCluster) ZookeeperVersion(tag string) (string, bool) {
+	if u := z.Spec.UpgradeStrategy; u != nil {
+		if version, ok := u.ZookeeperVersions[tag]; ok {
+			return version, true
+		}
+	}
+	if version, ok := ZookeeperVersions[tag]; ok {
+		return version, true
+	}
+	// the tags of the operator images are versioned 0.x
+	if v, err := parseZookeeperVersion(tag); err == nil && v[0] >= 3 {
+		return tag, true
+	}
+	return "", false
+}
+
+// CheckUpgradePath checks the upgrade of the members from one image tag to
+// the other against the compatibility matrix. Paths which cannot be checked,
+// and unsupported paths under the Warn policy, return a warning. Unsupported
+// paths under the Block policy return an error.
+func (z *ZookeeperCluster) CheckUpgradePath(fromTag, toTag string) (warning string, err error) {
+	if fromTag == toTag {
+		return "", nil
+	}
+	fromVersion, fromOk := z.ZookeeperVersion(fromTag)
+	toVersion, toOk := z.ZookeeperVersion(toTag)
+	if !fromOk || !toOk {
+		unknown := fromTag
+		if fromOk {
+			unknown = toTag
+		}
+		return fmt.Sprintf("the ZooKeeper release of the image tag %s is unknown, the upgrade from %s to %s cannot be checked", unknown, fromTag, toTag), nil
+	}
+	from, err := parseZookeeperVersion(fromVersion)
+	if err != nil {
+		return err.Error(), nil
+	}
+	to, err := parseZookeeperVersion(toVersion)
+	if err != nil {
+		return err.Error(), nil
+	}
+	if err := checkVersionSkew(from, to); err != nil {
+		if z.Spec.UpgradeStrategy.versionSkewPolicy() == VersionSkewWarn {
+			return err.Error(), nil
+		}
+		return "", err
+	}
+	return "", nil
+}
+
+// versionSkewPolicy returns the policy of the upgrade strategy, Block unless
+// it is set
+func (u *UpgradeStrategy) versionSkewPolicy() VersionSkewPolicy {
+	if u == nil || u.VersionSkewPolicy == "" {
+		return VersionSkewBlock
+	}
+	return u.VersionSkewPolicy
+}
+
+// validateVersions checks that every custom image tag maps to a ZooKeeper
+// release
+func (u *UpgradeStrategy) validateVersions(versionsPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	for tag, version := range u.ZookeeperVersions {
+		if _, err := parseZookeeperVersion(version); err != nil {
+			errs = append(errs, field.Invalid(versionsPath.Key(tag), version, "must be a ZooKeeper release such as 3.7.2"))
+		}
+	}
+	return errs
+}
+
+// validateUpgradePath rejects a change of the image tag which upgrades the
+// members along an unsupported path, and returns the warnings of the path
+func (z *ZookeeperCluster) validateUpgradePath(old *ZookeeperCluster) (field.ErrorList, []string) {
+	from, to := old.Status.CurrentVersion, z.Spec.Image.Tag
+	if from == "" || to == old.Spec.Image.Tag {
+		return nil, nil
+	}
+	warning, err := z.CheckUpgradePath(from, to)
+	if err != nil {
+		return field.ErrorList{field.Forbidden(field.NewPath("spec", "image", "tag"), err.Error())}, nil
+	}
+	if warning != "" {
+		return nil, []string{warning}
+	}
+	return nil, nil
+}
@@ -0,0 +1,245 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1
+
+import (
+	"fmt"
+	"path"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultS3Region is the region the requests to an S3-compatible endpoint
+	// are signed for
+	DefaultS3Region = "us-east-1"
+
+	// S3AccessKeyIDKey and S3SecretAccessKeyKey are the keys of the S3
+	// credentials in their Secret
+	S3AccessKeyIDKey     = "accessKeyId"
+	S3SecretAccessKeyKey = "secretAccessKey"
+
+	// BackupArchiveExtension is the extension of the backup artifacts, which
+	// are gzip compressed tar archives
+	BackupArchiveExtension = ".tar.gz"
+)
+
+// BackupPhase is the phase of a backup in its lifecycle
+type BackupPhase string
+
+const (
+	// BackupPhasePending waits for a member of the cluster to be ready
+	BackupPhasePending BackupPhase = "Pending"
+	// BackupPhaseRunning archives the data of a member through a job
+	BackupPhaseRunning BackupPhase = "Running"
+	// BackupPhaseSucceeded keeps the artifact in the storage of the backup
+	BackupPhaseSucceeded BackupPhase = "Succeeded"
+	// BackupPhaseFailed is final, the backup is not retried
+	BackupPhaseFailed BackupPhase = "Failed"
+)
+
+// BackupStorage is where the backup artifacts are kept. Exactly one of s3
+// and persistentVolumeClaim has to be configured.
+type BackupStorage struct {
+	// S3 keeps the artifacts in a bucket of an S3-compatible object store
+	// +optional
+	S3 *S3Storage `json:"s3,omitempty"`
+
+	// PersistentVolumeClaim keeps the artifacts on a volume, which is mounted
+	// on the node of the member that is backed up
+	// +optional
+	PersistentVolumeClaim *PVCStorage `json:"persistentVolumeClaim,omitempty"`
+}
+
+// S3Storage configures a bucket of an S3-compatible object store
+type S3Storage struct {
+	// Endpoint is the URL of the object store, e.g.
+	// https://s3.eu-west-1.amazonaws.com or http://minio.minio:9000. Objects
+	// are addressed path-style.
+	Endpoint string `json:"endpoint"`
+
+	// Region the requests are signed for. Default is us-east-1.
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// Bucket is the name of the bucket
+	Bucket string `json:"bucket"`
+
+	// Prefix is prepended to the keys of the artifacts
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// CredentialsSecretName is the name of a Secret holding the access key in
+	// its accessKeyId key and the secret key in its secretAccessKey key
+	CredentialsSecretName string `json:"credentialsSecretName"`
+}
+
+// PVCStorage configures a persistent volume claim
+type PVCStorage struct {
+	// ClaimName is the name of the claim, in the namespace of the backup
+	ClaimName string `json:"claimName"`
+
+	// Path is the directory of the artifacts on the volume
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+func (s *BackupStorage) withDefaults() (changed bool) {
+	if s.S3 != nil && s.S3.Region == "" {
+		s.S3.Region = DefaultS3Region
+		changed = true
+	}
+	return changed
+}
+
+// Validate reports why the storage cannot keep artifacts, if it cannot
+func (s *BackupStorage) Validate() error {
+	switch {
+	case s.S3 == nil && s.PersistentVolumeClaim == nil:
+		return fmt.Errorf("one of s3 and persistentVolumeClaim is required")
+	case s.S3 != nil && s.PersistentVolumeClaim != nil:
+		return fmt.Errorf("only one of s3 and persistentVolumeClaim may be configured")
+	}
+	return nil
+}
+
+// prefix returns the part of the path of an artifact set by the storage
+func (s *BackupStorage) prefix() string {
+	if s.S3 != nil {
+		return s.S3.Prefix
+	}
+	return s.PersistentVolumeClaim.Path
+}
+
+// ZookeeperBackupSpec defines the desired state of ZookeeperBackup
+type ZookeeperBackupSpec struct {
+	// ZookeeperCluster is the name of the cluster to back up, in the
+	// namespace of the backup. Its data has to be kept on persistent volumes.
+	ZookeeperCluster string `json:"zookeeperCluster"`
+
+	// Storage is where the artifact of the backup is kept
+	Storage BackupStorage `json:"storage"`
+
+	// ReclaimPolicy is what happens to the artifact when the backup is
+	// deleted. It is kept with Retain, which is the default, and deleted
+	// from the storage with Delete.
+	// +kubebuilder:validation:Enum="Delete";"Retain"
+	// +optional
+	ReclaimPolicy VolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+}
+
+// ZookeeperBackupStatus defines the observed state of ZookeeperBackup
+type ZookeeperBackupStatus struct {
+	// Phase is the phase of the backup in its lifecycle
+	// +optional
+	Phase BackupPhase `json:"phase,omitempty"`
+
+	// Member is the member whose data is backed up
+	// +optional
+	Member string `json:"member,omitempty"`
+
+	// Location is the URL of the artifact, s3://<bucket>/<key> or
+	// pvc://<claim>/<path>
+	// +optional
+	Location string `json:"location,omitempty"`
+
+	// Zxid is the last transaction held by the artifact, as read back from
+	// it once it was written
+	// +optional
+	Zxid string `json:"zxid,omitempty"`
+
+	// Size is the size of the artifact in bytes
+	// +optional
+	Size int64 `json:"size,omitempty"`
+
+	// Checksum is the SHA-256 checksum of the artifact, as sha256:<hex>
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+
+	// StartTime is the time the job of the backup was created
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is the time the backup succeeded or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message explains why the backup is pending or failed
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=zkbackup
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.zookeeperCluster`,description="The ZooKeeper cluster which is backed up"
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`,description="The phase of the backup"
+// +kubebuilder:printcolumn:name="Zxid",type=string,JSONPath=`.status.zxid`,description="The last transaction held by the backup"
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=`.status.size`,description="The size of the artifact in bytes"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ZookeeperBackup is the Schema for the zookeeperbackups API. It takes a
+// single backup of the snapshots and transaction logs of a member.
+type ZookeeperBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ZookeeperBackupSpec   `json:"spec,omitempty"`
+	Status ZookeeperBackupStatus `json:"status,omitempty"`
+}
+
+// WithDefaults set default values when not defined in the spec.
+func (b *ZookeeperBackup) WithDefaults() bool {
+	return b.Spec.withDefaults()
+}
+
+func (s *ZookeeperBackupSpec) withDefaults() (changed bool) {
+	if !s.ReclaimPolicy.isValid() {
+		s.ReclaimPolicy = VolumeReclaimPolicyRetain
+		changed = true
+	}
+	if s.Storage.withDefaults() {
+		changed = true
+	}
+	return changed
+}
+
+// ArtifactPath returns the path of the artifact relative to the bucket or
+// the volume of the storage
+func (b *ZookeeperBackup) ArtifactPath() string {
+	return path.Join(b.Spec.Storage.prefix(), b.Namespace, b.Spec.ZookeeperCluster, b.Name+BackupArchiveExtension)
+}
+
+// ArtifactLocation returns the URL of the artifact
+func (b *ZookeeperBackup) ArtifactLocation() string {
+	if s3 := b.Spec.Storage.S3; s3 != nil {
+		return "s3://" + path.Join(s3.Bucket, b.ArtifactPath())
+	}
+	return "pvc://" + path.Join(b.Spec.Storage.PersistentVolumeClaim.ClaimName, b.ArtifactPath())
+}
+
+// IsFinished reports whether the backup succeeded or failed
+func (s *ZookeeperBackupStatus) IsFinished() bool {
+	return s.Phase == BackupPhaseSucceeded || s.Phase == BackupPhaseFailed
+}
+
+// +kubebuilder:object:root=true
+
+// ZookeeperBackupList contains a list of ZookeeperBackup
+type ZookeeperBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ZookeeperBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ZookeeperBackup{}, &ZookeeperBackupList{})
+}
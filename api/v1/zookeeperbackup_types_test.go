@@ -0,0 +1,72 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1_test
+
+import (
+	v1 "github.com/pravega/zookeeper-operator/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ZookeeperBackup Types", func() {
+	var b v1.ZookeeperBackup
+
+	BeforeEach(func() {
+		b = v1.ZookeeperBackup{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "nightly",
+				Namespace: "default",
+			},
+			Spec: v1.ZookeeperBackupSpec{
+				ZookeeperCluster: "example",
+				Storage: v1.BackupStorage{
+					S3: &v1.S3Storage{Bucket: "backups", Prefix: "zookeeper"},
+				},
+			},
+		}
+	})
+
+	It("should sign for the default region", func() {
+		Ω(b.WithDefaults()).To(BeTrue())
+		Ω(b.Spec.Storage.S3.Region).To(Equal("us-east-1"))
+		Ω(b.WithDefaults()).To(BeFalse())
+	})
+
+	It("should retain the artifact by default", func() {
+		b.WithDefaults()
+		Ω(b.Spec.ReclaimPolicy).To(Equal(v1.VolumeReclaimPolicyRetain))
+
+		s := v1.ZookeeperBackupSchedule{Spec: v1.ZookeeperBackupScheduleSpec{Backup: b.Spec}}
+		s.Spec.Backup.ReclaimPolicy = ""
+		Ω(s.WithDefaults()).To(BeTrue())
+		Ω(s.Spec.Backup.ReclaimPolicy).To(Equal(v1.VolumeReclaimPolicyDelete))
+	})
+
+	It("should locate the artifact in the bucket", func() {
+		Ω(b.ArtifactPath()).To(Equal("zookeeper/default/example/nightly.tar.gz"))
+		Ω(b.ArtifactLocation()).To(Equal("s3://backups/zookeeper/default/example/nightly.tar.gz"))
+	})
+
+	It("should locate the artifact on the volume", func() {
+		b.Spec.Storage = v1.BackupStorage{PersistentVolumeClaim: &v1.PVCStorage{ClaimName: "backups"}}
+		Ω(b.ArtifactLocation()).To(Equal("pvc://backups/default/example/nightly.tar.gz"))
+	})
+
+	It("should require exactly one storage", func() {
+		Ω(b.Spec.Storage.Validate()).To(Succeed())
+		b.Spec.Storage.PersistentVolumeClaim = &v1.PVCStorage{ClaimName: "backups"}
+		Ω(b.Spec.Storage.Validate()).NotTo(Succeed())
+		b.Spec.Storage = v1.BackupStorage{}
+		Ω(b.Spec.Storage.Validate()).NotTo(Succeed())
+	})
+})
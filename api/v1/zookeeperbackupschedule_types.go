@@ -0,0 +1,132 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BackupRetention bounds the backups kept by a schedule. The backups beyond
+// either bound are deleted, and with them their artifacts unless the
+// reclaimPolicy of the backups is Retain.
+type BackupRetention struct {
+	// MaxCount is the number of successful backups which are kept. A failed
+	// backup is kept until a later backup succeeded.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxCount *int32 `json:"maxCount,omitempty"`
+
+	// MaxAge is how long a finished backup is kept, e.g. 168h
+	// +optional
+	MaxAge *metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// ZookeeperBackupScheduleSpec defines the desired state of
+// ZookeeperBackupSchedule
+type ZookeeperBackupScheduleSpec struct {
+	// Schedule is a cron expression in UTC, with the minute, hour, day of
+	// month, month and day of week fields, or one of @yearly, @monthly,
+	// @weekly, @daily and @hourly
+	Schedule string `json:"schedule"`
+
+	// Backup is the spec of the backups taken on schedule. Their
+	// reclaimPolicy defaults to Delete, so that expired artifacts are
+	// removed from the storage.
+	Backup ZookeeperBackupSpec `json:"backup"`
+
+	// Retention bounds the backups which are kept. All of them are kept by
+	// default.
+	// +optional
+	Retention BackupRetention `json:"retention,omitempty"`
+}
+
+// ZookeeperBackupScheduleStatus defines the observed state of
+// ZookeeperBackupSchedule
+type ZookeeperBackupScheduleStatus struct {
+	// Active is the backup of the schedule which did not finish yet
+	// +optional
+	Active string `json:"active,omitempty"`
+
+	// LastScheduleTime is the last time a backup was due
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+
+	// NextScheduleTime is the next time a backup is due
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+
+	// LastSuccessfulBackup is the latest backup which succeeded
+	// +optional
+	LastSuccessfulBackup string `json:"lastSuccessfulBackup,omitempty"`
+
+	// LastSuccessfulTime is the time the latest successful backup completed
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+
+	// MissedSchedules counts the times a backup was due but not taken, as the
+	// previous backup was still running or the operator was not
+	// +optional
+	MissedSchedules int32 `json:"missedSchedules,omitempty"`
+
+	// LastMissedTime is the last time a backup was due but not taken
+	// +optional
+	LastMissedTime *metav1.Time `json:"lastMissedTime,omitempty"`
+
+	// Message explains why the last backup was missed, or why the schedule is
+	// invalid
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=zkbackupschedule
+// +kubebuilder:printcolumn:name="Cluster",type=string,JSONPath=`.spec.backup.zookeeperCluster`,description="The ZooKeeper cluster which is backed up"
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`,description="The cron expression of the schedule"
+// +kubebuilder:printcolumn:name="Last Backup",type=date,JSONPath=`.status.lastScheduleTime`,description="The last time a backup was due"
+// +kubebuilder:printcolumn:name="Missed",type=integer,JSONPath=`.status.missedSchedules`,description="The number of backups which were due but not taken"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ZookeeperBackupSchedule is the Schema for the zookeeperbackupschedules
+// API. It takes backups of a cluster on a cron schedule and deletes them
+// once they expire.
+type ZookeeperBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ZookeeperBackupScheduleSpec   `json:"spec,omitempty"`
+	Status ZookeeperBackupScheduleStatus `json:"status,omitempty"`
+}
+
+// WithDefaults set default values when not defined in the spec.
+func (s *ZookeeperBackupSchedule) WithDefaults() (changed bool) {
+	if s.Spec.Backup.ReclaimPolicy == "" {
+		s.Spec.Backup.ReclaimPolicy = VolumeReclaimPolicyDelete
+		changed = true
+	}
+	if s.Spec.Backup.withDefaults() {
+		changed = true
+	}
+	return changed
+}
+
+// +kubebuilder:object:root=true
+
+// ZookeeperBackupScheduleList contains a list of ZookeeperBackupSchedule
+type ZookeeperBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ZookeeperBackupSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ZookeeperBackupSchedule{}, &ZookeeperBackupScheduleList{})
+}
@@ -0,0 +1,881 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultZkContainerRepository is the default docker repo for the zookeeper
+	// container
+	DefaultZkContainerRepository = "pravega/zookeeper"
+
+	// DefaultZkContainerVersion is the default tag used for for the zookeeper
+	// container
+	DefaultZkContainerVersion = "0.2.15"
+
+	// DefaultZkContainerPolicy is the default container pull policy used
+	DefaultZkContainerPolicy = "Always"
+
+	// DefaultTerminationGracePeriod is the default time given before the
+	// container is stopped. This gives clients time to disconnect from a
+	// specific node gracefully.
+	DefaultTerminationGracePeriod = 30
+
+	// DefaultZookeeperCacheVolumeSize is the default volume size for the
+	// Zookeeper cache volume
+	DefaultZookeeperCacheVolumeSize = "20Gi"
+
+	// DefaultReadinessProbeInitialDelaySeconds is the default initial delay (in seconds)
+	// for the readiness probe
+	DefaultReadinessProbeInitialDelaySeconds = 10
+
+	// DefaultReadinessProbePeriodSeconds is the default probe period (in seconds)
+	// for the readiness probe
+	DefaultReadinessProbePeriodSeconds = 10
+
+	// DefaultReadinessProbeFailureThreshold is the default probe failure threshold
+	// for the readiness probe
+	DefaultReadinessProbeFailureThreshold = 3
+
+	// DefaultReadinessProbeSuccessThreshold is the default probe success threshold
+	// for the readiness probe
+	DefaultReadinessProbeSuccessThreshold = 1
+
+	// DefaultReadinessProbeTimeoutSeconds is the default probe timeout (in seconds)
+	// for the readiness probe
+	DefaultReadinessProbeTimeoutSeconds = 10
+
+	// DefaultLivenessProbeInitialDelaySeconds is the default initial delay (in seconds)
+	// for the liveness probe
+	DefaultLivenessProbeInitialDelaySeconds = 10
+
+	// DefaultLivenessProbePeriodSeconds is the default probe period (in seconds)
+	// for the liveness probe
+	DefaultLivenessProbePeriodSeconds = 10
+
+	// DefaultLivenessProbeFailureThreshold is the default probe failure threshold
+	// for the liveness probe
+	DefaultLivenessProbeFailureThreshold = 3
+
+	// DefaultLivenessProbeTimeoutSeconds is the default probe timeout (in seconds)
+	// for the liveness probe
+	DefaultLivenessProbeTimeoutSeconds = 10
+
+	// DefaultClientPort is the default port zookeeper serves clients on
+	DefaultClientPort = 2181
+
+	// DefaultQuorumPort is the default port followers connect to the leader on
+	DefaultQuorumPort = 2888
+
+	// DefaultLeaderElectionPort is the default port used for leader election
+	DefaultLeaderElectionPort = 3888
+
+	// DefaultMetricsPort is the default port of the prometheus metrics provider
+	DefaultMetricsPort = 7000
+
+	// DefaultAdminServerPort is the default port of the zookeeper admin server
+	DefaultAdminServerPort = 8080
+
+	// TriggerRollingRestartAnnotation instructs the operator to restart all the
+	// pods of the zookeeper cluster when set to "true". The operator removes it
+	// once the restart has been scheduled.
+	TriggerRollingRestartAnnotation = "zookeeper.pravega.io/trigger-rolling-restart"
+)
+
+// ZookeeperClusterSpec defines the desired state of ZookeeperCluster
+type ZookeeperClusterSpec struct {
+	// Image is the  container image. default is zookeeper:0.2.10
+	Image ContainerImage `json:"image,omitempty"`
+
+	// Labels specifies the labels to attach to all resources the operator
+	// creates for the zookeeper cluster, including StatefulSet, Pod,
+	// PersistentVolumeClaim, Service, ConfigMap, et al.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Replicas is the expected size of the zookeeper cluster.
+	// The pravega-operator will eventually make the size of the running cluster
+	// equal to the expected size.
+	//
+	// The valid range of size is from 1 to 7.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=7
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Ports are the ports the zookeeper container listens on. Ports which are
+	// not set get their default value.
+	Ports Ports `json:"ports,omitempty"`
+
+	// TLS configures TLS for the connections to the zookeeper members.
+	// +optional
+	TLS *TLSPolicy `json:"tls,omitempty"`
+
+	// OperatorClient configures the TLS key material and the credentials the
+	// operator connects to the ensemble with.
+	// +optional
+	OperatorClient *OperatorClientPolicy `json:"operatorClient,omitempty"`
+
+	// Auth configures the SASL authentication of the clients and of the
+	// members.
+	// +optional
+	Auth *AuthPolicy `json:"auth,omitempty"`
+
+	// ACL configures the access control of the znodes. ACLs are not enforced
+	// by default.
+	// +optional
+	ACL *ACLPolicy `json:"acl,omitempty"`
+
+	// RestoreFrom bootstraps a new cluster from the artifact of a backup.
+	// Only members whose data volume is empty restore it, and it cannot be
+	// added to or changed on an existing cluster.
+	// +optional
+	RestoreFrom *RestoreSource `json:"restoreFrom,omitempty"`
+
+	// Pod defines the policy to create pod for the zookeeper cluster.
+	// Updating the Pod does not take effect on any existing pods.
+	Pod PodPolicy `json:"pod,omitempty"`
+
+	// Observers configures a pool of observers, which serve reads without
+	// voting. There are no observers by default.
+	// +optional
+	Observers *ObserverPolicy `json:"observers,omitempty"`
+
+	// UpgradeStrategy rolls changes of the pod template out to a canary
+	// member first and to the other members in batches, each gated on the
+	// health of the members upgraded before. Without it the members are
+	// upgraded one after the other without gates.
+	// +optional
+	UpgradeStrategy *UpgradeStrategy `json:"upgradeStrategy,omitempty"`
+
+	// AdminServerService defines the policy to create AdminServer Service
+	// for the zookeeper cluster.
+	AdminServerService AdminServerServicePolicy `json:"adminServerService,omitempty"`
+
+	// ClientService defines the policy to create client Service
+	// for the zookeeper cluster.
+	ClientService ClientServicePolicy `json:"clientService,omitempty"`
+
+	// HeadlessService defines the policy to create headless Service
+	// for the zookeeper cluster.
+	HeadlessService HeadlessServicePolicy `json:"headlessService,omitempty"`
+
+	// Storage is the storage backing the zookeeper data directory.
+	// Persistent storage is used unless ephemeral storage is configured.
+	Storage Storage `json:"storage,omitempty"`
+
+	// Conf is the zookeeper configuration, which will be used to generate the
+	// static zookeeper configuration. If no configuration is provided required
+	// default values will be provided, and optional values will be excluded.
+	Conf ZookeeperConfig `json:"config,omitempty"`
+
+	// External host name appended for dns annotation
+	DomainName string `json:"domainName,omitempty"`
+
+	// Domain of the kubernetes cluster, defaults to cluster.local
+	KubernetesClusterDomain string `json:"kubernetesClusterDomain,omitempty"`
+
+	// Containers defines to support multi containers
+	Containers []corev1.Container `json:"containers,omitempty"`
+
+	// Init containers to support initialization
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+
+	// Volumes defines to support customized volumes
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// VolumeMounts defines to support customized volumeMounts
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
+	// Probes specifies the timeout values for the Readiness and Liveness Probes
+	// for the zookeeper pods.
+	// +optional
+	Probes *Probes `json:"probes,omitempty"`
+
+	// MaxUnavailableReplicas defines the
+	// MaxUnavailable Replicas in pdb.
+	// Default is 1.
+	MaxUnavailableReplicas int32 `json:"maxUnavailableReplicas,omitempty"`
+}
+
+type Probes struct {
+	// +optional
+	ReadinessProbe *Probe `json:"readinessProbe,omitempty"`
+	// +optional
+	LivenessProbe *Probe `json:"livenessProbe,omitempty"`
+}
+
+func (s *ZookeeperClusterSpec) withDefaults(z *ZookeeperCluster) (changed bool) {
+	changed = s.Image.withDefaults()
+	if s.Conf.withDefaults() {
+		changed = true
+	}
+	if s.Replicas == 0 {
+		s.Replicas = 3
+		changed = true
+	}
+	if s.Probes == nil {
+		changed = true
+		s.Probes = &Probes{}
+	}
+	if s.Probes.withDefaults() {
+		changed = true
+	}
+	if s.Ports.withDefaults() {
+		changed = true
+	}
+	if s.TLS != nil && s.TLS.withDefaults() {
+		changed = true
+	}
+	if s.OperatorClient != nil && s.OperatorClient.withDefaults() {
+		changed = true
+	}
+	if s.Auth != nil && s.Auth.withDefaults() {
+		changed = true
+	}
+	if s.RestoreFrom != nil && s.RestoreFrom.withDefaults() {
+		changed = true
+	}
+	if s.UpgradeStrategy != nil && s.UpgradeStrategy.withDefaults() {
+		changed = true
+	}
+	// the secure client port is only opened for TLS clients
+	if s.TLS.ClientEnabled() && s.Ports.SecureClient == 0 {
+		s.Ports.SecureClient = DefaultSecureClientPort
+		changed = true
+	}
+	if z.Spec.Labels == nil {
+		z.Spec.Labels = map[string]string{}
+		changed = true
+	}
+	if _, ok := z.Spec.Labels["app"]; !ok {
+		z.Spec.Labels["app"] = z.GetName()
+		changed = true
+	}
+	if _, ok := z.Spec.Labels["release"]; !ok {
+		z.Spec.Labels["release"] = z.GetName()
+		changed = true
+	}
+	if s.Pod.withDefaults(z) {
+		changed = true
+	}
+	if s.Storage.withDefaults() {
+		changed = true
+	}
+	if s.MaxUnavailableReplicas < 1 {
+		s.MaxUnavailableReplicas = 1
+		changed = true
+	}
+	return changed
+}
+
+type Probe struct {
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	InitialDelaySeconds int32 `json:"initialDelaySeconds"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	PeriodSeconds int32 `json:"periodSeconds"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	FailureThreshold int32 `json:"failureThreshold"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	SuccessThreshold int32 `json:"successThreshold"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds"`
+}
+
+// Generate CRD using kubebuilder
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.readyReplicas,selectorpath=.status.selector
+// +kubebuilder:storageversion
+// +kubebuilder:resource:shortName=zk
+// +kubebuilder:printcolumn:name="Replicas",type=integer,JSONPath=`.spec.replicas`,description="The number of ZooKeeper servers in the ensemble"
+// +kubebuilder:printcolumn:name="Ready Replicas",type=integer,JSONPath=`.status.readyReplicas`,description="The number of ZooKeeper servers in the ensemble that are in a Ready state"
+// +kubebuilder:printcolumn:name="Leader",type=string,JSONPath=`.status.leader`,description="The ZooKeeper server which currently leads the ensemble"
+// +kubebuilder:printcolumn:name="Version",type=string,JSONPath=`.status.currentVersion`,description="The current Zookeeper version"
+// +kubebuilder:printcolumn:name="Desired Version",type=string,JSONPath=`.spec.image.tag`,description="The desired Zookeeper version"
+// +kubebuilder:printcolumn:name="Internal Endpoint",type=string,JSONPath=`.status.internalClientEndpoint`,description="Client endpoint internal to cluster network"
+// +kubebuilder:printcolumn:name="External Endpoint",type=string,JSONPath=`.status.externalClientEndpoint`,description="Client endpoint external to cluster network via LoadBalancer"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ZookeeperCluster is the Schema for the zookeeperclusters API
+type ZookeeperCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ZookeeperClusterSpec   `json:"spec,omitempty"`
+	Status ZookeeperClusterStatus `json:"status,omitempty"`
+}
+
+// WithDefaults set default values when not defined in the spec.
+func (z *ZookeeperCluster) WithDefaults() bool {
+	return z.Spec.withDefaults(z)
+}
+
+// ConfigMapName returns the name of the cluster config-map
+func (z *ZookeeperCluster) ConfigMapName() string {
+	return fmt.Sprintf("%s-configmap", z.GetName())
+}
+
+// GetKubernetesClusterDomain returns the cluster domain of kubernetes
+func (z *ZookeeperCluster) GetKubernetesClusterDomain() string {
+	if z.Spec.KubernetesClusterDomain == "" {
+		return "cluster.local"
+	}
+	return z.Spec.KubernetesClusterDomain
+}
+
+// GetClientServiceName returns the name of the client service for the cluster
+func (z *ZookeeperCluster) GetClientServiceName() string {
+	return fmt.Sprintf("%s-client", z.GetName())
+}
+
+// GetAdminServerServiceName returns the name of the admin server service for the cluster
+func (z *ZookeeperCluster) GetAdminServerServiceName() string {
+	return fmt.Sprintf("%s-admin-server", z.GetName())
+}
+
+// GetTriggerRollingRestart reports whether a rolling restart of the cluster
+// has been requested through the trigger annotation
+func (z *ZookeeperCluster) GetTriggerRollingRestart() bool {
+	return z.GetAnnotations()[TriggerRollingRestartAnnotation] == "true"
+}
+
+// SetTriggerRollingRestart sets or clears the trigger annotation
+func (z *ZookeeperCluster) SetTriggerRollingRestart(val bool) {
+	annotations := z.GetAnnotations()
+	if !val {
+		delete(annotations, TriggerRollingRestartAnnotation)
+		return
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[TriggerRollingRestartAnnotation] = "true"
+	z.SetAnnotations(annotations)
+}
+
+// Ports are the ports of a zookeeper cluster node
+type Ports struct {
+	// Client is the port zookeeper serves clients on. Default is 2181. With
+	// client TLS it only listens on the loopback interface of the members,
+	// unless tls.client.allowPlaintext is set.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Client int32 `json:"client,omitempty"`
+
+	// Quorum is the port followers connect to the leader on. Default is 2888.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Quorum int32 `json:"quorum,omitempty"`
+
+	// LeaderElection is the port used for leader election. Default is 3888.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	LeaderElection int32 `json:"leaderElection,omitempty"`
+
+	// Metrics is the port of the prometheus metrics provider. Default is 7000.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Metrics int32 `json:"metrics,omitempty"`
+
+	// AdminServer is the port of the zookeeper admin server. Default is 8080.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	AdminServer int32 `json:"adminServer,omitempty"`
+
+	// SecureClient is the port zookeeper serves TLS clients on. It is only
+	// opened when client TLS is configured. Default is 2281.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	SecureClient int32 `json:"secureClient,omitempty"`
+}
+
+func (p *Ports) withDefaults() (changed bool) {
+	for _, port := range []struct {
+		value *int32
+		def   int32
+	}{
+		{&p.Client, DefaultClientPort},
+		{&p.Quorum, DefaultQuorumPort},
+		{&p.LeaderElection, DefaultLeaderElectionPort},
+		{&p.Metrics, DefaultMetricsPort},
+		{&p.AdminServer, DefaultAdminServerPort},
+	} {
+		if *port.value == 0 {
+			*port.value = port.def
+			changed = true
+		}
+	}
+	return changed
+}
+
+// ContainerPorts returns the ports as the named container ports of the
+// zookeeper container. Ports which are not set are left out.
+func (p *Ports) ContainerPorts() []corev1.ContainerPort {
+	var ports []corev1.ContainerPort
+	for _, port := range []corev1.ContainerPort{
+		{Name: "client", ContainerPort: p.Client},
+		{Name: "quorum", ContainerPort: p.Quorum},
+		{Name: "leader-election", ContainerPort: p.LeaderElection},
+		{Name: "metrics", ContainerPort: p.Metrics},
+		{Name: "admin-server", ContainerPort: p.AdminServer},
+		{Name: "secure-client", ContainerPort: p.SecureClient},
+	} {
+		if port.ContainerPort != 0 {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// ContainerImage defines the fields needed for a Docker repository image. The
+// format here matches the predominant format used in Helm charts.
+type ContainerImage struct {
+	Repository string `json:"repository,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	// +kubebuilder:validation:Enum="Always";"Never";"IfNotPresent"
+	PullPolicy corev1.PullPolicy `json:"pullPolicy,omitempty"`
+}
+
+func (c *ContainerImage) withDefaults() (changed bool) {
+	if c.Repository == "" {
+		changed = true
+		c.Repository = DefaultZkContainerRepository
+	}
+	if c.Tag == "" {
+		changed = true
+		c.Tag = DefaultZkContainerVersion
+	}
+	if c.PullPolicy == "" {
+		changed = true
+		c.PullPolicy = DefaultZkContainerPolicy
+	}
+	return changed
+}
+
+// ToString formats a container image struct as a docker compatible repository
+// string.
+func (c *ContainerImage) ToString() string {
+	return fmt.Sprintf("%s:%s", c.Repository, c.Tag)
+}
+
+// PodPolicy defines the common pod configuration for Pods, including when used
+// in deployments, stateful-sets, etc.
+type PodPolicy struct {
+	// Labels specifies the labels to attach to pods the operator creates for the
+	// zookeeper cluster. Overrides any values specified in Spec.Labels.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// NodeSelector specifies a map of key-value pairs. For the pod to be
+	// eligible to run on a node, the node must have each of the indicated
+	// key-value pairs as labels.
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// The scheduling constraints on pods.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// TopologySpreadConstraints to apply to the pods
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// Resources is the resource requirements for the container.
+	// This field cannot be updated once the cluster is created.
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Tolerations specifies the pod's tolerations.
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// List of environment variables to set in the container.
+	// This field cannot be updated.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Annotations specifies the annotations to attach to pods the operator
+	// creates.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// SecurityContext specifies the security context for the entire pod
+	// More info: https://kubernetes.io/docs/tasks/configure-pod-container/security-context
+	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+
+	// +kubebuilder:validation:Minimum=0
+	// TerminationGracePeriodSeconds is the amount of time that kubernetes will
+	// give for a pod instance to shutdown normally.
+	// The default value is 30.
+	TerminationGracePeriodSeconds int64 `json:"terminationGracePeriodSeconds,omitempty"`
+	// Service Account to be used in pods
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// ImagePullSecrets is a list of references to secrets in the same namespace to use for pulling any images
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+}
+
+func (p *PodPolicy) withDefaults(z *ZookeeperCluster) (changed bool) {
+	if p.Labels == nil {
+		p.Labels = map[string]string{}
+		changed = true
+	}
+	if p.TerminationGracePeriodSeconds == 0 {
+		p.TerminationGracePeriodSeconds = DefaultTerminationGracePeriod
+		changed = true
+	}
+	if p.ServiceAccountName == "" {
+		p.ServiceAccountName = "default"
+		changed = true
+	}
+	if _, ok := p.Labels["app"]; !ok {
+		p.Labels["app"] = z.GetName()
+		changed = true
+	}
+	if _, ok := p.Labels["release"]; !ok {
+		p.Labels["release"] = z.GetName()
+		changed = true
+	}
+	if p.Affinity == nil {
+		p.Affinity = &corev1.Affinity{
+			PodAntiAffinity: &corev1.PodAntiAffinity{
+				PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+					{
+						Weight: 20,
+						PodAffinityTerm: corev1.PodAffinityTerm{
+							TopologyKey: "kubernetes.io/hostname",
+							LabelSelector: &metav1.LabelSelector{
+								MatchExpressions: []metav1.LabelSelectorRequirement{
+									{
+										Key:      "app",
+										Operator: metav1.LabelSelectorOpIn,
+										Values:   []string{z.GetName()},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+		changed = true
+	}
+	return changed
+}
+
+type AdminServerServicePolicy struct {
+	// Annotations specifies the annotations to attach to AdminServer service the operator
+	// creates.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	External bool `json:"external,omitempty"`
+}
+
+type ClientServicePolicy struct {
+	// Annotations specifies the annotations to attach to client service the operator
+	// creates.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type HeadlessServicePolicy struct {
+	// Annotations specifies the annotations to attach to headless service the operator
+	// creates.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+func (s *Probes) withDefaults() (changed bool) {
+	if s.ReadinessProbe == nil {
+		changed = true
+		s.ReadinessProbe = &Probe{}
+		s.ReadinessProbe.InitialDelaySeconds = DefaultReadinessProbeInitialDelaySeconds
+		s.ReadinessProbe.PeriodSeconds = DefaultReadinessProbePeriodSeconds
+		s.ReadinessProbe.FailureThreshold = DefaultReadinessProbeFailureThreshold
+		s.ReadinessProbe.SuccessThreshold = DefaultReadinessProbeSuccessThreshold
+		s.ReadinessProbe.TimeoutSeconds = DefaultReadinessProbeTimeoutSeconds
+	}
+
+	if s.LivenessProbe == nil {
+		changed = true
+		s.LivenessProbe = &Probe{}
+		s.LivenessProbe.InitialDelaySeconds = DefaultLivenessProbeInitialDelaySeconds
+		s.LivenessProbe.PeriodSeconds = DefaultLivenessProbePeriodSeconds
+		s.LivenessProbe.FailureThreshold = DefaultLivenessProbeFailureThreshold
+		s.LivenessProbe.TimeoutSeconds = DefaultLivenessProbeTimeoutSeconds
+	}
+
+	return changed
+}
+
+// ZookeeperConfig is the current configuration of each Zookeeper node, which
+// sets these values in the config-map
+type ZookeeperConfig struct {
+	// InitLimit is the amount of time, in ticks, to allow followers to connect
+	// and sync to a leader.
+	//
+	// Default value is 10.
+	InitLimit int `json:"initLimit,omitempty"`
+
+	// TickTime is the length of a single tick, which is the basic time unit used
+	// by Zookeeper, as measured in milliseconds
+	//
+	// The default value is 2000.
+	TickTime int `json:"tickTime,omitempty"`
+
+	// SyncLimit is the amount of time, in ticks, to allow followers to sync with
+	// Zookeeper.
+	//
+	// The default value is 2.
+	SyncLimit int `json:"syncLimit,omitempty"`
+
+	// Clients can submit requests faster than ZooKeeper can process them, especially
+	// if there are a lot of clients. Zookeeper will throttle Clients so that requests
+	// won't exceed global outstanding limit.
+	//
+	// The default value is 1000
+	GlobalOutstandingLimit int `json:"globalOutstandingLimit,omitempty"`
+
+	// To avoid seeks ZooKeeper allocates space in the transaction log file in
+	// blocks of preAllocSize kilobytes
+	//
+	// The default value is 64M
+	PreAllocSize int `json:"preAllocSize,omitempty"`
+
+	// ZooKeeper records its transactions using snapshots and a transaction log
+	// The number of transactions recorded in the transaction log before a snapshot
+	// can be taken is determined by snapCount
+	//
+	// The default value is 100,000
+	SnapCount int `json:"snapCount,omitempty"`
+
+	// Zookeeper maintains an in-memory list of last committed requests for fast
+	// synchronization with followers
+	//
+	// The default value is 500
+	CommitLogCount int `json:"commitLogCount,omitempty"`
+
+	// Snapshot size limit in Kb
+	//
+	// The defult value is 4GB
+	SnapSizeLimitInKb int `json:"snapSizeLimitInKb,omitempty"`
+
+	// Limits the total number of concurrent connections that can be made to a
+	//zookeeper server
+	//
+	// The defult value is 0, indicating no limit
+	MaxCnxns int `json:"maxCnxns,omitempty"`
+
+	// Limits the number of concurrent connections that a single client, identified
+	// by IP address, may make to a single member of the ZooKeeper ensemble.
+	//
+	// The default value is 60
+	MaxClientCnxns int `json:"maxClientCnxns,omitempty"`
+
+	// The minimum session timeout in milliseconds that the server will allow the
+	// client to negotiate
+	//
+	// The default value is 4000
+	MinSessionTimeout int `json:"minSessionTimeout,omitempty"`
+
+	// The maximum session timeout in milliseconds that the server will allow the
+	// client to negotiate.
+	//
+	// The default value is 40000
+	MaxSessionTimeout int `json:"maxSessionTimeout,omitempty"`
+
+	// Retain the snapshots according to retain count
+	//
+	// The default value is 3
+	AutoPurgeSnapRetainCount int `json:"autoPurgeSnapRetainCount,omitempty"`
+
+	// The time interval in hours for which the purge task has to be triggered
+	//
+	// Disabled by default
+	AutoPurgePurgeInterval int `json:"autoPurgePurgeInterval,omitempty"`
+
+	// QuorumListenOnAllIPs when set to true the ZooKeeper server will listen for
+	// connections from its peers on all available IP addresses, and not only the
+	// address configured in the server list of the configuration file. It affects
+	// the connections handling the ZAB protocol and the Fast Leader Election protocol.
+	//
+	// The default value is false.
+	QuorumListenOnAllIPs bool `json:"quorumListenOnAllIPs,omitempty"`
+
+	// key-value map of additional zookeeper configuration parameters
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	AdditionalConfig map[string]string `json:"additionalConfig,omitempty"`
+}
+
+func (c *ZookeeperConfig) withDefaults() (changed bool) {
+	if c.InitLimit == 0 {
+		changed = true
+		c.InitLimit = 10
+	}
+	if c.TickTime == 0 {
+		changed = true
+		c.TickTime = 2000
+	}
+	if c.SyncLimit == 0 {
+		changed = true
+		c.SyncLimit = 2
+	}
+	if c.GlobalOutstandingLimit == 0 {
+		changed = true
+		c.GlobalOutstandingLimit = 1000
+	}
+	if c.PreAllocSize == 0 {
+		changed = true
+		c.PreAllocSize = 65536
+	}
+	if c.SnapCount == 0 {
+		changed = true
+		c.SnapCount = 10000
+	}
+	if c.CommitLogCount == 0 {
+		changed = true
+		c.CommitLogCount = 500
+	}
+	if c.SnapSizeLimitInKb == 0 {
+		changed = true
+		c.SnapSizeLimitInKb = 4194304
+	}
+	if c.MaxClientCnxns == 0 {
+		changed = true
+		c.MaxClientCnxns = 60
+	}
+	if c.MinSessionTimeout == 0 {
+		changed = true
+		c.MinSessionTimeout = 2 * c.TickTime
+	}
+	if c.MaxSessionTimeout == 0 {
+		changed = true
+		c.MaxSessionTimeout = 20 * c.TickTime
+	}
+	if c.AutoPurgeSnapRetainCount == 0 {
+		changed = true
+		c.AutoPurgeSnapRetainCount = 3
+	}
+	if c.AutoPurgePurgeInterval == 0 {
+		changed = true
+		c.AutoPurgePurgeInterval = 1
+	}
+
+	return changed
+}
+
+// Storage configures the volume backing the zookeeper data directory. At most
+// one of its members may be set.
+// +kubebuilder:validation:MaxProperties=1
+type Storage struct {
+	// Persistence stores the data on a PersistentVolumeClaim per member.
+	// +optional
+	Persistence *Persistence `json:"persistence,omitempty"`
+
+	// Ephemeral stores the data on an emptyDir volume which is lost when the
+	// pod is deleted.
+	// +optional
+	Ephemeral *Ephemeral `json:"ephemeral,omitempty"`
+}
+
+// IsEphemeral reports whether the data is kept on ephemeral storage
+func (s *Storage) IsEphemeral() bool {
+	return s.Ephemeral != nil
+}
+
+func (s *Storage) withDefaults() (changed bool) {
+	if s.IsEphemeral() {
+		return false
+	}
+	if s.Persistence == nil {
+		s.Persistence = &Persistence{}
+		changed = true
+	}
+	if s.Persistence.withDefaults() {
+		changed = true
+	}
+	return changed
+}
+
+type Persistence struct {
+	// VolumeReclaimPolicy is a zookeeper operator configuration. If it's set to Delete,
+	// the corresponding PVCs will be deleted by the operator when zookeeper cluster is deleted.
+	// The default value is Retain.
+	// +kubebuilder:validation:Enum="Delete";"Retain"
+	VolumeReclaimPolicy VolumeReclaimPolicy `json:"reclaimPolicy,omitempty"`
+	// PersistentVolumeClaimSpec is the spec to describe PVC for the container
+	// This field is optional. If no PVC is specified default persistentvolume
+	// will get created.
+	PersistentVolumeClaimSpec corev1.PersistentVolumeClaimSpec `json:"spec,omitempty"`
+	// Annotations specifies the annotations to attach to pvc the operator
+	// creates.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type Ephemeral struct {
+	//EmptyDirVolumeSource is optional and this will create the emptydir volume
+	//It has two parameters Medium and SizeLimit which are optional as well
+	//Medium specifies What type of storage medium should back this directory.
+	//SizeLimit specifies Total amount of local storage required for this EmptyDir volume.
+	EmptyDirVolumeSource corev1.EmptyDirVolumeSource `json:"emptydirvolumesource,omitempty"`
+}
+
+func (p *Persistence) withDefaults() (changed bool) {
+	if !p.VolumeReclaimPolicy.isValid() {
+		changed = true
+		p.VolumeReclaimPolicy = VolumeReclaimPolicyRetain
+	}
+	p.PersistentVolumeClaimSpec.AccessModes = []corev1.PersistentVolumeAccessMode{
+		corev1.ReadWriteOnce,
+	}
+
+	storage := p.PersistentVolumeClaimSpec.Resources.Requests[corev1.ResourceStorage]
+	if storage.IsZero() {
+		p.PersistentVolumeClaimSpec.Resources.Requests = corev1.ResourceList{
+			corev1.ResourceStorage: resource.MustParse(DefaultZookeeperCacheVolumeSize),
+		}
+		changed = true
+	}
+	return changed
+}
+
+func (v VolumeReclaimPolicy) isValid() bool {
+	if v != VolumeReclaimPolicyDelete && v != VolumeReclaimPolicyRetain {
+		return false
+	}
+	return true
+}
+
+type VolumeReclaimPolicy string
+
+const (
+	VolumeReclaimPolicyRetain VolumeReclaimPolicy = "Retain"
+	VolumeReclaimPolicyDelete VolumeReclaimPolicy = "Delete"
+)
+
+// +kubebuilder:object:root=true
+
+// ZookeeperClusterList contains a list of ZookeeperCluster
+type ZookeeperClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ZookeeperCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ZookeeperCluster{}, &ZookeeperClusterList{})
+}
@@ -0,0 +1,322 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1_test
+
+import (
+	"time"
+
+	v1 "github.com/pravega/zookeeper-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ZookeeperCluster Types", func() {
+	var z v1.ZookeeperCluster
+
+	BeforeEach(func() {
+		z = v1.ZookeeperCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "example",
+			},
+		}
+	})
+
+	Context("#WithDefaults", func() {
+		var changed bool
+
+		BeforeEach(func() {
+			changed = z.WithDefaults()
+		})
+
+		It("should return as changed", func() {
+			Ω(changed).To(BeTrue())
+		})
+
+		It("should not change on a second pass", func() {
+			Ω(z.WithDefaults()).To(BeFalse())
+		})
+
+		It("should have a replica count of 3", func() {
+			Ω(z.Spec.Replicas).To(BeEquivalentTo(3))
+		})
+
+		It("should have the default ports", func() {
+			Ω(z.Spec.Ports).To(Equal(v1.Ports{
+				Client:         2181,
+				Quorum:         2888,
+				LeaderElection: 3888,
+				Metrics:        7000,
+				AdminServer:    8080,
+			}))
+		})
+
+		It("should use persistent storage with a 20Gi volume", func() {
+			Ω(z.Spec.Storage.IsEphemeral()).To(BeFalse())
+			Ω(z.Spec.Storage.Persistence.VolumeReclaimPolicy).To(Equal(v1.VolumeReclaimPolicyRetain))
+			size := z.Spec.Storage.Persistence.PersistentVolumeClaimSpec.Resources.Requests[corev1.ResourceStorage]
+			Ω(size.String()).To(Equal("20Gi"))
+		})
+
+		It("should not request a rolling restart", func() {
+			Ω(z.GetTriggerRollingRestart()).To(BeFalse())
+		})
+	})
+
+	Context("Ephemeral storage", func() {
+		BeforeEach(func() {
+			z.Spec.Storage.Ephemeral = &v1.Ephemeral{}
+			z.WithDefaults()
+		})
+
+		It("should not add persistence", func() {
+			Ω(z.Spec.Storage.IsEphemeral()).To(BeTrue())
+			Ω(z.Spec.Storage.Persistence).To(BeNil())
+		})
+	})
+
+	Context("Persistence with a volume size", func() {
+		BeforeEach(func() {
+			z.Spec.Storage.Persistence = &v1.Persistence{
+				VolumeReclaimPolicy: v1.VolumeReclaimPolicyDelete,
+			}
+			z.Spec.Storage.Persistence.PersistentVolumeClaimSpec.Resources.Requests = corev1.ResourceList{
+				corev1.ResourceStorage: resource.MustParse("5Gi"),
+			}
+			z.WithDefaults()
+		})
+
+		It("should keep the reclaim policy and the size", func() {
+			Ω(z.Spec.Storage.Persistence.VolumeReclaimPolicy).To(Equal(v1.VolumeReclaimPolicyDelete))
+			size := z.Spec.Storage.Persistence.PersistentVolumeClaimSpec.Resources.Requests[corev1.ResourceStorage]
+			Ω(size.String()).To(Equal("5Gi"))
+		})
+	})
+
+	Context("Client TLS", func() {
+		BeforeEach(func() {
+			z.Spec.TLS = &v1.TLSPolicy{
+				Client: &v1.ClientTLS{TLSSecret: v1.TLSSecret{SecretName: "example-tls"}},
+			}
+			z.WithDefaults()
+		})
+
+		It("should default to PEM and open the secure client port", func() {
+			Ω(z.Spec.TLS.Client.Format).To(Equal(v1.TLSFormatPEM))
+			Ω(z.Spec.Ports.SecureClient).To(BeEquivalentTo(2281))
+			Ω(z.Spec.TLS.Secrets()).To(Equal([]v1.TLSSecret{{SecretName: "example-tls", Format: v1.TLSFormatPEM}}))
+		})
+
+		It("should not open the secure client port without TLS", func() {
+			other := &v1.ZookeeperCluster{}
+			other.WithDefaults()
+			Ω(other.Spec.Ports.SecureClient).To(BeZero())
+			Ω(other.Spec.TLS.Secrets()).To(BeEmpty())
+		})
+	})
+
+	Context("Auth", func() {
+		It("should default the member user and the keytab key", func() {
+			z.Spec.Auth = &v1.AuthPolicy{
+				Digest:   &v1.DigestAuth{SecretName: "example-users"},
+				Kerberos: &v1.KerberosAuth{KeytabSecretName: "example-keytab", Principal: "zookeeper/_HOST@EXAMPLE.COM"},
+			}
+			z.WithDefaults()
+			Ω(z.Spec.Auth.Digest.MemberUser).To(Equal("zookeeper"))
+			Ω(z.Spec.Auth.Kerberos.KeytabKey).To(Equal("krb5.keytab"))
+			Ω(z.Spec.Auth.Enabled()).To(BeTrue())
+		})
+
+		It("should not be enabled without a mechanism", func() {
+			Ω(z.Spec.Auth.Enabled()).To(BeFalse())
+			z.Spec.Auth = &v1.AuthPolicy{RequireClientAuth: true}
+			Ω(z.Spec.Auth.Enabled()).To(BeFalse())
+		})
+	})
+
+	Context("Upgrade strategy", func() {
+		It("should default the batch, the deadline and the health gates", func() {
+			z.Spec.UpgradeStrategy = &v1.UpgradeStrategy{}
+			z.WithDefaults()
+			Ω(z.Spec.UpgradeStrategy.BatchSize).To(BeEquivalentTo(v1.DefaultUpgradeBatchSize))
+			Ω(z.Spec.UpgradeStrategy.ProgressDeadline()).To(Equal(10 * time.Minute))
+			Ω(z.Spec.UpgradeStrategy.HealthGates.SoakSeconds).To(BeEquivalentTo(v1.DefaultUpgradeSoakSeconds))
+			Ω(z.Spec.UpgradeStrategy.HealthGates.ErrorMetrics).To(Equal(v1.DefaultUpgradeErrorMetrics))
+			Ω(z.Spec.UpgradeStrategy.IsPaused()).To(BeFalse())
+		})
+
+		It("should not be paused without a strategy", func() {
+			var strategy *v1.UpgradeStrategy
+			Ω(strategy.IsPaused()).To(BeFalse())
+		})
+
+		It("should have the default deadline without a strategy", func() {
+			var strategy *v1.UpgradeStrategy
+			Ω(strategy.ProgressDeadline()).To(Equal(10 * time.Minute))
+		})
+
+		It("should retain the backups taken before an upgrade", func() {
+			z.Spec.UpgradeStrategy = &v1.UpgradeStrategy{PreUpgradeBackup: &v1.PreUpgradeBackup{}}
+			z.WithDefaults()
+			Ω(z.Spec.UpgradeStrategy.BacksUp().ReclaimPolicy).To(Equal(v1.VolumeReclaimPolicyRetain))
+		})
+
+		It("should not back up without a strategy", func() {
+			var strategy *v1.UpgradeStrategy
+			Ω(strategy.BacksUp()).To(BeNil())
+		})
+	})
+
+	Context("Quorum TLS phases", func() {
+		It("should enable quorum TLS through port unification", func() {
+			phase := v1.QuorumTLSDisabled
+			var phases []v1.QuorumTLSPhase
+			for phase != v1.QuorumTLSEnabled {
+				phase = phase.Next(true)
+				phases = append(phases, phase)
+			}
+			Ω(phases).To(Equal([]v1.QuorumTLSPhase{
+				v1.QuorumTLSPortUnification,
+				v1.QuorumTLSWithPortUnification,
+				v1.QuorumTLSEnabled,
+			}))
+			Ω(phase.Next(true)).To(Equal(v1.QuorumTLSEnabled))
+		})
+
+		It("should disable quorum TLS the same way back", func() {
+			Ω(v1.QuorumTLSEnabled.Next(false)).To(Equal(v1.QuorumTLSWithPortUnification))
+			Ω(v1.QuorumTLSPortUnification.Next(false)).To(Equal(v1.QuorumTLSDisabled))
+			Ω(v1.QuorumTLSDisabled.Next(false)).To(Equal(v1.QuorumTLSDisabled))
+		})
+
+		It("should start a new ensemble in the phase of its spec", func() {
+			Ω(z.QuorumTLSPhase()).To(Equal(v1.QuorumTLSDisabled))
+			z.Spec.TLS = &v1.TLSPolicy{Quorum: &v1.TLSSecret{SecretName: "example-tls"}}
+			Ω(z.QuorumTLSPhase()).To(Equal(v1.QuorumTLSEnabled))
+			z.Status.QuorumTLS = &v1.QuorumTLSStatus{Phase: v1.QuorumTLSPortUnification}
+			Ω(z.QuorumTLSPhase()).To(Equal(v1.QuorumTLSPortUnification))
+		})
+	})
+
+	Context("#ContainerPorts", func() {
+		It("should name the ports in a fixed order", func() {
+			z.WithDefaults()
+			Ω(z.Spec.Ports.ContainerPorts()).To(Equal([]corev1.ContainerPort{
+				{Name: "client", ContainerPort: 2181},
+				{Name: "quorum", ContainerPort: 2888},
+				{Name: "leader-election", ContainerPort: 3888},
+				{Name: "metrics", ContainerPort: 7000},
+				{Name: "admin-server", ContainerPort: 8080},
+			}))
+		})
+
+		It("should leave out unset ports", func() {
+			z.Spec.Ports = v1.Ports{Client: 12181}
+			Ω(z.Spec.Ports.ContainerPorts()).To(Equal([]corev1.ContainerPort{
+				{Name: "client", ContainerPort: 12181},
+			}))
+		})
+	})
+
+	Context("#TriggerRollingRestart", func() {
+		It("should set and clear the annotation", func() {
+			z.SetTriggerRollingRestart(true)
+			Ω(z.GetTriggerRollingRestart()).To(BeTrue())
+			Ω(z.GetAnnotations()).To(HaveKeyWithValue(v1.TriggerRollingRestartAnnotation, "true"))
+
+			z.SetTriggerRollingRestart(false)
+			Ω(z.GetTriggerRollingRestart()).To(BeFalse())
+			Ω(z.GetAnnotations()).NotTo(HaveKey(v1.TriggerRollingRestartAnnotation))
+		})
+
+		It("should ignore values other than true", func() {
+			z.SetAnnotations(map[string]string{v1.TriggerRollingRestartAnnotation: "yes"})
+			Ω(z.GetTriggerRollingRestart()).To(BeFalse())
+		})
+	})
+
+	Context("#ZookeeperVersion", func() {
+		It("should map the operator images through the compatibility matrix", func() {
+			Ω(zookeeperVersion(&z, "0.2.15")).To(Equal("3.7.2"))
+		})
+
+		It("should read tags named after a ZooKeeper release", func() {
+			Ω(zookeeperVersion(&z, "3.8.4-custom")).To(Equal("3.8.4-custom"))
+		})
+
+		It("should not know other tags", func() {
+			_, ok := z.ZookeeperVersion("latest")
+			Ω(ok).To(BeFalse())
+			_, ok = z.ZookeeperVersion("0.2.9")
+			Ω(ok).To(BeFalse())
+		})
+
+		It("should prefer the versions of the upgrade strategy", func() {
+			z.Spec.UpgradeStrategy = &v1.UpgradeStrategy{ZookeeperVersions: map[string]string{"0.2.15": "3.7.1", "acme-7": "3.8.4"}}
+			Ω(zookeeperVersion(&z, "0.2.15")).To(Equal("3.7.1"))
+			Ω(zookeeperVersion(&z, "acme-7")).To(Equal("3.8.4"))
+		})
+	})
+
+	Context("#CheckUpgradePath", func() {
+		It("should accept patch and next minor upgrades", func() {
+			for _, to := range []string{"3.7.3", "3.8.0", "3.8.4"} {
+				warning, err := z.CheckUpgradePath("0.2.15", to)
+				Ω(err).NotTo(HaveOccurred())
+				Ω(warning).To(BeEmpty())
+			}
+		})
+
+		It("should accept a new tag of the same release", func() {
+			warning, err := z.CheckUpgradePath("3.7.2", "0.2.15")
+			Ω(err).NotTo(HaveOccurred())
+			Ω(warning).To(BeEmpty())
+		})
+
+		It("should block downgrades", func() {
+			_, err := z.CheckUpgradePath("3.8.4", "0.2.15")
+			Ω(err).To(MatchError(ContainSubstring("downgrading ZooKeeper from 3.8.4 to 3.7.2")))
+		})
+
+		It("should block upgrades skipping a minor release", func() {
+			_, err := z.CheckUpgradePath("0.2.15", "3.9.1")
+			Ω(err).To(MatchError(ContainSubstring("skips 3.8")))
+		})
+
+		It("should block upgrades across major releases", func() {
+			_, err := z.CheckUpgradePath("3.9.1", "4.0.0")
+			Ω(err).To(MatchError(ContainSubstring("major release")))
+		})
+
+		It("should only warn about unsupported paths under the Warn policy", func() {
+			z.Spec.UpgradeStrategy = &v1.UpgradeStrategy{VersionSkewPolicy: v1.VersionSkewWarn}
+			warning, err := z.CheckUpgradePath("0.2.15", "3.9.1")
+			Ω(err).NotTo(HaveOccurred())
+			Ω(warning).To(ContainSubstring("skips 3.8"))
+		})
+
+		It("should warn about unknown tags", func() {
+			warning, err := z.CheckUpgradePath("0.2.15", "latest")
+			Ω(err).NotTo(HaveOccurred())
+			Ω(warning).To(ContainSubstring("image tag latest is unknown"))
+		})
+	})
+})
+
+// zookeeperVersion returns the ZooKeeper release of a tag known to the cluster
+func zookeeperVersion(z *v1.ZookeeperCluster, tag string) string {
+	version, ok := z.ZookeeperVersion(tag)
+	Ω(ok).To(BeTrue())
+	return version
+}
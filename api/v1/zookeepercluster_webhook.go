@@ -0,0 +1,339 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// MaxReplicas is the largest ensemble size supported by the operator
+	MaxReplicas = 7
+
+	scaleWebhookPath = "/validate-zookeeper-pravega-io-v1-zookeepercluster-scale"
+)
+
+// SetupWebhookWithManager registers the ZookeeperCluster admission webhooks
+// with the manager's webhook server. Since v1 is the conversion hub, this also
+// registers the conversion webhook for the other served versions.
+func (z *ZookeeperCluster) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	err := ctrl.NewWebhookManagedBy(mgr).
+		For(z).
+		WithDefaulter(&zookeeperClusterDefaulter{}).
+		WithValidator(&zookeeperClusterValidator{}).
+		Complete()
+	if err != nil {
+		return err
+	}
+	mgr.GetWebhookServer().Register(scaleWebhookPath, &webhook.Admission{
+		Handler: &zookeeperClusterScaleValidator{reader: mgr.GetAPIReader()},
+	})
+	return nil
+}
+
+// +kubebuilder:webhook:path=/mutate-zookeeper-pravega-io-v1-zookeepercluster,mutating=true,failurePolicy=fail,sideEffects=None,groups=zookeeper.pravega.io,resources=zookeeperclusters,verbs=create;update,versions=v1,name=mzookeepercluster.kb.io,admissionReviewVersions=v1
+
+// zookeeperClusterDefaulter sets the default values of a ZookeeperCluster
+// when it is admitted, so the operator never has to write them back itself
+type zookeeperClusterDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &zookeeperClusterDefaulter{}
+
+func (d *zookeeperClusterDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	z, ok := obj.(*ZookeeperCluster)
+	if !ok {
+		return fmt.Errorf("expected a ZookeeperCluster but got a %T", obj)
+	}
+	z.WithDefaults()
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-zookeeper-pravega-io-v1-zookeepercluster,mutating=false,failurePolicy=fail,sideEffects=None,groups=zookeeper.pravega.io,resources=zookeeperclusters,verbs=create;update,versions=v1,name=vzookeepercluster.kb.io,admissionReviewVersions=v1
+
+// zookeeperClusterValidator rejects ZookeeperCluster specs that would only
+// surface later as broken pods or a lost quorum
+type zookeeperClusterValidator struct{}
+
+var _ webhook.CustomValidator = &zookeeperClusterValidator{}
+
+func (v *zookeeperClusterValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	z, ok := obj.(*ZookeeperCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected a ZookeeperCluster but got a %T", obj)
+	}
+	return nil, toInvalidError(z, z.ValidateCreate())
+}
+
+func (v *zookeeperClusterValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	z, ok := newObj.(*ZookeeperCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected a ZookeeperCluster but got a %T", newObj)
+	}
+	old, ok := oldObj.(*ZookeeperCluster)
+	if !ok {
+		return nil, fmt.Errorf("expected a ZookeeperCluster but got a %T", oldObj)
+	}
+	return z.UpdateWarnings(old), toInvalidError(z, z.ValidateUpdate(old))
+}
+
+func (v *zookeeperClusterValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// +kubebuilder:webhook:path=/validate-zookeeper-pravega-io-v1-zookeepercluster-scale,mutating=false,failurePolicy=fail,sideEffects=None,groups=zookeeper.pravega.io,resources=zookeeperclusters/scale,verbs=update,versions=v1,name=vzookeeperclusterscale.kb.io,admissionReviewVersions=v1
+
+// zookeeperClusterScaleValidator applies the replica checks of the validator
+// to updates through the scale subresource, which are admitted as a Scale
+// rather than as the ZookeeperCluster itself
+type zookeeperClusterScaleValidator struct {
+	reader client.Reader
+}
+
+var _ admission.Handler = &zookeeperClusterScaleValidator{}
+
+func (v *zookeeperClusterScaleValidator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	scale := &autoscalingv1.Scale{}
+	if err := json.Unmarshal(req.Object.Raw, scale); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	z := &ZookeeperCluster{}
+	if err := v.reader.Get(ctx, types.NamespacedName{Namespace: req.Namespace, Name: req.Name}, z); err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if err := toInvalidError(z, z.ValidateScale(scale.Spec.Replicas)); err != nil {
+		return admission.Denied(err.Error())
+	}
+	return admission.Allowed("")
+}
+
+func toInvalidError(z *ZookeeperCluster, errs field.ErrorList) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("ZookeeperCluster").GroupKind(), z.GetName(), errs)
+}
+
+// ValidateCreate returns the list of problems found in the spec of a new
+// zookeeper cluster
+func (z *ZookeeperCluster) ValidateCreate() field.ErrorList {
+	return z.Spec.validate(field.NewPath("spec"))
+}
+
+// ValidateUpdate returns the list of problems found in the spec of an updated
+// zookeeper cluster, including changes to fields that cannot be updated while
+// the cluster is in its current state
+func (z *ZookeeperCluster) ValidateUpdate(old *ZookeeperCluster) field.ErrorList {
+	specPath := field.NewPath("spec")
+	errs := z.Spec.validate(specPath)
+
+	if z.Spec.Storage.IsEphemeral() != old.Spec.Storage.IsEphemeral() {
+		errs = append(errs, field.Forbidden(specPath.Child("storage"),
+			"the storage of an existing cluster cannot be changed between persistence and ephemeral"))
+	}
+	if z.Spec.Auth.quorumEnabled() != old.Spec.Auth.quorumEnabled() {
+		errs = append(errs, field.Forbidden(specPath.Child("auth", "quorum"),
+			"the authentication between the members of an existing cluster cannot be turned on or off"))
+	}
+	// the data of the running members would be replaced as they restart
+	if z.Spec.RestoreFrom != nil && !apiequality.Semantic.DeepEqual(z.Spec.RestoreFrom, old.Spec.RestoreFrom) {
+		errs = append(errs, field.Forbidden(specPath.Child("restoreFrom"),
+			"an existing cluster cannot be restored from a backup, restoreFrom can only be removed"))
+	}
+
+	podPath := specPath.Child("pod")
+	if !old.Status.isSafeToRestartMembers() {
+		reason := "cannot be updated while the ensemble is degraded or being upgraded"
+		if !apiequality.Semantic.DeepEqual(z.Spec.Pod.Resources, old.Spec.Pod.Resources) {
+			errs = append(errs, field.Forbidden(podPath.Child("resources"), reason))
+		}
+		if !apiequality.Semantic.DeepEqual(z.Spec.Pod.Env, old.Spec.Pod.Env) {
+			errs = append(errs, field.Forbidden(podPath.Child("env"), reason))
+		}
+	}
+
+	pathErrs, _ := z.validateUpgradePath(old)
+	errs = append(errs, pathErrs...)
+	return errs
+}
+
+// UpdateWarnings returns the warnings about an update of the zookeeper
+// cluster which is admitted nonetheless
+func (z *ZookeeperCluster) UpdateWarnings(old *ZookeeperCluster) []string {
+	_, warnings := z.validateUpgradePath(old)
+	return warnings
+}
+
+// ValidateScale returns the list of problems the zookeeper cluster would have
+// once it is scaled to the given number of replicas
+func (z *ZookeeperCluster) ValidateScale(replicas int32) field.ErrorList {
+	specPath := field.NewPath("spec")
+	// unlike an unset spec field, a scale to zero does not mean the default size
+	if replicas < 1 {
+		return field.ErrorList{field.Invalid(specPath.Child("replicas"), replicas,
+			fmt.Sprintf("must be between 1 and %d", MaxReplicas))}
+	}
+	scaled := z.DeepCopy()
+	scaled.Spec.Replicas = replicas
+	return scaled.Spec.validate(specPath)
+}
+
+// validate checks the fields of the spec which are independent of the
+// previous state of the cluster
+func (s *ZookeeperClusterSpec) validate(specPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if s.Replicas < 0 || s.Replicas > MaxReplicas {
+		errs = append(errs, field.Invalid(specPath.Child("replicas"), s.Replicas,
+			fmt.Sprintf("must be between 1 and %d", MaxReplicas)))
+	}
+	errs = append(errs, s.Ports.validate(specPath.Child("ports"))...)
+	if s.TLS != nil {
+		errs = append(errs, s.TLS.validate(specPath.Child("tls"))...)
+	}
+	errs = append(errs, s.OperatorClient.validate(specPath.Child("operatorClient"), s.TLS)...)
+	if s.Auth != nil {
+		errs = append(errs, s.Auth.validate(specPath.Child("auth"))...)
+	}
+	if s.ACL.Enforced() && s.ACL.SuperUserSecretName == "" {
+		errs = append(errs, field.Required(specPath.Child("acl", "superUserSecretName"),
+			"the Secret holding the super user, which the operator needs once ACLs are enforced"))
+	}
+	if s.RestoreFrom != nil {
+		errs = append(errs, s.RestoreFrom.validate(specPath.Child("restoreFrom"))...)
+	}
+	if s.Observers != nil {
+		errs = append(errs, s.Observers.validate(specPath.Child("observers"), &s.Ports)...)
+	}
+	if s.UpgradeStrategy != nil {
+		errs = append(errs, s.UpgradeStrategy.validate(specPath.Child("upgradeStrategy"))...)
+	}
+
+	storagePath := specPath.Child("storage")
+	if s.Storage.Persistence != nil && s.Storage.Ephemeral != nil {
+		errs = append(errs, field.Forbidden(storagePath.Child("ephemeral"),
+			"only one of persistence and ephemeral may be configured"))
+	}
+	if s.UpgradeStrategy.BacksUp() != nil && s.Storage.IsEphemeral() {
+		errs = append(errs, field.Forbidden(specPath.Child("upgradeStrategy", "preUpgradeBackup"),
+			"the data of a cluster on ephemeral storage cannot be backed up"))
+	}
+
+	// The operator default of 1 is accepted for every ensemble size, larger
+	// values must still leave a majority of the voting members available.
+	replicas := s.Replicas
+	if replicas == 0 {
+		replicas = 3
+	}
+	if tolerated := (replicas - 1) / 2; s.MaxUnavailableReplicas > 1 && s.MaxUnavailableReplicas > tolerated {
+		errs = append(errs, field.Invalid(specPath.Child("maxUnavailableReplicas"), s.MaxUnavailableReplicas,
+			fmt.Sprintf("an ensemble of %d replicas keeps its quorum with at most %d unavailable replicas", replicas, tolerated)))
+	}
+	return errs
+}
+
+// validate rejects ports out of range and ports sharing a number, since
+// zookeeper binds each of them separately
+func (p *Ports) validate(portsPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	numbers := map[int32]bool{}
+	for _, port := range []struct {
+		name  string
+		value int32
+	}{
+		{"client", p.Client},
+		{"quorum", p.Quorum},
+		{"leaderElection", p.LeaderElection},
+		{"metrics", p.Metrics},
+		{"adminServer", p.AdminServer},
+		{"secureClient", p.SecureClient},
+	} {
+		// unset ports get their default value
+		if port.value == 0 {
+			continue
+		}
+		portPath := portsPath.Child(port.name)
+		if port.value < 1 || port.value > 65535 {
+			errs = append(errs, field.Invalid(portPath, port.value, "must be between 1 and 65535"))
+			continue
+		}
+		if numbers[port.value] {
+			errs = append(errs, field.Duplicate(portPath, port.value))
+		}
+		numbers[port.value] = true
+	}
+	return errs
+}
+
+// validate checks that every TLS configuration references a Secret
+func (t *TLSPolicy) validate(tlsPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if t.Client != nil && t.Client.SecretName == "" {
+		errs = append(errs, field.Required(tlsPath.Child("client", "secretName"),
+			"the Secret holding the key material of the members"))
+	}
+	if t.Quorum != nil && t.Quorum.SecretName == "" {
+		errs = append(errs, field.Required(tlsPath.Child("quorum", "secretName"),
+			"the Secret holding the key material of the members"))
+	}
+	return errs
+}
+
+// validate checks that the operator finds PEM key material for client TLS
+// and a Secret for its credentials
+func (p *OperatorClientPolicy) validate(operatorClientPath *field.Path, t *TLSPolicy) field.ErrorList {
+	var errs field.ErrorList
+	if t.ClientEnabled() && t.Client.Format == TLSFormatPKCS12 && (p == nil || p.TLSSecretName == "") {
+		errs = append(errs, field.Required(operatorClientPath.Child("tlsSecretName"),
+			"the operator only reads PEM key material, which the PKCS12 client TLS Secret does not hold"))
+	}
+	if p != nil && p.Auth != nil && p.Auth.SecretName == "" {
+		errs = append(errs, field.Required(operatorClientPath.Child("auth", "secretName"),
+			"the Secret holding the credentials of the operator"))
+	}
+	return errs
+}
+
+// validate checks that the authentication references a Secret for every
+// mechanism it configures
+func (a *AuthPolicy) validate(authPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if !a.Enabled() {
+		errs = append(errs, field.Required(authPath, "at least one of digest and kerberos"))
+	}
+	if a.Digest != nil && a.Digest.SecretName == "" {
+		errs = append(errs, field.Required(authPath.Child("digest", "secretName"),
+			"the Secret holding the digest users"))
+	}
+	if k := a.Kerberos; k != nil {
+		if k.KeytabSecretName == "" {
+			errs = append(errs, field.Required(authPath.Child("kerberos", "keytabSecretName"),
+				"the Secret holding the keytab of the members"))
+		}
+		if k.Principal == "" {
+			errs = append(errs, field.Required(authPath.Child("kerberos", "principal"),
+				"the principal of the members"))
+		}
+	}
+	return errs
+}
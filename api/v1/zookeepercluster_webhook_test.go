@@ -0,0 +1,359 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1_test
+
+import (
+	v1 "github.com/pravega/zookeeper-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func errorFields(errs field.ErrorList) []string {
+	fields := []string{}
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	return fields
+}
+
+var _ = Describe("ZookeeperCluster Webhook", func() {
+	var z *v1.ZookeeperCluster
+
+	BeforeEach(func() {
+		z = &v1.ZookeeperCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example",
+				Namespace: "default",
+			},
+		}
+	})
+
+	Context("#ValidateCreate", func() {
+		It("should accept an empty spec", func() {
+			Ω(z.ValidateCreate()).To(BeEmpty())
+		})
+
+		It("should accept a defaulted spec", func() {
+			z.WithDefaults()
+			Ω(z.ValidateCreate()).To(BeEmpty())
+		})
+
+		It("should reject more than 7 replicas", func() {
+			z.Spec.Replicas = 9
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.replicas"))
+		})
+
+		It("should reject both persistence and ephemeral storage", func() {
+			z.Spec.Storage.Persistence = &v1.Persistence{}
+			z.Spec.Storage.Ephemeral = &v1.Ephemeral{}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.storage.ephemeral"))
+		})
+
+		It("should accept ephemeral storage", func() {
+			z.Spec.Storage.Ephemeral = &v1.Ephemeral{}
+			Ω(z.ValidateCreate()).To(BeEmpty())
+		})
+
+		It("should reject ports with the same number", func() {
+			z.Spec.Ports = v1.Ports{Client: 2181, Metrics: 2181}
+			errs := z.ValidateCreate()
+			Ω(errorFields(errs)).To(ConsistOf("spec.ports.metrics"))
+			Ω(errs[0].Type).To(Equal(field.ErrorTypeDuplicate))
+		})
+
+		It("should reject a port which is the default of another port", func() {
+			z.WithDefaults()
+			z.Spec.Ports.AdminServer = v1.DefaultMetricsPort
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.ports.adminServer"))
+		})
+
+		It("should reject ports out of range", func() {
+			z.Spec.Ports = v1.Ports{Client: 65536, Quorum: -1}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.ports.client", "spec.ports.quorum"))
+		})
+
+		It("should reject client TLS without a secret", func() {
+			z.Spec.TLS = &v1.TLSPolicy{Client: &v1.ClientTLS{}}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.tls.client.secretName"))
+		})
+
+		It("should reject a secure client port which is taken", func() {
+			z.Spec.Ports = v1.Ports{Client: 2181, SecureClient: 2181}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.ports.secureClient"))
+		})
+
+		It("should require PEM key material for the operator with PKCS12 client TLS", func() {
+			z.Spec.TLS = &v1.TLSPolicy{Client: &v1.ClientTLS{
+				TLSSecret: v1.TLSSecret{SecretName: "example-tls", Format: v1.TLSFormatPKCS12},
+			}}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.operatorClient.tlsSecretName"))
+			z.Spec.OperatorClient = &v1.OperatorClientPolicy{TLSSecretName: "operator-tls"}
+			Ω(z.ValidateCreate()).To(BeEmpty())
+		})
+
+		It("should require a mechanism for the authentication", func() {
+			z.Spec.Auth = &v1.AuthPolicy{RequireClientAuth: true}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.auth"))
+		})
+
+		It("should reject authentication without its secrets", func() {
+			z.Spec.Auth = &v1.AuthPolicy{Digest: &v1.DigestAuth{}, Kerberos: &v1.KerberosAuth{}}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf(
+				"spec.auth.digest.secretName", "spec.auth.kerberos.keytabSecretName", "spec.auth.kerberos.principal"))
+		})
+
+		It("should require a super user to enforce ACLs", func() {
+			z.Spec.ACL = &v1.ACLPolicy{Enforce: true}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.acl.superUserSecretName"))
+			z.Spec.ACL.SuperUserSecretName = "example-super"
+			Ω(z.ValidateCreate()).To(BeEmpty())
+		})
+
+		It("should require the storage and the path of the artifact to restore", func() {
+			z.Spec.RestoreFrom = &v1.RestoreSource{Checksum: "0123"}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf(
+				"spec.restoreFrom.storage", "spec.restoreFrom.path", "spec.restoreFrom.checksum"))
+		})
+
+		It("should accept an observer pool", func() {
+			z.Spec.Observers = &v1.ObserverPolicy{Replicas: 3, ObserverMasterPort: 2191}
+			Ω(z.ValidateCreate()).To(BeEmpty())
+		})
+
+		It("should reject an observer master port which is taken", func() {
+			z.Spec.Observers = &v1.ObserverPolicy{Replicas: 3, ObserverMasterPort: v1.DefaultQuorumPort}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.observers.observerMasterPort"))
+		})
+
+		It("should reject a negative upgrade batch, deadline and error rate", func() {
+			z.Spec.UpgradeStrategy = &v1.UpgradeStrategy{
+				BatchSize:               -1,
+				ProgressDeadlineSeconds: -1,
+				HealthGates:             v1.UpgradeHealthGates{MaxErrorsPerMinute: -1, ErrorMetrics: []string{""}},
+			}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf(
+				"spec.upgradeStrategy.batchSize",
+				"spec.upgradeStrategy.progressDeadlineSeconds",
+				"spec.upgradeStrategy.healthGates.maxErrorsPerMinute",
+				"spec.upgradeStrategy.healthGates.errorMetrics[0]"))
+		})
+
+		It("should reject custom image tags which do not map to a ZooKeeper release", func() {
+			z.Spec.UpgradeStrategy = &v1.UpgradeStrategy{ZookeeperVersions: map[string]string{"acme-7": "3.8.4", "acme-8": "latest"}}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.upgradeStrategy.zookeeperVersions[acme-8]"))
+		})
+
+		It("should require the storage of the backup taken before an upgrade", func() {
+			z.Spec.UpgradeStrategy = &v1.UpgradeStrategy{PreUpgradeBackup: &v1.PreUpgradeBackup{}}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.upgradeStrategy.preUpgradeBackup.storage"))
+		})
+
+		It("should reject a backup before an upgrade of ephemeral members", func() {
+			z.Spec.Storage.Ephemeral = &v1.Ephemeral{}
+			z.Spec.UpgradeStrategy = &v1.UpgradeStrategy{PreUpgradeBackup: &v1.PreUpgradeBackup{
+				Storage: v1.BackupStorage{PersistentVolumeClaim: &v1.PVCStorage{ClaimName: "backups"}},
+			}}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.upgradeStrategy.preUpgradeBackup"))
+		})
+
+		It("should reject operator credentials without a secret", func() {
+			z.Spec.OperatorClient = &v1.OperatorClientPolicy{Auth: &v1.ClientCredentials{}}
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.operatorClient.auth.secretName"))
+		})
+
+		It("should accept the default maxUnavailableReplicas for a single replica", func() {
+			z.Spec.Replicas = 1
+			z.Spec.MaxUnavailableReplicas = 1
+			Ω(z.ValidateCreate()).To(BeEmpty())
+		})
+
+		It("should accept maxUnavailableReplicas which keeps the quorum", func() {
+			z.Spec.Replicas = 5
+			z.Spec.MaxUnavailableReplicas = 2
+			Ω(z.ValidateCreate()).To(BeEmpty())
+		})
+
+		It("should reject maxUnavailableReplicas which breaks the quorum", func() {
+			z.Spec.Replicas = 5
+			z.Spec.MaxUnavailableReplicas = 3
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.maxUnavailableReplicas"))
+		})
+
+		It("should check maxUnavailableReplicas against the default replica count", func() {
+			z.Spec.MaxUnavailableReplicas = 2
+			Ω(errorFields(z.ValidateCreate())).To(ConsistOf("spec.maxUnavailableReplicas"))
+		})
+	})
+
+	Context("#ValidateUpdate", func() {
+		var old *v1.ZookeeperCluster
+
+		BeforeEach(func() {
+			z.WithDefaults()
+			z.Status.Init()
+			z.Status.SetPodsReadyConditionTrue()
+			z.Status.ReadyReplicas = 3
+			old = z.DeepCopy()
+		})
+
+		It("should accept an unchanged spec", func() {
+			Ω(z.ValidateUpdate(old)).To(BeEmpty())
+		})
+
+		It("should accept writing the defaults of an existing cluster", func() {
+			old = &v1.ZookeeperCluster{ObjectMeta: z.ObjectMeta}
+			Ω(z.ValidateUpdate(old)).To(BeEmpty())
+		})
+
+		It("should reject more than 7 replicas", func() {
+			z.Spec.Replicas = 8
+			Ω(errorFields(z.ValidateUpdate(old))).To(ConsistOf("spec.replicas"))
+		})
+
+		It("should reject a change from persistence to ephemeral storage", func() {
+			z.Spec.Storage = v1.Storage{Ephemeral: &v1.Ephemeral{}}
+			Ω(errorFields(z.ValidateUpdate(old))).To(ConsistOf("spec.storage"))
+		})
+
+		It("should reject turning on the authentication between the members", func() {
+			z.Spec.Auth = &v1.AuthPolicy{Digest: &v1.DigestAuth{SecretName: "example-users"}, Quorum: true}
+			Ω(errorFields(z.ValidateUpdate(old))).To(ConsistOf("spec.auth.quorum"))
+		})
+
+		It("should reject restoring an existing cluster", func() {
+			z.Spec.RestoreFrom = &v1.RestoreSource{
+				Storage: v1.BackupStorage{PersistentVolumeClaim: &v1.PVCStorage{ClaimName: "backups"}},
+				Path:    "default/example/nightly.tar.gz",
+			}
+			Ω(errorFields(z.ValidateUpdate(old))).To(ConsistOf("spec.restoreFrom"))
+		})
+
+		It("should accept removing the restore of a cluster", func() {
+			old.Spec.RestoreFrom = &v1.RestoreSource{
+				Storage: v1.BackupStorage{PersistentVolumeClaim: &v1.PVCStorage{ClaimName: "backups"}},
+				Path:    "default/example/nightly.tar.gz",
+			}
+			Ω(z.ValidateUpdate(old)).To(BeEmpty())
+		})
+
+		It("should accept turning on the authentication of the clients", func() {
+			z.Spec.Auth = &v1.AuthPolicy{Digest: &v1.DigestAuth{SecretName: "example-users"}, RequireClientAuth: true}
+			Ω(z.ValidateUpdate(old)).To(BeEmpty())
+		})
+
+		It("should accept a change of the ephemeral storage settings", func() {
+			old.Spec.Storage = v1.Storage{Ephemeral: &v1.Ephemeral{}}
+			z.Spec.Storage = v1.Storage{Ephemeral: &v1.Ephemeral{
+				EmptyDirVolumeSource: corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+			}}
+			Ω(z.ValidateUpdate(old)).To(BeEmpty())
+		})
+
+		It("should accept resource changes on a ready cluster", func() {
+			z.Spec.Pod.Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+			Ω(z.ValidateUpdate(old)).To(BeEmpty())
+		})
+
+		It("should accept env changes on a cluster which never became ready", func() {
+			old.Status.SetPodsReadyConditionFalse()
+			old.Status.ReadyReplicas = 0
+			z.Spec.Pod.Env = []corev1.EnvVar{{Name: "FOO", Value: "bar"}}
+			Ω(z.ValidateUpdate(old)).To(BeEmpty())
+		})
+
+		It("should reject resource and env changes on a degraded cluster", func() {
+			old.Status.SetPodsReadyConditionFalse()
+			old.Status.ReadyReplicas = 2
+			z.Spec.Pod.Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+			z.Spec.Pod.Env = []corev1.EnvVar{{Name: "FOO", Value: "bar"}}
+			Ω(errorFields(z.ValidateUpdate(old))).To(ConsistOf("spec.pod.resources", "spec.pod.env"))
+		})
+
+		It("should reject env changes during an upgrade", func() {
+			old.Status.SetUpgradingConditionTrue("", "")
+			z.Spec.Pod.Env = []corev1.EnvVar{{Name: "FOO", Value: "bar"}}
+			Ω(errorFields(z.ValidateUpdate(old))).To(ConsistOf("spec.pod.env"))
+		})
+
+		Context("with members running a known version", func() {
+			BeforeEach(func() {
+				old.Spec.Image.Tag = "0.2.15"
+				old.Status.CurrentVersion = "0.2.15"
+			})
+
+			It("should accept an upgrade to the next minor release", func() {
+				z.Spec.Image.Tag = "3.8.4"
+				Ω(z.ValidateUpdate(old)).To(BeEmpty())
+				Ω(z.UpdateWarnings(old)).To(BeEmpty())
+			})
+
+			It("should reject an upgrade skipping a minor release", func() {
+				z.Spec.Image.Tag = "3.9.1"
+				Ω(errorFields(z.ValidateUpdate(old))).To(ConsistOf("spec.image.tag"))
+			})
+
+			It("should reject a downgrade of a custom image", func() {
+				z.Spec.UpgradeStrategy = &v1.UpgradeStrategy{ZookeeperVersions: map[string]string{"acme-6": "3.6.4"}}
+				z.Spec.Image.Tag = "acme-6"
+				Ω(errorFields(z.ValidateUpdate(old))).To(ConsistOf("spec.image.tag"))
+			})
+
+			It("should warn about an unsupported upgrade under the Warn policy", func() {
+				z.Spec.UpgradeStrategy = &v1.UpgradeStrategy{VersionSkewPolicy: v1.VersionSkewWarn}
+				z.Spec.Image.Tag = "3.9.1"
+				Ω(z.ValidateUpdate(old)).To(BeEmpty())
+				Ω(z.UpdateWarnings(old)).To(ConsistOf(ContainSubstring("skips 3.8")))
+			})
+
+			It("should warn about an upgrade to an unknown tag", func() {
+				z.Spec.Image.Tag = "latest"
+				Ω(z.ValidateUpdate(old)).To(BeEmpty())
+				Ω(z.UpdateWarnings(old)).To(HaveLen(1))
+			})
+
+			It("should not check a spec which keeps its tag", func() {
+				old.Spec.Image.Tag = "3.9.1"
+				z.Spec.Image.Tag = "3.9.1"
+				Ω(z.ValidateUpdate(old)).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("#ValidateScale", func() {
+		BeforeEach(func() {
+			z.WithDefaults()
+		})
+
+		It("should accept a scale within the supported sizes", func() {
+			Ω(z.ValidateScale(5)).To(BeEmpty())
+			Ω(z.Spec.Replicas).To(BeEquivalentTo(3))
+		})
+
+		It("should reject a scale to zero", func() {
+			Ω(errorFields(z.ValidateScale(0))).To(ConsistOf("spec.replicas"))
+		})
+
+		It("should reject more than 7 replicas", func() {
+			Ω(errorFields(z.ValidateScale(8))).To(ConsistOf("spec.replicas"))
+		})
+
+		It("should reject a scale which breaks the quorum of maxUnavailableReplicas", func() {
+			z.Spec.Replicas = 5
+			z.Spec.MaxUnavailableReplicas = 2
+			Ω(errorFields(z.ValidateScale(3))).To(ConsistOf("spec.maxUnavailableReplicas"))
+		})
+	})
+})
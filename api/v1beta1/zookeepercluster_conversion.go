@@ -0,0 +1,428 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1beta1
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
+)
+
+// FieldsAnnotation keeps the parts of a v1beta1 spec which have no exact
+// counterpart in v1, so that they survive a round trip through v1
+const FieldsAnnotation = "zookeeper.pravega.io/v1beta1-fields"
+
+// V1FieldsAnnotation keeps the parts of a v1 spec which v1beta1 cannot
+// describe, so that an update through v1beta1 does not drop them
+const V1FieldsAnnotation = "zookeeper.pravega.io/v1-fields"
+
+// conditionReasonRegexp is the format v1 requires for condition reasons
+var conditionReasonRegexp = regexp.MustCompile(`^[A-Za-z]([A-Za-z0-9_,:]*[A-Za-z0-9_])?$`)
+
+// v1beta1Fields is the content of the FieldsAnnotation. The ports, the
+// storage and every condition are restored separately, and only as long as v1
+// still describes the same ports, storage or condition, since they may have
+// been changed through v1.
+type v1beta1Fields struct {
+	Ports      *portFields        `json:"ports,omitempty"`
+	Storage    *storageFields     `json:"storage,omitempty"`
+	Conditions []ClusterCondition `json:"conditions,omitempty"`
+}
+
+// v1Fields is the content of the V1FieldsAnnotation
+type v1Fields struct {
+	TLS             *zookeeperv1.TLSPolicy            `json:"tls,omitempty"`
+	OperatorClient  *zookeeperv1.OperatorClientPolicy `json:"operatorClient,omitempty"`
+	Auth            *zookeeperv1.AuthPolicy           `json:"auth,omitempty"`
+	ACL             *zookeeperv1.ACLPolicy            `json:"acl,omitempty"`
+	RestoreFrom     *zookeeperv1.RestoreSource        `json:"restoreFrom,omitempty"`
+	Observers       *zookeeperv1.ObserverPolicy       `json:"observers,omitempty"`
+	UpgradeStrategy *zookeeperv1.UpgradeStrategy      `json:"upgradeStrategy,omitempty"`
+}
+
+type portFields struct {
+	Ports []v1.ContainerPort `json:"ports,omitempty"`
+}
+
+type storageFields struct {
+	StorageType string       `json:"storageType,omitempty"`
+	Persistence *Persistence `json:"persistence,omitempty"`
+	Ephemeral   *Ephemeral   `json:"ephemeral,omitempty"`
+}
+
+var _ conversion.Convertible = &ZookeeperCluster{}
+
+// ConvertTo converts this ZookeeperCluster to the hub version (v1)
+func (z *ZookeeperCluster) ConvertTo(hub conversion.Hub) error {
+	dst := hub.(*zookeeperv1.ZookeeperCluster)
+	src := z.DeepCopy()
+
+	v1FieldsData, hasV1Fields := src.Annotations[V1FieldsAnnotation]
+	dst.ObjectMeta = src.ObjectMeta
+	delete(dst.Annotations, FieldsAnnotation)
+	delete(dst.Annotations, V1FieldsAnnotation)
+	dst.SetTriggerRollingRestart(src.Spec.TriggerRollingRestart)
+
+	s := &src.Spec
+	dst.Spec = zookeeperv1.ZookeeperClusterSpec{
+		Image:                   zookeeperv1.ContainerImage(s.Image),
+		Labels:                  s.Labels,
+		Replicas:                s.Replicas,
+		Ports:                   toV1Ports(s.Ports),
+		Pod:                     zookeeperv1.PodPolicy(s.Pod),
+		AdminServerService:      zookeeperv1.AdminServerServicePolicy(s.AdminServerService),
+		ClientService:           zookeeperv1.ClientServicePolicy(s.ClientService),
+		HeadlessService:         zookeeperv1.HeadlessServicePolicy(s.HeadlessService),
+		Storage:                 toV1Storage(s.storageFields()),
+		Conf:                    zookeeperv1.ZookeeperConfig(s.Conf),
+		DomainName:              s.DomainName,
+		KubernetesClusterDomain: s.KubernetesClusterDomain,
+		Containers:              s.Containers,
+		InitContainers:          s.InitContainers,
+		Volumes:                 s.Volumes,
+		VolumeMounts:            s.VolumeMounts,
+		MaxUnavailableReplicas:  s.MaxUnavailableReplicas,
+	}
+	if s.Probes != nil {
+		dst.Spec.Probes = &zookeeperv1.Probes{
+			ReadinessProbe: (*zookeeperv1.Probe)(s.Probes.ReadinessProbe),
+			LivenessProbe:  (*zookeeperv1.Probe)(s.Probes.LivenessProbe),
+		}
+	}
+	if hasV1Fields {
+		var fields v1Fields
+		if err := json.Unmarshal([]byte(v1FieldsData), &fields); err != nil {
+			return err
+		}
+		dst.Spec.TLS = fields.TLS
+		dst.Spec.OperatorClient = fields.OperatorClient
+		dst.Spec.Auth = fields.Auth
+		dst.Spec.ACL = fields.ACL
+		dst.Spec.RestoreFrom = fields.RestoreFrom
+		dst.Spec.Observers = fields.Observers
+		dst.Spec.UpgradeStrategy = fields.UpgradeStrategy
+	}
+
+	// Keep whatever a conversion back from v1 would not reproduce.
+	var fields v1beta1Fields
+	canonical := fromV1(&dst.Spec)
+	if !apiequality.Semantic.DeepEqual(s.Ports, canonical.Ports) {
+		fields.Ports = &portFields{Ports: s.Ports}
+	}
+	if storage := s.storageFields(); !apiequality.Semantic.DeepEqual(storage, canonical.storageFields()) {
+		fields.Storage = &storage
+	}
+	dst.Status = toV1Status(&src.Status, src.CreationTimestamp)
+	// a condition keeps its reason and its times only if they are valid in
+	// v1, and its last update time only if it is the upgrading condition
+	canonicalStatus := fromV1Status(&dst.Status)
+	for i, c := range src.Status.Conditions {
+		if c != canonicalStatus.Conditions[i] {
+			fields.Conditions = append(fields.Conditions, c)
+		}
+	}
+	if fields.Ports != nil || fields.Storage != nil || len(fields.Conditions) > 0 {
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[FieldsAnnotation] = string(data)
+	}
+	return nil
+}
+
+// ConvertFrom converts from the hub version (v1) to this version
+func (z *ZookeeperCluster) ConvertFrom(hub conversion.Hub) error {
+	src := hub.(*zookeeperv1.ZookeeperCluster).DeepCopy()
+
+	z.ObjectMeta = src.ObjectMeta
+	z.Spec = *fromV1(&src.Spec)
+	z.Spec.TriggerRollingRestart = src.GetTriggerRollingRestart()
+	delete(z.Annotations, zookeeperv1.TriggerRollingRestartAnnotation)
+	delete(z.Annotations, V1FieldsAnnotation)
+	v1Only := v1Fields{TLS: src.Spec.TLS, OperatorClient: src.Spec.OperatorClient, Auth: src.Spec.Auth, ACL: src.Spec.ACL,
+		RestoreFrom: src.Spec.RestoreFrom, Observers: src.Spec.Observers, UpgradeStrategy: src.Spec.UpgradeStrategy}
+	if v1Only != (v1Fields{}) {
+		data, err := json.Marshal(v1Only)
+		if err != nil {
+			return err
+		}
+		if z.Annotations == nil {
+			z.Annotations = map[string]string{}
+		}
+		z.Annotations[V1FieldsAnnotation] = string(data)
+	}
+
+	z.Status = fromV1Status(&src.Status)
+	if data, ok := z.Annotations[FieldsAnnotation]; ok {
+		delete(z.Annotations, FieldsAnnotation)
+		var fields v1beta1Fields
+		if err := json.Unmarshal([]byte(data), &fields); err != nil {
+			return err
+		}
+		if fields.Ports != nil && apiequality.Semantic.DeepEqual(toV1Ports(fields.Ports.Ports), src.Spec.Ports) {
+			z.Spec.Ports = fields.Ports.Ports
+		}
+		if fields.Storage != nil && apiequality.Semantic.DeepEqual(toV1Storage(*fields.Storage), src.Spec.Storage) {
+			z.Spec.StorageType = fields.Storage.StorageType
+			z.Spec.Persistence = fields.Storage.Persistence
+			z.Spec.Ephemeral = fields.Storage.Ephemeral
+		}
+		for _, original := range fields.Conditions {
+			for i, c := range z.Status.Conditions {
+				if c.Type == original.Type && conditionUnchanged(original, &src.Status, src.CreationTimestamp) {
+					z.Status.Conditions[i] = original
+				}
+			}
+		}
+	}
+	if len(z.Annotations) == 0 {
+		z.Annotations = nil
+	}
+	return nil
+}
+
+// fromV1 returns the v1beta1 spec which describes the same cluster as the
+// given v1 spec
+func fromV1(s *zookeeperv1.ZookeeperClusterSpec) *ZookeeperClusterSpec {
+	spec := &ZookeeperClusterSpec{
+		Image:                   ContainerImage(s.Image),
+		Labels:                  s.Labels,
+		Replicas:                s.Replicas,
+		Ports:                   s.Ports.ContainerPorts(),
+		Pod:                     PodPolicy(s.Pod),
+		AdminServerService:      AdminServerServicePolicy(s.AdminServerService),
+		ClientService:           ClientServicePolicy(s.ClientService),
+		HeadlessService:         HeadlessServicePolicy(s.HeadlessService),
+		Conf:                    ZookeeperConfig(s.Conf),
+		DomainName:              s.DomainName,
+		KubernetesClusterDomain: s.KubernetesClusterDomain,
+		Containers:              s.Containers,
+		InitContainers:          s.InitContainers,
+		Volumes:                 s.Volumes,
+		VolumeMounts:            s.VolumeMounts,
+		MaxUnavailableReplicas:  s.MaxUnavailableReplicas,
+	}
+	if s.Probes != nil {
+		spec.Probes = &Probes{
+			ReadinessProbe: (*Probe)(s.Probes.ReadinessProbe),
+			LivenessProbe:  (*Probe)(s.Probes.LivenessProbe),
+		}
+	}
+	if s.Storage.Ephemeral != nil {
+		spec.StorageType = "ephemeral"
+		spec.Ephemeral = (*Ephemeral)(s.Storage.Ephemeral)
+	} else if p := s.Storage.Persistence; p != nil {
+		spec.StorageType = "persistence"
+		spec.Persistence = &Persistence{
+			VolumeReclaimPolicy:       VolumeReclaimPolicy(p.VolumeReclaimPolicy),
+			PersistentVolumeClaimSpec: p.PersistentVolumeClaimSpec,
+			Annotations:               p.Annotations,
+		}
+	}
+	return spec
+}
+
+func (s *ZookeeperClusterSpec) storageFields() storageFields {
+	return storageFields{
+		StorageType: s.StorageType,
+		Persistence: s.Persistence,
+		Ephemeral:   s.Ephemeral,
+	}
+}
+
+// toV1Ports picks the zookeeper ports out of the port list by name, the same
+// way ZookeeperPorts does. The secure client port is only known to v1.
+func toV1Ports(ports []v1.ContainerPort) zookeeperv1.Ports {
+	p := (&ZookeeperCluster{Spec: ZookeeperClusterSpec{Ports: ports}}).ZookeeperPorts()
+	v1Ports := zookeeperv1.Ports{
+		Client:         p.Client,
+		Quorum:         p.Quorum,
+		LeaderElection: p.Leader,
+		Metrics:        p.Metrics,
+		AdminServer:    p.AdminServer,
+	}
+	for _, port := range ports {
+		if port.Name == "secure-client" {
+			v1Ports.SecureClient = port.ContainerPort
+		}
+	}
+	return v1Ports
+}
+
+// toV1Storage keeps only the storage the storage type selects, as the
+// operator does. An ephemeral storage type always selects ephemeral storage.
+func toV1Storage(s storageFields) zookeeperv1.Storage {
+	if strings.EqualFold(s.StorageType, "ephemeral") {
+		ephemeral := &zookeeperv1.Ephemeral{}
+		if s.Ephemeral != nil {
+			ephemeral = (*zookeeperv1.Ephemeral)(s.Ephemeral)
+		}
+		return zookeeperv1.Storage{Ephemeral: ephemeral}
+	}
+	if p := s.Persistence; p != nil {
+		return zookeeperv1.Storage{Persistence: &zookeeperv1.Persistence{
+			VolumeReclaimPolicy:       zookeeperv1.VolumeReclaimPolicy(p.VolumeReclaimPolicy),
+			PersistentVolumeClaimSpec: p.PersistentVolumeClaimSpec,
+			Annotations:               p.Annotations,
+		}}
+	}
+	return zookeeperv1.Storage{}
+}
+
+// toV1Status converts the status. Condition times which do not parse, e.g.
+// the empty times of conditions which never transitioned, fall back to the
+// creation of the cluster, since v1 requires the transition time.
+func toV1Status(s *ZookeeperClusterStatus, created metav1.Time) zookeeperv1.ZookeeperClusterStatus {
+	status := zookeeperv1.ZookeeperClusterStatus{
+		Members:                zookeeperv1.MembersStatus(s.Members),
+		Replicas:               s.Replicas,
+		ReadyReplicas:          s.ReadyReplicas,
+		InternalClientEndpoint: s.InternalClientEndpoint,
+		ExternalClientEndpoint: s.ExternalClientEndpoint,
+		MetaRootCreated:        s.MetaRootCreated,
+		CurrentVersion:         s.CurrentVersion,
+		TargetVersion:          s.TargetVersion,
+	}
+	for _, c := range s.Conditions {
+		status.Conditions = append(status.Conditions, metav1.Condition{
+			Type:               string(c.Type),
+			Status:             metav1.ConditionStatus(c.Status),
+			Reason:             toV1Reason(string(c.Type), metav1.ConditionStatus(c.Status), c.Reason),
+			Message:            c.Message,
+			LastTransitionTime: parseConditionTime(c.LastTransitionTime, created),
+		})
+		// v1 only tracks when the upgrading condition was last updated
+		if c.Type == ClusterConditionUpgrading && c.LastUpdateTime != c.LastTransitionTime && c.LastUpdateTime != "" {
+			updated := parseConditionTime(c.LastUpdateTime, created)
+			status.LastUpgradeProgressTime = &updated
+		}
+	}
+	return status
+}
+
+func fromV1Status(s *zookeeperv1.ZookeeperClusterStatus) ZookeeperClusterStatus {
+	status := ZookeeperClusterStatus{
+		Members:                MembersStatus(s.Members),
+		Replicas:               s.Replicas,
+		ReadyReplicas:          s.ReadyReplicas,
+		InternalClientEndpoint: s.InternalClientEndpoint,
+		ExternalClientEndpoint: s.ExternalClientEndpoint,
+		MetaRootCreated:        s.MetaRootCreated,
+		CurrentVersion:         s.CurrentVersion,
+		TargetVersion:          s.TargetVersion,
+	}
+	for _, c := range s.Conditions {
+		transitioned := formatConditionTime(c.LastTransitionTime)
+		updated := transitioned
+		if c.Type == ClusterConditionUpgrading && s.LastUpgradeProgressTime != nil {
+			updated = formatConditionTime(*s.LastUpgradeProgressTime)
+		}
+		status.Conditions = append(status.Conditions, ClusterCondition{
+			Type:               ClusterConditionType(c.Type),
+			Status:             v1.ConditionStatus(c.Status),
+			Reason:             fromV1Reason(c.Type, c.Status, c.Reason),
+			Message:            c.Message,
+			LastUpdateTime:     updated,
+			LastTransitionTime: transitioned,
+		})
+	}
+	return status
+}
+
+// toV1Reason maps the reasons v1beta1 uses to ones which are valid in v1
+func toV1Reason(conditionType string, status metav1.ConditionStatus, reason string) string {
+	switch reason {
+	case "":
+		return zookeeperv1.DefaultConditionReason(conditionType, status)
+	case UpdatingZookeeperReason:
+		return zookeeperv1.UpdatingZookeeperReason
+	case UpgradeErrorReason:
+		return zookeeperv1.UpgradeErrorReason
+	}
+	if conditionReasonRegexp.MatchString(reason) {
+		return reason
+	}
+	// reasons the operator never sets are squeezed into the v1 format
+	reason = strings.Map(func(r rune) rune {
+		if r == '_' || r == ',' || r == ':' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') {
+			return r
+		}
+		return -1
+	}, strings.TrimLeft(reason, "0123456789_,: "))
+	reason = strings.TrimRight(reason, ",:")
+	if reason == "" {
+		return zookeeperv1.DefaultConditionReason(conditionType, status)
+	}
+	return reason
+}
+
+func fromV1Reason(conditionType string, status metav1.ConditionStatus, reason string) string {
+	switch reason {
+	case zookeeperv1.DefaultConditionReason(conditionType, status):
+		return ""
+	case zookeeperv1.UpdatingZookeeperReason:
+		return UpdatingZookeeperReason
+	case zookeeperv1.UpgradeErrorReason:
+		return UpgradeErrorReason
+	}
+	return reason
+}
+
+// conditionUnchanged reports whether v1 still describes the original
+// condition the way it was converted, i.e. the condition was not updated
+// through v1 since
+func conditionUnchanged(original ClusterCondition, s *zookeeperv1.ZookeeperClusterStatus, created metav1.Time) bool {
+	converted := toV1Status(&ZookeeperClusterStatus{Conditions: []ClusterCondition{original}}, created)
+	for _, c := range s.Conditions {
+		if c.Type != string(original.Type) {
+			continue
+		}
+		c.ObservedGeneration = 0
+		if !apiequality.Semantic.DeepEqual(c, converted.Conditions[0]) {
+			return false
+		}
+		return original.Type != ClusterConditionUpgrading ||
+			apiequality.Semantic.DeepEqual(s.LastUpgradeProgressTime, converted.LastUpgradeProgressTime)
+	}
+	return false
+}
+
+// parseConditionTime parses a v1beta1 condition time, falling back to the
+// given time, or to now without one, when it does not parse
+func parseConditionTime(value string, fallback metav1.Time) metav1.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if fallback.IsZero() {
+			return metav1.Now().Rfc3339Copy()
+		}
+		return fallback
+	}
+	return metav1.NewTime(t)
+}
+
+func formatConditionTime(t metav1.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
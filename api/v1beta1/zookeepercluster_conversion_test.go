@@ -0,0 +1,281 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package v1beta1_test
+
+import (
+	"time"
+
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
+	"github.com/pravega/zookeeper-operator/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ZookeeperCluster Conversion", func() {
+	var z *v1beta1.ZookeeperCluster
+
+	roundTrip := func(z *v1beta1.ZookeeperCluster) *v1beta1.ZookeeperCluster {
+		hub := &zookeeperv1.ZookeeperCluster{}
+		Ω(z.ConvertTo(hub)).To(Succeed())
+		back := &v1beta1.ZookeeperCluster{}
+		Ω(back.ConvertFrom(hub)).To(Succeed())
+		return back
+	}
+
+	BeforeEach(func() {
+		z = &v1beta1.ZookeeperCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example",
+				Namespace: "default",
+			},
+		}
+	})
+
+	Context("a defaulted cluster", func() {
+		var hub *zookeeperv1.ZookeeperCluster
+
+		BeforeEach(func() {
+			z.WithDefaults()
+			z.Spec.Pod.Env = []v1.EnvVar{{Name: "FOO", Value: "bar"}}
+			z.Spec.Conf.AdditionalConfig = map[string]string{"4lw.commands.whitelist": "*"}
+			hub = &zookeeperv1.ZookeeperCluster{}
+			Ω(z.ConvertTo(hub)).To(Succeed())
+		})
+
+		It("should convert the ports and the storage", func() {
+			Ω(hub.Spec.Ports).To(Equal(zookeeperv1.Ports{
+				Client:         2181,
+				Quorum:         2888,
+				LeaderElection: 3888,
+				Metrics:        7000,
+				AdminServer:    8080,
+			}))
+			Ω(hub.Spec.Storage.Persistence).NotTo(BeNil())
+			Ω(hub.Spec.Storage.Ephemeral).To(BeNil())
+		})
+
+		It("should not need any annotations", func() {
+			Ω(hub.GetAnnotations()).To(BeEmpty())
+		})
+
+		It("should survive a round trip", func() {
+			Ω(roundTrip(z)).To(Equal(z))
+		})
+	})
+
+	Context("a spec v1 cannot describe", func() {
+		BeforeEach(func() {
+			z.Spec.Ports = []v1.ContainerPort{
+				{Name: "client", ContainerPort: 2181},
+				{Name: "jmx", ContainerPort: 9999},
+			}
+			z.Spec.StorageType = "Ephemeral"
+			z.Spec.Persistence = &v1beta1.Persistence{VolumeReclaimPolicy: v1beta1.VolumeReclaimPolicyDelete}
+		})
+
+		It("should keep it in an annotation", func() {
+			hub := &zookeeperv1.ZookeeperCluster{}
+			Ω(z.ConvertTo(hub)).To(Succeed())
+			Ω(hub.GetAnnotations()).To(HaveKey(v1beta1.FieldsAnnotation))
+			Ω(hub.Spec.Storage.IsEphemeral()).To(BeTrue())
+			Ω(hub.Spec.Storage.Persistence).To(BeNil())
+		})
+
+		It("should survive a round trip", func() {
+			Ω(roundTrip(z)).To(Equal(z))
+		})
+
+		It("should drop the kept fields once they were changed in v1", func() {
+			hub := &zookeeperv1.ZookeeperCluster{}
+			Ω(z.ConvertTo(hub)).To(Succeed())
+			hub.Spec.Ports.Client = 12181
+			back := &v1beta1.ZookeeperCluster{}
+			Ω(back.ConvertFrom(hub)).To(Succeed())
+			Ω(back.Spec.Ports).To(Equal([]v1.ContainerPort{{Name: "client", ContainerPort: 12181}}))
+			Ω(back.Spec.StorageType).To(Equal("Ephemeral"))
+			Ω(back.GetAnnotations()).To(BeEmpty())
+		})
+	})
+
+	Context("a v1 spec with TLS", func() {
+		var hub *zookeeperv1.ZookeeperCluster
+
+		BeforeEach(func() {
+			hub = &zookeeperv1.ZookeeperCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+				Spec: zookeeperv1.ZookeeperClusterSpec{
+					TLS: &zookeeperv1.TLSPolicy{
+						Client: &zookeeperv1.ClientTLS{
+							TLSSecret: zookeeperv1.TLSSecret{SecretName: "example-tls"},
+						},
+					},
+					OperatorClient: &zookeeperv1.OperatorClientPolicy{
+						Auth: &zookeeperv1.ClientCredentials{SecretName: "operator-credentials"},
+					},
+					Auth: &zookeeperv1.AuthPolicy{
+						Digest: &zookeeperv1.DigestAuth{SecretName: "example-users"},
+					},
+					ACL: &zookeeperv1.ACLPolicy{Enforce: true, SuperUserSecretName: "example-super"},
+					RestoreFrom: &zookeeperv1.RestoreSource{
+						Storage: zookeeperv1.BackupStorage{
+							PersistentVolumeClaim: &zookeeperv1.PVCStorage{ClaimName: "backups"},
+						},
+						Path: "default/example/nightly.tar.gz",
+					},
+					Observers:       &zookeeperv1.ObserverPolicy{Replicas: 2, ObserverMasterPort: 2191},
+					UpgradeStrategy: &zookeeperv1.UpgradeStrategy{BatchSize: 2, Paused: true},
+				},
+			}
+			hub.WithDefaults()
+			Ω(z.ConvertFrom(hub)).To(Succeed())
+		})
+
+		It("should keep the TLS settings in an annotation", func() {
+			Ω(z.GetAnnotations()).To(HaveKey(v1beta1.V1FieldsAnnotation))
+			Ω(z.Spec.Ports).To(ContainElement(v1.ContainerPort{Name: "secure-client", ContainerPort: 2281}))
+		})
+
+		It("should restore them when converted back", func() {
+			back := &zookeeperv1.ZookeeperCluster{}
+			Ω(z.ConvertTo(back)).To(Succeed())
+			Ω(back.Spec.TLS).To(Equal(hub.Spec.TLS))
+			Ω(back.Spec.OperatorClient).To(Equal(hub.Spec.OperatorClient))
+			Ω(back.Spec.Auth).To(Equal(hub.Spec.Auth))
+			Ω(back.Spec.ACL).To(Equal(hub.Spec.ACL))
+			Ω(back.Spec.RestoreFrom).To(Equal(hub.Spec.RestoreFrom))
+			Ω(back.Spec.Observers).To(Equal(hub.Spec.Observers))
+			Ω(back.Spec.UpgradeStrategy).To(Equal(hub.Spec.UpgradeStrategy))
+			Ω(back.Spec.Ports).To(Equal(hub.Spec.Ports))
+			Ω(back.GetAnnotations()).To(BeEmpty())
+		})
+	})
+
+	Context("triggerRollingRestart", func() {
+		It("should become an annotation", func() {
+			z.Spec.TriggerRollingRestart = true
+			hub := &zookeeperv1.ZookeeperCluster{}
+			Ω(z.ConvertTo(hub)).To(Succeed())
+			Ω(hub.GetTriggerRollingRestart()).To(BeTrue())
+			Ω(roundTrip(z)).To(Equal(z))
+		})
+	})
+
+	Context("status", func() {
+		BeforeEach(func() {
+			z.Status = v1beta1.ZookeeperClusterStatus{
+				Replicas:       3,
+				ReadyReplicas:  3,
+				CurrentVersion: "0.2.14",
+				TargetVersion:  "0.2.15",
+				Conditions: []v1beta1.ClusterCondition{
+					{
+						Type:               v1beta1.ClusterConditionPodsReady,
+						Status:             v1.ConditionTrue,
+						LastUpdateTime:     "2024-01-01T10:00:00Z",
+						LastTransitionTime: "2024-01-01T10:00:00Z",
+					},
+					{
+						Type:               v1beta1.ClusterConditionUpgrading,
+						Status:             v1.ConditionTrue,
+						Reason:             v1beta1.UpdatingZookeeperReason,
+						Message:            "1",
+						LastUpdateTime:     "2024-01-01T10:05:00Z",
+						LastTransitionTime: "2024-01-01T10:01:00Z",
+					},
+					{
+						Type:               v1beta1.ClusterConditionError,
+						Status:             v1.ConditionFalse,
+						LastUpdateTime:     "2024-01-01T09:00:00Z",
+						LastTransitionTime: "2024-01-01T09:00:00Z",
+					},
+				},
+			}
+		})
+
+		It("should use valid reasons in v1", func() {
+			hub := &zookeeperv1.ZookeeperCluster{}
+			Ω(z.ConvertTo(hub)).To(Succeed())
+			reasons := []string{}
+			for _, c := range hub.Status.Conditions {
+				reasons = append(reasons, c.Reason)
+			}
+			Ω(reasons).To(Equal([]string{"AllPodsReady", zookeeperv1.UpdatingZookeeperReason, "NoError"}))
+			Ω(hub.Status.LastUpgradeProgressTime.UTC().Format("15:04")).To(Equal("10:05"))
+			Ω(hub.Status.IsClusterInUpgradingState()).To(BeTrue())
+		})
+
+		It("should survive a round trip", func() {
+			Ω(roundTrip(z)).To(Equal(z))
+		})
+
+		Context("with conditions v1 cannot describe", func() {
+			var hub *zookeeperv1.ZookeeperCluster
+
+			BeforeEach(func() {
+				z.CreationTimestamp = metav1.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+				z.Status.Conditions = []v1beta1.ClusterCondition{
+					{
+						Type:   v1beta1.ClusterConditionPodsReady,
+						Status: v1.ConditionFalse,
+					},
+					{
+						Type:               v1beta1.ClusterConditionUpgrading,
+						Status:             v1.ConditionFalse,
+						LastUpdateTime:     "",
+						LastTransitionTime: "2024-01-01T10:01:00Z",
+					},
+					{
+						Type:               v1beta1.ClusterConditionError,
+						Status:             v1.ConditionTrue,
+						Reason:             "some reason!",
+						Message:            "the members do not start",
+						LastUpdateTime:     "2024-01-01T10:05:00Z",
+						LastTransitionTime: "2024-01-01T10:00:00Z",
+					},
+				}
+				hub = &zookeeperv1.ZookeeperCluster{}
+				Ω(z.ConvertTo(hub)).To(Succeed())
+			})
+
+			It("should fall back to the creation of the cluster for empty times", func() {
+				_, c := hub.Status.GetClusterCondition(zookeeperv1.ClusterConditionPodsReady)
+				Ω(c.LastTransitionTime.Time).To(Equal(z.CreationTimestamp.Time))
+				Ω(hub.Status.LastUpgradeProgressTime).To(BeNil())
+			})
+
+			It("should use a valid reason in v1", func() {
+				_, c := hub.Status.GetClusterCondition(zookeeperv1.ClusterConditionError)
+				Ω(c.Reason).To(Equal("somereason"))
+			})
+
+			It("should keep them in an annotation", func() {
+				Ω(hub.Annotations).To(HaveKey(v1beta1.FieldsAnnotation))
+				Ω(hub.Annotations[v1beta1.FieldsAnnotation]).To(ContainSubstring("some reason!"))
+			})
+
+			It("should survive a round trip", func() {
+				Ω(roundTrip(z)).To(Equal(z))
+			})
+
+			It("should drop a kept condition once it was updated in v1", func() {
+				hub.Status.SetErrorConditionFalse()
+				back := &v1beta1.ZookeeperCluster{}
+				Ω(back.ConvertFrom(hub)).To(Succeed())
+				Ω(back.Status.Conditions[2].Status).To(Equal(v1.ConditionFalse))
+				Ω(back.Status.Conditions[2].Reason).To(BeEmpty())
+				Ω(back.Status.Conditions[0]).To(Equal(z.Status.Conditions[0]))
+			})
+		})
+	})
+})
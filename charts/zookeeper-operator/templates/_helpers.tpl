@@ -49,3 +49,38 @@ Default volume template
 {{- define "chart.additionalVolumes"}}
 {{ toYaml .Values.additionalVolumes }}
 {{- end}}
+
+{{/*
+Whether the webhooks are served. Only an explicit webhook.enabled=false turns
+them off, releases upgraded with values which predate the key keep them.
+*/}}
+{{- define "zookeeper-operator.webhookEnabled" -}}
+{{- if ne (toString (dig "enabled" true (.Values.webhook | default dict))) "false" }}true{{- end }}
+{{- end -}}
+
+{{/*
+Annotations of the zookeepercluster CRD
+*/}}
+{{- define "zookeeper-operator.crdAnnotations" -}}
+{{- if include "zookeeper-operator.webhookEnabled" . }}
+cert-manager.io/inject-ca-from: {{ .Release.Namespace }}/{{ template "zookeeper-operator.fullname" . }}-webhook-cert
+{{- end }}
+{{- end -}}
+
+{{/*
+Conversion webhook of the zookeepercluster CRD, which serves v1beta1 from the stored v1 resources
+*/}}
+{{- define "zookeeper-operator.crdConversion" -}}
+{{- if include "zookeeper-operator.webhookEnabled" . }}
+conversion:
+  strategy: Webhook
+  webhook:
+    clientConfig:
+      service:
+        name: {{ template "zookeeper-operator.fullname" . }}-webhook
+        namespace: {{ .Release.Namespace }}
+        path: /convert
+    conversionReviewVersions:
+    - v1
+{{- end }}
+{{- end -}}
@@ -0,0 +1,169 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/samuel/go-zookeeper/zk"
+
+	"github.com/pravega/zookeeper-operator/pkg/version"
+	"github.com/pravega/zookeeper-operator/pkg/znodedump"
+)
+
+const usage = `Usage: zookeeper-znodedump <command> [options]
+
+Commands:
+  export   dump a znode tree to a JSON or YAML file
+  import   load a dump into an ensemble
+  version  show the version
+
+Run zookeeper-znodedump <command> -help for the options of a command.
+`
+
+// authFlags collects the repeated -auth options
+type authFlags []string
+
+func (a *authFlags) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *authFlags) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return fmt.Errorf("expected scheme:credentials, got %q", value)
+	}
+	*a = append(*a, value)
+	return nil
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "export":
+		err = runExport(os.Args[2:])
+	case "import":
+		err = runImport(os.Args[2:])
+	case "version":
+		fmt.Printf("zookeeper-znodedump Version: %v\nGit SHA: %s\n", version.Version, version.GitSHA)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runExport(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	server := flags.String("server", "localhost:2181", "Comma separated zookeeper servers")
+	root := flags.String("root", "/", "Root of the tree to dump")
+	format := flags.String("format", "json", "Format of the dump, json or yaml")
+	output := flags.String("o", "", "Output file, standard output by default")
+	auth := authFlags{}
+	flags.Var(&auth, "auth", "Credentials as scheme:credentials, e.g. digest:user:password, can be repeated")
+	_ = flags.Parse(args)
+
+	conn, err := connect(*server, auth)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	dump, err := znodedump.Export(conn, *root)
+	if err != nil {
+		return err
+	}
+	data, err := znodedump.Marshal(dump, znodedump.Format(*format))
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err = os.WriteFile(*output, data, 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d znodes from %s to %s\n", len(dump.Nodes), dump.Root, *output)
+	return nil
+}
+
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	server := flags.String("server", "localhost:2181", "Comma separated zookeeper servers")
+	input := flags.String("i", "", "Dump to load, JSON or YAML")
+	root := flags.String("root", "", "Root to load the dump at, the root it was dumped from by default")
+	conflict := flags.String("conflict", string(znodedump.ConflictSkip), "What to do with existing znodes: skip, overwrite or fail")
+	ephemerals := flags.Bool("ephemerals", false, "Load ephemeral znodes as persistent ones instead of leaving them out")
+	noACL := flags.Bool("no-acl", false, "Load the znodes with the open ACL instead of their own")
+	auth := authFlags{}
+	flags.Var(&auth, "auth", "Credentials as scheme:credentials, e.g. digest:user:password, can be repeated")
+	_ = flags.Parse(args)
+
+	if *input == "" {
+		return fmt.Errorf("the dump to load is missing, set -i")
+	}
+	policy := znodedump.ConflictPolicy(*conflict)
+	if policy != znodedump.ConflictSkip && policy != znodedump.ConflictOverwrite && policy != znodedump.ConflictFail {
+		return fmt.Errorf("unknown conflict policy %q, expected skip, overwrite or fail", *conflict)
+	}
+	data, err := os.ReadFile(*input)
+	if err != nil {
+		return err
+	}
+	dump, err := znodedump.Unmarshal(data)
+	if err != nil {
+		return fmt.Errorf("Error reading dump %s: %v", *input, err)
+	}
+
+	conn, err := connect(*server, auth)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	result, err := znodedump.Import(conn, dump, znodedump.ImportOptions{
+		Root:       *root,
+		Conflict:   policy,
+		Ephemerals: *ephemerals,
+		WorldACL:   *noACL,
+	})
+	if result != nil {
+		fmt.Fprintf(os.Stderr, "Created %d, overwrote %d and skipped %d znodes\n",
+			result.Created, result.Overwritten, result.Skipped)
+	}
+	return err
+}
+
+func connect(server string, auth authFlags) (*zk.Conn, error) {
+	conn, _, err := zk.Connect(strings.Split(server, ","), 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to zookeeper: %s, Reason: %v", server, err)
+	}
+	for _, a := range auth {
+		parts := strings.SplitN(a, ":", 2)
+		if err = conn.AddAuth(parts[0], []byte(parts[1])); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("Failed to authenticate with scheme %s: %v", parts[0], err)
+		}
+	}
+	return conn, nil
+}
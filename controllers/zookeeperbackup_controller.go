@@ -0,0 +1,362 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
+	"github.com/pravega/zookeeper-operator/pkg/utils"
+	"github.com/pravega/zookeeper-operator/pkg/zk"
+)
+
+var backupLog = logf.Log.WithName("controller_zookeeperbackup")
+
+var _ reconcile.Reconciler = &ZookeeperBackupReconciler{}
+
+// ZookeeperBackupReconciler reconciles a ZookeeperBackup object
+type ZookeeperBackupReconciler struct {
+	Client   client.Client
+	Log      logr.Logger
+	Scheme   *runtime.Scheme
+	ZkClient zk.ZookeeperClient
+}
+
+// backupResult is the termination message of a successful backup job
+type backupResult struct {
+	Zxid     string `json:"zxid"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+// +kubebuilder:rbac:groups=zookeeper.pravega.io,resources=zookeeperbackups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=zookeeper.pravega.io,resources=zookeeperbackups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+func (r *ZookeeperBackupReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	r.Log = backupLog.WithValues(
+		"Request.Namespace", request.Namespace,
+		"Request.Name", request.Name)
+
+	backup := &zookeeperv1.ZookeeperBackup{}
+	err := r.Client.Get(ctx, request.NamespacedName, backup)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if !backup.GetDeletionTimestamp().IsZero() {
+		return r.reclaimArtifact(ctx, backup)
+	}
+	if backup.Status.IsFinished() {
+		return reconcile.Result{}, nil
+	}
+	backup.WithDefaults()
+	if backup.Spec.ReclaimPolicy == zookeeperv1.VolumeReclaimPolicyDelete &&
+		!utils.ContainsString(backup.Finalizers, utils.BackupFinalizer) {
+		finalizers := append(append([]string{}, backup.Finalizers...), utils.BackupFinalizer)
+		if err = r.updateFinalizers(ctx, backup, finalizers); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	job := &batchv1.Job{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: backup.Name, Namespace: backup.Namespace}, job)
+	if errors.IsNotFound(err) {
+		return r.startBackup(ctx, backup)
+	} else if err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, r.observeBackupJob(ctx, backup, job)
+}
+
+// startBackup creates the job of the backup next to a ready member of the
+// cluster, preferably one which does not lead the ensemble
+func (r *ZookeeperBackupReconciler) startBackup(ctx context.Context, backup *zookeeperv1.ZookeeperBackup) (ctrl.Result, error) {
+	if err := backup.Spec.Storage.Validate(); err != nil {
+		return reconcile.Result{}, r.finishBackup(ctx, backup, zookeeperv1.BackupPhaseFailed, fmt.Sprintf("Invalid storage: %v", err))
+	}
+	cluster := &zookeeperv1.ZookeeperCluster{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: backup.Spec.ZookeeperCluster, Namespace: backup.Namespace}, cluster)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, r.finishBackup(ctx, backup, zookeeperv1.BackupPhaseFailed,
+				fmt.Sprintf("ZookeeperCluster %s not found", backup.Spec.ZookeeperCluster))
+		}
+		return reconcile.Result{}, err
+	}
+	cluster.WithDefaults()
+	// the job runs the version of the members, which an upgrade waiting for
+	// its backup has not changed yet
+	if version := cluster.Status.CurrentVersion; version != "" {
+		cluster.Spec.Image.Tag = version
+	}
+	if cluster.Spec.Storage.IsEphemeral() {
+		return reconcile.Result{}, r.finishBackup(ctx, backup, zookeeperv1.BackupPhaseFailed,
+			fmt.Sprintf("ZookeeperCluster %s keeps its data on ephemeral storage", cluster.Name))
+	}
+
+	member, err := r.selectBackupMember(ctx, cluster)
+	if err != nil {
+		r.Log.Info("Waiting for a member to back up", "reason", err.Error())
+		backup.Status.Phase = zookeeperv1.BackupPhasePending
+		backup.Status.Message = err.Error()
+		if err := r.updateStatus(ctx, backup); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: ReconcileTime}, nil
+	}
+
+	job := zk.MakeBackupJob(backup, cluster, member)
+	if err = controllerutil.SetControllerReference(backup, job, r.Scheme); err != nil {
+		return reconcile.Result{}, err
+	}
+	r.Log.Info("Creating a new backup job", "Job.Name", job.Name, "Member", member)
+	if err = r.Client.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+		return reconcile.Result{}, err
+	}
+	now := metav1.Now()
+	backup.Status.Phase = zookeeperv1.BackupPhaseRunning
+	backup.Status.Member = member
+	backup.Status.Location = backup.ArtifactLocation()
+	backup.Status.StartTime = &now
+	backup.Status.Message = ""
+	return reconcile.Result{}, r.updateStatus(ctx, backup)
+}
+
+// selectBackupMember returns a ready member of the cluster which answers
+func (r *ZookeeperBackupReconciler) selectBackupMember(ctx context.Context, cluster *zookeeperv1.ZookeeperCluster) (string, error) {
+	// the leader is only backed up when no other member is ready
+	members := []string{}
+	for _, member := range cluster.Status.Members.Ready {
+		if member != cluster.Status.Leader {
+			members = append(members, member)
+		}
+	}
+	if utils.ContainsString(cluster.Status.Members.Ready, cluster.Status.Leader) {
+		members = append(members, cluster.Status.Leader)
+	}
+	for _, member := range members {
+		pod := &corev1.Pod{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: member, Namespace: cluster.Namespace}, pod)
+		if err != nil {
+			continue
+		}
+		memberUri, err := utils.GetMemberUri(cluster, pod)
+		if err != nil {
+			continue
+		}
+		tlsConfig, err := clientTLSConfig(ctx, r.Client, cluster, utils.GetMemberHost(cluster, pod))
+		if err != nil {
+			return "", err
+		}
+		if _, err = r.ZkClient.ServerStats(memberUri, tlsConfig); err != nil {
+			r.Log.Info("Member cannot be backed up", "Member", member, "reason", err.Error())
+			continue
+		}
+		return member, nil
+	}
+	return "", fmt.Errorf("No member of ZookeeperCluster %s is ready", cluster.Name)
+}
+
+// observeBackupJob records the result of the job once it finished
+func (r *ZookeeperBackupReconciler) observeBackupJob(ctx context.Context, backup *zookeeperv1.ZookeeperBackup, job *batchv1.Job) error {
+	condition := finishedJobCondition(job)
+	if condition == nil {
+		return nil
+	}
+	message, err := r.backupJobMessage(ctx, job)
+	if err != nil {
+		return err
+	}
+	if condition.Type == batchv1.JobFailed {
+		if message == "" {
+			message = condition.Message
+		}
+		return r.finishBackup(ctx, backup, zookeeperv1.BackupPhaseFailed, fmt.Sprintf("Job %s failed: %s", job.Name, message))
+	}
+	result := backupResult{}
+	if err = json.Unmarshal([]byte(message), &result); err != nil {
+		return r.finishBackup(ctx, backup, zookeeperv1.BackupPhaseFailed,
+			fmt.Sprintf("Invalid result of job %s: %v", job.Name, err))
+	}
+	backup.Status.Zxid = result.Zxid
+	backup.Status.Size = result.Size
+	backup.Status.Checksum = result.Checksum
+	return r.finishBackup(ctx, backup, zookeeperv1.BackupPhaseSucceeded, "")
+}
+
+// finishedJobCondition returns the condition the job completed or failed
+// with, or nil while it runs
+func finishedJobCondition(job *batchv1.Job) *batchv1.JobCondition {
+	for i, condition := range job.Status.Conditions {
+		if condition.Status == corev1.ConditionTrue &&
+			(condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) {
+			return &job.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// backupJobMessage returns the last line of the termination message of the
+// pod of a finished job
+func (r *ZookeeperBackupReconciler) backupJobMessage(ctx context.Context, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+	err := r.Client.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels(job.Spec.Template.Labels))
+	if err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if terminated := status.State.Terminated; terminated != nil && terminated.Message != "" {
+				lines := strings.Split(strings.TrimSpace(terminated.Message), "\n")
+				return lines[len(lines)-1], nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func (r *ZookeeperBackupReconciler) finishBackup(ctx context.Context, backup *zookeeperv1.ZookeeperBackup, phase zookeeperv1.BackupPhase, message string) error {
+	now := metav1.Now()
+	backup.Status.Phase = phase
+	backup.Status.Message = message
+	backup.Status.CompletionTime = &now
+	if phase == zookeeperv1.BackupPhaseFailed {
+		r.Log.Info("Backup failed", "reason", message)
+	} else {
+		r.Log.Info("Backup succeeded", "Location", backup.Status.Location)
+	}
+	return r.updateStatus(ctx, backup)
+}
+
+// reclaimArtifact deletes the artifact of a backup which is being deleted
+// through a job, and lets the backup go once the job finished
+func (r *ZookeeperBackupReconciler) reclaimArtifact(ctx context.Context, backup *zookeeperv1.ZookeeperBackup) (ctrl.Result, error) {
+	if !utils.ContainsString(backup.Finalizers, utils.BackupFinalizer) {
+		return reconcile.Result{}, nil
+	}
+	// nothing was written before the backup job was created
+	if backup.Status.Location != "" {
+		job := &batchv1.Job{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: backup.Name, Namespace: backup.Namespace}, job)
+		if err == nil && finishedJobCondition(job) == nil {
+			// the artifact could still be written while the job runs
+			if job.GetDeletionTimestamp().IsZero() {
+				r.Log.Info("Stopping the backup job", "Job.Name", job.Name)
+				err = r.Client.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationForeground))
+				if err != nil && !errors.IsNotFound(err) {
+					return reconcile.Result{}, err
+				}
+			}
+			return reconcile.Result{RequeueAfter: ReconcileTime}, nil
+		} else if err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+
+		deleteJob := &batchv1.Job{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: backup.Name + "-delete", Namespace: backup.Namespace}, deleteJob)
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, r.startDeleteJob(ctx, backup)
+		} else if err != nil {
+			return reconcile.Result{}, err
+		}
+		condition := finishedJobCondition(deleteJob)
+		if condition == nil {
+			return reconcile.Result{}, nil
+		}
+		if condition.Type == batchv1.JobFailed {
+			// the backup is not held back by an artifact which cannot be deleted
+			r.Log.Info("The artifact could not be deleted and has to be deleted by hand",
+				"Location", backup.Status.Location, "reason", condition.Message)
+		} else {
+			r.Log.Info("Deleted the artifact", "Location", backup.Status.Location)
+		}
+	}
+	return reconcile.Result{}, r.updateFinalizers(ctx, backup, utils.RemoveString(backup.Finalizers, utils.BackupFinalizer))
+}
+
+// updateFinalizers patches the finalizers of the backup, which leaves the
+// defaults applied to its spec in memory out of the write
+func (r *ZookeeperBackupReconciler) updateFinalizers(ctx context.Context, backup *zookeeperv1.ZookeeperBackup, finalizers []string) (err error) {
+	patched := backup.DeepCopy()
+	patched.ObjectMeta.Finalizers = finalizers
+	if err = r.Client.Patch(ctx, patched, client.MergeFrom(backup)); err != nil {
+		return err
+	}
+	backup.ObjectMeta = patched.ObjectMeta
+	return nil
+}
+
+// updateStatus writes the status of the backup from a copy which carries the
+// spec as it is stored, so that the defaults applied in memory are never
+// persisted
+func (r *ZookeeperBackupReconciler) updateStatus(ctx context.Context, backup *zookeeperv1.ZookeeperBackup) (err error) {
+	stored := &zookeeperv1.ZookeeperBackup{}
+	if err = r.Client.Get(ctx, client.ObjectKeyFromObject(backup), stored); err != nil {
+		return err
+	}
+	write := stored.DeepCopy()
+	backup.ObjectMeta.DeepCopyInto(&write.ObjectMeta)
+	backup.Status.DeepCopyInto(&write.Status)
+	if err = r.Client.Status().Update(ctx, write); err != nil {
+		return err
+	}
+	backup.ObjectMeta = write.ObjectMeta
+	return nil
+}
+
+// startDeleteJob creates the job which deletes the artifact of the backup
+func (r *ZookeeperBackupReconciler) startDeleteJob(ctx context.Context, backup *zookeeperv1.ZookeeperBackup) error {
+	cluster := &zookeeperv1.ZookeeperCluster{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: backup.Spec.ZookeeperCluster, Namespace: backup.Namespace}, cluster)
+	if errors.IsNotFound(err) {
+		// the artifact outlives the cluster, it is deleted with the default
+		// image
+		cluster = &zookeeperv1.ZookeeperCluster{}
+	} else if err != nil {
+		return err
+	}
+	cluster.WithDefaults()
+	job := zk.MakeBackupDeleteJob(backup, cluster)
+	if err = controllerutil.SetControllerReference(backup, job, r.Scheme); err != nil {
+		return err
+	}
+	r.Log.Info("Creating a job to delete the artifact", "Job.Name", job.Name, "Location", backup.Status.Location)
+	if err = r.Client.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func (r *ZookeeperBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&zookeeperv1.ZookeeperBackup{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
@@ -0,0 +1,310 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package controllers
+
+import (
+	"context"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
+	"github.com/pravega/zookeeper-operator/pkg/utils"
+	"github.com/pravega/zookeeper-operator/pkg/zk"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ZookeeperBackup Controller", func() {
+	const (
+		Name      = "nightly"
+		Namespace = "default"
+	)
+
+	var (
+		s       = scheme.Scheme
+		cl      client.Client
+		r       *ZookeeperBackupReconciler
+		req     reconcile.Request
+		res     reconcile.Result
+		err     error
+		b       *zookeeperv1.ZookeeperBackup
+		z       *zookeeperv1.ZookeeperCluster
+		objects []client.Object
+	)
+
+	foundBackup := func() *zookeeperv1.ZookeeperBackup {
+		backup := &zookeeperv1.ZookeeperBackup{}
+		Ω(cl.Get(context.TODO(), req.NamespacedName, backup)).To(Succeed())
+		return backup
+	}
+
+	memberPod := func(name string, ip string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: Namespace},
+			Status:     corev1.PodStatus{PodIP: ip},
+		}
+	}
+
+	BeforeEach(func() {
+		req = reconcile.Request{NamespacedName: types.NamespacedName{Name: Name, Namespace: Namespace}}
+		z = &zookeeperv1.ZookeeperCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: Namespace},
+		}
+		z.Status.Members.Ready = []string{"example-0", "example-1"}
+		z.Status.Leader = "example-0"
+		b = &zookeeperv1.ZookeeperBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: Name, Namespace: Namespace},
+			Spec: zookeeperv1.ZookeeperBackupSpec{
+				ZookeeperCluster: "example",
+				Storage: zookeeperv1.BackupStorage{
+					PersistentVolumeClaim: &zookeeperv1.PVCStorage{ClaimName: "backups"},
+				},
+			},
+		}
+		s.AddKnownTypes(zookeeperv1.GroupVersion, &zookeeperv1.ZookeeperCluster{}, &zookeeperv1.ZookeeperBackup{}, &zookeeperv1.ZookeeperBackupList{})
+		objects = []client.Object{
+			memberPod("example-0", "10.0.0.1"),
+			memberPod("example-1", "10.0.0.2"),
+		}
+	})
+
+	JustBeforeEach(func() {
+		mockZkClient := &MockZookeeperClient{serverStats: map[string]*zk.ServerStats{
+			"10.0.0.1:2181": {Mode: "leader", Zxid: 0x200000010},
+			"10.0.0.2:2181": {Mode: "follower", Zxid: 0x20000000f},
+		}}
+		cl = fake.NewClientBuilder().WithScheme(s).WithObjects(append(objects, z, b)...).WithStatusSubresource(b).Build()
+		r = &ZookeeperBackupReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+		res, err = r.Reconcile(context.TODO(), req)
+	})
+
+	It("should back up a follower through a job", func() {
+		Ω(err).To(BeNil())
+		job := &batchv1.Job{}
+		Ω(cl.Get(context.TODO(), req.NamespacedName, job)).To(Succeed())
+		Ω(job.OwnerReferences).To(HaveLen(1))
+		Ω(job.Spec.Template.Spec.Volumes).To(ContainElement(HaveField("VolumeSource.PersistentVolumeClaim.ClaimName", "data-example-1")))
+
+		backup := foundBackup()
+		Ω(backup.Status.Phase).To(Equal(zookeeperv1.BackupPhaseRunning))
+		Ω(backup.Status.Member).To(Equal("example-1"))
+		Ω(backup.Status.Zxid).To(BeEmpty())
+		Ω(backup.Status.Location).To(Equal("pvc://backups/default/example/nightly.tar.gz"))
+		Ω(backup.Status.StartTime).NotTo(BeNil())
+	})
+
+	Context("with only the leader ready", func() {
+		BeforeEach(func() {
+			z.Status.Members.Ready = []string{"example-0"}
+		})
+
+		It("should back up the leader", func() {
+			Ω(foundBackup().Status.Member).To(Equal("example-0"))
+		})
+	})
+
+	Context("without a ready member", func() {
+		BeforeEach(func() {
+			z.Status.Members.Ready = nil
+		})
+
+		It("should wait for one", func() {
+			Ω(err).To(BeNil())
+			Ω(res.RequeueAfter).To(Equal(ReconcileTime))
+			backup := foundBackup()
+			Ω(backup.Status.Phase).To(Equal(zookeeperv1.BackupPhasePending))
+			Ω(backup.Status.Message).To(ContainSubstring("No member of ZookeeperCluster example is ready"))
+		})
+	})
+
+	Context("of a cluster with ephemeral storage", func() {
+		BeforeEach(func() {
+			z.Spec.Storage.Ephemeral = &zookeeperv1.Ephemeral{}
+		})
+
+		It("should fail", func() {
+			backup := foundBackup()
+			Ω(backup.Status.Phase).To(Equal(zookeeperv1.BackupPhaseFailed))
+			Ω(backup.Status.Message).To(ContainSubstring("ephemeral storage"))
+			Ω(backup.Status.CompletionTime).NotTo(BeNil())
+		})
+	})
+
+	Context("without a storage", func() {
+		BeforeEach(func() {
+			b.Spec.Storage = zookeeperv1.BackupStorage{}
+		})
+
+		It("should fail", func() {
+			Ω(foundBackup().Status.Phase).To(Equal(zookeeperv1.BackupPhaseFailed))
+		})
+	})
+
+	Context("with a finished job", func() {
+		var (
+			job *batchv1.Job
+			pod *corev1.Pod
+		)
+
+		BeforeEach(func() {
+			b.Status.Phase = zookeeperv1.BackupPhaseRunning
+			job = zk.MakeBackupJob(b, z, "example-1")
+			pod = &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      Name + "-abcde",
+					Namespace: Namespace,
+					Labels:    job.Spec.Template.Labels,
+				},
+				Status: corev1.PodStatus{
+					ContainerStatuses: []corev1.ContainerStatus{{
+						State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+							Message: `{"zxid":"0x20000000c","size":1024,"checksum":"sha256:0123"}`,
+						}},
+					}},
+				},
+			}
+			objects = append(objects, job, pod)
+		})
+
+		Context("which completed", func() {
+			BeforeEach(func() {
+				job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+			})
+
+			It("should record the artifact", func() {
+				Ω(err).To(BeNil())
+				backup := foundBackup()
+				Ω(backup.Status.Phase).To(Equal(zookeeperv1.BackupPhaseSucceeded))
+				Ω(backup.Status.Zxid).To(Equal("0x20000000c"))
+				Ω(backup.Status.Size).To(BeEquivalentTo(1024))
+				Ω(backup.Status.Checksum).To(Equal("sha256:0123"))
+				Ω(backup.Status.CompletionTime).NotTo(BeNil())
+			})
+		})
+
+		Context("which failed", func() {
+			BeforeEach(func() {
+				job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+				pod.Status.ContainerStatuses[0].State.Terminated.Message = "+ tar -czf\nNo snapshot in /data/version-2\n"
+			})
+
+			It("should record the reason", func() {
+				backup := foundBackup()
+				Ω(backup.Status.Phase).To(Equal(zookeeperv1.BackupPhaseFailed))
+				Ω(backup.Status.Message).To(Equal("Job nightly failed: No snapshot in /data/version-2"))
+			})
+		})
+
+		Context("which is still running", func() {
+			It("should wait for it", func() {
+				Ω(err).To(BeNil())
+				Ω(foundBackup().Status.Phase).To(Equal(zookeeperv1.BackupPhaseRunning))
+			})
+		})
+	})
+
+	Context("with the Delete reclaim policy", func() {
+		BeforeEach(func() {
+			b.Spec.ReclaimPolicy = zookeeperv1.VolumeReclaimPolicyDelete
+		})
+
+		It("should hold the backup until its artifact is deleted", func() {
+			Ω(err).To(BeNil())
+			Ω(foundBackup().Finalizers).To(ContainElement(utils.BackupFinalizer))
+		})
+
+		Context("on S3", func() {
+			BeforeEach(func() {
+				b.Spec.Storage = zookeeperv1.BackupStorage{
+					S3: &zookeeperv1.S3Storage{Bucket: "backups"},
+				}
+			})
+
+			It("should not write the defaults back to the backup spec", func() {
+				Ω(err).To(BeNil())
+				backup := foundBackup()
+				Ω(backup.Finalizers).To(ContainElement(utils.BackupFinalizer))
+				Ω(backup.Spec.Storage.S3.Region).To(BeEmpty())
+				Ω(backup.Status.Phase).To(Equal(zookeeperv1.BackupPhaseRunning))
+			})
+		})
+	})
+
+	Context("which is deleted", func() {
+		var (
+			job       *batchv1.Job
+			deleteJob *batchv1.Job
+		)
+
+		BeforeEach(func() {
+			now := metav1.Now()
+			b.Spec.ReclaimPolicy = zookeeperv1.VolumeReclaimPolicyDelete
+			b.Finalizers = []string{utils.BackupFinalizer}
+			b.DeletionTimestamp = &now
+			b.Status.Phase = zookeeperv1.BackupPhaseSucceeded
+			b.Status.Location = b.ArtifactLocation()
+			job = zk.MakeBackupJob(b, z, "example-1")
+			job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+			deleteJob = zk.MakeBackupDeleteJob(b, z)
+			objects = append(objects, job)
+		})
+
+		It("should delete the artifact through a job", func() {
+			Ω(err).To(BeNil())
+			Ω(cl.Get(context.TODO(), types.NamespacedName{Name: Name + "-delete", Namespace: Namespace}, &batchv1.Job{})).To(Succeed())
+			Ω(foundBackup().Finalizers).To(ContainElement(utils.BackupFinalizer))
+		})
+
+		Context("while the backup job runs", func() {
+			BeforeEach(func() {
+				job.Status.Conditions = nil
+			})
+
+			It("should stop the backup job first", func() {
+				Ω(err).To(BeNil())
+				Ω(res.RequeueAfter).To(Equal(ReconcileTime))
+				Ω(cl.Get(context.TODO(), req.NamespacedName, &batchv1.Job{})).NotTo(Succeed())
+				Ω(cl.Get(context.TODO(), types.NamespacedName{Name: Name + "-delete", Namespace: Namespace}, &batchv1.Job{})).NotTo(Succeed())
+			})
+		})
+
+		Context("once the artifact is deleted", func() {
+			BeforeEach(func() {
+				deleteJob.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+				objects = append(objects, deleteJob)
+			})
+
+			It("should let the backup go", func() {
+				Ω(err).To(BeNil())
+				Ω(cl.Get(context.TODO(), req.NamespacedName, &zookeeperv1.ZookeeperBackup{})).NotTo(Succeed())
+			})
+		})
+
+		Context("before anything was written", func() {
+			BeforeEach(func() {
+				b.Status = zookeeperv1.ZookeeperBackupStatus{Phase: zookeeperv1.BackupPhaseFailed}
+			})
+
+			It("should let the backup go", func() {
+				Ω(err).To(BeNil())
+				Ω(cl.Get(context.TODO(), req.NamespacedName, &zookeeperv1.ZookeeperBackup{})).NotTo(Succeed())
+			})
+		})
+	})
+})
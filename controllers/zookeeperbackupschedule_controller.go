@@ -0,0 +1,274 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
+	"github.com/pravega/zookeeper-operator/pkg/utils"
+	"github.com/pravega/zookeeper-operator/pkg/zk"
+)
+
+const (
+	// invalidScheduleMessage starts the status message of a schedule whose
+	// cron expression cannot be parsed
+	invalidScheduleMessage = "Invalid schedule"
+
+	// maxMissedSchedules bounds the times counted as missed in a single
+	// reconcile, as the CronJob controller does
+	maxMissedSchedules = 100
+)
+
+var backupScheduleLog = logf.Log.WithName("controller_zookeeperbackupschedule")
+
+var _ reconcile.Reconciler = &ZookeeperBackupScheduleReconciler{}
+
+// ZookeeperBackupScheduleReconciler reconciles a ZookeeperBackupSchedule
+// object
+type ZookeeperBackupScheduleReconciler struct {
+	Client client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// now returns the current time, it is replaced in tests
+	now func() time.Time
+}
+
+// +kubebuilder:rbac:groups=zookeeper.pravega.io,resources=zookeeperbackupschedules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=zookeeper.pravega.io,resources=zookeeperbackupschedules/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=zookeeper.pravega.io,resources=zookeeperbackups,verbs=get;list;watch;create;update;patch;delete
+
+func (r *ZookeeperBackupScheduleReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	r.Log = backupScheduleLog.WithValues(
+		"Request.Namespace", request.Namespace,
+		"Request.Name", request.Name)
+
+	schedule := &zookeeperv1.ZookeeperBackupSchedule{}
+	err := r.Client.Get(ctx, request.NamespacedName, schedule)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	schedule.WithDefaults()
+	now := r.currentTime()
+
+	cron, err := utils.ParseCronSchedule(schedule.Spec.Schedule)
+	if err != nil {
+		// the schedule is not retried until it is updated
+		schedule.Status.Message = fmt.Sprintf("%s: %v", invalidScheduleMessage, err)
+		schedule.Status.NextScheduleTime = nil
+		return reconcile.Result{}, r.Client.Status().Update(ctx, schedule)
+	}
+	if strings.HasPrefix(schedule.Status.Message, invalidScheduleMessage) {
+		schedule.Status.Message = ""
+	}
+
+	backups := &zookeeperv1.ZookeeperBackupList{}
+	err = r.Client.List(ctx, backups, client.InNamespace(schedule.Namespace),
+		client.MatchingLabels{zk.BackupScheduleLabel: schedule.Name})
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	active, err := r.collectBackups(ctx, schedule, backups.Items, now)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if err = r.runSchedule(ctx, schedule, cron, active, now); err != nil {
+		return reconcile.Result{}, err
+	}
+	if err = r.Client.Status().Update(ctx, schedule); err != nil {
+		return reconcile.Result{}, err
+	}
+	if schedule.Status.NextScheduleTime == nil {
+		return reconcile.Result{}, nil
+	}
+	return reconcile.Result{RequeueAfter: schedule.Status.NextScheduleTime.Sub(now)}, nil
+}
+
+// collectBackups records the backups of the schedule in its status, deletes
+// those which expired and returns the one which did not finish, if any
+func (r *ZookeeperBackupScheduleReconciler) collectBackups(ctx context.Context, schedule *zookeeperv1.ZookeeperBackupSchedule, backups []zookeeperv1.ZookeeperBackup, now time.Time) (string, error) {
+	active := ""
+	finished := []zookeeperv1.ZookeeperBackup{}
+	for _, backup := range backups {
+		if !backup.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		if backup.Status.IsFinished() {
+			finished = append(finished, backup)
+		} else {
+			active = backup.Name
+		}
+	}
+	schedule.Status.Active = active
+
+	// the newest backups first
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[j].Status.CompletionTime.Before(finished[i].Status.CompletionTime)
+	})
+	retention := schedule.Spec.Retention
+	succeeded := int32(0)
+	for _, backup := range finished {
+		expired := false
+		if backup.Status.Phase == zookeeperv1.BackupPhaseSucceeded {
+			succeeded++
+			if succeeded == 1 {
+				schedule.Status.LastSuccessfulBackup = backup.Name
+				schedule.Status.LastSuccessfulTime = backup.Status.CompletionTime
+			}
+			expired = retention.MaxCount != nil && succeeded > *retention.MaxCount
+		} else {
+			// a failure is reported until a later backup succeeded
+			expired = succeeded > 0
+		}
+		if retention.MaxAge != nil && now.Sub(backup.Status.CompletionTime.Time) > retention.MaxAge.Duration {
+			expired = true
+		}
+		if !expired {
+			continue
+		}
+		r.Log.Info("Deleting an expired backup", "ZookeeperBackup.Name", backup.Name, "Location", backup.Status.Location)
+		if err := r.Client.Delete(ctx, &backup); err != nil && !errors.IsNotFound(err) {
+			return "", err
+		}
+	}
+	return active, nil
+}
+
+// runSchedule creates the backup which is due, unless the previous backup is
+// still running, and reports the times a backup was missed
+func (r *ZookeeperBackupScheduleReconciler) runSchedule(ctx context.Context, schedule *zookeeperv1.ZookeeperBackupSchedule, cron *utils.CronSchedule, active string, now time.Time) error {
+	status := &schedule.Status
+	last := schedule.GetCreationTimestamp().Time
+	if status.LastScheduleTime != nil {
+		last = status.LastScheduleTime.Time
+	}
+	if last.IsZero() {
+		last = now
+	}
+
+	// only the latest of the times which passed since the last backup is
+	// due, the earlier ones were missed while the operator was not running
+	due, lastMissed := time.Time{}, time.Time{}
+	missed, tooMany := int32(0), false
+	for t := cron.Next(last); !t.IsZero() && !t.After(now); t = cron.Next(t) {
+		if !due.IsZero() {
+			if missed == maxMissedSchedules {
+				// the times in between are not walked
+				lastMissed, due = latestScheduleTimes(cron, last, now)
+				tooMany = true
+				break
+			}
+			missed++
+			lastMissed = due
+		}
+		due = t
+	}
+	if tooMany {
+		r.missSchedule(schedule, missed, lastMissed,
+			fmt.Sprintf("Missed more than %d backups since %s, too many missed start times", missed, last.UTC().Format(time.RFC3339)))
+	} else if missed > 0 {
+		r.missSchedule(schedule, missed, lastMissed,
+			fmt.Sprintf("Missed %d backups since %s", missed, last.UTC().Format(time.RFC3339)))
+	}
+
+	if !due.IsZero() {
+		dueTime := metav1.NewTime(due)
+		status.LastScheduleTime = &dueTime
+		backup := zk.MakeScheduledBackup(schedule, due)
+		// the backup may have been created before the status was updated
+		if active != "" && active != backup.Name {
+			r.missSchedule(schedule, 1, due,
+				fmt.Sprintf("Skipped the backup of %s, backup %s is still running", due.UTC().Format(time.RFC3339), active))
+		} else {
+			if err := controllerutil.SetControllerReference(schedule, backup, r.Scheme); err != nil {
+				return err
+			}
+			r.Log.Info("Creating a new backup", "ZookeeperBackup.Name", backup.Name)
+			if err := r.Client.Create(ctx, backup); err != nil && !errors.IsAlreadyExists(err) {
+				return err
+			}
+			status.Active = backup.Name
+			if missed == 0 {
+				status.Message = ""
+			}
+		}
+	}
+
+	if next := cron.Next(now); !next.IsZero() {
+		nextTime := metav1.NewTime(next)
+		status.NextScheduleTime = &nextTime
+	} else {
+		status.NextScheduleTime = nil
+	}
+	return nil
+}
+
+// latestScheduleTimes returns the last two times of the schedule between since
+// and now. The window they are looked for in doubles until it holds both, so
+// that they are found in a few steps whatever the period of the schedule.
+func latestScheduleTimes(cron *utils.CronSchedule, since time.Time, now time.Time) (previous time.Time, latest time.Time) {
+	from := now
+	for back := time.Minute; from.After(since); back *= 2 {
+		from = now.Add(-back)
+		if from.Before(since) {
+			from = since
+		}
+		if first := cron.Next(from); !first.IsZero() && !first.After(now) {
+			if second := cron.Next(first); !second.IsZero() && !second.After(now) {
+				break
+			}
+		}
+	}
+	for t := cron.Next(from); !t.IsZero() && !t.After(now); t = cron.Next(t) {
+		previous, latest = latest, t
+	}
+	return previous, latest
+}
+
+func (r *ZookeeperBackupScheduleReconciler) missSchedule(schedule *zookeeperv1.ZookeeperBackupSchedule, count int32, at time.Time, message string) {
+	r.Log.Info("Missed a scheduled backup", "reason", message)
+	missedTime := metav1.NewTime(at)
+	schedule.Status.MissedSchedules += count
+	schedule.Status.LastMissedTime = &missedTime
+	schedule.Status.Message = message
+}
+
+func (r *ZookeeperBackupScheduleReconciler) currentTime() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+func (r *ZookeeperBackupScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&zookeeperv1.ZookeeperBackupSchedule{}).
+		Owns(&zookeeperv1.ZookeeperBackup{}).
+		Complete(r)
+}
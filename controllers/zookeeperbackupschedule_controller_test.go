@@ -0,0 +1,224 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
+	"github.com/pravega/zookeeper-operator/pkg/zk"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ZookeeperBackupSchedule Controller", func() {
+	const (
+		Name      = "nightly"
+		Namespace = "default"
+	)
+
+	var (
+		s        = scheme.Scheme
+		cl       client.Client
+		r        *ZookeeperBackupScheduleReconciler
+		req      reconcile.Request
+		res      reconcile.Result
+		err      error
+		schedule *zookeeperv1.ZookeeperBackupSchedule
+		objects  []client.Object
+		// the reconcile runs at 02:00:30, half a minute after the backup
+		// of the day was due
+		now = time.Date(2024, time.March, 1, 2, 0, 30, 0, time.UTC)
+	)
+
+	at := func(t time.Time) *metav1.Time {
+		mt := metav1.NewTime(t)
+		return &mt
+	}
+
+	foundSchedule := func() *zookeeperv1.ZookeeperBackupSchedule {
+		found := &zookeeperv1.ZookeeperBackupSchedule{}
+		Ω(cl.Get(context.TODO(), req.NamespacedName, found)).To(Succeed())
+		return found
+	}
+
+	scheduledBackups := func() []zookeeperv1.ZookeeperBackup {
+		backups := &zookeeperv1.ZookeeperBackupList{}
+		Ω(cl.List(context.TODO(), backups, client.MatchingLabels{zk.BackupScheduleLabel: Name})).To(Succeed())
+		return backups.Items
+	}
+
+	finishedBackup := func(scheduled time.Time, phase zookeeperv1.BackupPhase) *zookeeperv1.ZookeeperBackup {
+		b := zk.MakeScheduledBackup(schedule, scheduled)
+		b.Status.Phase = phase
+		b.Status.CompletionTime = at(scheduled.Add(time.Minute))
+		return b
+	}
+
+	BeforeEach(func() {
+		req = reconcile.Request{NamespacedName: types.NamespacedName{Name: Name, Namespace: Namespace}}
+		schedule = &zookeeperv1.ZookeeperBackupSchedule{
+			ObjectMeta: metav1.ObjectMeta{Name: Name, Namespace: Namespace},
+			Spec: zookeeperv1.ZookeeperBackupScheduleSpec{
+				Schedule: "0 2 * * *",
+				Backup: zookeeperv1.ZookeeperBackupSpec{
+					ZookeeperCluster: "example",
+					Storage: zookeeperv1.BackupStorage{
+						PersistentVolumeClaim: &zookeeperv1.PVCStorage{ClaimName: "backups"},
+					},
+				},
+			},
+		}
+		schedule.Status.LastScheduleTime = at(now.Add(-24*time.Hour - 30*time.Second))
+		s.AddKnownTypes(zookeeperv1.GroupVersion, &zookeeperv1.ZookeeperBackupSchedule{}, &zookeeperv1.ZookeeperBackupScheduleList{},
+			&zookeeperv1.ZookeeperBackup{}, &zookeeperv1.ZookeeperBackupList{})
+		objects = []client.Object{}
+	})
+
+	JustBeforeEach(func() {
+		cl = fake.NewClientBuilder().WithScheme(s).WithObjects(append(objects, schedule)...).WithStatusSubresource(schedule).Build()
+		r = &ZookeeperBackupScheduleReconciler{Client: cl, Scheme: s, now: func() time.Time { return now }}
+		res, err = r.Reconcile(context.TODO(), req)
+	})
+
+	It("should take the backup which is due", func() {
+		Ω(err).To(BeNil())
+		backups := scheduledBackups()
+		Ω(backups).To(HaveLen(1))
+		Ω(backups[0].Name).To(Equal("nightly-20240301-0200"))
+		Ω(backups[0].Spec.ReclaimPolicy).To(Equal(zookeeperv1.VolumeReclaimPolicyDelete))
+		Ω(backups[0].OwnerReferences).To(HaveLen(1))
+
+		found := foundSchedule()
+		Ω(found.Status.Active).To(Equal("nightly-20240301-0200"))
+		Ω(found.Status.LastScheduleTime.Time).To(BeTemporally("==", time.Date(2024, time.March, 1, 2, 0, 0, 0, time.UTC)))
+		Ω(found.Status.NextScheduleTime.Time).To(BeTemporally("==", time.Date(2024, time.March, 2, 2, 0, 0, 0, time.UTC)))
+		Ω(found.Status.MissedSchedules).To(BeZero())
+		Ω(res.RequeueAfter).To(Equal(24*time.Hour - 30*time.Second))
+	})
+
+	Context("before the backup is due", func() {
+		BeforeEach(func() {
+			schedule.Status.LastScheduleTime = at(time.Date(2024, time.March, 1, 2, 0, 0, 0, time.UTC))
+		})
+
+		It("should wait for it", func() {
+			Ω(err).To(BeNil())
+			Ω(scheduledBackups()).To(BeEmpty())
+			Ω(res.RequeueAfter).To(Equal(24*time.Hour - 30*time.Second))
+		})
+	})
+
+	Context("while the previous backup is still running", func() {
+		BeforeEach(func() {
+			previous := zk.MakeScheduledBackup(schedule, now.Add(-24*time.Hour))
+			previous.Status.Phase = zookeeperv1.BackupPhaseRunning
+			objects = append(objects, previous)
+		})
+
+		It("should skip the backup", func() {
+			Ω(err).To(BeNil())
+			Ω(scheduledBackups()).To(HaveLen(1))
+			found := foundSchedule()
+			Ω(found.Status.Active).To(Equal("nightly-20240229-0200"))
+			Ω(found.Status.MissedSchedules).To(BeEquivalentTo(1))
+			Ω(found.Status.Message).To(ContainSubstring("backup nightly-20240229-0200 is still running"))
+			Ω(found.Status.LastScheduleTime.Time).To(BeTemporally("==", time.Date(2024, time.March, 1, 2, 0, 0, 0, time.UTC)))
+		})
+	})
+
+	Context("after the operator was down", func() {
+		BeforeEach(func() {
+			schedule.Status.LastScheduleTime = at(time.Date(2024, time.February, 27, 2, 0, 0, 0, time.UTC))
+		})
+
+		It("should take the latest backup and report the missed ones", func() {
+			Ω(err).To(BeNil())
+			Ω(scheduledBackups()).To(ConsistOf(HaveField("Name", "nightly-20240301-0200")))
+			found := foundSchedule()
+			Ω(found.Status.MissedSchedules).To(BeEquivalentTo(2))
+			Ω(found.Status.LastMissedTime.Time).To(BeTemporally("==", time.Date(2024, time.February, 29, 2, 0, 0, 0, time.UTC)))
+			Ω(found.Status.Message).To(Equal("Missed 2 backups since 2024-02-27T02:00:00Z"))
+		})
+
+		Context("for months", func() {
+			BeforeEach(func() {
+				schedule.Spec.Schedule = "* * * * *"
+				schedule.Status.LastScheduleTime = at(time.Date(2023, time.September, 1, 0, 0, 0, 0, time.UTC))
+			})
+
+			It("should stop counting the missed backups and take the latest one", func() {
+				Ω(err).To(BeNil())
+				Ω(scheduledBackups()).To(ConsistOf(HaveField("Name", "nightly-20240301-0200")))
+				found := foundSchedule()
+				Ω(found.Status.MissedSchedules).To(BeEquivalentTo(100))
+				Ω(found.Status.LastMissedTime.Time).To(BeTemporally("==", time.Date(2024, time.March, 1, 1, 59, 0, 0, time.UTC)))
+				Ω(found.Status.LastScheduleTime.Time).To(BeTemporally("==", time.Date(2024, time.March, 1, 2, 0, 0, 0, time.UTC)))
+				Ω(found.Status.Message).To(Equal("Missed more than 100 backups since 2023-09-01T00:00:00Z, too many missed start times"))
+			})
+		})
+	})
+
+	Context("with retention", func() {
+		BeforeEach(func() {
+			maxCount := int32(2)
+			schedule.Spec.Retention = zookeeperv1.BackupRetention{
+				MaxCount: &maxCount,
+				MaxAge:   &metav1.Duration{Duration: 7 * 24 * time.Hour},
+			}
+			// the backup of today is not due yet
+			schedule.Status.LastScheduleTime = at(time.Date(2024, time.March, 1, 2, 0, 0, 0, time.UTC))
+			day := func(d int) time.Time { return time.Date(2024, time.February, d, 2, 0, 0, 0, time.UTC) }
+			objects = append(objects,
+				finishedBackup(day(20), zookeeperv1.BackupPhaseSucceeded),
+				finishedBackup(day(25), zookeeperv1.BackupPhaseSucceeded),
+				finishedBackup(day(26), zookeeperv1.BackupPhaseFailed),
+				finishedBackup(day(27), zookeeperv1.BackupPhaseSucceeded),
+				finishedBackup(day(28), zookeeperv1.BackupPhaseSucceeded),
+				finishedBackup(day(29), zookeeperv1.BackupPhaseFailed),
+			)
+		})
+
+		It("should delete the expired backups", func() {
+			Ω(err).To(BeNil())
+			Ω(scheduledBackups()).To(ConsistOf(
+				HaveField("Name", "nightly-20240227-0200"),
+				HaveField("Name", "nightly-20240228-0200"),
+				HaveField("Name", "nightly-20240229-0200"),
+			))
+			found := foundSchedule()
+			Ω(found.Status.LastSuccessfulBackup).To(Equal("nightly-20240228-0200"))
+			Ω(found.Status.Active).To(BeEmpty())
+		})
+	})
+
+	Context("with an invalid schedule", func() {
+		BeforeEach(func() {
+			schedule.Spec.Schedule = "0 25 * * *"
+		})
+
+		It("should report it", func() {
+			Ω(err).To(BeNil())
+			Ω(res.RequeueAfter).To(BeZero())
+			Ω(scheduledBackups()).To(BeEmpty())
+			Ω(foundSchedule().Status.Message).To(HavePrefix("Invalid schedule"))
+		})
+	})
+})
@@ -11,8 +11,14 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel/trace"
@@ -40,12 +46,38 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	zookeeperv1beta1 "github.com/pravega/zookeeper-operator/api/v1beta1"
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
 )
 
 // ReconcileTime is the delay between reconciliations
 const ReconcileTime = 30 * time.Second
 
+// scaleDownPhaseTimeout is how long a phase of a scale down may take before
+// the scale down is reported as failed
+const scaleDownPhaseTimeout = 10 * time.Minute
+
+// crashLoopRestarts is the number of restarts after which a member crash
+// looping on the revision being rolled out fails the upgrade
+const crashLoopRestarts = 3
+
+// maxSyncLag is how many transactions a member may be behind the leader and
+// still count as caught up, since the leader keeps committing while the
+// members are queried
+const maxSyncLag = 1000
+
+// configHashAnnotation carries a hash of the zoo.cfg of the members in the pod
+// template, so that the members are restarted once their config changes
+const configHashAnnotation = "zookeeper.pravega.io/config-hash"
+
+// tlsSecretsHashAnnotation carries a hash of the key material of the TLS
+// Secrets in the pod template, so that the members are restarted with the
+// new key material once a Secret changes
+const tlsSecretsHashAnnotation = "zookeeper.pravega.io/tls-secrets-hash"
+
+// authSecretsHashAnnotation does the same for the Secrets the JAAS file of
+// the members is written from
+const authSecretsHashAnnotation = "zookeeper.pravega.io/auth-secrets-hash"
+
 var log = logf.Log.WithName("controller_zookeepercluster")
 
 var _ reconcile.Reconciler = &ZookeeperClusterReconciler{}
@@ -59,7 +91,7 @@ type ZookeeperClusterReconciler struct {
 	Tracer   trace.Tracer
 }
 
-type reconcileFun func(ctx context.Context, cluster *zookeeperv1beta1.ZookeeperCluster) error
+type reconcileFun func(ctx context.Context, cluster *zookeeperv1.ZookeeperCluster) error
 
 // +kubebuilder:rbac:groups=zookeeper.pravega.io.zookeeper.pravega.io,resources=zookeeperclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=zookeeper.pravega.io.zookeeper.pravega.io,resources=zookeeperclusters/status,verbs=get;update;patch
@@ -73,7 +105,7 @@ func (r *ZookeeperClusterReconciler) Reconcile(ctx context.Context, request ctrl
 	r.Log.Info("Reconciling ZookeeperCluster")
 
 	// Fetch the ZookeeperCluster instance
-	instance := &zookeeperv1beta1.ZookeeperCluster{}
+	instance := &zookeeperv1.ZookeeperCluster{}
 	err := r.Client.Get(ctx, request.NamespacedName, instance)
 	if err != nil {
 		if errors.IsNotFound(err) {
@@ -86,7 +118,6 @@ func (r *ZookeeperClusterReconciler) Reconcile(ctx context.Context, request ctrl
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
 	}
-	changed := instance.WithDefaults()
 	if instance.GetTriggerRollingRestart() {
 		r.Log.Info("Restarting zookeeper cluster")
 		annotationkey, annotationvalue := getRollingRestartAnnotation()
@@ -95,19 +126,23 @@ func (r *ZookeeperClusterReconciler) Reconcile(ctx context.Context, request ctrl
 		}
 		instance.Spec.Pod.Annotations[annotationkey] = annotationvalue
 		instance.SetTriggerRollingRestart(false)
-		changed = true
-	}
-	if changed {
-		r.Log.Info("Setting default settings for zookeeper-cluster")
 		if err := r.Client.Update(ctx, instance); err != nil {
 			return reconcile.Result{}, err
 		}
 		return reconcile.Result{Requeue: true}, nil
 	}
+	// The defaults are persisted by the mutating webhook when the cluster is
+	// admitted. Clusters admitted without it get them in memory only, they are
+	// never written back to the spec by the operator.
+	instance.WithDefaults()
+	// every condition is evaluated again for the current spec
+	instance.Status.ObserveGeneration(instance.Generation)
 	for _, fun := range []reconcileFun{
 		r.reconcileFinalizers,
+		r.reconcileQuorumTLS,
 		r.reconcileConfigMap,
 		r.reconcileStatefulSet,
+		r.reconcileObserverStatefulSet,
 		r.reconcileClientService,
 		r.reconcileHeadlessService,
 		r.reconcileAdminServerService,
@@ -132,7 +167,7 @@ func getRollingRestartAnnotation() (string, string) {
 // 0 if versions are equal
 // -1 if ZookeeperCluster version is less than StatefulSet version
 // 1 if ZookeeperCluster version is greater than StatefulSet version
-func compareResourceVersion(zk *zookeeperv1beta1.ZookeeperCluster, sts *appsv1.StatefulSet) int {
+func compareResourceVersion(zk *zookeeperv1.ZookeeperCluster, sts *appsv1.StatefulSet) int {
 
 	zkResourceVersion, zkErr := strconv.Atoi(zk.ResourceVersion)
 	stsVersion, stsVersionFound := sts.Labels["owner-rv"]
@@ -153,135 +188,1199 @@ func compareResourceVersion(zk *zookeeperv1beta1.ZookeeperCluster, sts *appsv1.S
 		log.Info("Fail to convert StatefulSet version %s to integer; setting it to ZookeeperCluster version", stsVersion)
 		return 1
 	}
-	if zkResourceVersion < stsResourceVersion {
-		return -1
-	} else if zkResourceVersion > stsResourceVersion {
-		return 1
+	if zkResourceVersion < stsResourceVersion {
+		return -1
+	} else if zkResourceVersion > stsResourceVersion {
+		return 1
+	}
+	return 0
+}
+
+func (r *ZookeeperClusterReconciler) reconcileStatefulSet(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
+	ctx, span := r.Tracer.Start(ctx, "reconcileStatefulSet")
+	defer span.End()
+
+	// we cannot upgrade if cluster is in UpgradeFailed
+	if instance.Status.IsClusterInUpgradeFailedState() {
+		sts, err := r.makeStatefulSet(ctx, instance, zk.MakeStatefulSet)
+		if err != nil {
+			return err
+		}
+		foundSts := &appsv1.StatefulSet{}
+		err = r.Client.Get(ctx, types.NamespacedName{
+			Name:      sts.Name,
+			Namespace: sts.Namespace,
+		}, foundSts)
+		if err == nil {
+			rollingBack := isRollingBack(instance)
+			if rollingBack {
+				// the pod template goes back to the last known good revision
+				err = r.updateStatefulSet(ctx, instance, foundSts, sts)
+			} else {
+				err = r.Client.Update(ctx, foundSts)
+			}
+			if err != nil {
+				return err
+			}
+			// the members keep being restarted towards the target version, or
+			// back to the last known good one
+			if err = r.reconcileRollingUpdate(ctx, instance, foundSts); err != nil {
+				return err
+			}
+			if rollingBack {
+				if isStatefulSetRolledOut(foundSts) {
+					return r.completeRollback(ctx, instance)
+				}
+				r.Log.Info("Rolling back the failed upgrade", "UpdatedReplicas", foundSts.Status.UpdatedReplicas)
+			} else if foundSts.Status.Replicas == foundSts.Status.ReadyReplicas && isStatefulSetUpdated(foundSts) {
+				r.Log.Info("failed upgrade completed", "upgrade from:", instance.Status.CurrentVersion, "upgrade to:", instance.Status.TargetVersion)
+				instance.Status.CurrentVersion = instance.Status.TargetVersion
+				instance.Status.SetErrorConditionFalse()
+				return r.clearUpgradeStatus(ctx, instance)
+			} else {
+				r.Log.Info("Unable to recover failed upgrade, make sure all nodes are running the target version")
+			}
+
+		}
+	}
+
+	if instance.Status.IsClusterInUpgradeFailedState() {
+		return nil
+	}
+	if instance.Spec.Pod.ServiceAccountName != "default" {
+		serviceAccount := zk.MakeServiceAccount(instance)
+		if err = controllerutil.SetControllerReference(instance, serviceAccount, r.Scheme); err != nil {
+			return err
+		}
+		// Check if this ServiceAccount already exists
+		foundServiceAccount := &corev1.ServiceAccount{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: serviceAccount.Name, Namespace: serviceAccount.Namespace}, foundServiceAccount)
+		if err != nil && errors.IsNotFound(err) {
+			r.Log.Info("Creating a new ServiceAccount", "ServiceAccount.Namespace", serviceAccount.Namespace, "ServiceAccount.Name", serviceAccount.Name)
+			err = r.Client.Create(ctx, serviceAccount)
+			if err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		} else {
+			foundServiceAccount.ImagePullSecrets = serviceAccount.ImagePullSecrets
+			r.Log.Info("Updating ServiceAccount", "ServiceAccount.Namespace", serviceAccount.Namespace, "ServiceAccount.Name", serviceAccount.Name)
+			err = r.Client.Update(ctx, foundServiceAccount)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	sts, err := r.makeStatefulSet(ctx, instance, zk.MakeStatefulSet)
+	if err != nil {
+		return err
+	}
+	foundSts := &appsv1.StatefulSet{}
+	err = r.Client.Get(ctx, types.NamespacedName{
+		Name:      sts.Name,
+		Namespace: sts.Namespace,
+	}, foundSts)
+	if err != nil && errors.IsNotFound(err) {
+		r.Log.Info("Creating a new Zookeeper StatefulSet",
+			"StatefulSet.Namespace", sts.Namespace,
+			"StatefulSet.Name", sts.Name)
+		// label the RV of the zookeeperCluster when creating the sts
+		sts.Labels["owner-rv"] = instance.ResourceVersion
+		err = r.Client.Create(ctx, sts)
+		if err != nil {
+			return err
+		}
+		return nil
+	} else if err != nil {
+		return err
+	} else {
+		// check whether zookeeperCluster is updated before updating the sts
+		cmp := compareResourceVersion(instance, foundSts)
+		if cmp < 0 {
+			return fmt.Errorf("Staleness: cr.ResourceVersion %s is smaller than labeledRV %s", instance.ResourceVersion, foundSts.Labels["owner-rv"])
+		} else if cmp > 0 {
+			// Zookeeper StatefulSet version inherits ZookeeperCluster resource version
+			foundSts.Labels["owner-rv"] = instance.ResourceVersion
+		}
+		// departing members leave the ensemble before their pods are deleted
+		if err = r.reconcileMembership(ctx, instance, foundSts, sts); err != nil {
+			return err
+		}
+		if *sts.Spec.Replicas != *foundSts.Spec.Replicas {
+			if err = r.updateClusterSize(ctx, instance, *sts.Spec.Replicas); err != nil {
+				return err
+			}
+		}
+		err = r.updateStatefulSet(ctx, instance, foundSts, sts)
+		if err != nil {
+			return err
+		}
+		if err = r.reconcileRollingUpdate(ctx, instance, foundSts); err != nil {
+			return err
+		}
+		if err = r.reconcileLeaderPlacement(ctx, instance, foundSts); err != nil {
+			return err
+		}
+		recordKnownGood(instance, foundSts)
+		return r.upgradeStatefulSet(ctx, instance, foundSts)
+	}
+}
+
+// reconcileMembership reconfigures the ensemble towards the members of the
+// spec. Members which are not voting yet are added, or promoted if they are
+// observers, once they follow the leader. Voting members whose client address
+// changed, e.g. once client TLS is turned on, are updated in place. A scale
+// down holds the stateful set at its size until the departing members have
+// left, see scaleDown. Nothing is changed without a leader to commit the new
+// config.
+func (r *ZookeeperClusterReconciler) reconcileMembership(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, foundSts *appsv1.StatefulSet, sts *appsv1.StatefulSet) (err error) {
+	membership := &instance.Status.Membership
+	current := *foundSts.Spec.Replicas
+	scalingDown := instance.Spec.Replicas < current
+	if scalingDown {
+		membership.StartScaleDown(current, instance.Spec.Replicas)
+		held := current
+		sts.Spec.Replicas = &held
+	} else if membership.ScaleDown != nil && membership.ScaleDown.Phase != zookeeperv1.ScaleDownCompleted {
+		r.Log.Info("Scale down cancelled", "From", membership.ScaleDown.FromReplicas, "To", membership.ScaleDown.ToReplicas)
+		membership.ScaleDown = nil
+		if instance.Status.IsScaleDownFailed() {
+			instance.Status.SetErrorConditionFalse()
+		}
+	}
+
+	if instance.Status.Leader == "" {
+		if scalingDown && foundSts.Status.ReadyReplicas == 0 {
+			// no member serves, there is no quorum to lose
+			r.completeScaleDown(instance, sts)
+			return nil
+		}
+		if scalingDown {
+			r.holdScaleDown(instance, "the ensemble has no leader")
+		}
+		return nil
+	}
+	if _, err = r.connect(ctx, instance); err != nil {
+		if scalingDown {
+			r.holdScaleDown(instance, err.Error())
+			return nil
+		}
+		return fmt.Errorf("Error reading the ensemble config: %v", err)
+	}
+	defer r.ZkClient.Close()
+	config, err := r.ZkClient.GetConfig()
+	if err != nil {
+		if scalingDown {
+			r.holdScaleDown(instance, err.Error())
+			return nil
+		}
+		return err
+	}
+	recordMembership(instance, config)
+	if scalingDown {
+		return r.scaleDown(ctx, instance, config, sts)
+	}
+
+	replicas := int(instance.Spec.Replicas)
+	for _, server := range config.Servers {
+		if server.ID <= replicas || zk.IsObserverServerID(server.ID) {
+			continue
+		}
+		// a member left over from an earlier scale down. It leaves before
+		// any member joins, so that the quorum is never counted over it. The
+		// failed step is recorded before the reconciliation is retried.
+		member := fmt.Sprintf("%s-%d", instance.GetName(), server.ID-1)
+		if config, err = r.reconfigure(instance, config, zookeeperv1.ReconfigActionRemove, member, server); err != nil {
+			if serr := r.updateStatus(ctx, instance); serr != nil {
+				r.Log.Info("Unable to record the failed removal", "Member", member, "Error", serr.Error())
+			}
+			return fmt.Errorf("Error removing the left over member %s: %v", member, err)
+		}
+	}
+	for ordinal := 0; ordinal < replicas; ordinal++ {
+		member := fmt.Sprintf("%s-%d", instance.GetName(), ordinal)
+		action := zookeeperv1.ReconfigActionAdd
+		joining := zk.MakeServerConfig(instance, ordinal, zk.RoleParticipant)
+		if server := config.Server(ordinal + 1); server != nil {
+			clientAddress := joining.ClientAddress
+			action = zookeeperv1.ReconfigActionPromote
+			if server.Role == zk.RoleParticipant {
+				if zk.SameClientAddress(server.ClientAddress, clientAddress) {
+					continue
+				}
+				action = zookeeperv1.ReconfigActionUpdate
+			}
+			joining = *server
+			joining.Role = zk.RoleParticipant
+			joining.ClientAddress = clientAddress
+		}
+		// a leader demoted for its restart gets its vote back on the revision
+		if action == zookeeperv1.ReconfigActionPromote && r.isRestarting(ctx, instance, member) {
+			continue
+		}
+		// a member only gets a vote once it has synced with the leader
+		if m := instance.Status.GetMemberStatus(member); action != zookeeperv1.ReconfigActionUpdate && (m == nil ||
+			(m.Role != zookeeperv1.MemberRoleObserver && m.Role != zookeeperv1.MemberRoleFollower)) {
+			continue
+		}
+		next, err := r.reconfigure(instance, config, action, member, joining)
+		if err != nil {
+			// the other members are not held up, the step is retried on the
+			// next reconciliation
+			continue
+		}
+		config = next
+	}
+	return nil
+}
+
+// isRestarting reports whether the member is the leader which handed its
+// leadership over for its restart, and its pod does not run the revision
+// being rolled out yet
+func (r *ZookeeperClusterReconciler) isRestarting(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, member string) bool {
+	u := instance.Status.Upgrade
+	if u == nil || u.Restarting != member {
+		return false
+	}
+	pod := &corev1.Pod{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: member, Namespace: instance.Namespace}, pod); err != nil {
+		return true
+	}
+	return pod.Labels[appsv1.ControllerRevisionHashLabelKey] != u.Revision
+}
+
+// scaleDown moves a scale down through its phases. The departing members are
+// reconfigured out of the ensemble first. The stateful set is only shrunk once
+// the ensemble has committed the config without them and a quorum of the
+// remaining members serves. Until then it is held at its size, and a phase
+// which does not complete within scaleDownPhaseTimeout fails the scale down.
+// The scale down is still retried after that.
+func (r *ZookeeperClusterReconciler) scaleDown(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, config *zk.EnsembleConfig, sts *appsv1.StatefulSet) (err error) {
+	scale := instance.Status.Membership.ScaleDown
+	to := int(scale.ToReplicas)
+	if scale.Phase == zookeeperv1.ScaleDownRemovingMembers {
+		for _, server := range config.Servers {
+			if server.ID <= to || zk.IsObserverServerID(server.ID) {
+				continue
+			}
+			member := fmt.Sprintf("%s-%d", instance.GetName(), server.ID-1)
+			if config, err = r.reconfigure(instance, config, zookeeperv1.ReconfigActionRemove, member, server); err != nil {
+				r.holdScaleDown(instance, fmt.Sprintf("unable to remove %s: %v", member, err))
+				return nil
+			}
+		}
+		scale.SetPhase(zookeeperv1.ScaleDownVerifyingQuorum)
+	}
+	if err = r.verifyScaleDown(ctx, instance, to); err != nil {
+		r.holdScaleDown(instance, err.Error())
+		return nil
+	}
+	r.completeScaleDown(instance, sts)
+	return nil
+}
+
+// verifyScaleDown checks that the ensemble committed a config without the
+// departing members, and that a quorum of the remaining members serves
+func (r *ZookeeperClusterReconciler) verifyScaleDown(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, to int) error {
+	config, err := r.ZkClient.GetConfig()
+	if err != nil {
+		return err
+	}
+	recordMembership(instance, config)
+	for _, server := range config.Servers {
+		if server.ID > to && !zk.IsObserverServerID(server.ID) {
+			return fmt.Errorf("server %d is still in the ensemble config version %s", server.ID, config.Version)
+		}
+	}
+
+	foundPods := &corev1.PodList{}
+	err = r.Client.List(ctx, foundPods, &client.ListOptions{
+		Namespace:     instance.Namespace,
+		LabelSelector: labels.SelectorFromSet(map[string]string{"app": instance.GetName()}),
+	})
+	if err != nil {
+		return err
+	}
+	serving, leader := 0, false
+	for i := range foundPods.Items {
+		pod := &foundPods.Items[i]
+		ordinal, err := strconv.Atoi(strings.TrimPrefix(pod.Name, instance.GetName()+"-"))
+		if err != nil || ordinal >= to {
+			continue
+		}
+		stats, err := r.queryMember(ctx, instance, pod)
+		if err != nil {
+			continue
+		}
+		switch memberRole(stats.Mode) {
+		case zookeeperv1.MemberRoleLeader, zookeeperv1.MemberRoleStandalone:
+			leader = true
+			serving++
+		case zookeeperv1.MemberRoleFollower:
+			serving++
+		}
+	}
+	quorum := to/2 + 1
+	if !leader || serving < quorum {
+		return fmt.Errorf("%d of the %d remaining members are serving with a leader, %d are needed for a quorum", serving, to, quorum)
+	}
+	return nil
+}
+
+// holdScaleDown records why the scale down does not progress, and fails it
+// once its phase has exceeded the timeout
+func (r *ZookeeperClusterReconciler) holdScaleDown(instance *zookeeperv1.ZookeeperCluster, message string) {
+	scale := instance.Status.Membership.ScaleDown
+	scale.Message = message
+	r.Log.Info("Holding the scale down", "Phase", scale.Phase, "Reason", message)
+	if scale.PhaseExceeded(scaleDownPhaseTimeout) && !instance.Status.IsClusterInUpgradeFailedState() {
+		instance.Status.SetErrorConditionTrue(zookeeperv1.ScaleDownFailedReason,
+			fmt.Sprintf("scale down to %d replicas did not complete %s within %v: %s", scale.ToReplicas, scale.Phase, scaleDownPhaseTimeout, message))
+	}
+}
+
+// completeScaleDown lets the stateful set shrink to the spec
+func (r *ZookeeperClusterReconciler) completeScaleDown(instance *zookeeperv1.ZookeeperCluster, sts *appsv1.StatefulSet) {
+	scale := instance.Status.Membership.ScaleDown
+	r.Log.Info("Shrinking the StatefulSet", "From", scale.FromReplicas, "To", scale.ToReplicas)
+	scale.SetPhase(zookeeperv1.ScaleDownCompleted)
+	if instance.Status.IsScaleDownFailed() {
+		instance.Status.SetErrorConditionFalse()
+	}
+	sts.Spec.Replicas = &instance.Spec.Replicas
+}
+
+// reconcileRollingUpdate restarts the members which do not run the update
+// revision of the stateful set. The stateful set leaves its pods alone, so that
+// the members are restarted one at a time, the followers first and the leader
+// last. A member is only restarted while every member serves and has caught up
+// with the leader, so a restarted member has to rejoin the ensemble and sync
+// before the next one goes. Image upgrades, config changes and rolling restarts
+// all change the revision, and are rolled out the same way.
+//
+// With an upgrade strategy the members at or above the partition of the
+// rollout are restarted only, starting with the highest ordinal as the canary.
+// The partition is lowered by a batch once the health gates passed on the
+// members upgraded so far.
+func (r *ZookeeperClusterReconciler) reconcileRollingUpdate(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, foundSts *appsv1.StatefulSet) (err error) {
+	if foundSts.Spec.UpdateStrategy.Type != appsv1.OnDeleteStatefulSetStrategyType {
+		return nil
+	}
+	// the update revision is that of the last template the stateful set
+	// controller has seen
+	if foundSts.Status.ObservedGeneration < foundSts.Generation || foundSts.Status.UpdateRevision == "" {
+		return nil
+	}
+	// the departing members are not restarted, their pods are deleted once
+	// the scale down completes
+	if s := instance.Status.Membership.ScaleDown; s != nil && s.Phase != zookeeperv1.ScaleDownCompleted {
+		return nil
+	}
+	pods, err := r.listMemberPods(ctx, instance)
+	if err != nil {
+		return err
+	}
+	revision := foundSts.Status.UpdateRevision
+	replicas := *foundSts.Spec.Replicas
+	var outdated []*corev1.Pod
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			r.Log.Info("Waiting for the restarted member to terminate", "Member", pod.Name)
+			return nil
+		}
+		if pod.Labels[appsv1.ControllerRevisionHashLabelKey] != revision {
+			outdated = append(outdated, pod)
+		} else if u := instance.Status.Upgrade; u != nil && u.Restarting == pod.Name {
+			// the former leader runs the revision, it may be promoted again
+			u.Restarting = ""
+		}
+	}
+	if len(outdated) == 0 {
+		if u := instance.Status.Upgrade; u != nil && u.Revision == revision && u.Phase != zookeeperv1.UpgradeCompleted {
+			r.Log.Info("Rollout completed", "Revision", revision)
+			u.UpdatedReplicas = replicas
+			u.SetPhase(zookeeperv1.UpgradeCompleted)
+		}
+		return nil
+	}
+
+	strategy := instance.Spec.UpgradeStrategy
+	partition := int32(0)
+	if strategy != nil {
+		// the member with the highest ordinal is the canary
+		partition = replicas - 1
+	}
+	upgrade := instance.Status.StartUpgrade(revision, partition)
+	upgrade.UpdatedReplicas = replicas - int32(len(outdated))
+	if strategy == nil || isRollingBack(instance) {
+		// the rollout carries on without gates once the strategy is removed,
+		// and a rollback goes back to a revision which ran on every member
+		upgrade.Partition = 0
+	}
+	if strategy.IsPaused() {
+		if upgrade.Phase != zookeeperv1.UpgradePaused {
+			r.Log.Info("Pausing the rollout", "Revision", revision, "Updated", upgrade.UpdatedReplicas)
+			upgrade.SetPhase(zookeeperv1.UpgradePaused)
+			upgrade.Message = "the rollout is paused by spec.upgradeStrategy.paused"
+		}
+		return nil
+	}
+	candidates := membersFrom(instance, outdated, upgrade.Partition)
+	if len(candidates) == 0 {
+		if !r.checkHealthGates(ctx, instance, &strategy.HealthGates, upgrade, pods, replicas) {
+			return nil
+		}
+		upgrade.Partition -= strategy.BatchSize
+		if upgrade.Partition < 0 {
+			upgrade.Partition = 0
+		}
+		r.Log.Info("Health gates passed, upgrading the next batch", "Revision", revision, "Partition", upgrade.Partition)
+		candidates = membersFrom(instance, outdated, upgrade.Partition)
+	}
+	upgrade.SetPhase(zookeeperv1.UpgradeRollingOut)
+
+	leader, err := r.checkMembersSynced(ctx, instance, pods, replicas)
+	if err != nil {
+		r.Log.Info("Holding the rolling update", "Outdated", len(outdated), "Reason", err.Error())
+		upgrade.Message = err.Error()
+		return nil
+	}
+	upgrade.Message = ""
+	next := candidates[0]
+	for _, pod := range candidates {
+		if pod.Name == upgrade.Restarting {
+			// the former leader is restarted before any other member
+			next = pod
+			break
+		}
+		if pod.Name != leader && next.Name == leader {
+			next = pod
+		}
+	}
+	if next.Name == leader && len(pods) > 1 {
+		// the leader hands over to a follower before it goes, which spares
+		// the clients a full election. It is not promoted back until it runs
+		// the revision.
+		upgrade.Restarting = leader
+		if err = r.transferLeadership(ctx, instance, pods, leader); err != nil {
+			r.Log.Info("Holding the restart of the leader", "Leader", leader, "Reason", err.Error())
+			upgrade.Message = err.Error()
+			return nil
+		}
+	}
+	r.Log.Info("Restarting zookeeper member",
+		"Member", next.Name,
+		"Leader", next.Name == leader,
+		"Revision", revision)
+	if err = r.Client.Delete(ctx, next); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// membersFrom returns the pods whose ordinal is at or above the partition
+func membersFrom(instance *zookeeperv1.ZookeeperCluster, pods []*corev1.Pod, partition int32) []*corev1.Pod {
+	var found []*corev1.Pod
+	for _, pod := range pods {
+		if ordinal, err := memberOrdinal(instance, pod.Name); err == nil && int32(ordinal) >= partition {
+			found = append(found, pod)
+		}
+	}
+	return found
+}
+
+// checkHealthGates runs the health gates on the members upgraded so far. Every
+// member has to be synced with the leader on every pass, and the error metrics
+// of the upgraded members must not grow faster than allowed over the soak
+// time. It reports whether the gates passed for the whole soak time.
+func (r *ZookeeperClusterReconciler) checkHealthGates(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, gates *zookeeperv1.UpgradeHealthGates, upgrade *zookeeperv1.UpgradeStatus, pods []*corev1.Pod, replicas int32) bool {
+	upgrade.SetPhase(zookeeperv1.UpgradeVerifying)
+	if _, err := r.checkMembersSynced(ctx, instance, pods, replicas); err != nil {
+		r.Log.Info("Health gates failed", "Revision", upgrade.Revision, "Reason", err.Error())
+		upgrade.ResetGates()
+		upgrade.Message = err.Error()
+		return false
+	}
+	errorCount, err := r.countUpgradeErrors(instance, gates, pods, upgrade.Revision)
+	if err != nil {
+		r.Log.Info("Health gates failed", "Revision", upgrade.Revision, "Reason", err.Error())
+		upgrade.ResetGates()
+		upgrade.Message = err.Error()
+		return false
+	}
+	now := metav1.Now()
+	if upgrade.GateStartTime == nil {
+		upgrade.GateStartTime = &now
+		upgrade.GateErrors = errorCount
+	}
+	soaked := now.Sub(upgrade.GateStartTime.Time)
+	if soaked < time.Duration(gates.SoakSeconds)*time.Second {
+		upgrade.Message = fmt.Sprintf("the upgraded members are soaking for %ds", gates.SoakSeconds)
+		return false
+	}
+	rate := float64(errorCount-upgrade.GateErrors) / soaked.Minutes()
+	if rate > float64(gates.MaxErrorsPerMinute) {
+		r.Log.Info("Health gates failed", "Revision", upgrade.Revision, "ErrorsPerMinute", rate)
+		// the soak starts over from the current count
+		upgrade.GateStartTime = &now
+		upgrade.GateErrors = errorCount
+		upgrade.Message = fmt.Sprintf("the upgraded members counted %.1f errors per minute, at most %d are allowed", rate, gates.MaxErrorsPerMinute)
+		return false
+	}
+	return true
+}
+
+// countUpgradeErrors sums the error metrics of the members which run the
+// revision. A counter is read under its name as well as with the _total
+// suffix some exporters add to counters.
+func (r *ZookeeperClusterReconciler) countUpgradeErrors(instance *zookeeperv1.ZookeeperCluster, gates *zookeeperv1.UpgradeHealthGates, pods []*corev1.Pod, revision string) (int64, error) {
+	var count float64
+	for _, pod := range pods {
+		if pod.Labels[appsv1.ControllerRevisionHashLabelKey] != revision {
+			continue
+		}
+		address, err := utils.GetMemberMetricsUri(instance, pod)
+		if err != nil {
+			return 0, err
+		}
+		metrics, err := r.ZkClient.ServerMetrics(address)
+		if err != nil {
+			return 0, fmt.Errorf("unable to read the metrics of member %s: %v", pod.Name, err)
+		}
+		for _, name := range gates.ErrorMetrics {
+			count += metrics[name] + metrics[name+"_total"]
+		}
+	}
+	return int64(count), nil
+}
+
+// listMemberPods returns the pods of the voting members, the highest ordinal
+// first
+func (r *ZookeeperClusterReconciler) listMemberPods(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) ([]*corev1.Pod, error) {
+	foundPods := &corev1.PodList{}
+	err := r.Client.List(ctx, foundPods, &client.ListOptions{
+		Namespace:     instance.Namespace,
+		LabelSelector: labels.SelectorFromSet(map[string]string{"app": instance.GetName()}),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ordinals := map[string]int{}
+	pods := []*corev1.Pod{}
+	for i := range foundPods.Items {
+		pod := &foundPods.Items[i]
+		// the observers of the pool are named after their own stateful set
+		ordinal, err := memberOrdinal(instance, pod.Name)
+		if err != nil {
+			continue
+		}
+		ordinals[pod.Name] = ordinal
+		pods = append(pods, pod)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return ordinals[pods[i].Name] > ordinals[pods[j].Name]
+	})
+	return pods, nil
+}
+
+// transferLeadership moves the leadership away from the leader. The leader is
+// reconfigured into an observer, upon which ZooKeeper hands the leadership over
+// to a follower which acknowledged the new config, without an election. The
+// handover is confirmed with the members themselves. The former leader is
+// promoted again by reconcileMembership once it follows the new leader, or
+// once it runs the revision when it hands over for its restart.
+func (r *ZookeeperClusterReconciler) transferLeadership(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, pods []*corev1.Pod, leader string) (err error) {
+	ordinal, err := memberOrdinal(instance, leader)
+	if err != nil {
+		return err
+	}
+	if _, err = r.connect(ctx, instance); err != nil {
+		return err
+	}
+	defer r.ZkClient.Close()
+	config, err := r.ZkClient.GetConfig()
+	if err != nil {
+		return err
+	}
+	recordMembership(instance, config)
+	if server := config.Server(ordinal + 1); server != nil && server.Role == zk.RoleParticipant {
+		demoted := *server
+		demoted.Role = zk.RoleObserver
+		if _, err = r.reconfigure(instance, config, zookeeperv1.ReconfigActionDemote, leader, demoted); err != nil {
+			return err
+		}
+	}
+	for _, pod := range pods {
+		if pod.Name == leader {
+			continue
+		}
+		if stats, err := r.queryMember(ctx, instance, pod); err == nil && memberRole(stats.Mode) == zookeeperv1.MemberRoleLeader {
+			r.Log.Info("Leadership transferred", "From", leader, "To", pod.Name)
+			return nil
+		}
+	}
+	return fmt.Errorf("no member has taken the leadership over from %s yet", leader)
+}
+
+// reconcileLeaderPlacement moves the leadership away from a leader whose node
+// is cordoned, so that the leader is not evicted when the node is drained. The
+// nodes can only be read with the cluster wide role of the operator.
+func (r *ZookeeperClusterReconciler) reconcileLeaderPlacement(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, foundSts *appsv1.StatefulSet) (err error) {
+	leader := instance.Status.Leader
+	if leader == "" || *foundSts.Spec.Replicas < 2 {
+		return nil
+	}
+	if s := instance.Status.Membership.ScaleDown; s != nil && s.Phase != zookeeperv1.ScaleDownCompleted {
+		return nil
+	}
+	pods, err := r.listMemberPods(ctx, instance)
+	if err != nil {
+		return err
+	}
+	var leaderPod *corev1.Pod
+	for _, pod := range pods {
+		if pod.Name == leader {
+			leaderPod = pod
+		}
+	}
+	if leaderPod == nil || leaderPod.Spec.NodeName == "" {
+		return nil
+	}
+	node := &corev1.Node{}
+	if err = r.Client.Get(ctx, types.NamespacedName{Name: leaderPod.Spec.NodeName}, node); err != nil {
+		if !errors.IsNotFound(err) && !errors.IsForbidden(err) {
+			r.Log.Info("Unable to read the node of the leader", "Node", leaderPod.Spec.NodeName, "Error", err.Error())
+		}
+		return nil
+	}
+	if !node.Spec.Unschedulable {
+		return nil
+	}
+	if _, err = r.checkMembersSynced(ctx, instance, pods, *foundSts.Spec.Replicas); err != nil {
+		r.Log.Info("Holding the leadership transfer off the cordoned node", "Node", node.Name, "Reason", err.Error())
+		return nil
+	}
+	if err = r.transferLeadership(ctx, instance, pods, leader); err != nil {
+		r.Log.Info("Holding the leadership transfer off the cordoned node", "Node", node.Name, "Reason", err.Error())
+	}
+	return nil
+}
+
+// memberOrdinal returns the ordinal of the pod of a voting member
+func memberOrdinal(instance *zookeeperv1.ZookeeperCluster, name string) (int, error) {
+	return strconv.Atoi(strings.TrimPrefix(name, instance.GetName()+"-"))
+}
+
+// checkMembersSynced checks that every member runs and serves in the epoch of
+// the leader, at most maxSyncLag transactions behind it. It returns the name
+// of the leader.
+func (r *ZookeeperClusterReconciler) checkMembersSynced(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, pods []*corev1.Pod, replicas int32) (string, error) {
+	if len(pods) < int(replicas) {
+		return "", fmt.Errorf("%d of the %d members are running", len(pods), replicas)
+	}
+	leader := ""
+	var leaderStats *zk.ServerStats
+	stats := make([]*zk.ServerStats, len(pods))
+	for i, pod := range pods {
+		s, err := r.queryMember(ctx, instance, pod)
+		if err != nil {
+			return "", fmt.Errorf("member %s does not serve: %v", pod.Name, err)
+		}
+		switch memberRole(s.Mode) {
+		case zookeeperv1.MemberRoleLeader, zookeeperv1.MemberRoleStandalone:
+			leader, leaderStats = pod.Name, s
+		case zookeeperv1.MemberRoleFollower:
+		case zookeeperv1.MemberRoleObserver:
+			// the former leader observes until it is restarted
+			if u := instance.Status.Upgrade; u == nil || u.Restarting != pod.Name {
+				return "", fmt.Errorf("member %s is not following the leader, its mode is %q", pod.Name, s.Mode)
+			}
+		default:
+			return "", fmt.Errorf("member %s is not following the leader, its mode is %q", pod.Name, s.Mode)
+		}
+		stats[i] = s
+	}
+	if leader == "" {
+		return "", fmt.Errorf("the ensemble has no leader")
+	}
+	for i, s := range stats {
+		if s.Epoch() != leaderStats.Epoch() || leaderStats.Zxid-s.Zxid > maxSyncLag {
+			return "", fmt.Errorf("member %s is at zxid 0x%x, the leader is at 0x%x", pods[i].Name, s.Zxid, leaderStats.Zxid)
+		}
+	}
+	return leader, nil
+}
+
+// updateClusterSize stores the new size of the cluster in its metadata znode.
+// The znode is created with the size once the cluster is ready.
+func (r *ZookeeperClusterReconciler) updateClusterSize(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, size int32) (err error) {
+	if !instance.Status.MetaRootCreated {
+		return nil
+	}
+	zkUri, err := r.connect(ctx, instance)
+	if err != nil {
+		return fmt.Errorf("Error storing cluster size %v", err)
+	}
+	defer r.ZkClient.Close()
+	r.Log.Info("Connected to ZK", "ZKURI", zkUri)
+
+	path := utils.GetMetaPath(instance)
+	version, err := r.ZkClient.NodeExists(path)
+	if err != nil {
+		return fmt.Errorf("Error doing exists check for znode %s: %v", path, err)
+	}
+	data := "CLUSTER_SIZE=" + strconv.Itoa(int(size))
+	r.Log.Info("Updating Cluster Size.", "New Data:", data, "Version", version)
+	if err = r.ZkClient.UpdateNode(path, data, version); err != nil {
+		return fmt.Errorf("Error storing cluster size in znode %s: %v", path, err)
+	}
+	return nil
+}
+
+// reconcileObserverStatefulSet creates and scales the StatefulSet of the
+// observer pool. The StatefulSet is only created once the ensemble has a
+// leader, since observers join an ensemble which runs already.
+func (r *ZookeeperClusterReconciler) reconcileObserverStatefulSet(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
+	ctx, span := r.Tracer.Start(ctx, "reconcileObserverStatefulSet")
+	defer span.End()
+	if instance.Status.IsClusterInUpgradeFailedState() {
+		return nil
+	}
+	sts, err := r.makeStatefulSet(ctx, instance, zk.MakeObserverStatefulSet)
+	if err != nil {
+		return err
+	}
+	foundSts := &appsv1.StatefulSet{}
+	err = r.Client.Get(ctx, types.NamespacedName{
+		Name:      sts.Name,
+		Namespace: sts.Namespace,
+	}, foundSts)
+	if err != nil && errors.IsNotFound(err) {
+		instance.Status.Observers.Replicas = 0
+		instance.Status.Observers.ReadyReplicas = 0
+		if *sts.Spec.Replicas == 0 || instance.Status.Leader == "" {
+			return nil
+		}
+		r.Log.Info("Creating the observer StatefulSet",
+			"StatefulSet.Namespace", sts.Namespace,
+			"StatefulSet.Name", sts.Name)
+		return r.Client.Create(ctx, sts)
+	} else if err != nil {
+		return err
+	}
+	if err = r.reconcileObserverMembership(ctx, instance); err != nil {
+		return err
+	}
+	r.Log.Info("Updating the observer StatefulSet",
+		"StatefulSet.Namespace", foundSts.Namespace,
+		"StatefulSet.Name", foundSts.Name)
+	zk.SyncStatefulSet(foundSts, sts)
+	if err = r.Client.Update(ctx, foundSts); err != nil {
+		return err
+	}
+	instance.Status.Observers.Replicas = foundSts.Status.Replicas
+	instance.Status.Observers.ReadyReplicas = foundSts.Status.ReadyReplicas
+	return nil
+}
+
+// reconcileObserverMembership reconfigures the observers of the ensemble
+// towards the observer pool. Observers are added with the observer role once
+// they follow the leader, and removed when the pool shrinks. The voting
+// members are left as they are, so none of these steps can affect the
+// quorum, and a failed step does not hold up the observer StatefulSet.
+func (r *ZookeeperClusterReconciler) reconcileObserverMembership(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
+	if instance.Status.Leader == "" {
+		return nil
+	}
+	if _, err = r.connect(ctx, instance); err != nil {
+		return fmt.Errorf("Error reading the ensemble config: %v", err)
+	}
+	defer r.ZkClient.Close()
+	config, err := r.ZkClient.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	replicas := int(instance.ObserverReplicas())
+	for _, server := range config.Servers {
+		if !zk.IsObserverServerID(server.ID) || server.ID <= zk.ObserverServerIDOffset+replicas {
+			continue
+		}
+		member := fmt.Sprintf("%s-%d", instance.ObserverStatefulSetName(), server.ID-zk.ObserverServerIDOffset-1)
+		next, err := r.reconfigure(instance, config, zookeeperv1.ReconfigActionRemove, member, server)
+		if err != nil {
+			continue
+		}
+		config = next
+	}
+	for ordinal := 0; ordinal < replicas; ordinal++ {
+		member := fmt.Sprintf("%s-%d", instance.ObserverStatefulSetName(), ordinal)
+		joining := zk.MakeObserverServerConfig(instance, ordinal)
+		action := zookeeperv1.ReconfigActionAdd
+		if server := config.Server(joining.ID); server != nil && server.Role == zk.RoleObserver {
+			if zk.SameClientAddress(server.ClientAddress, joining.ClientAddress) {
+				continue
+			}
+			action = zookeeperv1.ReconfigActionUpdate
+		}
+		if m := instance.Status.GetMemberStatus(member); action == zookeeperv1.ReconfigActionAdd && (m == nil ||
+			(m.Role != zookeeperv1.MemberRoleObserver && m.Role != zookeeperv1.MemberRoleFollower)) {
+			continue
+		}
+		next, err := r.reconfigure(instance, config, action, member, joining)
+		if err != nil {
+			continue
+		}
+		config = next
+	}
+	return nil
+}
+
+// reconfigure sends a reconfiguration step to the ensemble, records it in the
+// status and returns the config the ensemble committed
+func (r *ZookeeperClusterReconciler) reconfigure(instance *zookeeperv1.ZookeeperCluster, config *zk.EnsembleConfig, action zookeeperv1.ReconfigAction, member string, server zk.ServerConfig) (*zk.EnsembleConfig, error) {
+	step := instance.Status.Membership.StartReconfigStep(action, member, int32(server.ID))
+	r.Log.Info("Reconfiguring the ensemble", "Action", action, "Member", member, "Server", server.String(), "Attempt", step.Attempts)
+	version, err := config.VersionNumber()
+	if err == nil {
+		if action == zookeeperv1.ReconfigActionRemove {
+			err = r.ZkClient.Reconfig(nil, []int{server.ID}, version)
+		} else {
+			err = r.ZkClient.Reconfig([]zk.ServerConfig{server}, nil, version)
+		}
+	}
+	if err == nil {
+		config, err = r.ZkClient.GetConfig()
+	}
+	if err != nil {
+		r.Log.Info("Unable to reconfigure the ensemble", "Action", action, "Member", member, "Error", err.Error())
+		step.Fail(err)
+		return nil, err
+	}
+	step.Succeed()
+	recordMembership(instance, config)
+	return config, nil
+}
+
+// recordMembership reports the members of the dynamic config in the status
+func recordMembership(instance *zookeeperv1.ZookeeperCluster, config *zk.EnsembleConfig) {
+	membership := &instance.Status.Membership
+	membership.ConfigVersion = config.Version
+	membership.Participants = config.Participants()
+	membership.Observers = config.Observers()
+}
+
+// makeStatefulSet returns the stateful set the generator makes for the cluster
+// the members run, see targetCluster, with the hashes of the config and of
+// the secrets stamped on the pod template
+func (r *ZookeeperClusterReconciler) makeStatefulSet(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, generate func(*zookeeperv1.ZookeeperCluster) *appsv1.StatefulSet) (*appsv1.StatefulSet, error) {
+	target := targetCluster(instance)
+	sts := generate(target)
+	if err := controllerutil.SetControllerReference(instance, sts, r.Scheme); err != nil {
+		return nil, err
+	}
+	annotateConfig(target, sts)
+	if err := r.annotateTLSSecrets(ctx, instance, sts); err != nil {
+		return nil, err
+	}
+	if err := r.annotateAuthSecrets(ctx, instance, sts); err != nil {
+		return nil, err
+	}
+	return sts, nil
+}
+
+// annotateConfig stamps the pod template with a hash of the zoo.cfg of the
+// members, which is only read when a member starts
+func annotateConfig(instance *zookeeperv1.ZookeeperCluster, sts *appsv1.StatefulSet) {
+	setPodTemplateAnnotation(sts, configHashAnnotation, configHash(instance))
+}
+
+// configHash returns a hash of the zoo.cfg of the members. The lines are
+// hashed in order, the additional config is not written in a stable order.
+func configHash(instance *zookeeperv1.ZookeeperCluster) string {
+	lines := strings.Split(zk.MakeConfigMap(instance).Data["zoo.cfg"], "\n")
+	sort.Strings(lines)
+	hash := sha256.New()
+	for _, line := range lines {
+		fmt.Fprintln(hash, line)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// rollbackTarget returns the cluster the members run. It is the cluster
+// itself, unless a failed upgrade has been rolled back and the spec still asks
+// for the image and the config of the failed upgrade. The image and the config
+// of the last known good revision replace them then.
+func rollbackTarget(instance *zookeeperv1.ZookeeperCluster) *zookeeperv1.ZookeeperCluster {
+	rb, good := instance.Status.Rollback, instance.Status.LastKnownGood
+	if rb == nil || good == nil || rb.FailedImage != instance.Spec.Image || rb.FailedConfigHash != configHash(instance) {
+		return instance
+	}
+	target := instance.DeepCopy()
+	target.Spec.Image = good.Image
+	good.Conf.DeepCopyInto(&target.Spec.Conf)
+	return target
+}
+
+// targetCluster returns the cluster the members run, see rollbackTarget. The
+// members keep their version while the spec asks for an upgrade along a path
+// the compatibility matrix does not support, and until the backup taken
+// before the upgrade succeeded.
+func targetCluster(instance *zookeeperv1.ZookeeperCluster) *zookeeperv1.ZookeeperCluster {
+	target := rollbackTarget(instance)
+	if _, err := checkUpgradePath(target); err == nil && !needsPreUpgradeBackup(target) {
+		return target
+	}
+	if target == instance {
+		target = instance.DeepCopy()
+	}
+	target.Spec.Image.Tag = instance.Status.CurrentVersion
+	return target
+}
+
+// checkUpgradePath checks the upgrade of the members from their current
+// version to the image of the cluster. An upgrade in progress is not checked
+// again.
+func checkUpgradePath(instance *zookeeperv1.ZookeeperCluster) (warning string, err error) {
+	from, to := instance.Status.CurrentVersion, instance.Spec.Image.Tag
+	if from == "" || from == to || instance.Status.TargetVersion == to {
+		return "", nil
+	}
+	return instance.CheckUpgradePath(from, to)
+}
+
+// needsPreUpgradeBackup reports whether the members wait for a backup before
+// they are upgraded to the image of the cluster. An upgrade in progress does
+// not wait.
+func needsPreUpgradeBackup(instance *zookeeperv1.ZookeeperCluster) bool {
+	from, to := instance.Status.CurrentVersion, instance.Spec.Image.Tag
+	if instance.Spec.UpgradeStrategy.BacksUp() == nil || from == "" || from == to || instance.Status.TargetVersion == to {
+		return false
+	}
+	return !instance.Status.HasPreUpgradeBackup(to)
+}
+
+// reconcilePreUpgradeBackup takes the backup before the upgrade to the image
+// of the cluster and records its progress. A failed backup holds the upgrade
+// until it is deleted, upon which it is taken again.
+func (r *ZookeeperClusterReconciler) reconcilePreUpgradeBackup(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) error {
+	from, to := instance.Status.CurrentVersion, instance.Spec.Image.Tag
+	status := instance.Status.PreUpgradeBackup
+	if status == nil || status.TargetVersion != to {
+		status = instance.Status.StartPreUpgradeBackup(zk.MakePreUpgradeBackup(instance).Name, to)
+	}
+	backup := &zookeeperv1.ZookeeperBackup{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: status.Name, Namespace: instance.Namespace}, backup)
+	if errors.IsNotFound(err) {
+		backup = zk.MakePreUpgradeBackup(instance)
+		backup.Name = status.Name
+		if err = controllerutil.SetControllerReference(instance, backup, r.Scheme); err != nil {
+			return err
+		}
+		r.Log.Info("Backing up before the upgrade", "Backup", backup.Name, "From", from, "To", to)
+		if err = r.Client.Create(ctx, backup); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		status = instance.Status.StartPreUpgradeBackup(status.Name, to)
+	} else if err != nil {
+		return err
+	}
+
+	if backup.Status.Phase != "" {
+		status.Phase = backup.Status.Phase
+	}
+	status.Location = backup.Status.Location
+	status.Zxid = backup.Status.Zxid
+	status.Checksum = backup.Status.Checksum
+	status.Message = backup.Status.Message
+	status.CompletionTime = backup.Status.CompletionTime
+	if status.Phase == zookeeperv1.BackupPhaseSucceeded {
+		if err = verifyBackup(backup); err != nil {
+			status.Phase = zookeeperv1.BackupPhaseFailed
+			status.Message = err.Error()
+		} else {
+			r.Log.Info("Backed up before the upgrade", "Backup", backup.Name, "Location", status.Location)
+			// the members are upgraded from the next reconcile on
+			instance.Status.SetUpgradingConditionFalse()
+			return r.updateStatus(ctx, instance)
+		}
+	}
+	if status.Phase == zookeeperv1.BackupPhaseFailed {
+		message := fmt.Sprintf("backup %s failed, the members stay on %s: %s", status.Name, from, status.Message)
+		if _, c := instance.Status.GetClusterCondition(zookeeperv1.ClusterConditionUpgrading); c == nil || c.Reason != zookeeperv1.PreUpgradeBackupFailedReason {
+			r.Log.Info("Refusing the upgrade", "From", from, "To", to, "Reason", message)
+		}
+		instance.Status.SetUpgradingConditionHeld(zookeeperv1.PreUpgradeBackupFailedReason, message)
+	} else {
+		instance.Status.SetUpgradingConditionHeld(zookeeperv1.BackingUpReason,
+			fmt.Sprintf("taking backup %s before upgrading to %s", status.Name, to))
+	}
+	return r.updateStatus(ctx, instance)
+}
+
+// verifyBackup checks that the backup left an artifact and its checksum
+func verifyBackup(backup *zookeeperv1.ZookeeperBackup) error {
+	if backup.Status.Size <= 0 {
+		return fmt.Errorf("backup %s left an empty artifact", backup.Name)
+	}
+	if !strings.HasPrefix(backup.Status.Checksum, "sha256:") {
+		return fmt.Errorf("backup %s left no checksum of its artifact", backup.Name)
+	}
+	return nil
+}
+
+// isRollingBack reports whether the members are being restarted back to the
+// last known good revision. A rollback is abandoned as soon as the spec moves
+// on from the failed upgrade.
+func isRollingBack(instance *zookeeperv1.ZookeeperCluster) bool {
+	return instance.Status.IsRollingBack() && rollbackTarget(instance) != instance
+}
+
+// startRollback rolls a failed upgrade back to the last known good revision,
+// if the upgrade strategy asks for it
+func (r *ZookeeperClusterReconciler) startRollback(instance *zookeeperv1.ZookeeperCluster, reason string) {
+	good := instance.Status.LastKnownGood
+	if !instance.Spec.UpgradeStrategy.RollsBack() || good == nil {
+		return
+	}
+	if good.Image == instance.Spec.Image && reflect.DeepEqual(good.Conf, instance.Spec.Conf) {
+		r.Log.Info("The failed upgrade runs the last known good revision, nothing to roll back")
+		return
 	}
-	return 0
+	r.Log.Info("Rolling the failed upgrade back",
+		"From", instance.Status.TargetVersion,
+		"To", good.Image.Tag,
+		"Revision", good.Revision)
+	instance.Status.StartRollback(instance.Status.TargetVersion, instance.Spec.Image, configHash(instance), reason)
+	instance.Status.SetRolledBackConditionFalse(zookeeperv1.RollingBackReason,
+		fmt.Sprintf("rolling back from %s to %s", instance.Status.TargetVersion, good.Image.Tag))
 }
 
-func (r *ZookeeperClusterReconciler) reconcileStatefulSet(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
-	ctx, span := r.Tracer.Start(ctx, "reconcileStatefulSet")
-	defer span.End()
+// completeRollback ends the failed upgrade once every member runs the last
+// known good revision again. The version of the failed upgrade is kept in the
+// rollback status.
+func (r *ZookeeperClusterReconciler) completeRollback(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) error {
+	rb := instance.Status.Rollback
+	rb.Complete()
+	instance.Status.CurrentVersion = instance.Status.LastKnownGood.Image.Tag
+	r.Log.Info("Rollback completed", "From", rb.FailedVersion, "To", instance.Status.CurrentVersion)
+	instance.Status.SetErrorConditionFalse()
+	instance.Status.SetRolledBackConditionTrue(
+		fmt.Sprintf("rolled back from %s to %s", rb.FailedVersion, instance.Status.CurrentVersion))
+	return r.clearUpgradeStatus(ctx, instance)
+}
 
-	// we cannot upgrade if cluster is in UpgradeFailed
-	if instance.Status.IsClusterInUpgradeFailedState() {
-		sts := zk.MakeStatefulSet(instance)
-		if err = controllerutil.SetControllerReference(instance, sts, r.Scheme); err != nil {
-			return err
+// recordKnownGood records the image and the config of the cluster the members
+// run as known good, once every member runs the revision of the stateful set
+// and is ready
+func recordKnownGood(instance *zookeeperv1.ZookeeperCluster, foundSts *appsv1.StatefulSet) {
+	if instance.Status.IsClusterInUpgradingState() || foundSts.Status.UpdateRevision == "" || !isStatefulSetRolledOut(foundSts) {
+		return
+	}
+	target := targetCluster(instance)
+	instance.Status.RecordKnownGood(foundSts.Status.UpdateRevision, target.Spec.Image, target.Spec.Conf)
+}
+
+// annotateTLSSecrets stamps the pod template with a hash of the TLS Secrets
+// of the cluster. Secrets are not watched, a change is picked up by the next
+// periodic reconciliation.
+func (r *ZookeeperClusterReconciler) annotateTLSSecrets(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, sts *appsv1.StatefulSet) error {
+	tlsSecrets := instance.Spec.TLS.Secrets()
+	if len(tlsSecrets) == 0 {
+		return nil
+	}
+	hash := sha256.New()
+	for _, tlsSecret := range tlsSecrets {
+		secret := &corev1.Secret{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: tlsSecret.SecretName, Namespace: instance.Namespace}, secret)
+		if err != nil {
+			return fmt.Errorf("Error reading TLS secret %s: %v", tlsSecret.SecretName, err)
 		}
-		foundSts := &appsv1.StatefulSet{}
-		err = r.Client.Get(ctx, types.NamespacedName{
-			Name:      sts.Name,
-			Namespace: sts.Namespace,
-		}, foundSts)
-		if err == nil {
-			err = r.Client.Update(ctx, foundSts)
-			if err != nil {
-				return err
-			}
-			if foundSts.Status.Replicas == foundSts.Status.ReadyReplicas && foundSts.Status.CurrentRevision == foundSts.Status.UpdateRevision {
-				r.Log.Info("failed upgrade completed", "upgrade from:", instance.Status.CurrentVersion, "upgrade to:", instance.Status.TargetVersion)
-				instance.Status.CurrentVersion = instance.Status.TargetVersion
-				instance.Status.SetErrorConditionFalse()
-				return r.clearUpgradeStatus(ctx, instance)
-			} else {
-				r.Log.Info("Unable to recover failed upgrade, make sure all nodes are running the target version")
+		for _, key := range tlsSecret.RequiredKeys() {
+			data, ok := secret.Data[key]
+			if !ok {
+				return fmt.Errorf("Error reading TLS secret %s: no %s key for the %s format", tlsSecret.SecretName, key, tlsSecret.Format)
 			}
-
+			fmt.Fprintf(hash, "%s/%s=%d:", tlsSecret.SecretName, key, len(data))
+			hash.Write(data)
 		}
 	}
+	setPodTemplateAnnotation(sts, tlsSecretsHashAnnotation, hex.EncodeToString(hash.Sum(nil)))
+	return nil
+}
 
-	if instance.Status.IsClusterInUpgradeFailedState() {
+// annotateAuthSecrets stamps the pod template with a hash of the Secrets of
+// the SASL authentication and of the super user, which are only read when a
+// member starts
+func (r *ZookeeperClusterReconciler) annotateAuthSecrets(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, sts *appsv1.StatefulSet) error {
+	auth := instance.Spec.Auth
+	if !auth.Enabled() && !instance.Spec.ACL.Enforced() {
 		return nil
 	}
-	if instance.Spec.Pod.ServiceAccountName != "default" {
-		serviceAccount := zk.MakeServiceAccount(instance)
-		if err = controllerutil.SetControllerReference(instance, serviceAccount, r.Scheme); err != nil {
-			return err
-		}
-		// Check if this ServiceAccount already exists
-		foundServiceAccount := &corev1.ServiceAccount{}
-		err = r.Client.Get(ctx, types.NamespacedName{Name: serviceAccount.Name, Namespace: serviceAccount.Namespace}, foundServiceAccount)
-		if err != nil && errors.IsNotFound(err) {
-			r.Log.Info("Creating a new ServiceAccount", "ServiceAccount.Namespace", serviceAccount.Namespace, "ServiceAccount.Name", serviceAccount.Name)
-			err = r.Client.Create(ctx, serviceAccount)
+	hash := sha256.New()
+	if auth.Enabled() {
+		if d := auth.Digest; d != nil {
+			secret := &corev1.Secret{}
+			err := r.Client.Get(ctx, types.NamespacedName{Name: d.SecretName, Namespace: instance.Namespace}, secret)
 			if err != nil {
-				return err
+				return fmt.Errorf("Error reading digest secret %s: %v", d.SecretName, err)
 			}
-		} else if err != nil {
-			return err
-		} else {
-			foundServiceAccount.ImagePullSecrets = serviceAccount.ImagePullSecrets
-			r.Log.Info("Updating ServiceAccount", "ServiceAccount.Namespace", serviceAccount.Namespace, "ServiceAccount.Name", serviceAccount.Name)
-			err = r.Client.Update(ctx, foundServiceAccount)
-			if err != nil {
-				return err
+			if _, ok := secret.Data[d.MemberUser]; !ok {
+				return fmt.Errorf("Error reading digest secret %s: no %s key for the member user", d.SecretName, d.MemberUser)
+			}
+			users := make([]string, 0, len(secret.Data))
+			for user := range secret.Data {
+				users = append(users, user)
+			}
+			sort.Strings(users)
+			for _, user := range users {
+				fmt.Fprintf(hash, "%s/%s=%d:", d.SecretName, user, len(secret.Data[user]))
+				hash.Write(secret.Data[user])
 			}
 		}
-	}
-	sts := zk.MakeStatefulSet(instance)
-	if err = controllerutil.SetControllerReference(instance, sts, r.Scheme); err != nil {
-		return err
-	}
-	foundSts := &appsv1.StatefulSet{}
-	err = r.Client.Get(ctx, types.NamespacedName{
-		Name:      sts.Name,
-		Namespace: sts.Namespace,
-	}, foundSts)
-	if err != nil && errors.IsNotFound(err) {
-		r.Log.Info("Creating a new Zookeeper StatefulSet",
-			"StatefulSet.Namespace", sts.Namespace,
-			"StatefulSet.Name", sts.Name)
-		// label the RV of the zookeeperCluster when creating the sts
-		sts.Labels["owner-rv"] = instance.ResourceVersion
-		err = r.Client.Create(ctx, sts)
-		if err != nil {
-			return err
-		}
-		return nil
-	} else if err != nil {
-		return err
-	} else {
-		// check whether zookeeperCluster is updated before updating the sts
-		cmp := compareResourceVersion(instance, foundSts)
-		if cmp < 0 {
-			return fmt.Errorf("Staleness: cr.ResourceVersion %s is smaller than labeledRV %s", instance.ResourceVersion, foundSts.Labels["owner-rv"])
-		} else if cmp > 0 {
-			// Zookeeper StatefulSet version inherits ZookeeperCluster resource version
-			foundSts.Labels["owner-rv"] = instance.ResourceVersion
-		}
-		foundSTSSize := *foundSts.Spec.Replicas
-		newSTSSize := *sts.Spec.Replicas
-		if newSTSSize != foundSTSSize {
-			zkUri := utils.GetZkServiceUri(instance)
-			err = r.ZkClient.Connect(zkUri)
+		if k := auth.Kerberos; k != nil {
+			secret := &corev1.Secret{}
+			err := r.Client.Get(ctx, types.NamespacedName{Name: k.KeytabSecretName, Namespace: instance.Namespace}, secret)
 			if err != nil {
-				return fmt.Errorf("Error storing cluster size %v", err)
+				return fmt.Errorf("Error reading keytab secret %s: %v", k.KeytabSecretName, err)
 			}
-			defer r.ZkClient.Close()
-			r.Log.Info("Connected to ZK", "ZKURI", zkUri)
-
-			path := utils.GetMetaPath(instance)
-			version, err := r.ZkClient.NodeExists(path)
-			if err != nil {
-				return fmt.Errorf("Error doing exists check for znode %s: %v", path, err)
+			keytab, ok := secret.Data[k.KeytabKey]
+			if !ok {
+				return fmt.Errorf("Error reading keytab secret %s: no %s key", k.KeytabSecretName, k.KeytabKey)
 			}
-
-			data := "CLUSTER_SIZE=" + strconv.Itoa(int(newSTSSize))
-			r.Log.Info("Updating Cluster Size.", "New Data:", data, "Version", version)
-			r.ZkClient.UpdateNode(path, data, version)
+			fmt.Fprintf(hash, "%s/%s=%d:", k.KeytabSecretName, k.KeytabKey, len(keytab))
+			hash.Write(keytab)
 		}
-		err = r.updateStatefulSet(ctx, instance, foundSts, sts)
+	}
+	if instance.Spec.ACL.Enforced() {
+		secretName := instance.Spec.ACL.SuperUserSecretName
+		secret := &corev1.Secret{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: instance.Namespace}, secret)
 		if err != nil {
-			return err
+			return fmt.Errorf("Error reading super user secret %s: %v", secretName, err)
 		}
-		return r.upgradeStatefulSet(ctx, instance, foundSts)
+		for _, key := range []string{zookeeperv1.DigestUsernameKey, zookeeperv1.DigestPasswordKey} {
+			fmt.Fprintf(hash, "%s/%s=%d:", secretName, key, len(secret.Data[key]))
+			hash.Write(secret.Data[key])
+		}
+	}
+	setPodTemplateAnnotation(sts, authSecretsHashAnnotation, hex.EncodeToString(hash.Sum(nil)))
+	return nil
+}
+
+// setPodTemplateAnnotation sets an annotation of the pod template without
+// modifying the annotations of the pod policy it was created from
+func setPodTemplateAnnotation(sts *appsv1.StatefulSet, key string, value string) {
+	annotations := map[string]string{}
+	for k, v := range sts.Spec.Template.Annotations {
+		annotations[k] = v
 	}
+	annotations[key] = value
+	sts.Spec.Template.Annotations = annotations
 }
 
-func (r *ZookeeperClusterReconciler) updateStatefulSet(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster, foundSts *appsv1.StatefulSet, sts *appsv1.StatefulSet) (err error) {
+func (r *ZookeeperClusterReconciler) updateStatefulSet(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, foundSts *appsv1.StatefulSet, sts *appsv1.StatefulSet) (err error) {
 	r.Log.Info("Updating StatefulSet",
 		"StatefulSet.Namespace", foundSts.Namespace,
 		"StatefulSet.Name", foundSts.Name)
@@ -296,10 +1395,10 @@ func (r *ZookeeperClusterReconciler) updateStatefulSet(ctx context.Context, inst
 	return nil
 }
 
-func (r *ZookeeperClusterReconciler) upgradeStatefulSet(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster, foundSts *appsv1.StatefulSet) (err error) {
+func (r *ZookeeperClusterReconciler) upgradeStatefulSet(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, foundSts *appsv1.StatefulSet) (err error) {
 
 	// Getting the upgradeCondition from the zk clustercondition
-	_, upgradeCondition := instance.Status.GetClusterCondition(zookeeperv1beta1.ClusterConditionUpgrading)
+	_, upgradeCondition := instance.Status.GetClusterCondition(zookeeperv1.ClusterConditionUpgrading)
 
 	if upgradeCondition == nil {
 		// Initially set upgrading condition to false
@@ -308,73 +1407,240 @@ func (r *ZookeeperClusterReconciler) upgradeStatefulSet(ctx context.Context, ins
 	}
 
 	// Setting the upgrade condition to true to trigger the upgrade
-	// When the zk cluster is upgrading Statefulset CurrentRevision and UpdateRevision are not equal and zk cluster image tag is not equal to CurrentVersion
-	if upgradeCondition.Status == corev1.ConditionFalse {
-		if instance.Status.IsClusterInReadyState() && foundSts.Status.CurrentRevision != foundSts.Status.UpdateRevision && instance.Spec.Image.Tag != instance.Status.CurrentVersion {
+	// When the zk cluster is upgrading some of the pods do not run the Statefulset UpdateRevision and zk cluster image tag is not equal to CurrentVersion
+	if upgradeCondition.Status == metav1.ConditionFalse {
+		warning, err := checkUpgradePath(instance)
+		if err != nil {
+			// the stateful set keeps the current version, see targetCluster
+			if !instance.Status.IsUpgradeBlocked() {
+				r.Log.Info("Holding the members on their version", "From", instance.Status.CurrentVersion, "To", instance.Spec.Image.Tag, "Reason", err.Error())
+			}
+			instance.Status.SetUpgradingConditionBlocked(err.Error())
+			return r.updateStatus(ctx, instance)
+		}
+		if needsPreUpgradeBackup(instance) {
+			return r.reconcilePreUpgradeBackup(ctx, instance)
+		}
+		if instance.Status.IsUpgradeHeld() {
+			instance.Status.SetUpgradingConditionFalse()
+		}
+		if instance.Status.IsClusterInReadyState() && !isStatefulSetUpdated(foundSts) && instance.Spec.Image.Tag != instance.Status.CurrentVersion {
+			if warning != "" {
+				r.Log.Info("Upgrading along an unsupported upgrade path", "Warning", warning)
+			}
 			instance.Status.TargetVersion = instance.Spec.Image.Tag
 			instance.Status.SetPodsReadyConditionFalse()
 			instance.Status.SetUpgradingConditionTrue("", "")
+			if _, rolledBack := instance.Status.GetClusterCondition(zookeeperv1.ClusterConditionRolledBack); rolledBack != nil {
+				instance.Status.SetRolledBackConditionFalse("", "")
+			}
 		}
 	}
 
 	// checking if the upgrade is in progress
-	if upgradeCondition.Status == corev1.ConditionTrue {
+	if upgradeCondition.Status == metav1.ConditionTrue {
 		// checking when the targetversion is empty
 		if instance.Status.TargetVersion == "" {
 			r.Log.Info("upgrading to an unknown version: cancelling upgrade process")
 			return r.clearUpgradeStatus(ctx, instance)
 		}
 		// Checking for upgrade completion
-		if foundSts.Status.CurrentRevision == foundSts.Status.UpdateRevision {
+		if isStatefulSetUpdated(foundSts) {
 			instance.Status.CurrentVersion = instance.Status.TargetVersion
 			r.Log.Info("upgrade completed")
 			return r.clearUpgradeStatus(ctx, instance)
 		}
 		// updating the upgradecondition if upgrade is in progress
-		if foundSts.Status.CurrentRevision != foundSts.Status.UpdateRevision {
+		if !isStatefulSetUpdated(foundSts) {
 			r.Log.Info("upgrade in progress")
-			if fmt.Sprint(foundSts.Status.UpdatedReplicas) != upgradeCondition.Message {
-				instance.Status.UpdateProgress(zookeeperv1beta1.UpdatingZookeeperReason, fmt.Sprint(foundSts.Status.UpdatedReplicas))
+			pods, err := r.listMemberPods(ctx, instance)
+			if err != nil {
+				return err
+			}
+			members := memberUpgradeProgress(instance, pods, foundSts.Status.UpdateRevision, *foundSts.Spec.Replicas)
+			if u := instance.Status.Upgrade; u != nil && u.Revision == foundSts.Status.UpdateRevision {
+				u.Members = members
+			}
+			message := upgradeProgressMessage(members)
+			if failed := failedMember(members); failed != nil {
+				reason := fmt.Sprintf("member %s is %s", failed.Name, failed.Message)
+				r.Log.Info("upgrade failed", "Member", failed.Name, "Reason", failed.Message)
+				instance.Status.SetErrorConditionTrue(zookeeperv1.UpgradeFailedReason, reason)
+				r.startRollback(instance, reason)
+				return r.updateStatus(ctx, instance)
+			}
+			if instance.Spec.UpgradeStrategy.IsPaused() {
+				// a paused upgrade does not run against the progress deadline
+				instance.Status.UpdateProgress(zookeeperv1.UpgradePausedReason, message)
+				return r.updateStatus(ctx, instance)
+			}
+			if message != upgradeCondition.Message || upgradeCondition.Reason != zookeeperv1.UpdatingZookeeperReason {
+				instance.Status.UpdateProgress(zookeeperv1.UpdatingZookeeperReason, message)
+			} else if isMemberSyncing(members) {
+				// a member catching up with the leader may take long on a
+				// large snapshot, it is progress nonetheless
+				instance.Status.RecordUpgradeProgress()
 			} else {
-				err = checkSyncTimeout(instance, zookeeperv1beta1.UpdatingZookeeperReason, foundSts.Status.UpdatedReplicas, 10*time.Minute)
+				err = checkSyncTimeout(instance, zookeeperv1.UpdatingZookeeperReason, message, instance.Spec.UpgradeStrategy.ProgressDeadline())
 				if err != nil {
-					instance.Status.SetErrorConditionTrue("UpgradeFailed", err.Error())
-					return r.Client.Status().Update(ctx, instance)
-				} else {
-					return nil
+					instance.Status.SetErrorConditionTrue(zookeeperv1.UpgradeFailedReason, err.Error())
+					r.startRollback(instance, err.Error())
 				}
 			}
 		}
 	}
-	return r.Client.Status().Update(ctx, instance)
+	return r.updateStatus(ctx, instance)
+}
+
+// memberUpgradeProgress returns the progress of every member towards the
+// revision
+func memberUpgradeProgress(instance *zookeeperv1.ZookeeperCluster, pods []*corev1.Pod, revision string, replicas int32) []zookeeperv1.MemberUpgradeStatus {
+	byName := map[string]*corev1.Pod{}
+	for _, pod := range pods {
+		byName[pod.Name] = pod
+	}
+	members := make([]zookeeperv1.MemberUpgradeStatus, 0, replicas)
+	for i := int32(0); i < replicas; i++ {
+		name := fmt.Sprintf("%s-%d", instance.GetName(), i)
+		members = append(members, memberUpgradeStatus(name, byName[name], revision))
+	}
+	return members
+}
+
+// memberUpgradeStatus returns the progress of a single member. A member whose
+// pod is missing or terminating is being restarted.
+func memberUpgradeStatus(name string, pod *corev1.Pod, revision string) zookeeperv1.MemberUpgradeStatus {
+	member := zookeeperv1.MemberUpgradeStatus{Name: name, Phase: zookeeperv1.MemberUpgradeRestarting}
+	if pod == nil || pod.DeletionTimestamp != nil {
+		return member
+	}
+	if pod.Labels[appsv1.ControllerRevisionHashLabelKey] != revision {
+		member.Phase = zookeeperv1.MemberUpgradePending
+		return member
+	}
+	var container *corev1.ContainerStatus
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == "zookeeper" {
+			container = &pod.Status.ContainerStatuses[i]
+		}
+	}
+	if container == nil {
+		return member
+	}
+	member.Restarts = container.RestartCount
+	switch {
+	case isCrashLooping(container):
+		member.Phase = zookeeperv1.MemberUpgradeFailed
+		member.Message = fmt.Sprintf("crash looping after %d restarts", container.RestartCount)
+		if last := container.LastTerminationState.Terminated; last != nil {
+			member.Message += fmt.Sprintf(", last exit code %d (%s)", last.ExitCode, last.Reason)
+		}
+	case container.State.Running == nil:
+		member.Phase = zookeeperv1.MemberUpgradeRestarting
+	case container.Ready:
+		member.Phase = zookeeperv1.MemberUpgradeUpdated
+	default:
+		member.Phase = zookeeperv1.MemberUpgradeSyncing
+	}
+	return member
 }
 
-func (r *ZookeeperClusterReconciler) clearUpgradeStatus(ctx context.Context, z *zookeeperv1beta1.ZookeeperCluster) (err error) {
+// isCrashLooping reports whether the container is backing off after it failed
+// crashLoopRestarts times. The first crashes are left to the kubelet, a member
+// may fail to start while the ensemble elects a new leader.
+func isCrashLooping(container *corev1.ContainerStatus) bool {
+	waiting := container.State.Waiting
+	return waiting != nil && waiting.Reason == "CrashLoopBackOff" && container.RestartCount >= crashLoopRestarts
+}
+
+// failedMember returns the first member which failed the upgrade
+func failedMember(members []zookeeperv1.MemberUpgradeStatus) *zookeeperv1.MemberUpgradeStatus {
+	for i := range members {
+		if members[i].Phase == zookeeperv1.MemberUpgradeFailed {
+			return &members[i]
+		}
+	}
+	return nil
+}
+
+// isMemberSyncing reports whether a member runs the revision and catches up
+// with the leader
+func isMemberSyncing(members []zookeeperv1.MemberUpgradeStatus) bool {
+	for _, member := range members {
+		if member.Phase == zookeeperv1.MemberUpgradeSyncing {
+			return true
+		}
+	}
+	return false
+}
+
+// upgradeProgressMessage sums the progress of the members up for the
+// upgrading condition, e.g. "1 of 3 members updated, example-2 syncing"
+func upgradeProgressMessage(members []zookeeperv1.MemberUpgradeStatus) string {
+	updated := 0
+	var busy []string
+	for _, member := range members {
+		switch member.Phase {
+		case zookeeperv1.MemberUpgradeUpdated:
+			updated++
+		case zookeeperv1.MemberUpgradeRestarting, zookeeperv1.MemberUpgradeSyncing:
+			busy = append(busy, member.Name+" "+strings.ToLower(string(member.Phase)))
+		}
+	}
+	message := fmt.Sprintf("%d of %d members updated", updated, len(members))
+	if len(busy) > 0 {
+		message += ", " + strings.Join(busy, ", ")
+	}
+	return message
+}
+
+func (r *ZookeeperClusterReconciler) clearUpgradeStatus(ctx context.Context, z *zookeeperv1.ZookeeperCluster) (err error) {
 	z.Status.SetUpgradingConditionFalse()
 	z.Status.TargetVersion = ""
-	// need to deep copy the status struct, otherwise it will be overwritten
-	// when updating the CR below
-	status := z.Status.DeepCopy()
+	return r.updateStatus(ctx, z)
+}
 
-	err = r.Client.Update(ctx, z)
-	if err != nil {
+// updateStatus writes the status of the instance. The write is sent from a
+// copy which carries the spec as it is stored, so that the defaults applied in
+// memory are never persisted, whether or not the status subresource drops the
+// spec of the write. The instance keeps its defaults for the reconcile steps
+// that follow.
+func (r *ZookeeperClusterReconciler) updateStatus(ctx context.Context, z *zookeeperv1.ZookeeperCluster) (err error) {
+	stored := &zookeeperv1.ZookeeperCluster{}
+	if err = r.Client.Get(ctx, client.ObjectKeyFromObject(z), stored); err != nil {
+		return err
+	}
+	write := stored.DeepCopy()
+	z.ObjectMeta.DeepCopyInto(&write.ObjectMeta)
+	z.Status.DeepCopyInto(&write.Status)
+	if err = r.Client.Status().Update(ctx, write); err != nil {
 		return err
 	}
+	z.ObjectMeta = write.ObjectMeta
+	return nil
+}
 
-	z.Status = *status
+// updateFinalizers patches the finalizers of the instance, leaving the spec
+// untouched so that the in-memory defaults are not persisted
+func (r *ZookeeperClusterReconciler) updateFinalizers(ctx context.Context, z *zookeeperv1.ZookeeperCluster, finalizers []string) (err error) {
+	patched := z.DeepCopy()
+	patched.ObjectMeta.Finalizers = finalizers
+	if err = r.Client.Patch(ctx, patched, client.MergeFrom(z)); err != nil {
+		return err
+	}
+	z.ObjectMeta = patched.ObjectMeta
 	return nil
 }
 
-func checkSyncTimeout(z *zookeeperv1beta1.ZookeeperCluster, reason string, updatedReplicas int32, t time.Duration) error {
+func checkSyncTimeout(z *zookeeperv1.ZookeeperCluster, reason string, message string, t time.Duration) error {
 	lastCondition := z.Status.GetLastCondition()
 	if lastCondition == nil {
 		return nil
 	}
-	if lastCondition.Reason == reason && lastCondition.Message == fmt.Sprint(updatedReplicas) {
+	if lastCondition.Reason == reason && lastCondition.Message == message {
 		// if reason and message are the same as before, which means there is no progress since the last reconciling,
 		// then check if it reaches the timeout.
-		parsedTime, _ := time.Parse(time.RFC3339, lastCondition.LastUpdateTime)
-		if time.Now().After(parsedTime.Add(t)) {
+		if progressTime := z.Status.LastUpgradeProgressTime; progressTime != nil && time.Now().After(progressTime.Add(t)) {
 			// timeout
 			return fmt.Errorf("progress deadline exceeded")
 		}
@@ -382,7 +1648,7 @@ func checkSyncTimeout(z *zookeeperv1beta1.ZookeeperCluster, reason string, updat
 	return nil
 }
 
-func (r *ZookeeperClusterReconciler) reconcileClientService(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+func (r *ZookeeperClusterReconciler) reconcileClientService(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	ctx, span := r.Tracer.Start(ctx, "reconcileClientService")
 	defer span.End()
 	svc := zk.MakeClientService(instance)
@@ -414,7 +1680,7 @@ func (r *ZookeeperClusterReconciler) reconcileClientService(ctx context.Context,
 		if err != nil {
 			return err
 		}
-		port := instance.ZookeeperPorts().Client
+		port := instance.Spec.Ports.Client
 		instance.Status.InternalClientEndpoint = fmt.Sprintf("%s:%d",
 			foundSvc.Spec.ClusterIP, port)
 		if foundSvc.Spec.Type == "LoadBalancer" {
@@ -431,7 +1697,7 @@ func (r *ZookeeperClusterReconciler) reconcileClientService(ctx context.Context,
 	return nil
 }
 
-func (r *ZookeeperClusterReconciler) reconcileHeadlessService(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+func (r *ZookeeperClusterReconciler) reconcileHeadlessService(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	ctx, span := r.Tracer.Start(ctx, "reconcileHeadlessService")
 	defer span.End()
 	svc := zk.MakeHeadlessService(instance)
@@ -467,7 +1733,7 @@ func (r *ZookeeperClusterReconciler) reconcileHeadlessService(ctx context.Contex
 	return nil
 }
 
-func (r *ZookeeperClusterReconciler) reconcileAdminServerService(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+func (r *ZookeeperClusterReconciler) reconcileAdminServerService(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	ctx, span := r.Tracer.Start(ctx, "reconcileAdminServerService")
 	defer span.End()
 	svc := zk.MakeAdminServerService(instance)
@@ -503,7 +1769,7 @@ func (r *ZookeeperClusterReconciler) reconcileAdminServerService(ctx context.Con
 	return nil
 }
 
-func (r *ZookeeperClusterReconciler) reconcilePodDisruptionBudget(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+func (r *ZookeeperClusterReconciler) reconcilePodDisruptionBudget(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	ctx, span := r.Tracer.Start(ctx, "reconcilePodDisruptionBudget")
 	defer span.End()
 	pdb := zk.MakePodDisruptionBudget(instance)
@@ -530,10 +1796,81 @@ func (r *ZookeeperClusterReconciler) reconcilePodDisruptionBudget(ctx context.Co
 	return nil
 }
 
-func (r *ZookeeperClusterReconciler) reconcileConfigMap(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+// reconcileQuorumTLS moves the members one phase closer to the quorum TLS
+// of the spec. Each phase has to be rolled out to every member before the
+// next one starts, since members only talk to peers in an adjacent phase.
+func (r *ZookeeperClusterReconciler) reconcileQuorumTLS(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
+	ctx, span := r.Tracer.Start(ctx, "reconcileQuorumTLS")
+	defer span.End()
+
+	enable := instance.Spec.TLS.QuorumEnabled()
+	status := instance.Status.QuorumTLS
+	if status == nil {
+		if !enable {
+			return nil
+		}
+		status = &zookeeperv1.QuorumTLSStatus{Phase: zookeeperv1.QuorumTLSDisabled}
+		instance.Status.QuorumTLS = status
+	}
+	if enable {
+		status.Secret = instance.Spec.TLS.Quorum.DeepCopy()
+	}
+	target := zookeeperv1.QuorumTLSDisabled
+	if enable {
+		target = zookeeperv1.QuorumTLSEnabled
+	}
+
+	foundSts := &appsv1.StatefulSet{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: instance.GetName(), Namespace: instance.Namespace}, foundSts)
+	if err != nil && errors.IsNotFound(err) {
+		// a new ensemble starts right away in the target phase
+		status.Phase = target
+	} else if err != nil {
+		return err
+	} else {
+		// the pod template knows best which phase the members run in, should
+		// the status have been lost after the last phase change
+		rolledOutPhase := zookeeperv1.QuorumTLSPhase(foundSts.Spec.Template.Annotations[zk.QuorumTLSPhaseAnnotation])
+		if rolledOutPhase == "" {
+			rolledOutPhase = zookeeperv1.QuorumTLSDisabled
+		}
+		status.Phase = rolledOutPhase
+		if rolledOutPhase != target {
+			if !isStatefulSetRolledOut(foundSts) {
+				r.Log.Info("Waiting for the members to restart in the quorum TLS phase", "phase", rolledOutPhase)
+				return nil
+			}
+			status.Phase = rolledOutPhase.Next(enable)
+			r.Log.Info("Restarting the members in the next quorum TLS phase", "phase", status.Phase)
+		}
+	}
+	if status.Phase == zookeeperv1.QuorumTLSDisabled {
+		instance.Status.QuorumTLS = nil
+	}
+	return nil
+}
+
+// isStatefulSetRolledOut reports whether every pod of the stateful set runs
+// its current template and is ready
+func isStatefulSetRolledOut(sts *appsv1.StatefulSet) bool {
+	return sts.Status.ObservedGeneration == sts.Generation &&
+		sts.Status.UpdatedReplicas == *sts.Spec.Replicas &&
+		sts.Status.ReadyReplicas == *sts.Spec.Replicas
+}
+
+// isStatefulSetUpdated reports whether every pod of the stateful set runs its
+// update revision. The stateful set controller does not move the current
+// revision of an OnDelete stateful set forward, the updated replicas tell
+// instead.
+func isStatefulSetUpdated(sts *appsv1.StatefulSet) bool {
+	return sts.Status.CurrentRevision == sts.Status.UpdateRevision ||
+		sts.Status.UpdatedReplicas == *sts.Spec.Replicas
+}
+
+func (r *ZookeeperClusterReconciler) reconcileConfigMap(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	ctx, span := r.Tracer.Start(ctx, "reconcileConfigMap")
 	defer span.End()
-	cm := zk.MakeConfigMap(instance)
+	cm := zk.MakeConfigMap(targetCluster(instance))
 	if err = controllerutil.SetControllerReference(instance, cm, r.Scheme); err != nil {
 		return err
 	}
@@ -566,15 +1903,16 @@ func (r *ZookeeperClusterReconciler) reconcileConfigMap(ctx context.Context, ins
 	return nil
 }
 
-func (r *ZookeeperClusterReconciler) reconcileClusterStatus(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+func (r *ZookeeperClusterReconciler) reconcileClusterStatus(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	ctx, span := r.Tracer.Start(ctx, "reconcileClusterStatus")
 	defer span.End()
-	if instance.Status.IsClusterInUpgradingState() || instance.Status.IsClusterInUpgradeFailedState() {
-		return nil
-	}
-	instance.Status.Init()
 	foundPods := &corev1.PodList{}
 	labelSelector := labels.SelectorFromSet(map[string]string{"app": instance.GetName()})
+	// the scale subresource counts the voting members only, like readyReplicas
+	instance.Status.Selector = labels.SelectorFromSet(map[string]string{
+		"app":  instance.GetName(),
+		"kind": zk.MemberKind,
+	}).String()
 	listOps := &client.ListOptions{
 		Namespace:     instance.Namespace,
 		LabelSelector: labelSelector,
@@ -583,10 +1921,7 @@ func (r *ZookeeperClusterReconciler) reconcileClusterStatus(ctx context.Context,
 	if err != nil {
 		return err
 	}
-	var (
-		readyMembers   []string
-		unreadyMembers []string
-	)
+	var members, observers zookeeperv1.MembersStatus
 	for _, p := range foundPods.Items {
 		ready := true
 		for _, c := range p.Status.ContainerStatuses {
@@ -594,20 +1929,31 @@ func (r *ZookeeperClusterReconciler) reconcileClusterStatus(ctx context.Context,
 				ready = false
 			}
 		}
+		status := &members
+		if p.Labels["kind"] == zk.ObserverKind {
+			status = &observers
+		}
 		if ready {
-			readyMembers = append(readyMembers, p.Name)
+			status.Ready = append(status.Ready, p.Name)
 		} else {
-			unreadyMembers = append(unreadyMembers, p.Name)
+			status.Unready = append(status.Unready, p.Name)
 		}
 	}
-	instance.Status.Members.Ready = readyMembers
-	instance.Status.Members.Unready = unreadyMembers
+	instance.Status.Members = members
+	instance.Status.Observers.Members = observers
+	r.updateMemberStatuses(ctx, instance, foundPods.Items)
+	r.updateQuorumConditions(ctx, instance)
+
+	// The remaining conditions are managed by the upgrade while it runs
+	if instance.Status.IsClusterInUpgradingState() || instance.Status.IsClusterInUpgradeFailedState() {
+		return r.updateStatus(ctx, instance)
+	}
+	instance.Status.Init()
 
 	// If Cluster is in a ready state...
 	if instance.Spec.Replicas == instance.Status.ReadyReplicas && (!instance.Status.MetaRootCreated) {
 		r.Log.Info("Cluster is Ready, Creating ZK Metadata...")
-		zkUri := utils.GetZkServiceUri(instance)
-		err := r.ZkClient.Connect(zkUri)
+		zkUri, err := r.connect(ctx, instance)
 		if err != nil {
 			return fmt.Errorf("Error creating cluster metaroot. Connect to zk failed %v", err)
 		}
@@ -631,13 +1977,203 @@ func (r *ZookeeperClusterReconciler) reconcileClusterStatus(ctx context.Context,
 	if instance.Status.CurrentVersion == "" && instance.Status.IsClusterInReadyState() {
 		instance.Status.CurrentVersion = instance.Spec.Image.Tag
 	}
-	return r.Client.Status().Update(ctx, instance)
+	return r.updateStatus(ctx, instance)
+}
+
+// updateMemberStatuses queries every member for its role and health. A member
+// which does not answer keeps its last known status with an unknown role.
+func (r *ZookeeperClusterReconciler) updateMemberStatuses(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, pods []corev1.Pod) {
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	now := metav1.Now()
+	leader := ""
+	memberStatuses := make([]zookeeperv1.MemberStatus, 0, len(pods))
+	for i := range pods {
+		member := zookeeperv1.MemberStatus{Name: pods[i].Name}
+		if last := instance.Status.GetMemberStatus(member.Name); last != nil {
+			member = *last.DeepCopy()
+		}
+		stats, err := r.queryMember(ctx, instance, &pods[i])
+		if err != nil {
+			r.Log.Info("Unable to query zookeeper member", "Member", member.Name, "Error", err.Error())
+			member.Role = zookeeperv1.MemberRoleUnknown
+		} else {
+			member.Role = memberRole(stats.Mode)
+			member.Zxid = fmt.Sprintf("0x%x", stats.Zxid)
+			member.Epoch = stats.Epoch()
+			member.Connections = stats.Connections
+			member.OutstandingRequests = stats.Outstanding
+			member.Latency = stats.Latency
+			member.LastSeenTime = &now
+		}
+		if member.Role == zookeeperv1.MemberRoleLeader || member.Role == zookeeperv1.MemberRoleStandalone {
+			leader = member.Name
+		}
+		memberStatuses = append(memberStatuses, member)
+	}
+	instance.Status.MemberStatuses = memberStatuses
+	instance.Status.Leader = leader
+}
+
+// updateQuorumConditions derives the ensemble conditions from the member
+// statuses, and from the dynamic config of the ensemble while it has a leader
+// to read it from
+func (r *ZookeeperClusterReconciler) updateQuorumConditions(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) {
+	status := &instance.Status
+	var serving int32
+	for _, m := range status.MemberStatuses {
+		switch m.Role {
+		case zookeeperv1.MemberRoleLeader, zookeeperv1.MemberRoleFollower, zookeeperv1.MemberRoleStandalone:
+			serving++
+		}
+	}
+	voters := instance.Spec.Replicas
+	membershipMismatch := false
+	if status.Leader != "" {
+		config, err := r.getEnsembleConfig(ctx, instance)
+		if err != nil {
+			r.Log.Info("Unable to read the ensemble config", "Error", err.Error())
+		} else {
+			recordMembership(instance, config)
+			membershipMismatch = config.Participants() != instance.Spec.Replicas
+			voters = config.Participants()
+		}
+	}
+	quorum := voters/2 + 1
+	servingMessage := fmt.Sprintf("%d of %d voting members are serving", serving, voters)
+
+	if status.Leader != "" {
+		status.SetLeaderElectedConditionTrue(status.Leader)
+	} else {
+		status.SetLeaderElectedConditionFalse()
+	}
+	quorumAvailable := status.Leader != "" && serving >= quorum
+	if quorumAvailable {
+		status.SetQuorumAvailableConditionTrue(servingMessage)
+	} else {
+		status.SetQuorumAvailableConditionFalse(servingMessage)
+	}
+	switch {
+	case !quorumAvailable:
+		status.SetDegradedConditionTrue(zookeeperv1.QuorumLostReason, servingMessage)
+	case membershipMismatch:
+		status.SetDegradedConditionTrue(zookeeperv1.MembershipMismatchReason,
+			fmt.Sprintf("the ensemble config has %d voting members but %d replicas are requested", voters, instance.Spec.Replicas))
+	case serving < voters && serving == quorum:
+		status.SetDegradedConditionTrue(zookeeperv1.NoRedundancyReason,
+			servingMessage+", the quorum is lost if another one fails")
+	case serving < voters:
+		status.SetDegradedConditionTrue(zookeeperv1.MembersUnavailableReason, servingMessage)
+	default:
+		status.SetDegradedConditionFalse()
+	}
+}
+
+func (r *ZookeeperClusterReconciler) getEnsembleConfig(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (*zk.EnsembleConfig, error) {
+	if _, err := r.connect(ctx, instance); err != nil {
+		return nil, err
+	}
+	defer r.ZkClient.Close()
+	return r.ZkClient.GetConfig()
+}
+
+// connect opens a session of the operator with the ensemble. It goes through
+// the secure client port when the members serve clients over TLS, and is
+// authenticated with the credentials of the operator, if any. The Secrets are
+// read on every connection so that rotated key material is picked up.
+func (r *ZookeeperClusterReconciler) connect(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (zkUri string, err error) {
+	zkUri = utils.GetZkServiceUri(instance)
+	opts := &zk.ConnectOptions{}
+	opts.TLSConfig, err = clientTLSConfig(ctx, r.Client, instance, utils.GetZkServiceHost(instance))
+	if err != nil {
+		return "", err
+	}
+	if opts.TLSConfig != nil {
+		zkUri = utils.GetZkSecureServiceUri(instance)
+	}
+	if p := instance.Spec.OperatorClient; p != nil && p.Auth != nil {
+		secret := &corev1.Secret{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: p.Auth.SecretName, Namespace: instance.Namespace}, secret)
+		if err != nil {
+			return "", fmt.Errorf("Error reading credentials secret %s: %v", p.Auth.SecretName, err)
+		}
+		creds, err := zk.NewDigestCredentials(secret.Data)
+		if err != nil {
+			return "", fmt.Errorf("Error reading credentials secret %s: %v", p.Auth.SecretName, err)
+		}
+		opts.Credentials = append(opts.Credentials, creds)
+	}
+	if instance.Spec.ACL.Enforced() {
+		// the metadata of the operator is only readable by the super user
+		secretName := instance.Spec.ACL.SuperUserSecretName
+		secret := &corev1.Secret{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: secretName, Namespace: instance.Namespace}, secret)
+		if err != nil {
+			return "", fmt.Errorf("Error reading super user secret %s: %v", secretName, err)
+		}
+		creds, err := zk.NewDigestCredentials(secret.Data)
+		if err != nil {
+			return "", fmt.Errorf("Error reading super user secret %s: %v", secretName, err)
+		}
+		opts.Credentials = append(opts.Credentials, creds)
+	}
+	return zkUri, r.ZkClient.Connect(zkUri, opts)
+}
+
+// queryMember reads the stats of a single member, over TLS when the members
+// serve clients over TLS
+func (r *ZookeeperClusterReconciler) queryMember(ctx context.Context, instance *zookeeperv1.ZookeeperCluster, pod *corev1.Pod) (*zk.ServerStats, error) {
+	memberUri, err := utils.GetMemberUri(instance, pod)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := clientTLSConfig(ctx, r.Client, instance, utils.GetMemberHost(instance, pod))
+	if err != nil {
+		return nil, err
+	}
+	return r.ZkClient.ServerStats(memberUri, tlsConfig)
+}
+
+// clientTLSConfig returns the TLS config the operator connects to the members
+// with, verifying them against the server name, or nil when the members serve
+// clients in plaintext. The Secret is read on every call so that rotated key
+// material is picked up.
+func clientTLSConfig(ctx context.Context, c client.Client, instance *zookeeperv1.ZookeeperCluster, serverName string) (*tls.Config, error) {
+	secretName := instance.OperatorTLSSecretName()
+	if secretName == "" {
+		return nil, nil
+	}
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: secretName, Namespace: instance.Namespace}, secret); err != nil {
+		return nil, fmt.Errorf("Error reading TLS secret %s: %v", secretName, err)
+	}
+	config, err := zk.NewClientTLSConfig(serverName, secret.Data)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading TLS secret %s: %v", secretName, err)
+	}
+	return config, nil
+}
+
+// memberRole maps the mode reported by a zookeeper server to a member role
+func memberRole(mode string) zookeeperv1.MemberRole {
+	switch mode {
+	case "leader":
+		return zookeeperv1.MemberRoleLeader
+	case "follower":
+		return zookeeperv1.MemberRoleFollower
+	case "observer":
+		return zookeeperv1.MemberRoleObserver
+	case "standalone":
+		return zookeeperv1.MemberRoleStandalone
+	}
+	return zookeeperv1.MemberRoleUnknown
 }
 
 // YAMLExporterReconciler returns a fake Reconciler which is being used for generating YAML files
-func YAMLExporterReconciler(zookeepercluster *zookeeperv1beta1.ZookeeperCluster) *ZookeeperClusterReconciler {
+func YAMLExporterReconciler(zookeepercluster *zookeeperv1.ZookeeperCluster) *ZookeeperClusterReconciler {
 	var scheme = scheme.Scheme
-	scheme.AddKnownTypes(zookeeperv1beta1.GroupVersion, zookeepercluster)
+	scheme.AddKnownTypes(zookeeperv1.GroupVersion, zookeepercluster)
 	return &ZookeeperClusterReconciler{
 		Client:   fake.NewClientBuilder().WithRuntimeObjects(zookeepercluster).Build(),
 		Scheme:   scheme,
@@ -646,7 +2182,7 @@ func YAMLExporterReconciler(zookeepercluster *zookeeperv1beta1.ZookeeperCluster)
 }
 
 // GenerateYAML generated secondary resource of ZookeeperCluster resources YAML files
-func (r *ZookeeperClusterReconciler) GenerateYAML(inst *zookeeperv1beta1.ZookeeperCluster) error {
+func (r *ZookeeperClusterReconciler) GenerateYAML(inst *zookeeperv1.ZookeeperCluster) error {
 	if inst.WithDefaults() {
 		fmt.Println("set default values")
 	}
@@ -666,7 +2202,7 @@ func (r *ZookeeperClusterReconciler) GenerateYAML(inst *zookeeperv1beta1.Zookeep
 }
 
 // yamlStatefulSet will generates YAML file for StatefulSet
-func (r *ZookeeperClusterReconciler) yamlStatefulSet(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+func (r *ZookeeperClusterReconciler) yamlStatefulSet(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	sts := zk.MakeStatefulSet(instance)
 
 	subdir, err := yamlexporter.CreateOutputSubDir("ZookeeperCluster", sts.Labels["component"])
@@ -674,7 +2210,7 @@ func (r *ZookeeperClusterReconciler) yamlStatefulSet(ctx context.Context, instan
 }
 
 // yamlClientService will generates YAML file for zookeeper Client service
-func (r *ZookeeperClusterReconciler) yamlClientService(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+func (r *ZookeeperClusterReconciler) yamlClientService(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	svc := zk.MakeClientService(instance)
 
 	subdir, err := yamlexporter.CreateOutputSubDir("ZookeeperCluster", "client")
@@ -685,7 +2221,7 @@ func (r *ZookeeperClusterReconciler) yamlClientService(ctx context.Context, inst
 }
 
 // yamlHeadlessService will generates YAML file for zookeeper headless service
-func (r *ZookeeperClusterReconciler) yamlHeadlessService(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+func (r *ZookeeperClusterReconciler) yamlHeadlessService(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	svc := zk.MakeHeadlessService(instance)
 
 	subdir, err := yamlexporter.CreateOutputSubDir("ZookeeperCluster", "headless")
@@ -696,7 +2232,7 @@ func (r *ZookeeperClusterReconciler) yamlHeadlessService(ctx context.Context, in
 }
 
 // yamlPodDisruptionBudget will generates YAML file for zookeeper PDB
-func (r *ZookeeperClusterReconciler) yamlPodDisruptionBudget(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+func (r *ZookeeperClusterReconciler) yamlPodDisruptionBudget(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	pdb := zk.MakePodDisruptionBudget(instance)
 
 	subdir, err := yamlexporter.CreateOutputSubDir("ZookeeperCluster", "pdb")
@@ -707,7 +2243,7 @@ func (r *ZookeeperClusterReconciler) yamlPodDisruptionBudget(ctx context.Context
 }
 
 // yamlConfigMap will generates YAML file for Zookeeper configmap
-func (r *ZookeeperClusterReconciler) yamlConfigMap(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+func (r *ZookeeperClusterReconciler) yamlConfigMap(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	cm := zk.MakeConfigMap(instance)
 
 	subdir, err := yamlexporter.CreateOutputSubDir("ZookeeperCluster", "config")
@@ -717,27 +2253,30 @@ func (r *ZookeeperClusterReconciler) yamlConfigMap(ctx context.Context, instance
 	return yamlexporter.GenerateOutputYAMLFile(subdir, cm.Kind, cm)
 }
 
-func (r *ZookeeperClusterReconciler) reconcileFinalizers(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+func (r *ZookeeperClusterReconciler) reconcileFinalizers(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	ctx, span := r.Tracer.Start(ctx, "reconcileFinalizers")
 	defer span.End()
-	if instance.Spec.Persistence != nil && instance.Spec.Persistence.VolumeReclaimPolicy != zookeeperv1beta1.VolumeReclaimPolicyDelete {
+	if instance.Spec.Storage.Persistence != nil && instance.Spec.Storage.Persistence.VolumeReclaimPolicy != zookeeperv1.VolumeReclaimPolicyDelete {
 		return nil
 	}
 	if instance.DeletionTimestamp.IsZero() {
 		if !utils.ContainsString(instance.ObjectMeta.Finalizers, utils.ZkFinalizer) && !config.DisableFinalizer {
-			instance.ObjectMeta.Finalizers = append(instance.ObjectMeta.Finalizers, utils.ZkFinalizer)
-			if err = r.Client.Update(ctx, instance); err != nil {
+			finalizers := append(instance.ObjectMeta.Finalizers, utils.ZkFinalizer)
+			if err = r.updateFinalizers(ctx, instance, finalizers); err != nil {
 				return err
 			}
 		}
-		return r.cleanupOrphanPVCs(ctx, instance)
+		if err = r.cleanupOrphanPVCs(ctx, instance); err != nil {
+			return err
+		}
+		return r.cleanupOrphanObserverPVCs(ctx, instance)
 	} else {
 		if utils.ContainsString(instance.ObjectMeta.Finalizers, utils.ZkFinalizer) {
 			if err = r.cleanUpAllPVCs(ctx, instance); err != nil {
 				return err
 			}
-			instance.ObjectMeta.Finalizers = utils.RemoveString(instance.ObjectMeta.Finalizers, utils.ZkFinalizer)
-			if err = r.Client.Update(ctx, instance); err != nil {
+			finalizers := utils.RemoveString(instance.ObjectMeta.Finalizers, utils.ZkFinalizer)
+			if err = r.updateFinalizers(ctx, instance, finalizers); err != nil {
 				return err
 			}
 		}
@@ -745,7 +2284,7 @@ func (r *ZookeeperClusterReconciler) reconcileFinalizers(ctx context.Context, in
 	return nil
 }
 
-func (r *ZookeeperClusterReconciler) getPVCCount(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (pvcCount int, err error) {
+func (r *ZookeeperClusterReconciler) getPVCCount(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (pvcCount int, err error) {
 	pvcList, err := r.getPVCList(ctx, instance)
 	if err != nil {
 		return -1, err
@@ -754,7 +2293,7 @@ func (r *ZookeeperClusterReconciler) getPVCCount(ctx context.Context, instance *
 	return pvcCount, nil
 }
 
-func (r *ZookeeperClusterReconciler) cleanupOrphanPVCs(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+func (r *ZookeeperClusterReconciler) cleanupOrphanPVCs(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	// this check should make sure we do not delete the PVCs before the STS has scaled down
 	if instance.Status.ReadyReplicas == instance.Spec.Replicas {
 		pvcCount, err := r.getPVCCount(ctx, instance)
@@ -769,7 +2308,7 @@ func (r *ZookeeperClusterReconciler) cleanupOrphanPVCs(ctx context.Context, inst
 			}
 			for _, pvcItem := range pvcList.Items {
 				// delete only Orphan PVCs
-				if utils.IsPVCOrphan(pvcItem.Name, instance.Spec.Replicas) {
+				if pvcItem.Labels["kind"] != zk.ObserverKind && utils.IsPVCOrphan(pvcItem.Name, instance.Spec.Replicas) {
 					r.deletePVC(ctx, pvcItem)
 				}
 			}
@@ -778,7 +2317,26 @@ func (r *ZookeeperClusterReconciler) cleanupOrphanPVCs(ctx context.Context, inst
 	return nil
 }
 
-func (r *ZookeeperClusterReconciler) getPVCList(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (pvList corev1.PersistentVolumeClaimList, err error) {
+// cleanupOrphanObserverPVCs deletes the PVCs of the observers the pool has
+// been scaled down from
+func (r *ZookeeperClusterReconciler) cleanupOrphanObserverPVCs(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
+	replicas := instance.ObserverReplicas()
+	if instance.Status.Observers.ReadyReplicas != replicas {
+		return nil
+	}
+	pvcList, err := r.getPVCList(ctx, instance)
+	if err != nil {
+		return err
+	}
+	for _, pvcItem := range pvcList.Items {
+		if pvcItem.Labels["kind"] == zk.ObserverKind && utils.IsPVCOrphan(pvcItem.Name, replicas) {
+			r.deletePVC(ctx, pvcItem)
+		}
+	}
+	return nil
+}
+
+func (r *ZookeeperClusterReconciler) getPVCList(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (pvList corev1.PersistentVolumeClaimList, err error) {
 	selector, err := metav1.LabelSelectorAsSelector(&metav1.LabelSelector{
 		MatchLabels: map[string]string{"app": instance.GetName(), "uid": string(instance.UID)},
 	})
@@ -791,7 +2349,7 @@ func (r *ZookeeperClusterReconciler) getPVCList(ctx context.Context, instance *z
 	return *pvcList, err
 }
 
-func (r *ZookeeperClusterReconciler) cleanUpAllPVCs(ctx context.Context, instance *zookeeperv1beta1.ZookeeperCluster) (err error) {
+func (r *ZookeeperClusterReconciler) cleanUpAllPVCs(ctx context.Context, instance *zookeeperv1.ZookeeperCluster) (err error) {
 	pvcList, err := r.getPVCList(ctx, instance)
 	if err != nil {
 		return err
@@ -818,7 +2376,7 @@ func (r *ZookeeperClusterReconciler) deletePVC(ctx context.Context, pvcItem core
 
 func (r *ZookeeperClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&zookeeperv1beta1.ZookeeperCluster{}).
+		For(&zookeeperv1.ZookeeperCluster{}).
 		Owns(&appsv1.StatefulSet{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.Pod{}).
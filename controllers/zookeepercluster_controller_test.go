@@ -12,21 +12,34 @@ package controllers
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"go.opentelemetry.io/otel/trace"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	"github.com/pravega/zookeeper-operator/api/v1beta1"
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
 	"github.com/pravega/zookeeper-operator/pkg/controller/config"
 	"github.com/pravega/zookeeper-operator/pkg/zk"
 
@@ -40,15 +53,35 @@ func TestZookeepercluster(t *testing.T) {
 }
 
 type MockZookeeperClient struct {
-	// dummy struct
+	// serverStats are the stats reported by the zookeeper servers by address
+	serverStats map[string]*zk.ServerStats
+	// serverMetrics are the metrics reported by the zookeeper servers by the
+	// address of their metrics provider
+	serverMetrics map[string]map[string]float64
+	// ensembleConfig is the dynamic config of the ensemble
+	ensembleConfig *zk.EnsembleConfig
+	// reconfigs are the servers which joined, and the ids of those which
+	// left, by reconfiguration
+	reconfigs []string
+	// reconfigErr fails every reconfiguration
+	reconfigErr error
+	// onReconfig is called after every successful reconfiguration, e.g. to
+	// hand the leadership over
+	onReconfig func()
+	// zkUri and opts are the arguments of the last connection
+	zkUri string
+	opts  *zk.ConnectOptions
+	// statsTLSConfig is the TLS config of the last stats query
+	statsTLSConfig *tls.Config
 }
 
-func (client *MockZookeeperClient) Connect(zkUri string) (err error) {
-	// do nothing
+func (client *MockZookeeperClient) Connect(zkUri string, opts *zk.ConnectOptions) (err error) {
+	client.zkUri = zkUri
+	client.opts = opts
 	return nil
 }
 
-func (client *MockZookeeperClient) CreateNode(zoo *v1beta1.ZookeeperCluster, zNodePath string) (err error) {
+func (client *MockZookeeperClient) CreateNode(zoo *zookeeperv1.ZookeeperCluster, zNodePath string) (err error) {
 	return nil
 }
 
@@ -60,10 +93,85 @@ func (client *MockZookeeperClient) NodeExists(zNodePath string) (version int32,
 	return 0, nil
 }
 
+func (client *MockZookeeperClient) ServerStats(address string, tlsConfig *tls.Config) (*zk.ServerStats, error) {
+	client.statsTLSConfig = tlsConfig
+	if stats, ok := client.serverStats[address]; ok {
+		return stats, nil
+	}
+	return nil, fmt.Errorf("no zookeeper server at %s", address)
+}
+
+func (client *MockZookeeperClient) ServerMetrics(address string) (map[string]float64, error) {
+	if metrics, ok := client.serverMetrics[address]; ok {
+		return metrics, nil
+	}
+	return nil, fmt.Errorf("no metrics provider at %s", address)
+}
+
+func (client *MockZookeeperClient) GetConfig() (*zk.EnsembleConfig, error) {
+	if client.ensembleConfig == nil {
+		return nil, fmt.Errorf("no ensemble config")
+	}
+	return client.ensembleConfig, nil
+}
+
+func (client *MockZookeeperClient) Reconfig(joining []zk.ServerConfig, leaving []int, version int64) error {
+	if client.reconfigErr != nil {
+		return client.reconfigErr
+	}
+	config := client.ensembleConfig
+	servers := []zk.ServerConfig{}
+	for _, s := range config.Servers {
+		kept := true
+		for _, id := range leaving {
+			kept = kept && s.ID != id
+		}
+		for _, j := range joining {
+			kept = kept && s.ID != j.ID
+		}
+		if kept {
+			servers = append(servers, s)
+		}
+	}
+	for _, id := range leaving {
+		client.reconfigs = append(client.reconfigs, fmt.Sprintf("-%d", id))
+	}
+	for _, j := range joining {
+		client.reconfigs = append(client.reconfigs, "+"+j.String())
+		servers = append(servers, j)
+	}
+	client.ensembleConfig = &zk.EnsembleConfig{Servers: servers, Version: fmt.Sprintf("%x", version+1)}
+	if client.onReconfig != nil {
+		client.onReconfig()
+	}
+	return nil
+}
+
 func (client *MockZookeeperClient) Close() {
 	return
 }
 
+// makeCertificate returns a PEM encoded self-signed certificate and its key
+func makeCertificate() (cert []byte, key []byte) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Ω(err).To(BeNil())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "example-client"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	Ω(err).To(BeNil())
+	keyDer, err := x509.MarshalECPrivateKey(privateKey)
+	Ω(err).To(BeNil())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
 var _ = Describe("ZookeeperCluster Controller", func() {
 	const (
 		Name      = "example"
@@ -73,6 +181,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 	var (
 		s            = scheme.Scheme
 		mockZkClient = new(MockZookeeperClient)
+		tracer       = trace.NewNoopTracerProvider().Tracer("")
 		r            *ZookeeperClusterReconciler
 	)
 
@@ -80,7 +189,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 		var (
 			res reconcile.Result
 			req reconcile.Request
-			z   *v1beta1.ZookeeperCluster
+			z   *zookeeperv1.ZookeeperCluster
 		)
 
 		BeforeEach(func() {
@@ -90,13 +199,13 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 					Namespace: Namespace,
 				},
 			}
-			z = &v1beta1.ZookeeperCluster{
+			z = &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      Name,
 					Namespace: Namespace,
 				},
 			}
-			s.AddKnownTypes(v1beta1.GroupVersion, z)
+			s.AddKnownTypes(zookeeperv1.GroupVersion, z)
 		})
 
 		Context("Before defaults are applied", func() {
@@ -107,7 +216,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 
 			BeforeEach(func() {
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z).WithStatusSubresource(z).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 			})
 
@@ -115,15 +224,24 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				Ω(err).To(BeNil())
 			})
 
-			It("should set the default zk spec options", func() {
-				foundZk := &v1beta1.ZookeeperCluster{}
+			It("should not write the defaults back to the zk spec", func() {
+				foundZk := &zookeeperv1.ZookeeperCluster{}
 				err = cl.Get(context.TODO(), req.NamespacedName, foundZk)
 				Ω(err).To(BeNil())
-				Ω(foundZk.Spec.Replicas).To(BeEquivalentTo(3))
+				Ω(foundZk.Spec.Replicas).To(BeEquivalentTo(0))
+				Ω(foundZk.Spec.Image.Repository).To(BeEmpty())
 			})
 
-			It("should requeue the request", func() {
-				Ω(res.Requeue).To(BeTrue())
+			It("should create the sts with the default replicas", func() {
+				foundSts := &appsv1.StatefulSet{}
+				err = cl.Get(context.TODO(), req.NamespacedName, foundSts)
+				Ω(err).To(BeNil())
+				Ω(*foundSts.Spec.Replicas).To(BeEquivalentTo(3))
+			})
+
+			It("should not requeue the request immediately", func() {
+				Ω(res.Requeue).To(BeFalse())
+				Ω(res.RequeueAfter).To(Equal(ReconcileTime))
 			})
 		})
 
@@ -136,7 +254,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 			BeforeEach(func() {
 				z.WithDefaults()
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z).WithStatusSubresource(z).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 			})
 
@@ -191,6 +309,298 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				Ω(err).To(BeNil())
 			})
 
+			It("should publish the pod selector for the scale subresource", func() {
+				foundZk := &zookeeperv1.ZookeeperCluster{}
+				err = cl.Get(context.TODO(), req.NamespacedName, foundZk)
+				Ω(err).To(BeNil())
+				Ω(foundZk.Status.Selector).To(Equal("app=" + Name + ",kind=ZookeeperMember"))
+			})
+
+		})
+
+		Context("With client TLS", func() {
+			var (
+				cl     client.Client
+				err    error
+				secret *corev1.Secret
+			)
+
+			stsHash := func() string {
+				foundSts := &appsv1.StatefulSet{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundSts)).To(Succeed())
+				return foundSts.Spec.Template.Annotations["zookeeper.pravega.io/tls-secrets-hash"]
+			}
+
+			BeforeEach(func() {
+				z.Spec.TLS = &zookeeperv1.TLSPolicy{
+					Client: &zookeeperv1.ClientTLS{
+						TLSSecret: zookeeperv1.TLSSecret{SecretName: "example-tls"},
+					},
+				}
+				z.WithDefaults()
+				secret = &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "example-tls", Namespace: Namespace},
+					Data: map[string][]byte{
+						"tls.crt": []byte("certificate"),
+						"tls.key": []byte("key"),
+						"ca.crt":  []byte("ca"),
+					},
+				}
+			})
+
+			JustBeforeEach(func() {
+				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z, secret).WithStatusSubresource(z).Build()
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
+				res, err = r.Reconcile(context.TODO(), req)
+			})
+
+			It("should annotate the pods with a hash of the secret", func() {
+				Ω(err).To(BeNil())
+				Ω(stsHash()).NotTo(BeEmpty())
+			})
+
+			It("should restart the members when the secret changes", func() {
+				hash := stsHash()
+				secret.Data["tls.crt"] = []byte("renewed certificate")
+				Ω(cl.Update(context.TODO(), secret)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), req)
+				Ω(err).To(BeNil())
+				Ω(stsHash()).NotTo(Equal(hash))
+			})
+
+			Context("with a secret of the wrong format", func() {
+				BeforeEach(func() {
+					delete(secret.Data, "ca.crt")
+				})
+
+				It("should raise an error", func() {
+					Ω(err).NotTo(BeNil())
+					Ω(err.Error()).To(ContainSubstring("no ca.crt key"))
+				})
+			})
+		})
+
+		Context("With auth", func() {
+			var (
+				cl     client.Client
+				err    error
+				secret *corev1.Secret
+			)
+
+			stsHash := func() string {
+				foundSts := &appsv1.StatefulSet{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundSts)).To(Succeed())
+				return foundSts.Spec.Template.Annotations["zookeeper.pravega.io/auth-secrets-hash"]
+			}
+
+			BeforeEach(func() {
+				z.Spec.Auth = &zookeeperv1.AuthPolicy{
+					Digest: &zookeeperv1.DigestAuth{SecretName: "example-users"},
+				}
+				z.WithDefaults()
+				secret = &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "example-users", Namespace: Namespace},
+					Data: map[string][]byte{
+						"zookeeper": []byte("member-password"),
+						"app":       []byte("app-password"),
+					},
+				}
+			})
+
+			JustBeforeEach(func() {
+				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z, secret).WithStatusSubresource(z).Build()
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
+				res, err = r.Reconcile(context.TODO(), req)
+			})
+
+			It("should restart the members when a user is added", func() {
+				Ω(err).To(BeNil())
+				hash := stsHash()
+				Ω(hash).NotTo(BeEmpty())
+				secret.Data["other"] = []byte("other-password")
+				Ω(cl.Update(context.TODO(), secret)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), req)
+				Ω(err).To(BeNil())
+				Ω(stsHash()).NotTo(Equal(hash))
+			})
+
+			Context("without the member user", func() {
+				BeforeEach(func() {
+					delete(secret.Data, "zookeeper")
+				})
+
+				It("should raise an error", func() {
+					Ω(err).NotTo(BeNil())
+					Ω(err.Error()).To(ContainSubstring("no zookeeper key for the member user"))
+				})
+			})
+		})
+
+		Context("With ACLs enforced", func() {
+			var (
+				cl     client.Client
+				err    error
+				secret *corev1.Secret
+			)
+
+			stsHash := func() string {
+				foundSts := &appsv1.StatefulSet{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundSts)).To(Succeed())
+				return foundSts.Spec.Template.Annotations["zookeeper.pravega.io/auth-secrets-hash"]
+			}
+
+			BeforeEach(func() {
+				z.Spec.ACL = &zookeeperv1.ACLPolicy{Enforce: true, SuperUserSecretName: "example-super"}
+				z.WithDefaults()
+				secret = &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "example-super", Namespace: Namespace},
+					Data:       map[string][]byte{"username": []byte("super"), "password": []byte("super-password")},
+				}
+			})
+
+			JustBeforeEach(func() {
+				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z, secret).WithStatusSubresource(z).Build()
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
+				res, err = r.Reconcile(context.TODO(), req)
+			})
+
+			It("should restart the members when the super user changes", func() {
+				Ω(err).To(BeNil())
+				hash := stsHash()
+				Ω(hash).NotTo(BeEmpty())
+				secret.Data["password"] = []byte("other-password")
+				Ω(cl.Update(context.TODO(), secret)).To(Succeed())
+				_, err = r.Reconcile(context.TODO(), req)
+				Ω(err).To(BeNil())
+				Ω(stsHash()).NotTo(Equal(hash))
+			})
+
+			Context("without the super user secret", func() {
+				BeforeEach(func() {
+					secret.Name = "other"
+				})
+
+				It("should raise an error", func() {
+					Ω(err).NotTo(BeNil())
+					Ω(err.Error()).To(ContainSubstring("Error reading super user secret example-super"))
+				})
+			})
+		})
+
+		Context("With quorum TLS", func() {
+			var (
+				cl      client.Client
+				err     error
+				sts     *appsv1.StatefulSet
+				objects []client.Object
+			)
+
+			quorumTLS := func() *zookeeperv1.QuorumTLSStatus {
+				foundZk := &zookeeperv1.ZookeeperCluster{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundZk)).To(Succeed())
+				return foundZk.Status.QuorumTLS
+			}
+
+			stsPhase := func() string {
+				foundSts := &appsv1.StatefulSet{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundSts)).To(Succeed())
+				return foundSts.Spec.Template.Annotations[zk.QuorumTLSPhaseAnnotation]
+			}
+
+			BeforeEach(func() {
+				z.WithDefaults()
+				z.Status.Init()
+				// the members run plaintext and every one of them is ready
+				sts = zk.MakeStatefulSet(z)
+				sts.Status.ReadyReplicas = 3
+				sts.Status.UpdatedReplicas = 3
+				sts.Status.CurrentRevision = "rev-1"
+				sts.Status.UpdateRevision = "rev-1"
+				z.Spec.TLS = &zookeeperv1.TLSPolicy{
+					Quorum: &zookeeperv1.TLSSecret{SecretName: "example-tls", Format: zookeeperv1.TLSFormatPEM},
+				}
+				secret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "example-tls", Namespace: Namespace},
+					Data: map[string][]byte{
+						"tls.crt": []byte("certificate"),
+						"tls.key": []byte("key"),
+						"ca.crt":  []byte("ca"),
+					},
+				}
+				objects = []client.Object{z, secret, sts}
+			})
+
+			JustBeforeEach(func() {
+				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).WithStatusSubresource(z).Build()
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
+				res, err = r.Reconcile(context.TODO(), req)
+			})
+
+			It("should start with port unification on a running ensemble", func() {
+				Ω(err).To(BeNil())
+				Ω(quorumTLS().Phase).To(Equal(zookeeperv1.QuorumTLSPortUnification))
+				Ω(quorumTLS().Secret.SecretName).To(Equal("example-tls"))
+				Ω(stsPhase()).To(Equal("PortUnification"))
+			})
+
+			It("should wait for the members to restart before the next phase", func() {
+				// the stateful set controller starts rolling out the new template
+				foundSts := &appsv1.StatefulSet{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundSts)).To(Succeed())
+				foundSts.Status.UpdateRevision = "rev-2"
+				foundSts.Status.UpdatedReplicas = 1
+				Ω(cl.Status().Update(context.TODO(), foundSts)).To(Succeed())
+
+				_, err = r.Reconcile(context.TODO(), req)
+				Ω(err).To(BeNil())
+				Ω(quorumTLS().Phase).To(Equal(zookeeperv1.QuorumTLSPortUnification))
+			})
+
+			It("should move on once the members have restarted", func() {
+				foundSts := &appsv1.StatefulSet{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundSts)).To(Succeed())
+				foundSts.Status = sts.Status
+				foundSts.Status.ObservedGeneration = foundSts.Generation
+				Ω(cl.Status().Update(context.TODO(), foundSts)).To(Succeed())
+
+				_, err = r.Reconcile(context.TODO(), req)
+				Ω(err).To(BeNil())
+				Ω(quorumTLS().Phase).To(Equal(zookeeperv1.QuorumTLSWithPortUnification))
+				Ω(stsPhase()).To(Equal("TLSWithPortUnification"))
+			})
+
+			Context("on a new ensemble", func() {
+				BeforeEach(func() {
+					objects = objects[:2]
+				})
+
+				It("should enable quorum TLS right away", func() {
+					Ω(err).To(BeNil())
+					Ω(quorumTLS().Phase).To(Equal(zookeeperv1.QuorumTLSEnabled))
+					Ω(stsPhase()).To(Equal("Enabled"))
+				})
+			})
+
+			Context("once removed from the spec", func() {
+				BeforeEach(func() {
+					z.Status.QuorumTLS = &zookeeperv1.QuorumTLSStatus{
+						Phase:  zookeeperv1.QuorumTLSEnabled,
+						Secret: z.Spec.TLS.Quorum.DeepCopy(),
+					}
+					sts = zk.MakeStatefulSet(z)
+					sts.Status.ReadyReplicas = 3
+					sts.Status.UpdatedReplicas = 3
+					z.Spec.TLS = nil
+					objects = []client.Object{z, objects[1], sts}
+				})
+
+				It("should disable it the same way back with the key material of the status", func() {
+					Ω(err).To(BeNil())
+					Ω(quorumTLS().Phase).To(Equal(zookeeperv1.QuorumTLSWithPortUnification))
+					Ω(quorumTLS().Secret.SecretName).To(Equal("example-tls"))
+					Ω(stsPhase()).To(Equal("TLSWithPortUnification"))
+				})
+			})
 		})
 
 		Context("With update to sts", func() {
@@ -207,7 +617,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				st := zk.MakeStatefulSet(z)
 				next.Spec.Replicas = 6
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(next, st).WithStatusSubresource(next).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 			})
 
@@ -235,7 +645,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				next := z.DeepCopy()
 				st := zk.MakeStatefulSet(z)
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(next, st).WithStatusSubresource(next).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 			})
 
@@ -251,11 +661,1393 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 
 		})
 
+		Context("Reconfiguring the membership", func() {
+			var (
+				cl       client.Client
+				err      error
+				zkClient *MockZookeeperClient
+				stsSize  int32
+				stsReady int32
+				pods     []client.Object
+				foundZk  *zookeeperv1.ZookeeperCluster
+				foundSts *appsv1.StatefulSet
+			)
+
+			const member3 = "example-2.example-headless.default.svc.cluster.local:2888:3888"
+
+			makePod := func(ordinal int) *corev1.Pod {
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("%s-%d", Name, ordinal),
+						Namespace: Namespace,
+						Labels:    map[string]string{"app": Name},
+					},
+				}
+				pod.Status.PodIP = fmt.Sprintf("10.0.0.%d", ordinal+1)
+				return pod
+			}
+
+			makeEnsembleConfig := func(servers int) *zk.EnsembleConfig {
+				config := &zk.EnsembleConfig{Version: "100000004"}
+				for i := 0; i < servers; i++ {
+					config.Servers = append(config.Servers, zk.MakeServerConfig(z, i, zk.RoleParticipant))
+				}
+				return config
+			}
+
+			BeforeEach(func() {
+				z.WithDefaults()
+				z.Status.Init()
+				z.Status.Leader = Name + "-0"
+				z.Status.MemberStatuses = []zookeeperv1.MemberStatus{
+					{Name: Name + "-0", Role: zookeeperv1.MemberRoleLeader},
+					{Name: Name + "-1", Role: zookeeperv1.MemberRoleFollower},
+					{Name: Name + "-2", Role: zookeeperv1.MemberRoleObserver},
+				}
+				stsSize = 3
+				stsReady = 3
+				pods = nil
+				zkClient = &MockZookeeperClient{ensembleConfig: makeEnsembleConfig(2)}
+			})
+
+			JustBeforeEach(func() {
+				st := zk.MakeStatefulSet(z)
+				st.Spec.Replicas = &stsSize
+				st.Status.ReadyReplicas = stsReady
+				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z, st).WithObjects(pods...).WithStatusSubresource(z).Build()
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: zkClient, Tracer: tracer}
+				res, err = r.Reconcile(context.TODO(), req)
+				foundZk = &zookeeperv1.ZookeeperCluster{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundZk)).To(Succeed())
+				foundSts = &appsv1.StatefulSet{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundSts)).To(Succeed())
+			})
+
+			It("should add a new member once it follows the leader", func() {
+				Ω(err).To(BeNil())
+				Ω(zkClient.reconfigs).To(Equal([]string{"+server.3=" + member3 + ":participant;2181"}))
+				steps := foundZk.Status.Membership.Steps
+				Ω(steps).To(HaveLen(1))
+				Ω(steps[0].Action).To(Equal(zookeeperv1.ReconfigActionAdd))
+				Ω(steps[0].Member).To(Equal(Name + "-2"))
+				Ω(steps[0].ServerID).To(BeEquivalentTo(3))
+				Ω(steps[0].State).To(Equal(zookeeperv1.ReconfigStepSucceeded))
+				Ω(steps[0].Attempts).To(BeEquivalentTo(1))
+				Ω(foundZk.Status.Membership.ConfigVersion).To(Equal("100000005"))
+			})
+
+			Context("with the new member as observer", func() {
+				BeforeEach(func() {
+					observer := zk.MakeServerConfig(z, 2, zk.RoleObserver)
+					zkClient.ensembleConfig.Servers = append(zkClient.ensembleConfig.Servers, observer)
+				})
+
+				It("should promote it", func() {
+					Ω(err).To(BeNil())
+					Ω(zkClient.reconfigs).To(Equal([]string{"+server.3=" + member3 + ":participant;2181"}))
+					Ω(foundZk.Status.Membership.Steps[0].Action).To(Equal(zookeeperv1.ReconfigActionPromote))
+				})
+			})
+
+			Context("before the new member follows the leader", func() {
+				BeforeEach(func() {
+					z.Status.MemberStatuses[2].Role = zookeeperv1.MemberRoleUnknown
+				})
+
+				It("should wait for it", func() {
+					Ω(err).To(BeNil())
+					Ω(zkClient.reconfigs).To(BeEmpty())
+					Ω(foundZk.Status.Membership.Steps).To(BeEmpty())
+				})
+			})
+
+			Context("without a leader", func() {
+				BeforeEach(func() {
+					z.Status.Leader = ""
+				})
+
+				It("should not reconfigure the ensemble", func() {
+					Ω(err).To(BeNil())
+					Ω(zkClient.reconfigs).To(BeEmpty())
+				})
+			})
+
+			Context("when the reconfiguration fails", func() {
+				BeforeEach(func() {
+					zkClient.reconfigErr = fmt.Errorf("no quorum")
+				})
+
+				It("should keep the step pending for another attempt", func() {
+					Ω(err).To(BeNil())
+					step := foundZk.Status.Membership.PendingReconfigStep(Name + "-2")
+					Ω(step).NotTo(BeNil())
+					Ω(step.Attempts).To(BeEquivalentTo(1))
+					Ω(step.Message).To(ContainSubstring("no quorum"))
+				})
+			})
+
+			Context("with a member left over from an earlier scale down", func() {
+				BeforeEach(func() {
+					zkClient.ensembleConfig.Servers = append(zkClient.ensembleConfig.Servers, zk.MakeServerConfig(z, 3, zk.RoleParticipant))
+				})
+
+				It("should remove it before adding the new member", func() {
+					Ω(err).To(BeNil())
+					Ω(zkClient.reconfigs).To(Equal([]string{"-4", "+server.3=" + member3 + ":participant;2181"}))
+				})
+
+				Context("when it cannot be removed", func() {
+					BeforeEach(func() {
+						zkClient.reconfigErr = fmt.Errorf("no quorum")
+					})
+
+					It("should retry the reconciliation without adding the new member", func() {
+						Ω(err).NotTo(BeNil())
+						Ω(err.Error()).To(ContainSubstring("left over member " + Name + "-3"))
+						step := foundZk.Status.Membership.PendingReconfigStep(Name + "-3")
+						Ω(step).NotTo(BeNil())
+						Ω(step.Action).To(Equal(zookeeperv1.ReconfigActionRemove))
+						Ω(step.Message).To(ContainSubstring("no quorum"))
+						Ω(foundZk.Status.Membership.PendingReconfigStep(Name + "-2")).To(BeNil())
+					})
+				})
+			})
+
+			Context("when scaling down", func() {
+				BeforeEach(func() {
+					stsSize = 5
+					stsReady = 5
+					z.Status.MemberStatuses[2].Role = zookeeperv1.MemberRoleFollower
+					zkClient.ensembleConfig = makeEnsembleConfig(5)
+					zkClient.serverStats = map[string]*zk.ServerStats{
+						"10.0.0.1:2181": {Mode: "leader"},
+						"10.0.0.2:2181": {Mode: "follower"},
+						"10.0.0.3:2181": {Mode: "follower"},
+					}
+					for i := 0; i < 5; i++ {
+						pods = append(pods, makePod(i))
+					}
+				})
+
+				It("should remove the departing members before the pods", func() {
+					Ω(err).To(BeNil())
+					Ω(zkClient.reconfigs).To(Equal([]string{"-4", "-5"}))
+					Ω(*foundSts.Spec.Replicas).To(BeEquivalentTo(3))
+					Ω(foundZk.Status.Membership.Participants).To(BeEquivalentTo(3))
+					Ω(foundZk.Status.Membership.Steps).To(ConsistOf(
+						HaveField("Member", Name+"-3"),
+						HaveField("Member", Name+"-4"),
+					))
+					scale := foundZk.Status.Membership.ScaleDown
+					Ω(scale.FromReplicas).To(BeEquivalentTo(5))
+					Ω(scale.ToReplicas).To(BeEquivalentTo(3))
+					Ω(scale.Phase).To(Equal(zookeeperv1.ScaleDownCompleted))
+					Ω(scale.CompletionTime).NotTo(BeNil())
+				})
+
+				Context("when the reconfiguration fails", func() {
+					BeforeEach(func() {
+						zkClient.reconfigErr = fmt.Errorf("no quorum")
+					})
+
+					It("should keep the pods of the departing members", func() {
+						Ω(err).To(BeNil())
+						Ω(*foundSts.Spec.Replicas).To(BeEquivalentTo(5))
+						step := foundZk.Status.Membership.PendingReconfigStep(Name + "-3")
+						Ω(step.Action).To(Equal(zookeeperv1.ReconfigActionRemove))
+						Ω(step.Message).To(ContainSubstring("no quorum"))
+						scale := foundZk.Status.Membership.ScaleDown
+						Ω(scale.Phase).To(Equal(zookeeperv1.ScaleDownRemovingMembers))
+						Ω(scale.Message).To(ContainSubstring("unable to remove example-3: no quorum"))
+						Ω(foundZk.Status.IsScaleDownFailed()).To(BeFalse())
+					})
+
+					Context("for longer than the timeout", func() {
+						BeforeEach(func() {
+							z.Status.Membership.StartScaleDown(5, 3)
+							started := metav1.NewTime(time.Now().Add(-scaleDownPhaseTimeout - time.Minute))
+							z.Status.Membership.ScaleDown.PhaseStartTime = &started
+						})
+
+						It("should fail the scale down", func() {
+							Ω(err).To(BeNil())
+							Ω(*foundSts.Spec.Replicas).To(BeEquivalentTo(5))
+							Ω(foundZk.Status.IsScaleDownFailed()).To(BeTrue())
+							_, condition := foundZk.Status.GetClusterCondition(zookeeperv1.ClusterConditionError)
+							Ω(condition.Message).To(ContainSubstring("did not complete RemovingMembers"))
+						})
+					})
+				})
+
+				Context("before a quorum of the remaining members serves", func() {
+					BeforeEach(func() {
+						delete(zkClient.serverStats, "10.0.0.2:2181")
+						delete(zkClient.serverStats, "10.0.0.3:2181")
+					})
+
+					It("should keep the pods of the departing members", func() {
+						Ω(err).To(BeNil())
+						Ω(zkClient.reconfigs).To(Equal([]string{"-4", "-5"}))
+						Ω(*foundSts.Spec.Replicas).To(BeEquivalentTo(5))
+						scale := foundZk.Status.Membership.ScaleDown
+						Ω(scale.Phase).To(Equal(zookeeperv1.ScaleDownVerifyingQuorum))
+						Ω(scale.Message).To(Equal("1 of the 3 remaining members are serving with a leader, 2 are needed for a quorum"))
+					})
+				})
+
+				Context("without a leader", func() {
+					BeforeEach(func() {
+						z.Status.Leader = ""
+					})
+
+					It("should keep the pods of the departing members", func() {
+						Ω(err).To(BeNil())
+						Ω(zkClient.reconfigs).To(BeEmpty())
+						Ω(*foundSts.Spec.Replicas).To(BeEquivalentTo(5))
+						Ω(foundZk.Status.Membership.ScaleDown.Message).To(Equal("the ensemble has no leader"))
+					})
+
+					Context("and no ready member", func() {
+						BeforeEach(func() {
+							stsReady = 0
+						})
+
+						It("should shrink the stateful set", func() {
+							Ω(err).To(BeNil())
+							Ω(*foundSts.Spec.Replicas).To(BeEquivalentTo(3))
+							Ω(foundZk.Status.Membership.ScaleDown.Phase).To(Equal(zookeeperv1.ScaleDownCompleted))
+						})
+					})
+				})
+			})
+
+			Context("when a failed scale down is cancelled", func() {
+				BeforeEach(func() {
+					z.Spec.Replicas = 5
+					stsSize = 5
+					z.Status.Membership.StartScaleDown(5, 3)
+					z.Status.SetErrorConditionTrue(zookeeperv1.ScaleDownFailedReason, "timed out")
+				})
+
+				It("should clear it", func() {
+					Ω(err).To(BeNil())
+					Ω(*foundSts.Spec.Replicas).To(BeEquivalentTo(5))
+					Ω(foundZk.Status.Membership.ScaleDown).To(BeNil())
+					Ω(foundZk.Status.IsScaleDownFailed()).To(BeFalse())
+				})
+			})
+		})
+
+		Context("With an observer pool", func() {
+			var (
+				cl          client.Client
+				err         error
+				zkClient    *MockZookeeperClient
+				observers   *appsv1.StatefulSet
+				objects     []client.Object
+				foundZk     *zookeeperv1.ZookeeperCluster
+				foundMember *appsv1.StatefulSet
+				foundSts    *appsv1.StatefulSet
+				stsErr      error
+			)
+
+			makeEnsembleConfig := func(observers int) *zk.EnsembleConfig {
+				config := &zk.EnsembleConfig{Version: "100000004"}
+				for i := 0; i < 3; i++ {
+					config.Servers = append(config.Servers, zk.MakeServerConfig(z, i, zk.RoleParticipant))
+				}
+				for i := 0; i < observers; i++ {
+					config.Servers = append(config.Servers, zk.MakeObserverServerConfig(z, i))
+				}
+				return config
+			}
+
+			makeObserverPod := func(ordinal int, ready bool) *corev1.Pod {
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("%s-observer-%d", Name, ordinal),
+						Namespace: Namespace,
+						Labels:    map[string]string{"app": Name, "kind": zk.ObserverKind},
+					},
+				}
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "zookeeper", Ready: ready}}
+				return pod
+			}
+
+			BeforeEach(func() {
+				z.WithDefaults()
+				z.Spec.Observers = &zookeeperv1.ObserverPolicy{Replicas: 2}
+				z.Status.Init()
+				z.Status.Leader = Name + "-0"
+				z.Status.MemberStatuses = []zookeeperv1.MemberStatus{
+					{Name: Name + "-0", Role: zookeeperv1.MemberRoleLeader},
+					{Name: Name + "-1", Role: zookeeperv1.MemberRoleFollower},
+					{Name: Name + "-2", Role: zookeeperv1.MemberRoleFollower},
+					{Name: Name + "-observer-0", Role: zookeeperv1.MemberRoleObserver},
+					{Name: Name + "-observer-1", Role: zookeeperv1.MemberRoleObserver},
+				}
+				zkClient = &MockZookeeperClient{ensembleConfig: makeEnsembleConfig(0)}
+				observers = zk.MakeObserverStatefulSet(z)
+				objects = []client.Object{observers}
+			})
+
+			JustBeforeEach(func() {
+				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).
+					WithRuntimeObjects(z, zk.MakeStatefulSet(z)).WithObjects(objects...).
+					WithStatusSubresource(z).Build()
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: zkClient, Tracer: tracer}
+				res, err = r.Reconcile(context.TODO(), req)
+				foundZk = &zookeeperv1.ZookeeperCluster{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundZk)).To(Succeed())
+				foundMember = &appsv1.StatefulSet{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundMember)).To(Succeed())
+				foundSts = &appsv1.StatefulSet{}
+				stsErr = cl.Get(context.TODO(), types.NamespacedName{Name: Name + "-observer", Namespace: Namespace}, foundSts)
+			})
+
+			It("should add the observers with the observer role", func() {
+				Ω(err).To(BeNil())
+				Ω(stsErr).To(BeNil())
+				Ω(zkClient.reconfigs).To(Equal([]string{
+					"+server.1001=example-observer-0.example-headless.default.svc.cluster.local:2888:3888:observer;2181",
+					"+server.1002=example-observer-1.example-headless.default.svc.cluster.local:2888:3888:observer;2181",
+				}))
+				Ω(foundZk.Status.Membership.Participants).To(BeEquivalentTo(3))
+				Ω(foundZk.Status.Membership.Observers).To(BeEquivalentTo(2))
+			})
+
+			Context("when the pool shrinks", func() {
+				BeforeEach(func() {
+					replicas := int32(3)
+					observers.Spec.Replicas = &replicas
+					zkClient.ensembleConfig = makeEnsembleConfig(3)
+					objects = append(objects, makeObserverPod(0, true), makeObserverPod(1, false))
+				})
+
+				It("should only remove the departing observers", func() {
+					Ω(err).To(BeNil())
+					Ω(zkClient.reconfigs).To(Equal([]string{"-1003"}))
+					Ω(*foundSts.Spec.Replicas).To(BeEquivalentTo(2))
+					Ω(*foundMember.Spec.Replicas).To(BeEquivalentTo(3))
+					Ω(foundZk.Status.Membership.Participants).To(BeEquivalentTo(3))
+					Ω(foundZk.Status.Membership.ScaleDown).To(BeNil())
+				})
+
+				It("should report the observers apart from the members", func() {
+					Ω(foundZk.Status.Observers.Members.Ready).To(ConsistOf(Name + "-observer-0"))
+					Ω(foundZk.Status.Observers.Members.Unready).To(ConsistOf(Name + "-observer-1"))
+					Ω(foundZk.Status.Members.Ready).To(BeEmpty())
+				})
+
+				It("should leave the observers out of the selector of the scale subresource", func() {
+					Ω(foundZk.Status.Selector).To(Equal("app=" + Name + ",kind=ZookeeperMember"))
+					selector, err := labels.Parse(foundZk.Status.Selector)
+					Ω(err).To(BeNil())
+					Ω(selector.Matches(labels.Set(makeObserverPod(0, true).Labels))).To(BeFalse())
+					Ω(selector.Matches(labels.Set(zk.MakeStatefulSet(z).Spec.Template.Labels))).To(BeTrue())
+				})
+			})
+
+			Context("when the pool is new", func() {
+				BeforeEach(func() {
+					objects = nil
+				})
+
+				It("should create the observers", func() {
+					Ω(err).To(BeNil())
+					Ω(stsErr).To(BeNil())
+					Ω(*foundSts.Spec.Replicas).To(BeEquivalentTo(2))
+				})
+
+				Context("before the ensemble has a leader", func() {
+					BeforeEach(func() {
+						z.Status.Leader = ""
+					})
+
+					It("should wait for it", func() {
+						Ω(err).To(BeNil())
+						Ω(errors.IsNotFound(stsErr)).To(BeTrue())
+					})
+				})
+			})
+		})
+
+		Context("Rolling the members", func() {
+			var (
+				cl       client.Client
+				err      error
+				zkClient *MockZookeeperClient
+				stsSize  int32
+				pods     []client.Object
+			)
+
+			makePod := func(ordinal int, revision string) *corev1.Pod {
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("%s-%d", Name, ordinal),
+						Namespace: Namespace,
+						Labels: map[string]string{
+							"app":                                 Name,
+							"kind":                                "ZookeeperMember",
+							appsv1.ControllerRevisionHashLabelKey: revision,
+						},
+					},
+				}
+				pod.Status.PodIP = fmt.Sprintf("10.0.0.%d", ordinal+1)
+				return pod
+			}
+
+			const member1 = "example-0.example-headless.default.svc.cluster.local:2888:3888"
+
+			remainingPods := func() []string {
+				foundPods := &corev1.PodList{}
+				Ω(cl.List(context.TODO(), foundPods)).To(Succeed())
+				names := []string{}
+				for _, p := range foundPods.Items {
+					names = append(names, p.Name)
+				}
+				return names
+			}
+
+			BeforeEach(func() {
+				z.WithDefaults()
+				z.Status.Init()
+				z.Status.Leader = Name + "-0"
+				config := &zk.EnsembleConfig{Version: "100000004"}
+				for i := 0; i < 3; i++ {
+					config.Servers = append(config.Servers, zk.MakeServerConfig(z, i, zk.RoleParticipant))
+				}
+				zkClient = &MockZookeeperClient{
+					ensembleConfig: config,
+					serverStats: map[string]*zk.ServerStats{
+						"10.0.0.1:2181": {Mode: "leader", Zxid: 0x200000010},
+						"10.0.0.2:2181": {Mode: "follower", Zxid: 0x200000010},
+						"10.0.0.3:2181": {Mode: "follower", Zxid: 0x20000000f},
+					},
+				}
+				stsSize = 3
+				pods = []client.Object{makePod(0, "rev-1"), makePod(1, "rev-1"), makePod(2, "rev-1")}
+			})
+
+			JustBeforeEach(func() {
+				st := zk.MakeStatefulSet(z)
+				st.Spec.Replicas = &stsSize
+				st.Status.ReadyReplicas = stsSize
+				st.Status.CurrentRevision = "rev-1"
+				st.Status.UpdateRevision = "rev-2"
+				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z, st).WithObjects(pods...).WithStatusSubresource(z).Build()
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: zkClient, Tracer: tracer}
+				res, err = r.Reconcile(context.TODO(), req)
+			})
+
+			It("should restart the follower with the highest ordinal first", func() {
+				Ω(err).To(BeNil())
+				Ω(remainingPods()).To(ConsistOf(Name+"-0", Name+"-1"))
+			})
+
+			It("should restart the members once their config changes", func() {
+				foundSts := &appsv1.StatefulSet{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundSts)).To(Succeed())
+				hash := foundSts.Spec.Template.Annotations[configHashAnnotation]
+				Ω(hash).NotTo(BeEmpty())
+
+				next := z.DeepCopy()
+				next.Spec.Conf.TickTime = 3000
+				sts := zk.MakeStatefulSet(next)
+				annotateConfig(next, sts)
+				Ω(sts.Spec.Template.Annotations[configHashAnnotation]).NotTo(Equal(hash))
+			})
+
+			Context("when the leader has the highest ordinal", func() {
+				BeforeEach(func() {
+					zkClient.serverStats["10.0.0.1:2181"].Mode = "follower"
+					zkClient.serverStats["10.0.0.3:2181"].Mode = "leader"
+				})
+
+				It("should restart a follower instead", func() {
+					Ω(err).To(BeNil())
+					Ω(remainingPods()).To(ConsistOf(Name+"-0", Name+"-2"))
+				})
+			})
+
+			Context("when only the leader is outdated", func() {
+				BeforeEach(func() {
+					pods = []client.Object{makePod(0, "rev-1"), makePod(1, "rev-2"), makePod(2, "rev-2")}
+					zkClient.onReconfig = func() {
+						zkClient.serverStats["10.0.0.1:2181"].Mode = "observer"
+						zkClient.serverStats["10.0.0.2:2181"].Mode = "leader"
+					}
+				})
+
+				It("should hand the leadership over and restart the leader last", func() {
+					Ω(err).To(BeNil())
+					Ω(zkClient.reconfigs).To(Equal([]string{"+server.1=" + member1 + ":observer;2181"}))
+					Ω(remainingPods()).To(ConsistOf(Name+"-1", Name+"-2"))
+					foundZk := &zookeeperv1.ZookeeperCluster{}
+					Ω(cl.Get(context.TODO(), req.NamespacedName, foundZk)).To(Succeed())
+					steps := foundZk.Status.Membership.Steps
+					Ω(steps).To(HaveLen(1))
+					Ω(steps[0].Action).To(Equal(zookeeperv1.ReconfigActionDemote))
+					Ω(steps[0].State).To(Equal(zookeeperv1.ReconfigStepSucceeded))
+					Ω(foundZk.Status.Upgrade.Restarting).To(Equal(Name + "-0"))
+				})
+
+				Context("once the leader handed its leadership over", func() {
+					BeforeEach(func() {
+						zkClient.onReconfig = nil
+						zkClient.ensembleConfig.Servers[0].Role = zk.RoleObserver
+						zkClient.serverStats["10.0.0.1:2181"].Mode = "observer"
+						zkClient.serverStats["10.0.0.2:2181"].Mode = "leader"
+						z.Status.Leader = Name + "-1"
+						z.Status.MemberStatuses = []zookeeperv1.MemberStatus{
+							{Name: Name + "-0", Role: zookeeperv1.MemberRoleObserver},
+							{Name: Name + "-1", Role: zookeeperv1.MemberRoleLeader},
+							{Name: Name + "-2", Role: zookeeperv1.MemberRoleFollower},
+						}
+						z.Status.Upgrade = &zookeeperv1.UpgradeStatus{
+							Revision:   "rev-2",
+							Phase:      zookeeperv1.UpgradeRollingOut,
+							Restarting: Name + "-0",
+						}
+					})
+
+					It("should restart it without giving its vote back", func() {
+						Ω(err).To(BeNil())
+						Ω(zkClient.reconfigs).To(BeEmpty())
+						Ω(remainingPods()).To(ConsistOf(Name+"-1", Name+"-2"))
+					})
+
+					Context("once it runs the revision", func() {
+						BeforeEach(func() {
+							pods = []client.Object{makePod(0, "rev-2"), makePod(1, "rev-2"), makePod(2, "rev-2")}
+						})
+
+						It("should promote it again", func() {
+							Ω(err).To(BeNil())
+							Ω(zkClient.reconfigs).To(Equal([]string{"+server.1=" + member1 + ":participant;2181"}))
+							foundZk := &zookeeperv1.ZookeeperCluster{}
+							Ω(cl.Get(context.TODO(), req.NamespacedName, foundZk)).To(Succeed())
+							Ω(foundZk.Status.Upgrade.Restarting).To(BeEmpty())
+							Ω(foundZk.Status.Upgrade.Phase).To(Equal(zookeeperv1.UpgradeCompleted))
+						})
+					})
+				})
+
+				Context("before a follower takes the leadership over", func() {
+					BeforeEach(func() {
+						zkClient.onReconfig = nil
+					})
+
+					It("should keep the leader", func() {
+						Ω(err).To(BeNil())
+						Ω(zkClient.reconfigs).To(HaveLen(1))
+						Ω(remainingPods()).To(HaveLen(3))
+					})
+				})
+
+				Context("when the leader cannot be demoted", func() {
+					BeforeEach(func() {
+						zkClient.reconfigErr = fmt.Errorf("no quorum")
+					})
+
+					It("should keep the leader", func() {
+						Ω(err).To(BeNil())
+						Ω(remainingPods()).To(HaveLen(3))
+					})
+				})
+			})
+
+			Context("when the node of the leader is cordoned", func() {
+				var node *corev1.Node
+
+				BeforeEach(func() {
+					pods = []client.Object{makePod(0, "rev-2"), makePod(1, "rev-2"), makePod(2, "rev-2")}
+					pods[0].(*corev1.Pod).Spec.NodeName = "node-1"
+					node = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}
+					node.Spec.Unschedulable = true
+					pods = append(pods, node)
+					zkClient.onReconfig = func() {
+						zkClient.serverStats["10.0.0.1:2181"].Mode = "observer"
+						zkClient.serverStats["10.0.0.3:2181"].Mode = "leader"
+					}
+				})
+
+				It("should move the leadership off the node", func() {
+					Ω(err).To(BeNil())
+					Ω(zkClient.reconfigs).To(Equal([]string{"+server.1=" + member1 + ":observer;2181"}))
+					Ω(remainingPods()).To(HaveLen(3))
+				})
+
+				Context("while it is schedulable", func() {
+					BeforeEach(func() {
+						node.Spec.Unschedulable = false
+					})
+
+					It("should keep the leadership where it is", func() {
+						Ω(err).To(BeNil())
+						Ω(zkClient.reconfigs).To(BeEmpty())
+					})
+				})
+			})
+
+			Context("before the restarted member has caught up", func() {
+				BeforeEach(func() {
+					pods = []client.Object{makePod(0, "rev-1"), makePod(1, "rev-1"), makePod(2, "rev-2")}
+					zkClient.serverStats["10.0.0.3:2181"].Zxid = 0x100000040
+				})
+
+				It("should wait for it", func() {
+					Ω(err).To(BeNil())
+					Ω(remainingPods()).To(HaveLen(3))
+				})
+			})
+
+			Context("before the restarted member rejoins", func() {
+				BeforeEach(func() {
+					delete(zkClient.serverStats, "10.0.0.3:2181")
+				})
+
+				It("should wait for it", func() {
+					Ω(err).To(BeNil())
+					Ω(remainingPods()).To(HaveLen(3))
+				})
+			})
+
+			Context("before the restarted member is recreated", func() {
+				BeforeEach(func() {
+					pods = []client.Object{makePod(0, "rev-1"), makePod(1, "rev-1")}
+				})
+
+				It("should wait for it", func() {
+					Ω(err).To(BeNil())
+					Ω(remainingPods()).To(HaveLen(2))
+				})
+			})
+
+			Context("once every member runs the update revision", func() {
+				BeforeEach(func() {
+					pods = []client.Object{makePod(0, "rev-2"), makePod(1, "rev-2"), makePod(2, "rev-2")}
+				})
+
+				It("should not restart any member", func() {
+					Ω(err).To(BeNil())
+					Ω(remainingPods()).To(HaveLen(3))
+				})
+			})
+
+			Context("with an upgrade strategy", func() {
+				foundUpgrade := func() *zookeeperv1.UpgradeStatus {
+					foundZk := &zookeeperv1.ZookeeperCluster{}
+					Ω(cl.Get(context.TODO(), req.NamespacedName, foundZk)).To(Succeed())
+					return foundZk.Status.Upgrade
+				}
+
+				BeforeEach(func() {
+					z.Spec.UpgradeStrategy = &zookeeperv1.UpgradeStrategy{}
+					z.WithDefaults()
+				})
+
+				It("should restart the canary first", func() {
+					Ω(err).To(BeNil())
+					Ω(remainingPods()).To(ConsistOf(Name+"-0", Name+"-1"))
+					upgrade := foundUpgrade()
+					Ω(upgrade).NotTo(BeNil())
+					Ω(upgrade.Revision).To(Equal("rev-2"))
+					Ω(upgrade.Phase).To(Equal(zookeeperv1.UpgradeRollingOut))
+					Ω(upgrade.Partition).To(BeEquivalentTo(2))
+					Ω(upgrade.UpdatedReplicas).To(BeEquivalentTo(0))
+				})
+
+				Context("once the canary is upgraded", func() {
+					BeforeEach(func() {
+						pods = []client.Object{makePod(0, "rev-1"), makePod(1, "rev-1"), makePod(2, "rev-2")}
+						zkClient.serverMetrics = map[string]map[string]float64{
+							"10.0.0.3:7000": {"unsuccessful_handshake": 2},
+						}
+						z.Status.StartUpgrade("rev-2", 2)
+					})
+
+					It("should start the health gates", func() {
+						Ω(err).To(BeNil())
+						Ω(remainingPods()).To(HaveLen(3))
+						upgrade := foundUpgrade()
+						Ω(upgrade.Phase).To(Equal(zookeeperv1.UpgradeVerifying))
+						Ω(upgrade.Partition).To(BeEquivalentTo(2))
+						Ω(upgrade.UpdatedReplicas).To(BeEquivalentTo(1))
+						Ω(upgrade.GateStartTime).NotTo(BeNil())
+						Ω(upgrade.GateErrors).To(BeEquivalentTo(2))
+					})
+
+					Context("after the soak time", func() {
+						BeforeEach(func() {
+							start := metav1.NewTime(time.Now().Add(-2 * time.Minute))
+							z.Status.Upgrade.SetPhase(zookeeperv1.UpgradeVerifying)
+							z.Status.Upgrade.GateStartTime = &start
+							z.Status.Upgrade.GateErrors = 2
+						})
+
+						It("should upgrade the next batch", func() {
+							Ω(err).To(BeNil())
+							Ω(remainingPods()).To(ConsistOf(Name+"-0", Name+"-2"))
+							upgrade := foundUpgrade()
+							Ω(upgrade.Phase).To(Equal(zookeeperv1.UpgradeRollingOut))
+							Ω(upgrade.Partition).To(BeEquivalentTo(1))
+							Ω(upgrade.GateStartTime).To(BeNil())
+						})
+
+						Context("with a larger batch", func() {
+							BeforeEach(func() {
+								z.Spec.UpgradeStrategy.BatchSize = 5
+							})
+
+							It("should lower the partition to the first member", func() {
+								Ω(err).To(BeNil())
+								Ω(remainingPods()).To(ConsistOf(Name+"-0", Name+"-2"))
+								Ω(foundUpgrade().Partition).To(BeEquivalentTo(0))
+							})
+						})
+
+						Context("when the canary counts errors", func() {
+							BeforeEach(func() {
+								zkClient.serverMetrics["10.0.0.3:7000"]["unsuccessful_handshake"] = 10
+							})
+
+							It("should start the soak over", func() {
+								Ω(err).To(BeNil())
+								Ω(remainingPods()).To(HaveLen(3))
+								upgrade := foundUpgrade()
+								Ω(upgrade.Phase).To(Equal(zookeeperv1.UpgradeVerifying))
+								Ω(upgrade.Partition).To(BeEquivalentTo(2))
+								Ω(upgrade.GateErrors).To(BeEquivalentTo(10))
+								Ω(upgrade.Message).To(ContainSubstring("errors per minute"))
+							})
+						})
+					})
+
+					Context("when its metrics cannot be read", func() {
+						BeforeEach(func() {
+							zkClient.serverMetrics = nil
+						})
+
+						It("should hold the rollout", func() {
+							Ω(err).To(BeNil())
+							Ω(remainingPods()).To(HaveLen(3))
+							upgrade := foundUpgrade()
+							Ω(upgrade.GateStartTime).To(BeNil())
+							Ω(upgrade.Message).To(ContainSubstring(Name + "-2"))
+						})
+					})
+
+					Context("before it has caught up", func() {
+						BeforeEach(func() {
+							zkClient.serverStats["10.0.0.3:2181"].Zxid = 0x100000040
+						})
+
+						It("should hold the rollout", func() {
+							Ω(err).To(BeNil())
+							Ω(remainingPods()).To(HaveLen(3))
+							Ω(foundUpgrade().GateStartTime).To(BeNil())
+						})
+					})
+				})
+
+				Context("while it is paused", func() {
+					BeforeEach(func() {
+						z.Spec.UpgradeStrategy.Paused = true
+						z.Status.TargetVersion = "0.2.16"
+						z.Status.SetUpgradingConditionTrue(zookeeperv1.UpdatingZookeeperReason, "0")
+					})
+
+					It("should not restart any member", func() {
+						Ω(err).To(BeNil())
+						Ω(remainingPods()).To(HaveLen(3))
+						foundZk := &zookeeperv1.ZookeeperCluster{}
+						Ω(cl.Get(context.TODO(), req.NamespacedName, foundZk)).To(Succeed())
+						Ω(foundZk.Status.Upgrade.Phase).To(Equal(zookeeperv1.UpgradePaused))
+						_, condition := foundZk.Status.GetClusterCondition(zookeeperv1.ClusterConditionUpgrading)
+						Ω(condition.Reason).To(Equal(zookeeperv1.UpgradePausedReason))
+					})
+				})
+
+				Context("once every member runs the update revision", func() {
+					BeforeEach(func() {
+						pods = []client.Object{makePod(0, "rev-2"), makePod(1, "rev-2"), makePod(2, "rev-2")}
+						z.Status.StartUpgrade("rev-2", 1)
+					})
+
+					It("should complete the rollout", func() {
+						Ω(err).To(BeNil())
+						upgrade := foundUpgrade()
+						Ω(upgrade.Phase).To(Equal(zookeeperv1.UpgradeCompleted))
+						Ω(upgrade.Partition).To(BeEquivalentTo(0))
+						Ω(upgrade.UpdatedReplicas).To(BeEquivalentTo(3))
+						Ω(upgrade.CompletionTime).NotTo(BeNil())
+					})
+				})
+			})
+
+			Context("while scaling down", func() {
+				BeforeEach(func() {
+					stsSize = 5
+					pods = append(pods, makePod(3, "rev-1"), makePod(4, "rev-1"))
+					zkClient.ensembleConfig.Servers = append(zkClient.ensembleConfig.Servers,
+						zk.MakeServerConfig(z, 3, zk.RoleParticipant), zk.MakeServerConfig(z, 4, zk.RoleParticipant))
+					zkClient.reconfigErr = fmt.Errorf("no quorum")
+				})
+
+				It("should not restart any member", func() {
+					Ω(err).To(BeNil())
+					Ω(remainingPods()).To(HaveLen(5))
+				})
+			})
+		})
+
+		Context("Rolling back a failed upgrade", func() {
+			var (
+				cl       client.Client
+				err      error
+				zkClient *MockZookeeperClient
+				st       *appsv1.StatefulSet
+				pods     []client.Object
+				good     zookeeperv1.ContainerImage
+			)
+
+			makePod := func(ordinal int, revision string) *corev1.Pod {
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("%s-%d", Name, ordinal),
+						Namespace: Namespace,
+						Labels: map[string]string{
+							"app":                                 Name,
+							"kind":                                "ZookeeperMember",
+							appsv1.ControllerRevisionHashLabelKey: revision,
+						},
+					},
+				}
+				pod.Status.PodIP = fmt.Sprintf("10.0.0.%d", ordinal+1)
+				return pod
+			}
+
+			foundState := func() (*zookeeperv1.ZookeeperCluster, *appsv1.StatefulSet) {
+				foundZk := &zookeeperv1.ZookeeperCluster{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundZk)).To(Succeed())
+				foundSts := &appsv1.StatefulSet{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundSts)).To(Succeed())
+				return foundZk, foundSts
+			}
+
+			zkImage := func(sts *appsv1.StatefulSet) string {
+				for _, c := range sts.Spec.Template.Spec.Containers {
+					if c.Name == "zookeeper" {
+						return c.Image
+					}
+				}
+				return ""
+			}
+
+			BeforeEach(func() {
+				z.WithDefaults()
+				z.Status.Init()
+				z.Spec.UpgradeStrategy = &zookeeperv1.UpgradeStrategy{AutoRollback: true}
+				good = z.Spec.Image
+				z.Status.CurrentVersion = good.Tag
+				z.Status.RecordKnownGood("rev-1", good, z.Spec.Conf)
+				z.Spec.Image.Tag = "0.2.16"
+				z.Spec.Conf.TickTime = 3000
+				zkClient = &MockZookeeperClient{
+					serverStats: map[string]*zk.ServerStats{
+						"10.0.0.1:2181": {Mode: "leader", Zxid: 0x200000010},
+						"10.0.0.2:2181": {Mode: "follower", Zxid: 0x200000010},
+						"10.0.0.3:2181": {Mode: "follower", Zxid: 0x200000010},
+					},
+				}
+				pods = []client.Object{makePod(0, "rev-1"), makePod(1, "rev-1"), makePod(2, "rev-2")}
+				st = zk.MakeStatefulSet(z)
+				st.Status.ReadyReplicas = 3
+				st.Status.UpdatedReplicas = 1
+				st.Status.CurrentRevision = "rev-1"
+				st.Status.UpdateRevision = "rev-2"
+			})
+
+			JustBeforeEach(func() {
+				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z, st).WithObjects(pods...).WithStatusSubresource(z).Build()
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: zkClient, Tracer: tracer}
+				res, err = r.Reconcile(context.TODO(), req)
+			})
+
+			Context("when the upgrade stalls", func() {
+				BeforeEach(func() {
+					z.Status.TargetVersion = "0.2.16"
+					z.Status.SetUpgradingConditionTrue(zookeeperv1.UpdatingZookeeperReason,
+						fmt.Sprintf("0 of 3 members updated, %s-2 restarting", Name))
+					stalled := metav1.NewTime(time.Now().Add(-11 * time.Minute))
+					z.Status.LastUpgradeProgressTime = &stalled
+				})
+
+				It("should start rolling back", func() {
+					Ω(err).To(BeNil())
+					foundZk, _ := foundState()
+					Ω(foundZk.Status.IsClusterInUpgradeFailedState()).To(BeTrue())
+					rb := foundZk.Status.Rollback
+					Ω(rb).NotTo(BeNil())
+					Ω(rb.Phase).To(Equal(zookeeperv1.RollbackRollingBack))
+					Ω(rb.FailedVersion).To(Equal("0.2.16"))
+					Ω(rb.FailedImage.Tag).To(Equal("0.2.16"))
+					Ω(rb.Revision).To(Equal("rev-1"))
+					_, condition := foundZk.Status.GetClusterCondition(zookeeperv1.ClusterConditionRolledBack)
+					Ω(condition).NotTo(BeNil())
+					Ω(condition.Status).To(Equal(metav1.ConditionFalse))
+					Ω(condition.Reason).To(Equal(zookeeperv1.RollingBackReason))
+				})
+
+				Context("without auto rollback", func() {
+					BeforeEach(func() {
+						z.Spec.UpgradeStrategy.AutoRollback = false
+					})
+
+					It("should only fail the upgrade", func() {
+						Ω(err).To(BeNil())
+						foundZk, _ := foundState()
+						Ω(foundZk.Status.IsClusterInUpgradeFailedState()).To(BeTrue())
+						Ω(foundZk.Status.Rollback).To(BeNil())
+					})
+				})
+
+				Context("with a longer progress deadline", func() {
+					BeforeEach(func() {
+						z.Spec.UpgradeStrategy.ProgressDeadlineSeconds = 1800
+					})
+
+					It("should keep waiting", func() {
+						Ω(err).To(BeNil())
+						foundZk, _ := foundState()
+						Ω(foundZk.Status.IsClusterInUpgradeFailedState()).To(BeFalse())
+						Ω(foundZk.Status.IsClusterInUpgradingState()).To(BeTrue())
+					})
+				})
+
+				Context("while the upgraded member syncs", func() {
+					BeforeEach(func() {
+						pods[2].(*corev1.Pod).Status.ContainerStatuses = []corev1.ContainerStatus{{
+							Name:  "zookeeper",
+							State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+						}}
+						z.Status.SetUpgradingConditionTrue(zookeeperv1.UpdatingZookeeperReason,
+							fmt.Sprintf("0 of 3 members updated, %s-2 syncing", Name))
+						stalled := metav1.NewTime(time.Now().Add(-11 * time.Minute))
+						z.Status.LastUpgradeProgressTime = &stalled
+					})
+
+					It("should count it as progress", func() {
+						Ω(err).To(BeNil())
+						foundZk, _ := foundState()
+						Ω(foundZk.Status.IsClusterInUpgradeFailedState()).To(BeFalse())
+						Ω(foundZk.Status.LastUpgradeProgressTime.Time).To(BeTemporally("~", time.Now(), time.Minute))
+						members := foundZk.Status.Upgrade.Members
+						Ω(members).To(HaveLen(3))
+						Ω(members[0].Phase).To(Equal(zookeeperv1.MemberUpgradePending))
+						Ω(members[2].Phase).To(Equal(zookeeperv1.MemberUpgradeSyncing))
+					})
+				})
+			})
+
+			Context("when the upgraded member crash loops", func() {
+				BeforeEach(func() {
+					z.Status.TargetVersion = "0.2.16"
+					z.Status.SetUpgradingConditionTrue(zookeeperv1.UpdatingZookeeperReason, "0 of 3 members updated")
+					pods[2].(*corev1.Pod).Status.ContainerStatuses = []corev1.ContainerStatus{{
+						Name:         "zookeeper",
+						RestartCount: 3,
+						State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+						LastTerminationState: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"},
+						},
+					}}
+				})
+
+				It("should fail the upgrade right away and roll it back", func() {
+					Ω(err).To(BeNil())
+					foundZk, _ := foundState()
+					Ω(foundZk.Status.IsClusterInUpgradeFailedState()).To(BeTrue())
+					Ω(foundZk.Status.Rollback).NotTo(BeNil())
+					Ω(foundZk.Status.Rollback.Reason).To(Equal(
+						fmt.Sprintf("member %s-2 is crash looping after 3 restarts, last exit code 1 (Error)", Name)))
+					member := foundZk.Status.Upgrade.Members[2]
+					Ω(member.Phase).To(Equal(zookeeperv1.MemberUpgradeFailed))
+					Ω(member.Restarts).To(BeEquivalentTo(3))
+				})
+
+				Context("before it backed off a few times", func() {
+					BeforeEach(func() {
+						pods[2].(*corev1.Pod).Status.ContainerStatuses[0].RestartCount = 1
+					})
+
+					It("should leave it to the kubelet", func() {
+						Ω(err).To(BeNil())
+						foundZk, _ := foundState()
+						Ω(foundZk.Status.IsClusterInUpgradeFailedState()).To(BeFalse())
+						Ω(foundZk.Status.Upgrade.Members[2].Phase).To(Equal(zookeeperv1.MemberUpgradeRestarting))
+					})
+				})
+			})
+
+			Context("while rolling back", func() {
+				BeforeEach(func() {
+					z.Status.TargetVersion = "0.2.16"
+					z.Status.SetUpgradingConditionTrue(zookeeperv1.UpdatingZookeeperReason, "1")
+					z.Status.SetErrorConditionTrue(zookeeperv1.UpgradeFailedReason, "progress deadline exceeded")
+					z.Status.StartRollback("0.2.16", z.Spec.Image, configHash(z), "progress deadline exceeded")
+					// the stateful set runs the known good template again
+					st.Status.UpdateRevision = "rev-1"
+					st.Status.UpdatedReplicas = 2
+				})
+
+				It("should restore the known good image", func() {
+					Ω(err).To(BeNil())
+					foundZk, foundSts := foundState()
+					Ω(zkImage(foundSts)).To(Equal(good.ToString()))
+					Ω(foundZk.Status.Rollback.Phase).To(Equal(zookeeperv1.RollbackRollingBack))
+					Ω(foundZk.Status.TargetVersion).To(Equal("0.2.16"))
+				})
+
+				It("should restart the members without the canary gates", func() {
+					Ω(err).To(BeNil())
+					foundZk, _ := foundState()
+					Ω(foundZk.Status.Upgrade.Partition).To(BeEquivalentTo(0))
+					foundPods := &corev1.PodList{}
+					Ω(cl.List(context.TODO(), foundPods)).To(Succeed())
+					Ω(foundPods.Items).To(HaveLen(2))
+				})
+
+				Context("once every member runs the known good revision", func() {
+					BeforeEach(func() {
+						pods = []client.Object{makePod(0, "rev-1"), makePod(1, "rev-1"), makePod(2, "rev-1")}
+						st.Status.UpdatedReplicas = 3
+					})
+
+					It("should complete the rollback and keep the failed version", func() {
+						Ω(err).To(BeNil())
+						foundZk, _ := foundState()
+						Ω(foundZk.Status.IsClusterInUpgradeFailedState()).To(BeFalse())
+						Ω(foundZk.Status.IsClusterInUpgradingState()).To(BeFalse())
+						Ω(foundZk.Status.CurrentVersion).To(Equal(good.Tag))
+						Ω(foundZk.Status.TargetVersion).To(BeEmpty())
+						Ω(foundZk.Status.Rollback.Phase).To(Equal(zookeeperv1.RollbackRolledBack))
+						Ω(foundZk.Status.Rollback.FailedVersion).To(Equal("0.2.16"))
+						Ω(foundZk.Status.Rollback.CompletionTime).NotTo(BeNil())
+						_, condition := foundZk.Status.GetClusterCondition(zookeeperv1.ClusterConditionRolledBack)
+						Ω(condition.Status).To(Equal(metav1.ConditionTrue))
+					})
+				})
+			})
+
+			Context("once rolled back", func() {
+				BeforeEach(func() {
+					z.Status.StartRollback("0.2.16", z.Spec.Image, configHash(z), "progress deadline exceeded").Complete()
+					pods = []client.Object{makePod(0, "rev-1"), makePod(1, "rev-1"), makePod(2, "rev-1")}
+					st.Status.UpdateRevision = "rev-1"
+					st.Status.UpdatedReplicas = 3
+				})
+
+				It("should keep the members on the known good revision", func() {
+					Ω(err).To(BeNil())
+					_, foundSts := foundState()
+					Ω(zkImage(foundSts)).To(Equal(good.ToString()))
+					foundCm := &corev1.ConfigMap{}
+					Ω(cl.Get(context.TODO(), types.NamespacedName{Name: z.ConfigMapName(), Namespace: Namespace}, foundCm)).To(Succeed())
+					Ω(foundCm.Data["zoo.cfg"]).To(ContainSubstring("tickTime=2000\n"))
+				})
+
+				Context("when the spec moves on", func() {
+					BeforeEach(func() {
+						z.Spec.Image.Tag = "0.2.17"
+					})
+
+					It("should roll the new image out", func() {
+						Ω(err).To(BeNil())
+						_, foundSts := foundState()
+						Ω(zkImage(foundSts)).To(Equal(z.Spec.Image.ToString()))
+					})
+				})
+			})
+
+			Context("once every member is ready on the spec", func() {
+				BeforeEach(func() {
+					z.Status.LastKnownGood = nil
+					pods = []client.Object{makePod(0, "rev-2"), makePod(1, "rev-2"), makePod(2, "rev-2")}
+					st.Status.UpdatedReplicas = 3
+				})
+
+				It("should record it as known good", func() {
+					Ω(err).To(BeNil())
+					foundZk, _ := foundState()
+					good := foundZk.Status.LastKnownGood
+					Ω(good).NotTo(BeNil())
+					Ω(good.Revision).To(Equal("rev-2"))
+					Ω(good.Image.Tag).To(Equal("0.2.16"))
+					Ω(good.RecordTime).NotTo(BeNil())
+				})
+			})
+
+			Context("while a member is outdated", func() {
+				BeforeEach(func() {
+					z.Status.LastKnownGood = nil
+				})
+
+				It("should not record a known good revision", func() {
+					Ω(err).To(BeNil())
+					foundZk, _ := foundState()
+					Ω(foundZk.Status.LastKnownGood).To(BeNil())
+				})
+			})
+		})
+
+		Context("Checking the upgrade path", func() {
+			var (
+				cl       client.Client
+				err      error
+				zkClient *MockZookeeperClient
+				st       *appsv1.StatefulSet
+				pods     []client.Object
+			)
+
+			makePod := func(ordinal int) *corev1.Pod {
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      fmt.Sprintf("%s-%d", Name, ordinal),
+						Namespace: Namespace,
+						Labels: map[string]string{
+							"app":                                 Name,
+							"kind":                                "ZookeeperMember",
+							appsv1.ControllerRevisionHashLabelKey: "rev-1",
+						},
+					},
+				}
+				pod.Status.PodIP = fmt.Sprintf("10.0.0.%d", ordinal+1)
+				return pod
+			}
+
+			foundState := func() (*zookeeperv1.ZookeeperCluster, string) {
+				foundZk := &zookeeperv1.ZookeeperCluster{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundZk)).To(Succeed())
+				foundSts := &appsv1.StatefulSet{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundSts)).To(Succeed())
+				for _, c := range foundSts.Spec.Template.Spec.Containers {
+					if c.Name == "zookeeper" {
+						return foundZk, c.Image
+					}
+				}
+				return foundZk, ""
+			}
+
+			BeforeEach(func() {
+				z.WithDefaults()
+				z.Status.Init()
+				z.Status.SetPodsReadyConditionTrue()
+				z.Status.CurrentVersion = z.Spec.Image.Tag
+				zkClient = &MockZookeeperClient{
+					serverStats: map[string]*zk.ServerStats{
+						"10.0.0.1:2181": {Mode: "leader", Zxid: 0x200000010},
+						"10.0.0.2:2181": {Mode: "follower", Zxid: 0x200000010},
+						"10.0.0.3:2181": {Mode: "follower", Zxid: 0x200000010},
+					},
+				}
+				pods = []client.Object{makePod(0), makePod(1), makePod(2)}
+				st = zk.MakeStatefulSet(z)
+				st.Status.ReadyReplicas = 3
+				st.Status.UpdatedReplicas = 3
+				st.Status.CurrentRevision = "rev-1"
+				st.Status.UpdateRevision = "rev-1"
+				z.Spec.Image.Tag = "3.9.1"
+			})
+
+			JustBeforeEach(func() {
+				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z, st).WithObjects(pods...).WithStatusSubresource(z).Build()
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: zkClient, Tracer: tracer}
+				res, err = r.Reconcile(context.TODO(), req)
+			})
+
+			It("should hold the members on their version", func() {
+				Ω(err).To(BeNil())
+				foundZk, image := foundState()
+				Ω(image).To(Equal(zookeeperv1.DefaultZkContainerRepository + ":" + zookeeperv1.DefaultZkContainerVersion))
+				Ω(foundZk.Status.TargetVersion).To(BeEmpty())
+				Ω(foundZk.Status.IsUpgradeBlocked()).To(BeTrue())
+				_, condition := foundZk.Status.GetClusterCondition(zookeeperv1.ClusterConditionUpgrading)
+				Ω(condition.Message).To(ContainSubstring("skips 3.8"))
+			})
+
+			Context("under the Warn policy", func() {
+				BeforeEach(func() {
+					z.Spec.UpgradeStrategy = &zookeeperv1.UpgradeStrategy{VersionSkewPolicy: zookeeperv1.VersionSkewWarn}
+				})
+
+				It("should roll the new image out", func() {
+					Ω(err).To(BeNil())
+					foundZk, image := foundState()
+					Ω(image).To(HaveSuffix(":3.9.1"))
+					Ω(foundZk.Status.IsUpgradeBlocked()).To(BeFalse())
+				})
+			})
+
+			Context("once the spec asks for a supported version", func() {
+				BeforeEach(func() {
+					z.Status.SetUpgradingConditionBlocked("upgrading ZooKeeper from 3.7.2 to 3.9.1 skips 3.8, upgrade to it first")
+					z.Spec.Image.Tag = "3.8.4"
+				})
+
+				It("should release the hold and roll the new image out", func() {
+					Ω(err).To(BeNil())
+					foundZk, image := foundState()
+					Ω(image).To(HaveSuffix(":3.8.4"))
+					Ω(foundZk.Status.IsUpgradeBlocked()).To(BeFalse())
+				})
+			})
+		})
+
+		Context("Backing up before an upgrade", func() {
+			var (
+				cl      client.Client
+				err     error
+				st      *appsv1.StatefulSet
+				objects []client.Object
+			)
+
+			makeBackup := func(phase zookeeperv1.BackupPhase) *zookeeperv1.ZookeeperBackup {
+				backup := zk.MakePreUpgradeBackup(z)
+				backup.Status.Phase = phase
+				backup.Status.Location = "pvc://backups/default/example/example-pre-upgrade-0.tar.gz"
+				backup.Status.Zxid = "0x200000010"
+				return backup
+			}
+
+			foundState := func() (*zookeeperv1.ZookeeperCluster, string) {
+				foundZk := &zookeeperv1.ZookeeperCluster{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundZk)).To(Succeed())
+				foundSts := &appsv1.StatefulSet{}
+				Ω(cl.Get(context.TODO(), req.NamespacedName, foundSts)).To(Succeed())
+				for _, c := range foundSts.Spec.Template.Spec.Containers {
+					if c.Name == "zookeeper" {
+						return foundZk, c.Image
+					}
+				}
+				return foundZk, ""
+			}
+
+			BeforeEach(func() {
+				s.AddKnownTypes(zookeeperv1.GroupVersion, &zookeeperv1.ZookeeperBackup{}, &zookeeperv1.ZookeeperBackupList{})
+				z.Spec.UpgradeStrategy = &zookeeperv1.UpgradeStrategy{
+					PreUpgradeBackup: &zookeeperv1.PreUpgradeBackup{
+						Storage: zookeeperv1.BackupStorage{
+							PersistentVolumeClaim: &zookeeperv1.PVCStorage{ClaimName: "backups"},
+						},
+					},
+				}
+				z.WithDefaults()
+				z.Status.Init()
+				z.Status.SetPodsReadyConditionTrue()
+				z.Status.CurrentVersion = z.Spec.Image.Tag
+				st = zk.MakeStatefulSet(z)
+				st.Status.ReadyReplicas = 3
+				st.Status.UpdatedReplicas = 3
+				st.Status.CurrentRevision = "rev-1"
+				st.Status.UpdateRevision = "rev-1"
+				z.Spec.Image.Tag = "3.8.4"
+				objects = nil
+			})
+
+			JustBeforeEach(func() {
+				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z, st).WithObjects(objects...).WithStatusSubresource(z).Build()
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
+				res, err = r.Reconcile(context.TODO(), req)
+			})
+
+			It("should take the backup and hold the members on their version", func() {
+				Ω(err).To(BeNil())
+				foundZk, image := foundState()
+				Ω(image).To(Equal(zookeeperv1.DefaultZkContainerRepository + ":" + zookeeperv1.DefaultZkContainerVersion))
+				Ω(foundZk.Status.TargetVersion).To(BeEmpty())
+				Ω(foundZk.Status.IsUpgradeHeld()).To(BeTrue())
+				_, condition := foundZk.Status.GetClusterCondition(zookeeperv1.ClusterConditionUpgrading)
+				Ω(condition.Reason).To(Equal(zookeeperv1.BackingUpReason))
+
+				backup := &zookeeperv1.ZookeeperBackup{}
+				Ω(cl.Get(context.TODO(), types.NamespacedName{Name: Name + "-pre-upgrade-0", Namespace: Namespace}, backup)).To(Succeed())
+				Ω(backup.Spec.ZookeeperCluster).To(Equal(Name))
+				Ω(backup.Spec.ReclaimPolicy).To(Equal(zookeeperv1.VolumeReclaimPolicyRetain))
+				Ω(backup.OwnerReferences).To(HaveLen(1))
+				Ω(foundZk.Status.PreUpgradeBackup.Name).To(Equal(backup.Name))
+				Ω(foundZk.Status.PreUpgradeBackup.FromVersion).To(Equal(zookeeperv1.DefaultZkContainerVersion))
+				Ω(foundZk.Status.PreUpgradeBackup.TargetVersion).To(Equal("3.8.4"))
+			})
+
+			Context("when the backup fails", func() {
+				BeforeEach(func() {
+					backup := makeBackup(zookeeperv1.BackupPhaseFailed)
+					backup.Status.Message = "the claim backups is full"
+					objects = []client.Object{backup}
+				})
+
+				It("should refuse to start the rollout", func() {
+					Ω(err).To(BeNil())
+					foundZk, image := foundState()
+					Ω(image).NotTo(HaveSuffix(":3.8.4"))
+					Ω(foundZk.Status.PreUpgradeBackup.Phase).To(Equal(zookeeperv1.BackupPhaseFailed))
+					_, condition := foundZk.Status.GetClusterCondition(zookeeperv1.ClusterConditionUpgrading)
+					Ω(condition.Status).To(Equal(metav1.ConditionFalse))
+					Ω(condition.Reason).To(Equal(zookeeperv1.PreUpgradeBackupFailedReason))
+					Ω(condition.Message).To(ContainSubstring("the claim backups is full"))
+				})
+			})
+
+			Context("when the backup succeeds", func() {
+				BeforeEach(func() {
+					backup := makeBackup(zookeeperv1.BackupPhaseSucceeded)
+					backup.Status.Size = 1024
+					backup.Status.Checksum = "sha256:0123456789abcdef"
+					objects = []client.Object{backup}
+				})
+
+				It("should record the backup and roll the new image out", func() {
+					Ω(err).To(BeNil())
+					foundZk, image := foundState()
+					Ω(image).NotTo(HaveSuffix(":3.8.4"))
+					Ω(foundZk.Status.IsUpgradeHeld()).To(BeFalse())
+					Ω(foundZk.Status.PreUpgradeBackup.Phase).To(Equal(zookeeperv1.BackupPhaseSucceeded))
+					Ω(foundZk.Status.PreUpgradeBackup.Location).To(Equal("pvc://backups/default/example/example-pre-upgrade-0.tar.gz"))
+					Ω(foundZk.Status.PreUpgradeBackup.Checksum).To(Equal("sha256:0123456789abcdef"))
+
+					_, err = r.Reconcile(context.TODO(), req)
+					Ω(err).To(BeNil())
+					foundZk, image = foundState()
+					Ω(image).To(HaveSuffix(":3.8.4"))
+					Ω(foundZk.Status.IsUpgradeHeld()).To(BeFalse())
+				})
+			})
+
+			Context("when the backup leaves no checksum", func() {
+				BeforeEach(func() {
+					backup := makeBackup(zookeeperv1.BackupPhaseSucceeded)
+					backup.Status.Size = 1024
+					objects = []client.Object{backup}
+				})
+
+				It("should fail the backup", func() {
+					Ω(err).To(BeNil())
+					foundZk, _ := foundState()
+					Ω(foundZk.Status.PreUpgradeBackup.Phase).To(Equal(zookeeperv1.BackupPhaseFailed))
+					Ω(foundZk.Status.PreUpgradeBackup.Message).To(ContainSubstring("no checksum"))
+					_, condition := foundZk.Status.GetClusterCondition(zookeeperv1.ClusterConditionUpgrading)
+					Ω(condition.Reason).To(Equal(zookeeperv1.PreUpgradeBackupFailedReason))
+				})
+			})
+		})
+
 		Context("With update to ImagePullSecrets", func() {
 			var (
 				cl   client.Client
 				err  error
-				next *v1beta1.ZookeeperCluster
+				next *zookeeperv1.ZookeeperCluster
 				sa   *corev1.ServiceAccount
 			)
 
@@ -266,7 +2058,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				next = z.DeepCopy()
 				sa = zk.MakeServiceAccount(z)
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(next, sa).WithStatusSubresource(next).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 			})
 
@@ -283,7 +2075,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 			It("should update the service account", func() {
 				next.Spec.Pod.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "test-pull-secret"}}
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(next, sa).WithStatusSubresource(next).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				_, err := r.Reconcile(context.TODO(), req)
 				Ω(err).To(BeNil())
 
@@ -314,7 +2106,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				st.Status.CurrentRevision = "CurrentRevision"
 				st.Status.UpdateRevision = "UpdateRevision"
 				cl.Status().Update(context.TODO(), st)
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 			})
 
@@ -323,21 +2115,21 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 			})
 
 			It("should set the upgrade condition to true", func() {
-				foundZookeeper := &v1beta1.ZookeeperCluster{}
+				foundZookeeper := &zookeeperv1.ZookeeperCluster{}
 				_ = cl.Get(context.TODO(), req.NamespacedName, foundZookeeper)
 				Ω(err).To(BeNil())
 				Ω(foundZookeeper.Status.IsClusterInUpgradingState()).To(BeTrue())
 			})
 
 			It("should set the target version", func() {
-				foundZookeeper := &v1beta1.ZookeeperCluster{}
+				foundZookeeper := &zookeeperv1.ZookeeperCluster{}
 				_ = cl.Get(context.TODO(), req.NamespacedName, foundZookeeper)
 				Ω(err).To(BeNil())
 				Ω(foundZookeeper.Status.TargetVersion).To(BeEquivalentTo("0.2.7"))
 			})
 
 			It("should set the target version", func() {
-				foundZookeeper := &v1beta1.ZookeeperCluster{}
+				foundZookeeper := &zookeeperv1.ZookeeperCluster{}
 				_ = cl.Get(context.TODO(), req.NamespacedName, foundZookeeper)
 
 				Ω(err).To(BeNil())
@@ -374,8 +2166,8 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				st.Status.CurrentRevision = "complete"
 				st.Status.UpdateRevision = "complete"
 				cl.Status().Update(context.TODO(), st)
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
-				foundZookeeper := &v1beta1.ZookeeperCluster{}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
+				foundZookeeper := &zookeeperv1.ZookeeperCluster{}
 				_ = cl.Get(context.TODO(), req.NamespacedName, foundZookeeper)
 				res, err = r.Reconcile(context.TODO(), req)
 				res, err = r.Reconcile(context.TODO(), req)
@@ -387,14 +2179,14 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 			})
 
 			It("should set the currentversion to Image.tag", func() {
-				foundZookeeper := &v1beta1.ZookeeperCluster{}
+				foundZookeeper := &zookeeperv1.ZookeeperCluster{}
 				_ = cl.Get(context.TODO(), req.NamespacedName, foundZookeeper)
 				Ω(err).To(BeNil())
 				Ω(foundZookeeper.Status.CurrentVersion).To(BeEquivalentTo("0.2.7"))
 			})
 
 			It("should set the target version to empty", func() {
-				foundZookeeper := &v1beta1.ZookeeperCluster{}
+				foundZookeeper := &zookeeperv1.ZookeeperCluster{}
 				_ = cl.Get(context.TODO(), req.NamespacedName, foundZookeeper)
 				Ω(err).To(BeNil())
 				Ω(foundZookeeper.Status.TargetVersion).To(BeEquivalentTo(""))
@@ -422,20 +2214,20 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				st.Status.UpdateRevision = "updateRevision"
 				st.Status.UpdatedReplicas = 2
 				cl.Status().Update(context.TODO(), st)
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 				// sleeping for 3 seconds
 				time.Sleep(3 * time.Second)
 				// checking if more than 2 secs have passed from the last update time
-				err = checkSyncTimeout(next, " ", 1, 2*time.Second)
+				err = checkSyncTimeout(next, " ", "1", 2*time.Second)
 
 			})
 
 			It("checking update replicas", func() {
-				foundZookeeper := &v1beta1.ZookeeperCluster{}
+				foundZookeeper := &zookeeperv1.ZookeeperCluster{}
 				_ = cl.Get(context.TODO(), req.NamespacedName, foundZookeeper)
-				_, condition := foundZookeeper.Status.GetClusterCondition(v1beta1.ClusterConditionUpgrading)
-				Ω(condition.Message).To(Equal("2"))
+				_, condition := foundZookeeper.Status.GetClusterCondition(zookeeperv1.ClusterConditionUpgrading)
+				Ω(condition.Message).To(HavePrefix("0 of 3 members updated"))
 			})
 
 			It("should raise an error", func() {
@@ -460,7 +2252,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				next.Spec.Replicas = 3
 				next.Spec.Image.Tag = "0.2.7"
 				st := zk.MakeStatefulSet(z)
-				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(next, st).Build()
+				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(next, st).WithStatusSubresource(next).Build()
 				st = &appsv1.StatefulSet{}
 				err = cl.Get(context.TODO(), req.NamespacedName, st)
 				// changing the Revision value to simulate the upgrade scenario
@@ -468,17 +2260,17 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				st.Status.UpdateRevision = "updateRevision"
 				st.Status.UpdatedReplicas = 2
 				cl.Status().Update(context.TODO(), st)
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 				// sleeping for 3 seconds
 				time.Sleep(3 * time.Second)
 				// checking if more than 2 secs have passed from the last update time
-				err = checkSyncTimeout(next, " ", 1, 2*time.Second)
+				err = checkSyncTimeout(next, " ", "1", 2*time.Second)
 
 			})
 
 			It("checking update replicas", func() {
-				foundZookeeper := &v1beta1.ZookeeperCluster{}
+				foundZookeeper := &zookeeperv1.ZookeeperCluster{}
 				_ = cl.Get(context.TODO(), req.NamespacedName, foundZookeeper)
 				condition := foundZookeeper.Status.CurrentVersion
 				Ω(condition).To(Equal("0.2.7"))
@@ -505,7 +2297,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				next.Status.IsClusterInUpgradingState()
 				st := zk.MakeStatefulSet(z)
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(next, st).WithStatusSubresource(next).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 			})
 
@@ -513,7 +2305,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				Ω(err).To(BeNil())
 			})
 			It("should set the upgrade condition to false", func() {
-				foundZookeeper := &v1beta1.ZookeeperCluster{}
+				foundZookeeper := &zookeeperv1.ZookeeperCluster{}
 				_ = cl.Get(context.TODO(), req.NamespacedName, foundZookeeper)
 				Ω(foundZookeeper.Status.IsClusterInUpgradingState()).To(Equal(false))
 			})
@@ -529,7 +2321,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				z.WithDefaults()
 				z.Status.Init()
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z).WithStatusSubresource(z).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				req.NamespacedName.Namespace = "temp"
 				res, err = r.Reconcile(context.TODO(), req)
 			})
@@ -539,6 +2331,274 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 			})
 		})
 
+		Context("Reporting the member statuses", func() {
+			var (
+				cl       client.Client
+				err      error
+				lastSeen metav1.Time
+				foundZk  *zookeeperv1.ZookeeperCluster
+				zkClient *MockZookeeperClient
+				secrets  []client.Object
+			)
+
+			makePod := func(name, ip string) *corev1.Pod {
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: Namespace,
+						Labels:    map[string]string{"app": Name},
+					},
+				}
+				pod.Status.PodIP = ip
+				return pod
+			}
+
+			makeEnsembleConfig := func(participants int) *zk.EnsembleConfig {
+				config := &zk.EnsembleConfig{}
+				for i := 1; i <= participants; i++ {
+					config.Servers = append(config.Servers, zk.ServerConfig{ID: i, Role: zk.RoleParticipant})
+				}
+				return config
+			}
+
+			condition := func(t string) *metav1.Condition {
+				_, c := foundZk.Status.GetClusterCondition(t)
+				return c
+			}
+
+			BeforeEach(func() {
+				z.WithDefaults()
+				z.Status.Init()
+				z.Generation = 2
+				lastSeen = metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+				z.Status.MemberStatuses = []zookeeperv1.MemberStatus{
+					{Name: Name + "-2", Role: zookeeperv1.MemberRoleFollower, Zxid: "0x100000003", Epoch: 1, LastSeenTime: &lastSeen},
+					{Name: Name + "-3", Role: zookeeperv1.MemberRoleFollower, LastSeenTime: &lastSeen},
+				}
+				zkClient = &MockZookeeperClient{
+					serverStats: map[string]*zk.ServerStats{
+						"10.0.0.1:2181": {Mode: "follower", Zxid: 0x200000005, Connections: 2, Latency: "0/0.5/3"},
+						"10.0.0.2:2181": {Mode: "leader", Zxid: 0x200000005, Outstanding: 1},
+					},
+					ensembleConfig: makeEnsembleConfig(3),
+				}
+				secrets = nil
+			})
+
+			JustBeforeEach(func() {
+				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).
+					WithRuntimeObjects(z, makePod(Name+"-1", "10.0.0.2"), makePod(Name+"-0", "10.0.0.1"), makePod(Name+"-2", "")).
+					WithObjects(secrets...).
+					WithStatusSubresource(z).Build()
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: zkClient, Tracer: tracer}
+				res, err = r.Reconcile(context.TODO(), req)
+				foundZk = &zookeeperv1.ZookeeperCluster{}
+				_ = cl.Get(context.TODO(), req.NamespacedName, foundZk)
+			})
+
+			It("should not raise an error", func() {
+				Ω(err).To(BeNil())
+			})
+
+			It("should report the leader", func() {
+				Ω(foundZk.Status.Leader).To(Equal(Name + "-1"))
+			})
+
+			It("should report every member in order", func() {
+				names := []string{}
+				for _, m := range foundZk.Status.MemberStatuses {
+					names = append(names, m.Name)
+				}
+				Ω(names).To(Equal([]string{Name + "-0", Name + "-1", Name + "-2"}))
+			})
+
+			It("should report the stats of the members which answered", func() {
+				m := foundZk.Status.GetMemberStatus(Name + "-0")
+				Ω(m.Role).To(Equal(zookeeperv1.MemberRoleFollower))
+				Ω(m.Zxid).To(Equal("0x200000005"))
+				Ω(m.Epoch).To(BeEquivalentTo(2))
+				Ω(m.Connections).To(BeEquivalentTo(2))
+				Ω(m.Latency).To(Equal("0/0.5/3"))
+				Ω(m.LastSeenTime.After(lastSeen.Time)).To(BeTrue())
+				Ω(foundZk.Status.GetMemberStatus(Name + "-1").OutstandingRequests).To(BeEquivalentTo(1))
+			})
+
+			It("should keep the last known stats of a member which did not answer", func() {
+				m := foundZk.Status.GetMemberStatus(Name + "-2")
+				Ω(m.Role).To(Equal(zookeeperv1.MemberRoleUnknown))
+				Ω(m.Zxid).To(Equal("0x100000003"))
+				Ω(m.LastSeenTime.Equal(&lastSeen)).To(BeTrue())
+			})
+
+			It("should report the elected leader", func() {
+				Ω(condition(zookeeperv1.ClusterConditionLeaderElected).Status).To(Equal(metav1.ConditionTrue))
+				Ω(condition(zookeeperv1.ClusterConditionLeaderElected).Message).To(ContainSubstring(Name + "-1"))
+			})
+
+			It("should report the quorum as available but without redundancy", func() {
+				Ω(condition(zookeeperv1.ClusterConditionQuorumAvailable).Status).To(Equal(metav1.ConditionTrue))
+				Ω(condition(zookeeperv1.ClusterConditionDegraded).Status).To(Equal(metav1.ConditionTrue))
+				Ω(condition(zookeeperv1.ClusterConditionDegraded).Reason).To(Equal(zookeeperv1.NoRedundancyReason))
+				Ω(foundZk.Status.IsQuorumAvailable()).To(BeTrue())
+			})
+
+			It("should record the observed generation", func() {
+				Ω(foundZk.Status.ObservedGeneration).To(BeEquivalentTo(2))
+				for _, c := range foundZk.Status.Conditions {
+					Ω(c.ObservedGeneration).To(BeEquivalentTo(2))
+				}
+			})
+
+			Context("with every member serving", func() {
+				BeforeEach(func() {
+					zkClient.serverStats["10.0.0.3:2181"] = &zk.ServerStats{Mode: "follower"}
+					z.Spec.Replicas = 2
+					zkClient.ensembleConfig = makeEnsembleConfig(2)
+				})
+
+				It("should not be degraded", func() {
+					Ω(condition(zookeeperv1.ClusterConditionQuorumAvailable).Status).To(Equal(metav1.ConditionTrue))
+					Ω(condition(zookeeperv1.ClusterConditionDegraded).Status).To(Equal(metav1.ConditionFalse))
+				})
+			})
+
+			Context("with a membership other than requested", func() {
+				BeforeEach(func() {
+					zkClient.ensembleConfig = makeEnsembleConfig(2)
+				})
+
+				It("should be degraded with a membership mismatch", func() {
+					Ω(condition(zookeeperv1.ClusterConditionQuorumAvailable).Status).To(Equal(metav1.ConditionTrue))
+					Ω(condition(zookeeperv1.ClusterConditionDegraded).Reason).To(Equal(zookeeperv1.MembershipMismatchReason))
+				})
+			})
+
+			Context("without a leader", func() {
+				BeforeEach(func() {
+					zkClient.serverStats["10.0.0.2:2181"] = &zk.ServerStats{Mode: "follower"}
+				})
+
+				It("should report the quorum as lost", func() {
+					Ω(foundZk.Status.Leader).To(BeEmpty())
+					Ω(condition(zookeeperv1.ClusterConditionLeaderElected).Status).To(Equal(metav1.ConditionFalse))
+					Ω(condition(zookeeperv1.ClusterConditionQuorumAvailable).Status).To(Equal(metav1.ConditionFalse))
+					Ω(condition(zookeeperv1.ClusterConditionDegraded).Reason).To(Equal(zookeeperv1.QuorumLostReason))
+				})
+			})
+
+			It("should read the ensemble config over a plaintext session", func() {
+				Ω(zkClient.zkUri).To(Equal("example-client.default.svc.cluster.local:2181"))
+				Ω(zkClient.opts.TLSConfig).To(BeNil())
+				Ω(zkClient.opts.Credentials).To(BeEmpty())
+			})
+
+			Context("with client TLS and operator credentials", func() {
+				BeforeEach(func() {
+					z.Spec.TLS = &zookeeperv1.TLSPolicy{
+						Client: &zookeeperv1.ClientTLS{
+							TLSSecret: zookeeperv1.TLSSecret{SecretName: "example-tls"},
+						},
+					}
+					z.Spec.OperatorClient = &zookeeperv1.OperatorClientPolicy{
+						Auth: &zookeeperv1.ClientCredentials{SecretName: "operator-credentials"},
+					}
+					z.WithDefaults()
+					cert, key := makeCertificate()
+					secrets = []client.Object{
+						&corev1.Secret{
+							ObjectMeta: metav1.ObjectMeta{Name: "example-tls", Namespace: Namespace},
+							Data:       map[string][]byte{"tls.crt": cert, "tls.key": key, "ca.crt": cert},
+						},
+						&corev1.Secret{
+							ObjectMeta: metav1.ObjectMeta{Name: "operator-credentials", Namespace: Namespace},
+							Data:       map[string][]byte{"username": []byte("operator"), "password": []byte("secret")},
+						},
+					}
+					// the members only serve the operator on the secure client port
+					for address, stats := range zkClient.serverStats {
+						delete(zkClient.serverStats, address)
+						zkClient.serverStats[strings.TrimSuffix(address, ":2181")+":2281"] = stats
+					}
+				})
+
+				It("should connect over TLS on the secure client port", func() {
+					Ω(zkClient.zkUri).To(Equal("example-client.default.svc.cluster.local:2281"))
+					Ω(zkClient.opts.TLSConfig).NotTo(BeNil())
+					Ω(zkClient.opts.TLSConfig.ServerName).To(Equal("example-client.default.svc.cluster.local"))
+					Ω(zkClient.opts.TLSConfig.Certificates).To(HaveLen(1))
+				})
+
+				It("should query the members over TLS on the secure client port", func() {
+					Ω(foundZk.Status.Leader).NotTo(BeEmpty())
+					Ω(zkClient.statsTLSConfig).NotTo(BeNil())
+					Ω(zkClient.statsTLSConfig.ServerName).To(HaveSuffix(".example-headless.default.svc.cluster.local"))
+				})
+
+				It("should authenticate with the digest credentials", func() {
+					Ω(zkClient.opts.Credentials).To(Equal([]zk.Credentials{
+						{Scheme: "digest", Auth: []byte("operator:secret")},
+					}))
+				})
+
+				Context("with key material of the operator", func() {
+					BeforeEach(func() {
+						z.Spec.OperatorClient.TLSSecretName = "operator-tls"
+						cert, _ := makeCertificate()
+						secrets = append(secrets, &corev1.Secret{
+							ObjectMeta: metav1.ObjectMeta{Name: "operator-tls", Namespace: Namespace},
+							Data:       map[string][]byte{"ca.crt": cert},
+						})
+					})
+
+					It("should use it instead of the key material of the members", func() {
+						Ω(zkClient.zkUri).To(Equal("example-client.default.svc.cluster.local:2281"))
+						Ω(zkClient.opts.TLSConfig.Certificates).To(BeEmpty())
+					})
+				})
+
+				Context("without the credentials secret", func() {
+					BeforeEach(func() {
+						secrets = secrets[:1]
+					})
+
+					It("should not connect", func() {
+						Ω(err).To(BeNil())
+						Ω(zkClient.opts).To(BeNil())
+					})
+				})
+			})
+
+			Context("with ACLs enforced", func() {
+				BeforeEach(func() {
+					z.Spec.ACL = &zookeeperv1.ACLPolicy{Enforce: true, SuperUserSecretName: "example-super"}
+					secrets = []client.Object{
+						&corev1.Secret{
+							ObjectMeta: metav1.ObjectMeta{Name: "example-super", Namespace: Namespace},
+							Data:       map[string][]byte{"username": []byte("super"), "password": []byte("super-password")},
+						},
+					}
+				})
+
+				It("should authenticate as the super user", func() {
+					Ω(zkClient.opts.Credentials).To(Equal([]zk.Credentials{
+						{Scheme: "digest", Auth: []byte("super:super-password")},
+					}))
+				})
+			})
+
+			Context("during an upgrade", func() {
+				BeforeEach(func() {
+					z.Status.SetUpgradingConditionTrue("", "")
+				})
+
+				It("should still report the ensemble conditions", func() {
+					Ω(foundZk.Status.IsClusterInUpgradingState()).To(BeTrue())
+					Ω(condition(zookeeperv1.ClusterConditionLeaderElected).Status).To(Equal(metav1.ConditionTrue))
+					Ω(foundZk.Status.Leader).To(Equal(Name + "-1"))
+				})
+			})
+		})
+
 		Context("Checking client", func() {
 			var (
 				cl    client.Client
@@ -550,12 +2610,12 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				z.WithDefaults()
 				z.Status.Init()
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z).WithStatusSubresource(z).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 			})
 
 			It("should not raise an error", func() {
-				err = mockZkClient.Connect("127.0.0.0:2181")
+				err = mockZkClient.Connect("127.0.0.0:2181", nil)
 				Ω(err).To(BeNil())
 			})
 			It("should not raise an error", func() {
@@ -563,27 +2623,27 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				Ω(err).To(BeNil())
 			})
 			It("should not raise an error", func() {
-				err = r.cleanupOrphanPVCs(z)
+				err = r.cleanupOrphanPVCs(context.TODO(), z)
 				Ω(err).To(BeNil())
 			})
 			It("should not raise an error", func() {
 				z.Status.ReadyReplicas = -1
 				z.Spec.Replicas = -1
 				err = cl.Update(context.TODO(), z)
-				err = r.cleanupOrphanPVCs(z)
+				err = r.cleanupOrphanPVCs(context.TODO(), z)
 				Ω(err).To(BeNil())
 			})
 			It("should not raise an error", func() {
-				count, err = r.getPVCCount(z)
-				_, err = r.getPVCList(z)
+				count, err = r.getPVCCount(context.TODO(), z)
+				_, err = r.getPVCList(context.TODO(), z)
 				Ω(err).To(BeNil())
 				Ω(count).To(Equal(0))
 			})
 			It("should not raise an error", func() {
 				_ = cl.Get(context.TODO(), req.NamespacedName, z)
-				z.Spec.Persistence.VolumeReclaimPolicy = v1beta1.VolumeReclaimPolicyDelete
+				z.Spec.Storage.Persistence.VolumeReclaimPolicy = zookeeperv1.VolumeReclaimPolicyDelete
 				cl.Update(context.TODO(), z)
-				err = r.reconcileFinalizers(z)
+				err = r.reconcileFinalizers(context.TODO(), z)
 				Ω(err).To(BeNil())
 			})
 
@@ -595,12 +2655,12 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 					},
 				}
 				r.Client.Create(context.TODO(), pvcDelete)
-				r.deletePVC(*pvcDelete)
-				r.deletePVC(*pvcDelete)
+				r.deletePVC(context.TODO(), *pvcDelete)
+				r.deletePVC(context.TODO(), *pvcDelete)
 			})
 
 			It("should not raise an error", func() {
-				err = r.cleanUpAllPVCs(z)
+				err = r.cleanUpAllPVCs(context.TODO(), z)
 				_ = os.RemoveAll("ZookeeperCluster")
 				Ω(err).To(BeNil())
 			})
@@ -620,10 +2680,10 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 			BeforeEach(func() {
 				z.WithDefaults()
 				next := z.DeepCopy()
-				next.Spec.Ports[0].ContainerPort = 2182
+				next.Spec.Ports.Client = 2182
 				svc := zk.MakeClientService(z)
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(next, svc).WithStatusSubresource(next).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 			})
 
@@ -639,17 +2699,17 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 			)
 			BeforeEach(func() {
 				z.WithDefaults()
-				z.Spec.Persistence = nil
+				z.Spec.Storage.Persistence = nil
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z).WithStatusSubresource(z).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
-				err = r.reconcileFinalizers(z)
+				err = r.reconcileFinalizers(context.TODO(), z)
 				// update deletion timestamp
 				_ = cl.Get(context.TODO(), req.NamespacedName, z)
 				now := metav1.Now()
 				z.SetDeletionTimestamp(&now)
 				cl.Update(context.TODO(), z)
-				err = r.reconcileFinalizers(z)
+				err = r.reconcileFinalizers(context.TODO(), z)
 			})
 			It("should not raise an error", func() {
 				Ω(err).To(BeNil())
@@ -663,20 +2723,20 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 			)
 			BeforeEach(func() {
 				z.WithDefaults()
-				z.Spec.Persistence = nil
+				z.Spec.Storage.Persistence = nil
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(z).WithStatusSubresource(z).Build()
 			})
 			It("should have 1 finalizer, should not raise an error", func() {
 				config.DisableFinalizer = false
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
-				err = r.reconcileFinalizers(z)
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
+				err = r.reconcileFinalizers(context.TODO(), z)
 				Expect(z.ObjectMeta.Finalizers).To(HaveLen(1))
 				Ω(err).To(BeNil())
 			})
 			It("should have 0 finalizer, should not raise an error", func() {
 				config.DisableFinalizer = true
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
-				err = r.reconcileFinalizers(z)
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
+				err = r.reconcileFinalizers(context.TODO(), z)
 				Expect(z.ObjectMeta.Finalizers).To(HaveLen(0))
 				Ω(err).To(BeNil())
 			})
@@ -730,17 +2790,17 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 			var (
 				cl      client.Client
 				err     error
-				foundZk = &v1beta1.ZookeeperCluster{}
-				next    *v1beta1.ZookeeperCluster
+				foundZk = &zookeeperv1.ZookeeperCluster{}
+				next    *zookeeperv1.ZookeeperCluster
 				svc     *corev1.Service
 			)
 			BeforeEach(func() {
 				z.WithDefaults()
 				next = z.DeepCopy()
-				next.Spec.TriggerRollingRestart = true
+				next.SetTriggerRollingRestart(true)
 				svc = zk.MakeClientService(z)
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(next, svc).WithStatusSubresource(next).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 				err = cl.Get(context.TODO(), req.NamespacedName, foundZk)
 			})
@@ -748,7 +2808,7 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 			It("should update restartTime annotation and reset triggerRollingRestart to false when triggerRollingRestart is set to true", func() {
 				Ω(res.Requeue).To(Equal(true))
 				Ω(err).To(BeNil())
-				Ω(foundZk.Spec.TriggerRollingRestart).To(Equal(false))
+				Ω(foundZk.GetTriggerRollingRestart()).To(Equal(false))
 				_, restartTimeExists := foundZk.Spec.Pod.Annotations["restartTime"]
 				Ω(restartTimeExists).To(Equal(true))
 			})
@@ -757,15 +2817,15 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				_, restartTimeExists := foundZk.Spec.Pod.Annotations["restartTime"]
 				Ω(restartTimeExists).To(Equal(true))
 
-				next.Spec.TriggerRollingRestart = false
+				next.SetTriggerRollingRestart(false)
 				svc = zk.MakeClientService(z)
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(next, svc).WithStatusSubresource(next).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 
 				Ω(res.Requeue).To(Equal(false))
 				Ω(err).To(BeNil())
-				Ω(foundZk.Spec.TriggerRollingRestart).To(Equal(false))
+				Ω(foundZk.GetTriggerRollingRestart()).To(Equal(false))
 				_, restartTimeExists = foundZk.Spec.Pod.Annotations["restartTime"]
 				Ω(restartTimeExists).To(Equal(true))
 			})
@@ -778,16 +2838,16 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				time.Sleep(1 * time.Second)
 
 				// update the crd instance
-				next.Spec.TriggerRollingRestart = false
+				next.SetTriggerRollingRestart(false)
 				svc = zk.MakeClientService(z)
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 				err = cl.Get(context.TODO(), req.NamespacedName, foundZk)
 
 				// check that restartTime was not updated
 				Ω(res.Requeue).To(Equal(false))
 				Ω(err).To(BeNil())
-				Ω(foundZk.Spec.TriggerRollingRestart).To(Equal(false))
+				Ω(foundZk.GetTriggerRollingRestart()).To(Equal(false))
 				_, restartTimeExists = foundZk.Spec.Pod.Annotations["restartTime"]
 				Ω(restartTimeExists).To(Equal(true))
 
@@ -795,17 +2855,17 @@ var _ = Describe("ZookeeperCluster Controller", func() {
 				time.Sleep(1 * time.Second)
 
 				// update the crd instance to trigger rolling restart
-				next.Spec.TriggerRollingRestart = true
+				next.SetTriggerRollingRestart(true)
 				svc = zk.MakeClientService(z)
 				cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(next, svc).WithStatusSubresource(next).Build()
-				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient}
+				r = &ZookeeperClusterReconciler{Client: cl, Scheme: s, ZkClient: mockZkClient, Tracer: tracer}
 				res, err = r.Reconcile(context.TODO(), req)
 				err = cl.Get(context.TODO(), req.NamespacedName, foundZk)
 
 				// check that restartTime was updated
 				Ω(res.Requeue).To(Equal(true))
 				Ω(err).To(BeNil())
-				Ω(foundZk.Spec.TriggerRollingRestart).To(Equal(false))
+				Ω(foundZk.GetTriggerRollingRestart()).To(Equal(false))
 				newRestartValue, restartTimeExists := foundZk.Spec.Pod.Annotations["restartTime"]
 				Ω(restartTimeExists).To(Equal(true))
 				Ω(oldRestartValue).NotTo(Equal(newRestartValue))
@@ -0,0 +1,86 @@
+#!/usr/bin/env bash
+#
+# Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+
+# Archives the latest snapshots of a member together with the transaction
+# logs written since, and keeps the archive in $BACKUP_DIR/$BACKUP_PATH, or
+# uploads it to $BACKUP_PATH in the bucket in $S3_BUCKET. The last zxid, the
+# size and the checksum of the archive are reported through the termination
+# message.
+# With the delete argument, the archive is deleted from where it was kept.
+
+set -ex
+
+source /usr/local/bin/zookeeperFunctions.sh
+
+DATA_DIR=/data/version-2
+ARCHIVE=$BACKUP_DIR/$BACKUP_PATH
+TERMINATION_LOG=/dev/termination-log
+# the newest snapshot may still be written, the one before it is kept as well
+SNAPSHOT_COUNT=2
+
+if [[ "$1" == "delete" ]]; then
+  if [[ -n "$S3_BUCKET" ]]; then
+    s3Delete "$BACKUP_PATH"
+  else
+    rm -f "$ARCHIVE"
+  fi
+  exit 0
+fi
+
+# zxidSorted lists the files of the data directory starting with $1, prefixed
+# with the zxid in their name and sorted by it
+function zxidSorted() {
+  for FILE in $(ls $DATA_DIR | grep "^$1\."); do
+    echo "$(( 16#${FILE#*.} )) $FILE"
+  done | sort -n
+}
+
+SNAPSHOTS=$(zxidSorted snapshot | tail -n $SNAPSHOT_COUNT)
+if [[ -z "$SNAPSHOTS" ]]; then
+  echo "No snapshot in $DATA_DIR" > $TERMINATION_LOG
+  exit 1
+fi
+FILES=$(echo "$SNAPSHOTS" | cut -d' ' -f2)
+OLDEST_SNAPSHOT=$(echo "$SNAPSHOTS" | head -n 1 | cut -d' ' -f1)
+
+# the transaction logs since the oldest snapshot, starting with the log the
+# snapshot falls into
+FIRST_LOG=
+while read -r ZXID LOG; do
+  if (( ZXID <= OLDEST_SNAPSHOT )); then
+    FIRST_LOG=$LOG
+  else
+    FILES="$FILES $LOG"
+  fi
+done < <(zxidSorted log)
+FILES="$FIRST_LOG $FILES"
+
+mkdir -p "$(dirname "$ARCHIVE")"
+tar -czf "$ARCHIVE" -C $DATA_DIR $FILES
+# the archive has to read back with the snapshots it was made of, the last
+# transaction it holds is read from what it was read back to
+EXTRACTED=$(mktemp -d)
+tar -xzf "$ARCHIVE" -C "$EXTRACTED"
+if ! ls "$EXTRACTED" | grep -q "^snapshot\."; then
+  echo "The archive $ARCHIVE holds no snapshot" > $TERMINATION_LOG
+  exit 1
+fi
+ZXID=$(java -jar /opt/libs/zu.jar last-zxid "$EXTRACTED")
+rm -rf "$EXTRACTED"
+SIZE=$(stat -c %s "$ARCHIVE")
+CHECKSUM=$(sha256sum "$ARCHIVE" | cut -d' ' -f1)
+
+if [[ -n "$S3_BUCKET" ]]; then
+  s3Upload "$ARCHIVE" "$BACKUP_PATH"
+  rm -f "$ARCHIVE"
+fi
+
+echo "{\"zxid\":\"$ZXID\",\"size\":$SIZE,\"checksum\":\"sha256:$CHECKSUM\"}" > $TERMINATION_LOG
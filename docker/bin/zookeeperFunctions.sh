@@ -13,7 +13,7 @@
 set -ex
 
 function zkConfig() {
-  echo "$HOST.$DOMAIN:$QUORUM_PORT:$LEADER_PORT:$ROLE;$CLIENT_PORT"
+  echo "$HOST.$DOMAIN:$QUORUM_PORT:$LEADER_PORT:$ROLE;${CLIENT_ADDRESS:-$CLIENT_PORT}"
 }
 
 function zkConnectionString() {
@@ -25,6 +25,38 @@ function zkConnectionString() {
     echo "localhost:${CLIENT_PORT}"
   else
     set -e
-    echo "${CLIENT_HOST}:${CLIENT_PORT}"
+    # the client service only serves TLS when SECURE_CLIENT_PORT is set
+    echo "${CLIENT_HOST}:${SECURE_CLIENT_PORT:-$CLIENT_PORT}"
   fi
-}
\ No newline at end of file
+}
+
+# the SHA-256 hash of the empty payload of a signed request
+S3_EMPTY_PAYLOAD_HASH=e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855
+
+# s3Curl sends a request to the S3-compatible endpoint, signed for $S3_REGION
+# with the credentials in $AWS_ACCESS_KEY_ID and $AWS_SECRET_ACCESS_KEY. The
+# arguments are passed on to curl.
+function s3Curl() {
+  # keep the credentials off the command line and out of the trace
+  { set +x; } 2>/dev/null
+  echo "user = \"$AWS_ACCESS_KEY_ID:$AWS_SECRET_ACCESS_KEY\"" |
+    curl --fail --silent --show-error --aws-sigv4 "aws:amz:$S3_REGION:s3" --config - "$@"
+  set -x
+}
+
+# s3Upload uploads the file $1 to the key $2 of the bucket in $S3_BUCKET
+function s3Upload() {
+  local FILE=$1 KEY=$2
+  local PAYLOAD_HASH=$(sha256sum "$FILE" | cut -d' ' -f1)
+  s3Curl --upload-file "$FILE" -H "x-amz-content-sha256: $PAYLOAD_HASH" "$S3_ENDPOINT/$S3_BUCKET/$KEY"
+}
+
+# s3Download downloads the key $1 of the bucket in $S3_BUCKET to the file $2
+function s3Download() {
+  s3Curl -H "x-amz-content-sha256: $S3_EMPTY_PAYLOAD_HASH" -o "$2" "$S3_ENDPOINT/$S3_BUCKET/$1"
+}
+
+# s3Delete deletes the key $1 of the bucket in $S3_BUCKET
+function s3Delete() {
+  s3Curl -X DELETE -H "x-amz-content-sha256: $S3_EMPTY_PAYLOAD_HASH" "$S3_ENDPOINT/$S3_BUCKET/$1"
+}
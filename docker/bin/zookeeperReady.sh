@@ -44,7 +44,7 @@ if [[ "$OK" == "imok" ]]; then
         echo Failed to parse name and ordinal of Pod
         exit 1
     fi
-    MYID=$((ORD+1))
+    MYID=$((ORD+1+${ZK_SERVER_ID_OFFSET:-0}))
     ONDISK_CONFIG=false
     if [ -f $MYID_FILE ]; then
       EXISTING_ID="`cat $DATA_DIR/myid`"
@@ -71,13 +71,9 @@ if [[ "$OK" == "imok" ]]; then
       echo "Zookeeper service is available and an active participant"
       exit 0
     elif [[ "$ROLE" == "observer" ]]; then
-      echo "Zookeeper service is ready to be upgraded from observer to participant."
-      ROLE=participant
-      ZKURL=$(zkConnectionString)
-      ZKCONFIG=$(zkConfig)
-      java -Dlog4j.configuration=file:"$LOG4J_CONF" -jar /opt/libs/zu.jar remove $ZKURL $MYID
-      sleep 1
-      java -Dlog4j.configuration=file:"$LOG4J_CONF" -jar /opt/libs/zu.jar add $ZKURL $MYID $ZKCONFIG
+      # The operator promotes a joining member to a participant, the
+      # members of the observer pool stay observers
+      echo "Zookeeper service is available as an observer"
       exit 0
     else
       echo "Something has gone wrong. Unable to determine zookeeper role."
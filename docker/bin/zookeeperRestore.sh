@@ -0,0 +1,58 @@
+#!/usr/bin/env bash
+#
+# Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+
+# Restores the snapshots and transaction logs of a backup into the data
+# directory of a new member, from $BACKUP_DIR/$BACKUP_PATH or from
+# $BACKUP_PATH in the bucket in $S3_BUCKET. A member which holds data already
+# is left as it is. The myid and the dynamic configuration are not restored,
+# zookeeperStart.sh writes them as for any new member.
+
+set -ex
+
+source /usr/local/bin/zookeeperFunctions.sh
+
+DATA_DIR=/data
+LOG_DIR=$DATA_DIR/version-2
+ARCHIVE=$BACKUP_DIR/$BACKUP_PATH
+TERMINATION_LOG=/dev/termination-log
+
+if ls $LOG_DIR/snapshot.* > /dev/null 2>&1; then
+  echo "$LOG_DIR holds data already, the backup is not restored"
+  exit 0
+fi
+
+if [[ -n "$S3_BUCKET" ]]; then
+  ARCHIVE=$BACKUP_DIR/$(basename "$BACKUP_PATH")
+  s3Download "$BACKUP_PATH" "$ARCHIVE"
+fi
+
+if [[ -n "$RESTORE_CHECKSUM" ]]; then
+  CHECKSUM="sha256:$(sha256sum "$ARCHIVE" | cut -d' ' -f1)"
+  if [[ "$CHECKSUM" != "$RESTORE_CHECKSUM" ]]; then
+    echo "Checksum $CHECKSUM of $BACKUP_PATH does not match $RESTORE_CHECKSUM" > $TERMINATION_LOG
+    exit 1
+  fi
+fi
+
+# the configuration the member may have written before it took a snapshot
+# belongs to a previous ensemble
+rm -rf $DATA_DIR/myid $DATA_DIR/zoo.cfg.dynamic* $DATA_DIR/conf $LOG_DIR
+
+# a partial extraction is not mistaken for restored data if it is interrupted
+rm -rf $DATA_DIR/restore
+mkdir $DATA_DIR/restore
+tar -xzf "$ARCHIVE" -C $DATA_DIR/restore
+mv $DATA_DIR/restore $LOG_DIR
+
+if [[ -n "$S3_BUCKET" ]]; then
+  rm -f "$ARCHIVE"
+fi
+echo "Restored $BACKUP_PATH into $LOG_DIR"
@@ -30,7 +30,9 @@ else
     exit 1
 fi
 
-MYID=$((ORD+1))
+# Observers get their ids after ZK_SERVER_ID_OFFSET, they never bootstrap
+# the ensemble
+MYID=$((ORD+1+${ZK_SERVER_ID_OFFSET:-0}))
 
 # Values for first startup
 WRITE_CONFIGURATION=true
@@ -129,12 +131,22 @@ if [[ "$WRITE_CONFIGURATION" == true ]]; then
 fi
 
 if [[ "$REGISTER_NODE" == true ]]; then
+    # The operator adds the node to the ensemble once it follows the leader.
+    # Until then it joins with the current config, in which it observes.
     ROLE=observer
     ZKURL=$(zkConnectionString)
     ZKCONFIG=$(zkConfig)
+    TLS_JVMFLAGS=""
+    if [[ -n "$SECURE_CLIENT_PORT" && "$ZKURL" != localhost:* ]]; then
+      TLS_JVMFLAGS="$CLIENT_TLS_JVMFLAGS"
+    fi
     set -e
-    echo Registering node and writing local configuration to disk.
-    java -Dlog4j.configuration=file:"$LOG4J_CONF" -jar /opt/libs/zu.jar add $ZKURL $MYID  $ZKCONFIG $DYNCONFIG
+    echo Writing the configuration of the ensemble to disk.
+    ENSEMBLE_CONFIG=$(java $CLIENT_JVMFLAGS $TLS_JVMFLAGS -Dlog4j.configuration=file:"$LOG4J_CONF" -jar /opt/libs/zu.jar get-all $ZKURL)
+    echo "$ENSEMBLE_CONFIG" | grep -v "^version=" > $DYNCONFIG
+    if ! grep -q "^server.${MYID}=" $DYNCONFIG; then
+      echo "server.${MYID}=${ZKCONFIG}" >> $DYNCONFIG
+    fi
     set +e
 fi
 
@@ -152,6 +164,14 @@ cp -f /conf/log4j.properties $ZOOCFGDIR
 cp -f /conf/log4j-quiet.properties $ZOOCFGDIR
 cp -f /conf/env.sh $ZOOCFGDIR
 
+if [[ -n "$ZK_SUPER_USER" ]]; then
+  # The ensemble enforces ACLs, let the super user of the operator access every znode
+  set +x
+  SUPER_DIGEST=$(java -jar /opt/libs/zu.jar digest)
+  export SERVER_JVMFLAGS="$SERVER_JVMFLAGS -Dzookeeper.DigestAuthenticationProvider.superDigest=$SUPER_DIGEST"
+  set -x
+fi
+
 if [ -f $DYNCONFIG ]; then
   # Node registered, start server
   echo Starting zookeeper service
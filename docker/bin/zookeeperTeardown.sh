@@ -14,10 +14,6 @@ set -ex
 source /conf/env.sh
 source /usr/local/bin/zookeeperFunctions.sh
 
-DATA_DIR=/data
-MYID_FILE=$DATA_DIR/myid
-LOG4J_CONF=/conf/log4j-quiet.properties
-
 # Wait for client connections to drain. Kubernetes will wait until the confiugred
 # "terminationGracePeriodSeconds" before focibly killing the container
 for (( i = 0; i < 6; i++ )); do
@@ -31,20 +27,8 @@ for (( i = 0; i < 6; i++ )); do
   fi
 done
 
-# Check to see if zookeeper service for this node is a participant
-set +e
-ZKURL=$(zkConnectionString)
-set -e
-MYID=`cat $MYID_FILE`
-
-ZNODE_PATH="/zookeeper-operator/$CLUSTER_NAME"
-CLUSTERSIZE=`java -Dlog4j.configuration=file:"$LOG4J_CONF" -jar /opt/libs/zu.jar sync $ZKURL $ZNODE_PATH`
-echo "CLUSTER_SIZE=$CLUSTERSIZE, MyId=$MYID"
-if [[ -n "$CLUSTERSIZE" && "$CLUSTERSIZE" -lt "$MYID" ]]; then
-  # If ClusterSize < MyId, this server is being permanantly removed.
-  java -Dlog4j.configuration=file:"$LOG4J_CONF" -jar /opt/libs/zu.jar remove $ZKURL $MYID
-  echo $?
-fi
+# The operator removes the departing members from the ensemble before their
+# pods are deleted, there is nothing to reconfigure here
 
 # Kill the primary process ourselves to circumvent the terminationGracePeriodSeconds
 ps -ef | grep zoo.cfg | grep -v grep | awk '{print $2}' | xargs kill
@@ -12,6 +12,8 @@ package io.pravega.zookeeper
 
 import org.apache.zookeeper.data.Stat
 import org.apache.zookeeper.AsyncCallback.VoidCallback
+import org.apache.zookeeper.server.persistence.FileTxnLog
+import org.apache.zookeeper.server.persistence.Util
 import java.io.File
 
 const val OBSERVER = "observer"
@@ -21,7 +23,7 @@ const val PARTICIPANT= "participant"
  * Utility to Register a server with the Zookeeper Ensemble
  */
 fun main(args: Array<String>) {
-    val message = "Usage: zu <add | get-all | get | remove | get-role | sync> [options...]"
+    val message = "Usage: zu <add | get-all | get | remove | get-role | sync | digest | last-zxid> [options...]"
     if (args.isEmpty()) {
         help(message)
     }
@@ -33,6 +35,8 @@ fun main(args: Array<String>) {
         "remove" == args[0] -> runRemove(args)
         "get-role" == args[0] -> runGetRole(args)
         "sync" == args[0] -> runSync(args)
+        "digest" == args[0] -> runDigest()
+        "last-zxid" == args[0] -> runLastZxid(args)
         else -> help(message)
     }
 }
@@ -59,6 +63,37 @@ fun runSync(args: Array<String>, suppressOutput: Boolean = false): String {
     }
 }
 
+/**
+ * Prints the super digest of the super user from the environment
+ */
+fun runDigest() {
+    val digest = superDigest()
+    if (digest == null) {
+        help("Usage: $SUPER_USER_ENV=<user> $SUPER_PASSWORD_ENV=<password> zu digest")
+    }
+    print(digest)
+}
+
+/**
+ * Prints the last transaction held by the snapshots and the transaction logs
+ * of a data directory
+ */
+fun runLastZxid(args: Array<String>) {
+    if (args.size != 2) {
+        help("Usage: zu last-zxid <data-dir>")
+    }
+    val dir = File(args[1])
+    val snapshotZxid = dir.listFiles()
+            ?.filter { it.name.startsWith("snapshot.") }
+            ?.map { Util.getZxidFromName(it.name, "snapshot") }
+            ?.maxOrNull() ?: -1
+    val zxid = maxOf(FileTxnLog(dir).lastLoggedZxid, snapshotZxid)
+    if (zxid < 0) {
+        help("No transaction in $dir")
+    }
+    print("0x" + java.lang.Long.toHexString(zxid))
+}
+
 fun runGetAll(args: Array<String>, suppressOutput: Boolean = false): String {
     if (args.size != 2) {
         help("Usage: zu get-all <zk-url>")
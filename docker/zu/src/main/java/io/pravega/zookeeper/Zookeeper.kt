@@ -14,10 +14,33 @@ import org.apache.zookeeper.WatchedEvent
 import org.apache.zookeeper.Watcher
 import org.apache.zookeeper.ZooKeeper
 import org.apache.zookeeper.admin.ZooKeeperAdmin
+import org.apache.zookeeper.server.auth.DigestAuthenticationProvider
 import java.util.concurrent.CompletableFuture
 import java.util.concurrent.TimeUnit
 
 const val ZK_CONNECTION_TIMEOUT_MINS: Long  = 3
+const val SUPER_USER_ENV = "ZK_SUPER_USER"
+const val SUPER_PASSWORD_ENV = "ZK_SUPER_PASSWORD"
+
+/**
+ * Returns the digest credentials of the super user, if the ensemble enforces ACLs
+ */
+fun superUserCredentials() : String? {
+    val user = System.getenv(SUPER_USER_ENV)
+    val password = System.getenv(SUPER_PASSWORD_ENV)
+    if (user.isNullOrEmpty() || password == null) {
+        return null
+    }
+    return "$user:$password"
+}
+
+/**
+ * Returns the super digest of the super user, as expected by the
+ * zookeeper.DigestAuthenticationProvider.superDigest property
+ */
+fun superDigest() : String? {
+    return superUserCredentials()?.let { DigestAuthenticationProvider.generateDigest(it) }
+}
 
 /**
  * Creates a new Zookeeper Admin client and waits until it's in a connected state
@@ -27,6 +50,8 @@ fun newZookeeperAdminClient(zkUrl: String) : ZooKeeperAdmin {
 
     val connectionWatcher = ConnectionWatcher()
     val zk = ZooKeeperAdmin(zkUrl, 3000, connectionWatcher)
+    // the super user may reconfigure the ensemble and read every znode
+    superUserCredentials()?.let { zk.addAuthInfo("digest", it.toByteArray()) }
 
     connectionWatcher.waitUntilConnected()
 
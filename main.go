@@ -41,7 +41,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
-	api "github.com/pravega/zookeeper-operator/api/v1beta1"
+	api "github.com/pravega/zookeeper-operator/api/v1"
+	apiv1beta1 "github.com/pravega/zookeeper-operator/api/v1beta1"
 	"github.com/pravega/zookeeper-operator/controllers"
 	// +kubebuilder:scaffold:imports
 )
@@ -49,6 +50,7 @@ import (
 var (
 	log         = ctrl.Log.WithName("cmd")
 	versionFlag bool
+	webhookFlag bool
 	scheme      = apimachineryruntime.NewScheme()
 )
 
@@ -56,8 +58,12 @@ func init() {
 	flag.BoolVar(&versionFlag, "version", false, "Show version and quit")
 	flag.BoolVar(&zkConfig.DisableFinalizer, "disableFinalizer", false,
 		"Disable finalizers for zookeeperclusters. Use this flag with awareness of the consequences")
+	flag.BoolVar(&webhookFlag, "webhook", false,
+		"Enable the admission and conversion webhooks for zookeeperclusters. The serving certificate must be mounted in the operator pod. "+
+			"They are required when zookeeperclusters are served in another version than v1")
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(api.AddToScheme(scheme))
+	utilruntime.Must(apiv1beta1.AddToScheme(scheme))
 }
 
 func printVersion() {
@@ -176,6 +182,29 @@ func main() {
 		log.Error(err, "unable to create controller", "controller", "ZookeeperCluster")
 		os.Exit(1)
 	}
+	if err = (&controllers.ZookeeperBackupReconciler{
+		Client:   mgr.GetClient(),
+		Log:      ctrl.Log.WithName("controllers").WithName("ZookeeperBackup"),
+		Scheme:   mgr.GetScheme(),
+		ZkClient: new(zkClient.DefaultZookeeperClient),
+	}).SetupWithManager(mgr); err != nil {
+		log.Error(err, "unable to create controller", "controller", "ZookeeperBackup")
+		os.Exit(1)
+	}
+	if err = (&controllers.ZookeeperBackupScheduleReconciler{
+		Client: mgr.GetClient(),
+		Log:    ctrl.Log.WithName("controllers").WithName("ZookeeperBackupSchedule"),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		log.Error(err, "unable to create controller", "controller", "ZookeeperBackupSchedule")
+		os.Exit(1)
+	}
+	if webhookFlag {
+		if err = (&api.ZookeeperCluster{}).SetupWebhookWithManager(mgr); err != nil {
+			log.Error(err, "unable to create webhook", "webhook", "ZookeeperCluster")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	log.Info("starting manager")
@@ -11,7 +11,7 @@
 package e2eutil
 
 import (
-	api "github.com/pravega/zookeeper-operator/api/v1beta1"
+	api "github.com/pravega/zookeeper-operator/api/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -21,7 +21,7 @@ func NewDefaultCluster(namespace string) *api.ZookeeperCluster {
 	return &api.ZookeeperCluster{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "ZookeeperCluster",
-			APIVersion: "zookeeper.pravega.io/v1beta1",
+			APIVersion: "zookeeper.pravega.io/v1",
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "zookeeper",
@@ -44,7 +44,9 @@ func NewClusterWithVersion(namespace, version string) *api.ZookeeperCluster {
 func NewClusterWithEmptyDir(namespace string) *api.ZookeeperCluster {
 	cluster := NewDefaultCluster(namespace)
 	cluster.Spec = api.ZookeeperClusterSpec{
-		StorageType: "ephemeral",
+		Storage: api.Storage{
+			Ephemeral: &api.Ephemeral{},
+		},
 	}
 	return cluster
 }
@@ -0,0 +1,65 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package utils
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cron Schedule", func() {
+	// a thursday
+	start := time.Date(2024, time.February, 29, 10, 30, 15, 0, time.UTC)
+
+	next := func(expr string, t time.Time) time.Time {
+		s, err := ParseCronSchedule(expr)
+		Ω(err).To(BeNil())
+		return s.Next(t)
+	}
+
+	It("should return the next minute", func() {
+		Ω(next("* * * * *", start)).To(Equal(time.Date(2024, time.February, 29, 10, 31, 0, 0, time.UTC)))
+	})
+
+	It("should step through the hour", func() {
+		Ω(next("*/20 * * * *", start)).To(Equal(time.Date(2024, time.February, 29, 10, 40, 0, 0, time.UTC)))
+		Ω(next("15-45/15 * * * *", start)).To(Equal(time.Date(2024, time.February, 29, 10, 45, 0, 0, time.UTC)))
+	})
+
+	It("should roll over to the next day", func() {
+		Ω(next("0 3 * * *", start)).To(Equal(time.Date(2024, time.March, 1, 3, 0, 0, 0, time.UTC)))
+		Ω(next("@daily", start)).To(Equal(time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("should match names of months and days", func() {
+		Ω(next("0 0 1 jan *", start)).To(Equal(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)))
+		Ω(next("0 0 * * sat,sun", start)).To(Equal(time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC)))
+		Ω(next("0 0 * * 7", start)).To(Equal(time.Date(2024, time.March, 3, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("should match either day field when both are restricted", func() {
+		Ω(next("0 0 15 * mon", start)).To(Equal(time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)))
+	})
+
+	It("should skip months without the day", func() {
+		Ω(next("0 0 31 * *", start)).To(Equal(time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)))
+		Ω(next("0 0 30 2 *", start)).To(BeZero())
+	})
+
+	It("should reject invalid expressions", func() {
+		for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+			_, err := ParseCronSchedule(expr)
+			Ω(err).NotTo(BeNil(), expr)
+		}
+	})
+})
@@ -16,7 +16,8 @@ import (
 )
 
 const (
-	ZkFinalizer = "cleanUpZookeeperPVC"
+	ZkFinalizer     = "cleanUpZookeeperPVC"
+	BackupFinalizer = "cleanUpZookeeperBackupArtifact"
 )
 
 func ContainsString(slice []string, str string) bool {
@@ -14,7 +14,7 @@ import (
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	"github.com/pravega/zookeeper-operator/api/v1beta1"
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
 	"github.com/pravega/zookeeper-operator/pkg/zk"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -24,7 +24,7 @@ var _ = Describe("Zookeeper Test_Utils", func() {
 		var port string
 		var err string
 		BeforeEach(func() {
-			z := &v1beta1.ZookeeperCluster{
+			z := &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "example",
 					Namespace: "default",
@@ -12,9 +12,10 @@ package utils
 
 import (
 	"fmt"
+	"net"
 	"strconv"
 
-	v1beta1 "github.com/pravega/zookeeper-operator/api/v1beta1"
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -23,13 +24,53 @@ const (
 	ZKMetaRoot = "/zookeeper-operator"
 )
 
-func GetZkServiceUri(zoo *v1beta1.ZookeeperCluster) (zkUri string) {
-	zkClientPort, _ := ContainerPortByName(zoo.Spec.Ports, "client")
-	zkUri = zoo.GetClientServiceName() + "." + zoo.GetNamespace() + ".svc." + zoo.GetKubernetesClusterDomain() + ":" + strconv.Itoa(int(zkClientPort))
+func GetZkServiceUri(zoo *zookeeperv1.ZookeeperCluster) (zkUri string) {
+	zkClientPort := zoo.Spec.Ports.Client
+	zkUri = GetZkServiceHost(zoo) + ":" + strconv.Itoa(int(zkClientPort))
 	return zkUri
 }
 
-func GetMetaPath(zoo *v1beta1.ZookeeperCluster) (path string) {
+// GetZkSecureServiceUri returns the address TLS clients connect to
+func GetZkSecureServiceUri(zoo *zookeeperv1.ZookeeperCluster) (zkUri string) {
+	return GetZkServiceHost(zoo) + ":" + strconv.Itoa(int(zoo.Spec.Ports.SecureClient))
+}
+
+// GetZkServiceHost returns the DNS name of the client service, which the
+// certificate of the members has to be valid for
+func GetZkServiceHost(zoo *zookeeperv1.ZookeeperCluster) string {
+	return zoo.GetClientServiceName() + "." + zoo.GetNamespace() + ".svc." + zoo.GetKubernetesClusterDomain()
+}
+
+// GetMemberUri returns the client address of a single zookeeper member, on
+// the secure client port when the members serve clients over TLS. The pod IP
+// is used since unready members are not published by the headless service.
+func GetMemberUri(zoo *zookeeperv1.ZookeeperCluster, pod *corev1.Pod) (memberUri string, err error) {
+	if pod.Status.PodIP == "" {
+		return "", fmt.Errorf("pod %s has no IP address yet", pod.Name)
+	}
+	port := zoo.Spec.Ports.Client
+	if zoo.Spec.TLS.ClientEnabled() {
+		port = zoo.Spec.Ports.SecureClient
+	}
+	return net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(port))), nil
+}
+
+// GetMemberHost returns the DNS name of a single zookeeper member, which the
+// certificate of the members has to be valid for
+func GetMemberHost(zoo *zookeeperv1.ZookeeperCluster, pod *corev1.Pod) string {
+	return pod.Name + "." + zoo.GetName() + "-headless." + zoo.GetNamespace() + ".svc." + zoo.GetKubernetesClusterDomain()
+}
+
+// GetMemberMetricsUri returns the address of the Prometheus metrics of a
+// single zookeeper member
+func GetMemberMetricsUri(zoo *zookeeperv1.ZookeeperCluster, pod *corev1.Pod) (metricsUri string, err error) {
+	if pod.Status.PodIP == "" {
+		return "", fmt.Errorf("pod %s has no IP address yet", pod.Name)
+	}
+	return net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(zoo.Spec.Ports.Metrics))), nil
+}
+
+func GetMetaPath(zoo *zookeeperv1.ZookeeperCluster) (path string) {
 	return fmt.Sprintf("%s/%s", ZKMetaRoot, zoo.Name)
 }
 
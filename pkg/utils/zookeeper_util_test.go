@@ -10,7 +10,8 @@
 package utils
 
 import (
-	"github.com/pravega/zookeeper-operator/api/v1beta1"
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	. "github.com/onsi/ginkgo"
@@ -22,7 +23,7 @@ var _ = Describe("Zookeeper Utils", func() {
 	Context("with defaults", func() {
 		var zkuri, path, containerport string
 		BeforeEach(func() {
-			z := &v1beta1.ZookeeperCluster{
+			z := &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "example",
 					Namespace: "default",
@@ -31,7 +32,7 @@ var _ = Describe("Zookeeper Utils", func() {
 			z.WithDefaults()
 			zkuri = GetZkServiceUri(z)
 			path = GetMetaPath(z)
-			_, err := ContainerPortByName(z.Spec.Ports, "cl")
+			_, err := ContainerPortByName(z.Spec.Ports.ContainerPorts(), "cl")
 			if err != nil {
 				containerport = err.Error()
 			}
@@ -46,4 +47,59 @@ var _ = Describe("Zookeeper Utils", func() {
 			Ω(containerport).To(Equal("port not found"))
 		})
 	})
+
+	Context("with client TLS", func() {
+		It("should set the secure zkuri", func() {
+			z := &zookeeperv1.ZookeeperCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "default",
+				},
+				Spec: zookeeperv1.ZookeeperClusterSpec{
+					TLS: &zookeeperv1.TLSPolicy{
+						Client: &zookeeperv1.ClientTLS{TLSSecret: zookeeperv1.TLSSecret{SecretName: "example-tls"}},
+					},
+				},
+			}
+			z.WithDefaults()
+			Ω(GetZkSecureServiceUri(z)).To(Equal("example-client.default.svc.cluster.local:2281"))
+		})
+	})
+
+	Context("#GetMemberUri", func() {
+		var z *zookeeperv1.ZookeeperCluster
+		BeforeEach(func() {
+			z = &zookeeperv1.ZookeeperCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "default",
+				},
+			}
+			z.WithDefaults()
+		})
+		It("should use the pod IP and the client port", func() {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "example-0"}}
+			pod.Status.PodIP = "10.0.0.1"
+			uri, err := GetMemberUri(z, pod)
+			Ω(err).To(BeNil())
+			Ω(uri).To(Equal("10.0.0.1:2181"))
+		})
+		It("should fail for a pod without an IP", func() {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "example-0"}}
+			_, err := GetMemberUri(z, pod)
+			Ω(err).NotTo(BeNil())
+		})
+	})
+
+	Context("#GetMemberMetricsUri", func() {
+		It("should use the pod IP and the metrics port", func() {
+			z := &zookeeperv1.ZookeeperCluster{ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"}}
+			z.WithDefaults()
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "example-0"}}
+			pod.Status.PodIP = "10.0.0.1"
+			uri, err := GetMemberMetricsUri(z, pod)
+			Ω(err).To(BeNil())
+			Ω(uri).To(Equal("10.0.0.1:7000"))
+		})
+	})
 })
@@ -14,7 +14,7 @@ import (
 	"os"
 	"testing"
 
-	"github.com/pravega/zookeeper-operator/api/v1beta1"
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	. "github.com/onsi/ginkgo"
@@ -30,7 +30,7 @@ var _ = Describe("ZookeeperCluster yamlExporter", func() {
 	Context("with defaults", func() {
 		var err, err2, err3, err4 error
 		BeforeEach(func() {
-			z1 := &v1beta1.ZookeeperCluster{
+			z1 := &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "example",
 					Namespace: "default",
@@ -57,4 +57,32 @@ var _ = Describe("ZookeeperCluster yamlExporter", func() {
 			Ω(err4).To(BeNil())
 		})
 	})
+
+	Context("with a v1beta1 resource", func() {
+		var z *zookeeperv1.ZookeeperCluster
+		var err error
+		BeforeEach(func() {
+			_ = CreateYAMLOutputDir("test")
+			_ = os.WriteFile("test/zk.yaml", []byte(`apiVersion: zookeeper.pravega.io/v1beta1
+kind: ZookeeperCluster
+metadata:
+  name: example
+spec:
+  replicas: 3
+  storageType: ephemeral
+  ports:
+  - name: client
+    containerPort: 12181
+`), 0644)
+			z, err = ReadInputClusterYAMLFile("test/zk.yaml")
+			_ = os.RemoveAll("test")
+		})
+		It("should convert it to v1", func() {
+			Ω(err).To(BeNil())
+			Ω(z.APIVersion).To(Equal("zookeeper.pravega.io/v1"))
+			Ω(z.Spec.Replicas).To(BeEquivalentTo(3))
+			Ω(z.Spec.Ports.Client).To(BeEquivalentTo(12181))
+			Ω(z.Spec.Storage.IsEphemeral()).To(BeTrue())
+		})
+	})
 })
@@ -17,7 +17,9 @@ import (
 
 	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
 	"github.com/pravega/zookeeper-operator/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // YAMLOutputDir where the zookeeper YAML resources will get generated
@@ -71,16 +73,35 @@ func CreateYAMLOutputDir(maindir string) error {
 	return nil
 }
 
-// ReadInputClusterYAMLFile will read input YAML file and returns Go struct for ZookeeperCluster
-func ReadInputClusterYAMLFile(inyamlfile string) (*v1beta1.ZookeeperCluster, error) {
+// ReadInputClusterYAMLFile will read input YAML file and returns Go struct for ZookeeperCluster.
+// Resources written against v1beta1 are converted to v1.
+func ReadInputClusterYAMLFile(inyamlfile string) (*zookeeperv1.ZookeeperCluster, error) {
 	if _, err := os.Stat(inyamlfile); os.IsNotExist(err) {
 		return nil, err
 	}
-	var z v1beta1.ZookeeperCluster
 	source, err := os.ReadFile(inyamlfile)
 	if err != nil {
 		return nil, err
 	}
+	var typeMeta metav1.TypeMeta
+	err = yaml.Unmarshal(source, &typeMeta)
+	if err != nil {
+		return nil, err
+	}
+	var z zookeeperv1.ZookeeperCluster
+	if typeMeta.APIVersion == v1beta1.GroupVersion.String() {
+		var old v1beta1.ZookeeperCluster
+		err = yaml.Unmarshal(source, &old)
+		if err != nil {
+			return nil, err
+		}
+		err = old.ConvertTo(&z)
+		if err != nil {
+			return nil, err
+		}
+		z.TypeMeta = metav1.TypeMeta{APIVersion: zookeeperv1.GroupVersion.String(), Kind: typeMeta.Kind}
+		return &z, nil
+	}
 	err = yaml.Unmarshal(source, &z)
 	if err != nil {
 		return nil, err
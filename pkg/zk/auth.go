@@ -0,0 +1,226 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package zk
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
+)
+
+const (
+	jaasVolume       = "jaas"
+	jaasPath         = "/auth/jaas"
+	jaasFile         = jaasPath + "/jaas.conf"
+	digestUserVolume = "digest-users"
+	digestUserPath   = "/auth/digest"
+	keytabVolume     = "keytab"
+	keytabPath       = "/auth/kerberos"
+	krb5Volume       = "krb5"
+	krb5Path         = "/auth/krb5"
+
+	superUserEnv     = "ZK_SUPER_USER"
+	superPasswordEnv = "ZK_SUPER_PASSWORD"
+
+	digestLoginModule = "org.apache.zookeeper.server.auth.DigestLoginModule"
+	krb5LoginModule   = "com.sun.security.auth.module.Krb5LoginModule"
+)
+
+// addAuthTo mounts the key material of the SASL authentication into the
+// zookeeper container. The JAAS file holds the passwords of the digest users,
+// so an init container writes it from the Secret into a memory volume rather
+// than the config map.
+func addAuthTo(z *zookeeperv1.ZookeeperCluster, zkContainer *v1.Container, volumes []v1.Volume) ([]v1.Volume, *v1.Container) {
+	auth := z.Spec.Auth
+	volumes = append(volumes, v1.Volume{
+		Name: jaasVolume,
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{Medium: v1.StorageMediumMemory},
+		},
+	})
+	zkContainer.VolumeMounts = append(zkContainer.VolumeMounts, v1.VolumeMount{
+		Name:      jaasVolume,
+		MountPath: jaasPath,
+		ReadOnly:  true,
+	})
+	initContainer := &v1.Container{
+		Name:            jaasVolume,
+		Image:           z.Spec.Image.ToString(),
+		ImagePullPolicy: z.Spec.Image.PullPolicy,
+		Command:         []string{"bash", "-c", makeJaasScript(z)},
+		VolumeMounts:    []v1.VolumeMount{{Name: jaasVolume, MountPath: jaasPath}},
+	}
+
+	if auth.Digest != nil {
+		volumes = append(volumes, v1.Volume{
+			Name: digestUserVolume,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{SecretName: auth.Digest.SecretName},
+			},
+		})
+		initContainer.VolumeMounts = append(initContainer.VolumeMounts, v1.VolumeMount{
+			Name:      digestUserVolume,
+			MountPath: digestUserPath,
+			ReadOnly:  true,
+		})
+	}
+	if k := auth.Kerberos; k != nil {
+		volumes = append(volumes, v1.Volume{
+			Name: keytabVolume,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{SecretName: k.KeytabSecretName},
+			},
+		})
+		zkContainer.VolumeMounts = append(zkContainer.VolumeMounts, v1.VolumeMount{
+			Name:      keytabVolume,
+			MountPath: keytabPath,
+			ReadOnly:  true,
+		})
+		if k.Krb5ConfigMapName != "" {
+			volumes = append(volumes, v1.Volume{
+				Name: krb5Volume,
+				VolumeSource: v1.VolumeSource{
+					ConfigMap: &v1.ConfigMapVolumeSource{
+						LocalObjectReference: v1.LocalObjectReference{Name: k.Krb5ConfigMapName},
+					},
+				},
+			})
+			zkContainer.VolumeMounts = append(zkContainer.VolumeMounts, v1.VolumeMount{
+				Name:      krb5Volume,
+				MountPath: krb5Path,
+				ReadOnly:  true,
+			})
+		}
+	}
+	return volumes, initContainer
+}
+
+// makeJaasScript returns the script which writes the JAAS file of a member.
+// The Server section authenticates the clients, the Client section is used
+// by the scripts of the image to register the member with the ensemble, and
+// the Quorum sections authenticate the members to each other.
+func makeJaasScript(z *zookeeperv1.ZookeeperCluster) string {
+	auth := z.Spec.Auth
+	script := "set -e\n" +
+		"HOST=$(hostname -s)." + headlessDomain(z) + "\n"
+
+	var serverModules, memberLogin, quorumServerLogin string
+	if d := auth.Digest; d != nil {
+		script += "MEMBER_PASSWORD=$(cat " + digestUserPath + "/" + d.MemberUser + ")\n" +
+			"DIGEST_USERS=$(for user in $(ls " + digestUserPath + "); do " +
+			"printf '    user_%s=\"%s\"\\n' \"$user\" \"$(cat " + digestUserPath + "/$user)\"; done)\n"
+		serverModules += "  " + digestLoginModule + " required\n$DIGEST_USERS;\n"
+		memberLogin = "  " + digestLoginModule + " required\n" +
+			"    username=\"" + d.MemberUser + "\"\n" +
+			"    password=\"$MEMBER_PASSWORD\";\n"
+		quorumServerLogin = "  " + digestLoginModule + " required\n" +
+			"    user_" + d.MemberUser + "=\"$MEMBER_PASSWORD\";\n"
+	}
+	if k := auth.Kerberos; k != nil {
+		script += "PRINCIPAL=$(echo '" + k.Principal + "' | sed \"s/" + zookeeperv1.KerberosHostPlaceholder + "/$HOST/\")\n"
+		krb5Login := "  " + krb5LoginModule + " required\n" +
+			"    useKeyTab=true\n" +
+			"    keyTab=\"" + keytabPath + "/" + k.KeytabKey + "\"\n" +
+			"    storeKey=true\n" +
+			"    useTicketCache=false\n" +
+			"    principal=\"$PRINCIPAL\";\n"
+		serverModules += krb5Login
+		// the members prefer their Kerberos identity over their digest user
+		memberLogin = krb5Login
+		quorumServerLogin = krb5Login
+	}
+
+	jaas := jaasSection("Server", serverModules) + jaasSection("Client", memberLogin)
+	if auth.Quorum {
+		jaas += jaasSection("QuorumServer", quorumServerLogin) + jaasSection("QuorumLearner", memberLogin)
+	}
+	return script + "cat > " + jaasFile + " <<EOF\n" + jaas + "EOF\n"
+}
+
+func jaasSection(name string, modules string) string {
+	return name + " {\n" + modules + "};\n"
+}
+
+// makeZkAuthConfigString returns the SASL properties of zoo.cfg
+func makeZkAuthConfigString(z *zookeeperv1.ZookeeperCluster) string {
+	auth := z.Spec.Auth
+	if !auth.Enabled() {
+		return ""
+	}
+	config := "authProvider.sasl=org.apache.zookeeper.server.auth.SASLAuthenticationProvider\n"
+	if auth.RequireClientAuth {
+		schemes := []string{"sasl"}
+		// the operator authenticates with digest credentials, since its
+		// client does not implement SASL
+		if p := z.Spec.OperatorClient; z.Spec.ACL.Enforced() || (p != nil && p.Auth != nil) {
+			schemes = append(schemes, zookeeperv1.DigestAuthScheme)
+		}
+		config += "requireClientAuthScheme=sasl\n" +
+			"enforce.auth.enabled=true\n" +
+			"enforce.auth.schemes=" + strings.Join(schemes, ",") + "\n"
+	}
+	if auth.Quorum {
+		config += "quorum.auth.enableSasl=true\n" +
+			"quorum.auth.learnerRequireSasl=true\n" +
+			"quorum.auth.serverRequireSasl=true\n" +
+			"quorum.auth.learner.saslLoginContext=QuorumLearner\n" +
+			"quorum.auth.server.saslLoginContext=QuorumServer\n"
+		if auth.Kerberos != nil {
+			config += "quorum.auth.kerberos.servicePrincipal=" + auth.Kerberos.Principal + "\n"
+		}
+	}
+	return config
+}
+
+// makeZkAuthEnvString returns the part of env.sh which points the zookeeper
+// server, and the clients run by the scripts of the image, to the JAAS file
+func makeZkAuthEnvString(z *zookeeperv1.ZookeeperCluster) string {
+	if !z.Spec.Auth.Enabled() {
+		return ""
+	}
+	flags := "-Djava.security.auth.login.config=" + jaasFile
+	if k := z.Spec.Auth.Kerberos; k != nil && k.Krb5ConfigMapName != "" {
+		flags += " -Djava.security.krb5.conf=" + krb5Path + "/" + zookeeperv1.Krb5ConfKey
+	}
+	return "AUTH_JVMFLAGS=\"" + flags + "\"\n" +
+		"export SERVER_JVMFLAGS=\"$AUTH_JVMFLAGS $SERVER_JVMFLAGS\"\n" +
+		"export CLIENT_JVMFLAGS=\"$AUTH_JVMFLAGS $CLIENT_JVMFLAGS\"\n"
+}
+
+// addSuperUserTo hands the credentials of the super user to the zookeeper
+// container. The start script derives the super digest of the server from
+// them, and the scripts of the image authenticate as the super user, which
+// may reconfigure the ensemble and read the metadata of the operator.
+func addSuperUserTo(z *zookeeperv1.ZookeeperCluster, zkContainer *v1.Container) {
+	for _, env := range []struct{ name, key string }{
+		{superUserEnv, zookeeperv1.DigestUsernameKey},
+		{superPasswordEnv, zookeeperv1.DigestPasswordKey},
+	} {
+		zkContainer.Env = append(zkContainer.Env, v1.EnvVar{
+			Name: env.name,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: z.Spec.ACL.SuperUserSecretName},
+					Key:                  env.key,
+				},
+			},
+		})
+	}
+}
+
+func skipACL(z *zookeeperv1.ZookeeperCluster) string {
+	if z.Spec.ACL.Enforced() {
+		return "no"
+	}
+	return "yes"
+}
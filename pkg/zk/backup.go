@@ -0,0 +1,269 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package zk
+
+import (
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
+)
+
+const (
+	// BackupLabel carries the name of the backup on its job and pods
+	BackupLabel = "zookeeper.pravega.io/backup"
+	// BackupScheduleLabel carries the name of the schedule on its backups
+	BackupScheduleLabel = "zookeeper.pravega.io/backup-schedule"
+	// PreUpgradeBackupLabel marks the backups taken before an upgrade
+	PreUpgradeBackupLabel = "zookeeper.pravega.io/pre-upgrade"
+
+	backupVolume  = "backup"
+	backupPath    = "/backup"
+	restoreVolume = "restore"
+)
+
+// MakeBackupJob returns the job which archives the snapshots and transaction
+// logs of a member into the storage of the backup. The data volume of the
+// member can only be mounted on its node, so the job runs next to it.
+func MakeBackupJob(b *zookeeperv1.ZookeeperBackup, z *zookeeperv1.ZookeeperCluster, member string) *batchv1.Job {
+	container := makeBackupContainer(b, z)
+	container.VolumeMounts = append(container.VolumeMounts,
+		v1.VolumeMount{Name: zkDataVolume, MountPath: "/data", ReadOnly: true})
+	volumes := []v1.Volume{{
+		Name: zkDataVolume,
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+				ClaimName: zkDataVolume + "-" + member,
+				ReadOnly:  true,
+			},
+		},
+	}}
+	if b.Spec.Storage.S3 != nil {
+		// the archive is staged on the node before it is uploaded
+		volumes = append(volumes, v1.Volume{
+			Name:         backupVolume,
+			VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+		})
+	} else {
+		volumes = append(volumes, makeBackupClaimVolume(b))
+	}
+
+	podSpec := makeBackupPodSpec(z, container, volumes)
+	podSpec.Affinity = &v1.Affinity{
+		PodAffinity: &v1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{{
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"statefulset.kubernetes.io/pod-name": member},
+				},
+				TopologyKey: "kubernetes.io/hostname",
+			}},
+		},
+	}
+	return makeBackupJob(b, b.GetName(), podSpec)
+}
+
+// MakeBackupDeleteJob returns the job which deletes the artifact of the
+// backup from its storage
+func MakeBackupDeleteJob(b *zookeeperv1.ZookeeperBackup, z *zookeeperv1.ZookeeperCluster) *batchv1.Job {
+	container := makeBackupContainer(b, z)
+	container.Args = []string{"delete"}
+	volumes := []v1.Volume{}
+	if b.Spec.Storage.S3 == nil {
+		volumes = append(volumes, makeBackupClaimVolume(b))
+	} else {
+		container.VolumeMounts = nil
+	}
+	return makeBackupJob(b, b.GetName()+"-delete", makeBackupPodSpec(z, container, volumes))
+}
+
+// MakeScheduledBackup returns the backup the schedule takes at the given
+// time. Its name is made of the name of the schedule and the time, in UTC.
+func MakeScheduledBackup(s *zookeeperv1.ZookeeperBackupSchedule, scheduledTime time.Time) *zookeeperv1.ZookeeperBackup {
+	return &zookeeperv1.ZookeeperBackup{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ZookeeperBackup",
+			APIVersion: zookeeperv1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.GetName() + "-" + scheduledTime.UTC().Format("20060102-1504"),
+			Namespace: s.Namespace,
+			Labels: map[string]string{
+				"app":               s.Spec.Backup.ZookeeperCluster,
+				BackupScheduleLabel: s.GetName(),
+			},
+		},
+		Spec: *s.Spec.Backup.DeepCopy(),
+	}
+}
+
+// MakePreUpgradeBackup returns the backup taken before the members are
+// upgraded to the image of the cluster. Its name is made of the name of the
+// cluster and the generation which asked for the upgrade.
+func MakePreUpgradeBackup(z *zookeeperv1.ZookeeperCluster) *zookeeperv1.ZookeeperBackup {
+	backup := z.Spec.UpgradeStrategy.BacksUp()
+	return &zookeeperv1.ZookeeperBackup{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ZookeeperBackup",
+			APIVersion: zookeeperv1.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-pre-upgrade-%d", z.GetName(), z.GetGeneration()),
+			Namespace: z.Namespace,
+			Labels: map[string]string{
+				"app":                 z.GetName(),
+				PreUpgradeBackupLabel: "true",
+			},
+		},
+		Spec: zookeeperv1.ZookeeperBackupSpec{
+			ZookeeperCluster: z.GetName(),
+			Storage:          *backup.Storage.DeepCopy(),
+			ReclaimPolicy:    backup.ReclaimPolicy,
+		},
+	}
+}
+
+// makeBackupContainer returns the container which runs the backup script
+// against the storage of the backup
+func makeBackupContainer(b *zookeeperv1.ZookeeperBackup, z *zookeeperv1.ZookeeperCluster) v1.Container {
+	container := v1.Container{
+		Name:                     "backup",
+		Image:                    z.Spec.Image.ToString(),
+		ImagePullPolicy:          z.Spec.Image.PullPolicy,
+		Command:                  []string{"/usr/local/bin/zookeeperBackup.sh"},
+		TerminationMessagePolicy: v1.TerminationMessageFallbackToLogsOnError,
+		Env: []v1.EnvVar{
+			{Name: "BACKUP_DIR", Value: backupPath},
+			{Name: "BACKUP_PATH", Value: b.ArtifactPath()},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{Name: backupVolume, MountPath: backupPath},
+		},
+	}
+	if s3 := b.Spec.Storage.S3; s3 != nil {
+		container.Env = append(container.Env, makeS3Env(s3)...)
+	}
+	return container
+}
+
+// makeBackupClaimVolume returns the volume of the claim the artifacts of the
+// backup are kept on
+func makeBackupClaimVolume(b *zookeeperv1.ZookeeperBackup) v1.Volume {
+	return v1.Volume{
+		Name: backupVolume,
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{
+				ClaimName: b.Spec.Storage.PersistentVolumeClaim.ClaimName,
+			},
+		},
+	}
+}
+
+func makeBackupPodSpec(z *zookeeperv1.ZookeeperCluster, container v1.Container, volumes []v1.Volume) v1.PodSpec {
+	return v1.PodSpec{
+		Containers:    []v1.Container{container},
+		RestartPolicy: v1.RestartPolicyNever,
+		Volumes:       volumes,
+		// the job has to be scheduled wherever the members could be
+		NodeSelector:     z.Spec.Pod.NodeSelector,
+		Tolerations:      z.Spec.Pod.Tolerations,
+		SecurityContext:  z.Spec.Pod.SecurityContext,
+		ImagePullSecrets: z.Spec.Pod.ImagePullSecrets,
+	}
+}
+
+func makeBackupJob(b *zookeeperv1.ZookeeperBackup, name string, podSpec v1.PodSpec) *batchv1.Job {
+	labels := map[string]string{
+		"app":       b.Spec.ZookeeperCluster,
+		BackupLabel: b.GetName(),
+	}
+	backoffLimit := int32(0)
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Job",
+			APIVersion: "batch/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: b.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       podSpec,
+			},
+		},
+	}
+}
+
+// addRestoreTo returns the init container which restores the artifact of
+// spec.restoreFrom into the data volume of a new member, along with the
+// volume the artifact is read from
+func addRestoreTo(z *zookeeperv1.ZookeeperCluster, volumes []v1.Volume) ([]v1.Volume, *v1.Container) {
+	restore := z.Spec.RestoreFrom
+	container := &v1.Container{
+		Name:                     "restore",
+		Image:                    z.Spec.Image.ToString(),
+		ImagePullPolicy:          z.Spec.Image.PullPolicy,
+		Command:                  []string{"/usr/local/bin/zookeeperRestore.sh"},
+		TerminationMessagePolicy: v1.TerminationMessageFallbackToLogsOnError,
+		Env: []v1.EnvVar{
+			{Name: "BACKUP_DIR", Value: backupPath},
+			{Name: "BACKUP_PATH", Value: restore.Path},
+			{Name: "RESTORE_CHECKSUM", Value: restore.Checksum},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{Name: zkDataVolume, MountPath: "/data"},
+		},
+	}
+	volume := v1.Volume{Name: restoreVolume}
+	if s3 := restore.Storage.S3; s3 != nil {
+		container.Env = append(container.Env, makeS3Env(s3)...)
+		// the artifact is downloaded before it is extracted
+		volume.EmptyDir = &v1.EmptyDirVolumeSource{}
+		container.VolumeMounts = append(container.VolumeMounts,
+			v1.VolumeMount{Name: restoreVolume, MountPath: backupPath})
+	} else {
+		// every member reads the artifact from the claim
+		volume.PersistentVolumeClaim = &v1.PersistentVolumeClaimVolumeSource{
+			ClaimName: restore.Storage.PersistentVolumeClaim.ClaimName,
+			ReadOnly:  true,
+		}
+		container.VolumeMounts = append(container.VolumeMounts,
+			v1.VolumeMount{Name: restoreVolume, MountPath: backupPath, ReadOnly: true})
+	}
+	return append(volumes, volume), container
+}
+
+// makeS3Env returns the environment the scripts of the image reach a bucket
+// through
+func makeS3Env(s3 *zookeeperv1.S3Storage) []v1.EnvVar {
+	secretKey := func(key string) *v1.EnvVarSource {
+		return &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{Name: s3.CredentialsSecretName},
+				Key:                  key,
+			},
+		}
+	}
+	return []v1.EnvVar{
+		{Name: "S3_ENDPOINT", Value: s3.Endpoint},
+		{Name: "S3_REGION", Value: s3.Region},
+		{Name: "S3_BUCKET", Value: s3.Bucket},
+		{Name: "AWS_ACCESS_KEY_ID", ValueFrom: secretKey(zookeeperv1.S3AccessKeyIDKey)},
+		{Name: "AWS_SECRET_ACCESS_KEY", ValueFrom: secretKey(zookeeperv1.S3SecretAccessKeyKey)},
+	}
+}
@@ -0,0 +1,142 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package zk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ZkConfigPath is the znode holding the dynamic configuration of the ensemble
+	ZkConfigPath = "/zookeeper/config"
+
+	RoleParticipant = "participant"
+	RoleObserver    = "observer"
+)
+
+// ServerConfig is the entry of a single server in the dynamic configuration
+// of the ensemble, e.g.
+// server.1=zk-0.zk-headless.default.svc.cluster.local:2888:3888:participant;2181
+type ServerConfig struct {
+	ID int
+	// Address is the server address with the quorum and leader election ports
+	Address string
+	// Role is either participant or observer
+	Role string
+	// ClientAddress is the address the server serves clients on
+	ClientAddress string
+}
+
+// String returns the entry of the server as it is written in the dynamic
+// configuration and passed to a reconfiguration
+func (s ServerConfig) String() string {
+	entry := fmt.Sprintf("server.%d=%s:%s", s.ID, s.Address, s.Role)
+	if s.ClientAddress != "" {
+		entry += ";" + s.ClientAddress
+	}
+	return entry
+}
+
+// SameClientAddress reports whether both client addresses of a server are the
+// same. A bare port listens on every interface, which is how the ensemble
+// reports it.
+func SameClientAddress(a, b string) bool {
+	normalize := func(address string) string {
+		if address != "" && !strings.Contains(address, ":") {
+			return "0.0.0.0:" + address
+		}
+		return address
+	}
+	return normalize(a) == normalize(b)
+}
+
+// EnsembleConfig is the dynamic configuration of the ensemble, which lists
+// the servers taking part in it
+type EnsembleConfig struct {
+	Servers []ServerConfig
+	Version string
+}
+
+// Participants returns the number of voting members of the ensemble
+func (c *EnsembleConfig) Participants() int32 {
+	var participants int32
+	for _, s := range c.Servers {
+		if s.Role == RoleParticipant {
+			participants++
+		}
+	}
+	return participants
+}
+
+// Observers returns the number of non-voting members of the ensemble
+func (c *EnsembleConfig) Observers() int32 {
+	return int32(len(c.Servers)) - c.Participants()
+}
+
+// Server returns the entry of the server with the id, if it is a member
+func (c *EnsembleConfig) Server(id int) *ServerConfig {
+	for i := range c.Servers {
+		if c.Servers[i].ID == id {
+			return &c.Servers[i]
+		}
+	}
+	return nil
+}
+
+// VersionNumber returns the hexadecimal version as the number a conditional
+// reconfiguration expects, or -1 to reconfigure unconditionally if the
+// config carries no version
+func (c *EnsembleConfig) VersionNumber() (int64, error) {
+	if c.Version == "" {
+		return -1, nil
+	}
+	version, err := strconv.ParseInt(c.Version, 16, 64)
+	if err != nil {
+		return -1, fmt.Errorf("Invalid ensemble config version %s: %v", c.Version, err)
+	}
+	return version, nil
+}
+
+// ParseEnsembleConfig parses the content of the dynamic configuration znode
+func ParseEnsembleConfig(data string) (*EnsembleConfig, error) {
+	config := &EnsembleConfig{}
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("Invalid line in ensemble config: %s", line)
+		}
+		if key == "version" {
+			config.Version = value
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(key, "server."))
+		if !strings.HasPrefix(key, "server.") || err != nil {
+			return nil, fmt.Errorf("Invalid server id in ensemble config: %s", key)
+		}
+		server := ServerConfig{ID: id, Role: RoleParticipant}
+		address, clientAddress, _ := strings.Cut(value, ";")
+		server.ClientAddress = clientAddress
+		// the role is the optional fourth field after the host and both ports
+		if fields := strings.Split(address, ":"); len(fields) == 4 {
+			server.Role = fields[3]
+			address = strings.Join(fields[:3], ":")
+		}
+		server.Address = address
+		config.Servers = append(config.Servers, server)
+	}
+	return config, nil
+}
@@ -0,0 +1,106 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package zk_test
+
+import (
+	"github.com/pravega/zookeeper-operator/pkg/zk"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ensemble Config", func() {
+
+	Context("#ParseEnsembleConfig", func() {
+		var (
+			config *zk.EnsembleConfig
+			err    error
+		)
+
+		BeforeEach(func() {
+			config, err = zk.ParseEnsembleConfig(
+				"server.1=example-0.example-headless.default.svc.cluster.local:2888:3888:participant;0.0.0.0:2181\n" +
+					"server.2=example-1.example-headless.default.svc.cluster.local:2888:3888:participant;2181\n" +
+					"server.3=example-2.example-headless.default.svc.cluster.local:2888:3888:observer;2181\n" +
+					"server.4=example-3.example-headless.default.svc.cluster.local:2888:3888\n" +
+					"version=200000005\n")
+		})
+
+		It("should not raise an error", func() {
+			Ω(err).Should(BeNil())
+		})
+
+		It("should read every server", func() {
+			Ω(config.Servers).Should(HaveLen(4))
+			Ω(config.Servers[0]).Should(Equal(zk.ServerConfig{
+				ID:            1,
+				Address:       "example-0.example-headless.default.svc.cluster.local:2888:3888",
+				Role:          zk.RoleParticipant,
+				ClientAddress: "0.0.0.0:2181",
+			}))
+			Ω(config.Servers[2].Role).Should(Equal(zk.RoleObserver))
+			Ω(config.Version).Should(Equal("200000005"))
+		})
+
+		It("should treat servers without a role as participants", func() {
+			Ω(config.Servers[3].Role).Should(Equal(zk.RoleParticipant))
+			Ω(config.Participants()).Should(BeEquivalentTo(3))
+		})
+
+		It("should reject lines which are not server entries", func() {
+			_, err = zk.ParseEnsembleConfig("clientPort=2181\n")
+			Ω(err).ShouldNot(BeNil())
+			_, err = zk.ParseEnsembleConfig("server.1\n")
+			Ω(err).ShouldNot(BeNil())
+		})
+	})
+
+	Context("#Server", func() {
+		var config *zk.EnsembleConfig
+
+		BeforeEach(func() {
+			var err error
+			config, err = zk.ParseEnsembleConfig(
+				"server.1=example-0.example-headless:2888:3888:participant;2181\n" +
+					"server.2=example-1.example-headless:2888:3888:observer;2181\n" +
+					"version=1a\n")
+			Ω(err).Should(BeNil())
+		})
+
+		It("should find the servers by id", func() {
+			Ω(config.Server(2).Role).Should(Equal(zk.RoleObserver))
+			Ω(config.Server(3)).Should(BeNil())
+			Ω(config.Observers()).Should(BeEquivalentTo(1))
+		})
+
+		It("should write the entries back", func() {
+			Ω(config.Server(1).String()).Should(Equal("server.1=example-0.example-headless:2888:3888:participant;2181"))
+		})
+
+		It("should read the version as hexadecimal", func() {
+			version, err := config.VersionNumber()
+			Ω(err).Should(BeNil())
+			Ω(version).Should(BeEquivalentTo(26))
+			version, err = (&zk.EnsembleConfig{}).VersionNumber()
+			Ω(err).Should(BeNil())
+			Ω(version).Should(BeEquivalentTo(-1))
+		})
+	})
+
+	Context("#SameClientAddress", func() {
+		It("should treat a bare port as listening on every interface", func() {
+			Ω(zk.SameClientAddress("2181", "0.0.0.0:2181")).Should(BeTrue())
+			Ω(zk.SameClientAddress("2181", "2181")).Should(BeTrue())
+			Ω(zk.SameClientAddress("2181", "127.0.0.1:2181")).Should(BeFalse())
+			Ω(zk.SameClientAddress("", "0.0.0.0:2181")).Should(BeFalse())
+		})
+	})
+})
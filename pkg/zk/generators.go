@@ -22,34 +22,89 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
-	"github.com/pravega/zookeeper-operator/api/v1beta1"
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
 )
 
 const (
 	externalDNSAnnotationKey = "external-dns.alpha.kubernetes.io/hostname"
 	dot                      = "."
+
+	// QuorumTLSPhaseAnnotation carries the quorum TLS phase in the pod
+	// template, so that moving to another phase restarts the members
+	QuorumTLSPhaseAnnotation = "zookeeper.pravega.io/quorum-tls-phase"
+
+	// ObserverServerIDOffset is added to the ids of the observers, so that
+	// they never clash with the ids of the voting members
+	ObserverServerIDOffset = 1000
+
+	// MemberKind is the kind label of the pods of the voting members
+	MemberKind = "ZookeeperMember"
+
+	// ObserverKind is the kind label of the observer pods
+	ObserverKind = "ZookeeperObserver"
 )
 
-func headlessDomain(z *v1beta1.ZookeeperCluster) string {
+func headlessDomain(z *zookeeperv1.ZookeeperCluster) string {
 	return fmt.Sprintf("%s.%s.svc.%s", headlessSvcName(z), z.GetNamespace(), z.GetKubernetesClusterDomain())
 }
 
-func headlessSvcName(z *v1beta1.ZookeeperCluster) string {
+func headlessSvcName(z *zookeeperv1.ZookeeperCluster) string {
 	return fmt.Sprintf("%s-headless", z.GetName())
 }
 
+// MakeServerConfig returns the entry of a member in the dynamic
+// configuration of the ensemble, as the member writes it when it starts
+func MakeServerConfig(z *zookeeperv1.ZookeeperCluster, ordinal int, role string) ServerConfig {
+	ports := z.Spec.Ports
+	return ServerConfig{
+		ID:            ordinal + 1,
+		Address:       fmt.Sprintf("%s-%d.%s:%d:%d", z.GetName(), ordinal, headlessDomain(z), ports.Quorum, ports.LeaderElection),
+		Role:          role,
+		ClientAddress: ClientAddress(z),
+	}
+}
+
+// MakeObserverServerConfig returns the entry of an observer in the dynamic
+// configuration of the ensemble
+func MakeObserverServerConfig(z *zookeeperv1.ZookeeperCluster, ordinal int) ServerConfig {
+	ports := z.Spec.Ports
+	return ServerConfig{
+		ID:            ObserverServerIDOffset + ordinal + 1,
+		Address:       fmt.Sprintf("%s-%d.%s:%d:%d", z.ObserverStatefulSetName(), ordinal, headlessDomain(z), ports.Quorum, ports.LeaderElection),
+		Role:          RoleObserver,
+		ClientAddress: ClientAddress(z),
+	}
+}
+
+// ClientAddress returns the address the members serve plaintext clients on.
+// With client TLS it is the loopback interface, which only the probes of the
+// members use, unless plaintext clients are allowed.
+func ClientAddress(z *zookeeperv1.ZookeeperCluster) string {
+	port := strconv.Itoa(int(z.Spec.Ports.Client))
+	if z.Spec.TLS.ServesPlaintextClients() {
+		return port
+	}
+	return "127.0.0.1:" + port
+}
+
+// IsObserverServerID reports whether the server id belongs to the observer
+// pool rather than to a voting member
+func IsObserverServerID(id int) bool {
+	return id > ObserverServerIDOffset
+}
+
 var zkDataVolume = "data"
 
 // MakeStatefulSet return a zookeeper stateful set from the zk spec
-func MakeStatefulSet(z *v1beta1.ZookeeperCluster) *appsv1.StatefulSet {
+func MakeStatefulSet(z *zookeeperv1.ZookeeperCluster) *appsv1.StatefulSet {
 	extraVolumes := []v1.Volume{}
-	persistence := z.Spec.Persistence
+	persistence := z.Spec.Storage.Persistence
 	pvcs := []v1.PersistentVolumeClaim{}
-	if strings.EqualFold(z.Spec.StorageType, "ephemeral") {
+	if z.Spec.Storage.IsEphemeral() {
 		extraVolumes = append(extraVolumes, v1.Volume{
 			Name: zkDataVolume,
 			VolumeSource: v1.VolumeSource{
-				EmptyDir: &z.Spec.Ephemeral.EmptyDirVolumeSource,
+				EmptyDir: &z.Spec.Storage.Ephemeral.EmptyDirVolumeSource,
 			},
 		})
 	} else {
@@ -60,7 +115,7 @@ func MakeStatefulSet(z *v1beta1.ZookeeperCluster) *appsv1.StatefulSet {
 					z.Spec.Labels,
 					map[string]string{"app": z.GetName(), "uid": string(z.UID)},
 				),
-				Annotations: z.Spec.Persistence.Annotations,
+				Annotations: persistence.Annotations,
 			},
 			Spec: persistence.PersistentVolumeClaimSpec,
 		})
@@ -83,8 +138,10 @@ func MakeStatefulSet(z *v1beta1.ZookeeperCluster) *appsv1.StatefulSet {
 					"app": z.GetName(),
 				},
 			},
+			// the operator restarts the members itself, the followers first
+			// and the leader last
 			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
-				Type: appsv1.RollingUpdateStatefulSetStrategyType,
+				Type: appsv1.OnDeleteStatefulSetStrategyType,
 			},
 			PodManagementPolicy: appsv1.OrderedReadyPodManagement,
 			Template: v1.PodTemplateSpec{
@@ -95,10 +152,10 @@ func MakeStatefulSet(z *v1beta1.ZookeeperCluster) *appsv1.StatefulSet {
 						z.Spec.Pod.Labels,
 						map[string]string{
 							"app":  z.GetName(),
-							"kind": "ZookeeperMember",
+							"kind": MemberKind,
 						},
 					),
-					Annotations: z.Spec.Pod.Annotations,
+					Annotations: makePodAnnotations(z),
 				},
 				Spec: makeZkPodSpec(z, extraVolumes),
 			},
@@ -107,11 +164,77 @@ func MakeStatefulSet(z *v1beta1.ZookeeperCluster) *appsv1.StatefulSet {
 	}
 }
 
-func makeZkPodSpec(z *v1beta1.ZookeeperCluster, volumes []v1.Volume) v1.PodSpec {
+// MakeObserverStatefulSet returns the stateful set of the observers of the
+// zk spec. The observers run the pod of the voting members with their own
+// resources and scheduling, and register with the ensemble as observers.
+func MakeObserverStatefulSet(z *zookeeperv1.ZookeeperCluster) *appsv1.StatefulSet {
+	sts := MakeStatefulSet(z)
+	name := z.ObserverStatefulSetName()
+	replicas := z.ObserverReplicas()
+	sts.Name = name
+	sts.Spec.Replicas = &replicas
+	// observers do not vote, restarting them in any order is safe
+	sts.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+		Type: appsv1.RollingUpdateStatefulSetStrategyType,
+	}
+	// the voting members do not select on the kind, their selector cannot
+	// be changed anymore
+	sts.Spec.Selector.MatchLabels = map[string]string{
+		"app":  z.GetName(),
+		"kind": ObserverKind,
+	}
+	sts.Spec.Template.GenerateName = name
+	sts.Spec.Template.Labels["kind"] = ObserverKind
+	for i := range sts.Spec.VolumeClaimTemplates {
+		sts.Spec.VolumeClaimTemplates[i].Labels["kind"] = ObserverKind
+	}
+
+	podSpec := &sts.Spec.Template.Spec
+	if o := z.Spec.Observers; o != nil {
+		if o.NodeSelector != nil {
+			podSpec.NodeSelector = o.NodeSelector
+		}
+		if o.Affinity != nil {
+			podSpec.Affinity = o.Affinity
+		}
+		if o.TopologySpreadConstraints != nil {
+			podSpec.TopologySpreadConstraints = o.TopologySpreadConstraints
+		}
+		if o.Tolerations != nil {
+			podSpec.Tolerations = o.Tolerations
+		}
+	}
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name != "zookeeper" {
+			continue
+		}
+		if o := z.Spec.Observers; o != nil && o.Resources != nil {
+			c.Resources = *o.Resources
+		}
+		// the start script derives the server id from the ordinal
+		c.Env = append(c.Env, v1.EnvVar{Name: "ZK_SERVER_ID_OFFSET", Value: strconv.Itoa(ObserverServerIDOffset)})
+	}
+	return sts
+}
+
+func makePodAnnotations(z *zookeeperv1.ZookeeperCluster) map[string]string {
+	phase := z.QuorumTLSPhase()
+	if phase == zookeeperv1.QuorumTLSDisabled {
+		return z.Spec.Pod.Annotations
+	}
+	return mergeLabels(z.Spec.Pod.Annotations, map[string]string{QuorumTLSPhaseAnnotation: string(phase)})
+}
+
+func makeZkPodSpec(z *zookeeperv1.ZookeeperCluster, volumes []v1.Volume) v1.PodSpec {
+	ports := z.Spec.Ports.ContainerPorts()
+	if port := observerMasterPort(z); port != 0 {
+		ports = append(ports, v1.ContainerPort{Name: "observer-master", ContainerPort: port})
+	}
 	zkContainer := v1.Container{
 		Name:  "zookeeper",
 		Image: z.Spec.Image.ToString(),
-		Ports: z.Spec.Ports,
+		Ports: ports,
 		Env: []v1.EnvVar{
 			{
 				Name: "ENVOY_SIDECAR_STATUS",
@@ -172,6 +295,27 @@ func makeZkPodSpec(z *v1beta1.ZookeeperCluster, volumes []v1.Volume) v1.PodSpec
 	})
 
 	zkContainer.Env = append(zkContainer.Env, z.Spec.Pod.Env...)
+	var initContainers []v1.Container
+	for _, tls := range tlsMounts(z) {
+		var initContainer *v1.Container
+		volumes, initContainer = tls.mount.addTo(z, tls.secret, &zkContainer, volumes)
+		if initContainer != nil {
+			initContainers = append(initContainers, *initContainer)
+		}
+	}
+	if z.Spec.Auth.Enabled() {
+		var initContainer *v1.Container
+		volumes, initContainer = addAuthTo(z, &zkContainer, volumes)
+		initContainers = append(initContainers, *initContainer)
+	}
+	if z.Spec.ACL.Enforced() {
+		addSuperUserTo(z, &zkContainer)
+	}
+	if z.Spec.RestoreFrom != nil {
+		var initContainer *v1.Container
+		volumes, initContainer = addRestoreTo(z, volumes)
+		initContainers = append(initContainers, *initContainer)
+	}
 	podSpec := v1.PodSpec{
 		Containers:                append(z.Spec.Containers, zkContainer),
 		Affinity:                  z.Spec.Pod.Affinity,
@@ -185,26 +329,30 @@ func makeZkPodSpec(z *v1beta1.ZookeeperCluster, volumes []v1.Volume) v1.PodSpec
 	podSpec.Tolerations = z.Spec.Pod.Tolerations
 	podSpec.TerminationGracePeriodSeconds = &z.Spec.Pod.TerminationGracePeriodSeconds
 	podSpec.ServiceAccountName = z.Spec.Pod.ServiceAccountName
-	if z.Spec.InitContainers != nil {
-		podSpec.InitContainers = z.Spec.InitContainers
+	if z.Spec.InitContainers != nil || initContainers != nil {
+		podSpec.InitContainers = append(append([]v1.Container{}, z.Spec.InitContainers...), initContainers...)
 	}
 
 	return podSpec
 }
 
 // MakeClientService returns a client service resource for the zookeeper cluster
-func MakeClientService(z *v1beta1.ZookeeperCluster) *v1.Service {
-	ports := z.ZookeeperPorts()
-	svcPorts := []v1.ServicePort{
-		{Name: "tcp-client", Port: ports.Client},
+func MakeClientService(z *zookeeperv1.ZookeeperCluster) *v1.Service {
+	ports := z.Spec.Ports
+	var svcPorts []v1.ServicePort
+	if z.Spec.TLS.ServesPlaintextClients() {
+		svcPorts = append(svcPorts, v1.ServicePort{Name: "tcp-client", Port: ports.Client})
+	}
+	if z.Spec.TLS.ClientEnabled() {
+		svcPorts = append(svcPorts, v1.ServicePort{Name: "tcp-secure-client", Port: ports.SecureClient})
 	}
 	return makeService(z.GetClientServiceName(), svcPorts, true, false, z.Spec.ClientService.Annotations, z)
 }
 
 // MakeAdminServerService returns a service which provides an interface
 // to access the zookeeper admin server port
-func MakeAdminServerService(z *v1beta1.ZookeeperCluster) *v1.Service {
-	ports := z.ZookeeperPorts()
+func MakeAdminServerService(z *zookeeperv1.ZookeeperCluster) *v1.Service {
+	ports := z.Spec.Ports
 	svcPorts := []v1.ServicePort{
 		{Name: "tcp-admin-server", Port: ports.AdminServer},
 	}
@@ -214,7 +362,7 @@ func MakeAdminServerService(z *v1beta1.ZookeeperCluster) *v1.Service {
 }
 
 // MakeConfigMap returns a zookeeper config map
-func MakeConfigMap(z *v1beta1.ZookeeperCluster) *v1.ConfigMap {
+func MakeConfigMap(z *zookeeperv1.ZookeeperCluster) *v1.ConfigMap {
 	return &v1.ConfigMap{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "ConfigMap",
@@ -236,20 +384,38 @@ func MakeConfigMap(z *v1beta1.ZookeeperCluster) *v1.ConfigMap {
 
 // MakeHeadlessService returns an internal headless-service for the zk
 // stateful-set
-func MakeHeadlessService(z *v1beta1.ZookeeperCluster) *v1.Service {
-	ports := z.ZookeeperPorts()
-	svcPorts := []v1.ServicePort{
-		{Name: "tcp-client", Port: ports.Client},
-		{Name: "tcp-quorum", Port: ports.Quorum},
-		{Name: "tcp-leader-election", Port: ports.Leader},
-		{Name: "tcp-metrics", Port: ports.Metrics},
-		{Name: "tcp-admin-server", Port: ports.AdminServer},
+func MakeHeadlessService(z *zookeeperv1.ZookeeperCluster) *v1.Service {
+	ports := z.Spec.Ports
+	var svcPorts []v1.ServicePort
+	if z.Spec.TLS.ServesPlaintextClients() {
+		svcPorts = append(svcPorts, v1.ServicePort{Name: "tcp-client", Port: ports.Client})
+	}
+	svcPorts = append(svcPorts,
+		v1.ServicePort{Name: "tcp-quorum", Port: ports.Quorum},
+		v1.ServicePort{Name: "tcp-leader-election", Port: ports.LeaderElection},
+		v1.ServicePort{Name: "tcp-metrics", Port: ports.Metrics},
+		v1.ServicePort{Name: "tcp-admin-server", Port: ports.AdminServer},
+	)
+	if z.Spec.TLS.ClientEnabled() {
+		svcPorts = append(svcPorts, v1.ServicePort{Name: "tcp-secure-client", Port: ports.SecureClient})
+	}
+	if port := observerMasterPort(z); port != 0 {
+		svcPorts = append(svcPorts, v1.ServicePort{Name: "tcp-observer-master", Port: port})
 	}
 	return makeService(headlessSvcName(z), svcPorts, false, false, z.Spec.HeadlessService.Annotations, z)
 }
 
-func makeZkConfigString(z *v1beta1.ZookeeperCluster) string {
-	ports := z.ZookeeperPorts()
+// observerMasterPort returns the port the followers serve the observers on,
+// or 0 if the observers connect to the leader
+func observerMasterPort(z *zookeeperv1.ZookeeperCluster) int32 {
+	if z.Spec.Observers == nil {
+		return 0
+	}
+	return z.Spec.Observers.ObserverMasterPort
+}
+
+func makeZkConfigString(z *zookeeperv1.ZookeeperCluster) string {
+	ports := z.Spec.Ports
 
 	var zkConfig = ""
 	for key, value := range z.Spec.Conf.AdditionalConfig {
@@ -259,7 +425,7 @@ func makeZkConfigString(z *v1beta1.ZookeeperCluster) string {
 		"dataDir=/data\n" +
 		"standaloneEnabled=false\n" +
 		"reconfigEnabled=true\n" +
-		"skipACL=yes\n" +
+		"skipACL=" + skipACL(z) + "\n" +
 		"metricsProvider.className=org.apache.zookeeper.metrics.prometheus.PrometheusMetricsProvider\n" +
 		"metricsProvider.httpPort=" + strconv.Itoa(int(ports.Metrics)) + "\n" +
 		"metricsProvider.exportJvmInfo=true\n" +
@@ -279,9 +445,21 @@ func makeZkConfigString(z *v1beta1.ZookeeperCluster) string {
 		"autopurge.purgeInterval=" + strconv.Itoa(z.Spec.Conf.AutoPurgePurgeInterval) + "\n" +
 		"quorumListenOnAllIPs=" + strconv.FormatBool(z.Spec.Conf.QuorumListenOnAllIPs) + "\n" +
 		"admin.serverPort=" + strconv.Itoa(int(ports.AdminServer)) + "\n" +
+		makeZkObserverMasterConfigString(z) +
+		makeZkAuthConfigString(z) +
+		makeZkTLSConfigString(z) +
+		// the start script expects the dynamic config file on the last line
 		"dynamicConfigFile=/data/zoo.cfg.dynamic\n"
 }
 
+func makeZkObserverMasterConfigString(z *zookeeperv1.ZookeeperCluster) string {
+	port := observerMasterPort(z)
+	if port == 0 {
+		return ""
+	}
+	return "observerMasterPort=" + strconv.Itoa(int(port)) + "\n"
+}
+
 func makeZkLog4JQuietConfigString() string {
 	return "log4j.rootLogger=ERROR, CONSOLE\n" +
 		"log4j.appender.CONSOLE=org.apache.log4j.ConsoleAppender\n" +
@@ -300,21 +478,24 @@ func makeZkLog4JConfigString() string {
 		"log4j.appender.CONSOLE.layout.ConversionPattern=%d{ISO8601} [myid:%X{myid}] - %-5p [%t:%C{1}@%L] - %m%n\n"
 }
 
-func makeZkEnvConfigString(z *v1beta1.ZookeeperCluster) string {
-	ports := z.ZookeeperPorts()
+func makeZkEnvConfigString(z *zookeeperv1.ZookeeperCluster) string {
+	ports := z.Spec.Ports
 	return "#!/usr/bin/env bash\n\n" +
 		"DOMAIN=" + headlessDomain(z) + "\n" +
 		"QUORUM_PORT=" + strconv.Itoa(int(ports.Quorum)) + "\n" +
-		"LEADER_PORT=" + strconv.Itoa(int(ports.Leader)) + "\n" +
+		"LEADER_PORT=" + strconv.Itoa(int(ports.LeaderElection)) + "\n" +
 		"CLIENT_HOST=" + z.GetClientServiceName() + "\n" +
 		"CLIENT_PORT=" + strconv.Itoa(int(ports.Client)) + "\n" +
+		"CLIENT_ADDRESS=" + ClientAddress(z) + "\n" +
 		"ADMIN_SERVER_HOST=" + z.GetAdminServerServiceName() + "\n" +
 		"ADMIN_SERVER_PORT=" + strconv.Itoa(int(ports.AdminServer)) + "\n" +
 		"CLUSTER_NAME=" + z.GetName() + "\n" +
-		"CLUSTER_SIZE=" + fmt.Sprint(z.Spec.Replicas) + "\n"
+		"CLUSTER_SIZE=" + fmt.Sprint(z.Spec.Replicas) + "\n" +
+		makeZkAuthEnvString(z) +
+		makeZkTLSEnvString(z)
 }
 
-func makeService(name string, ports []v1.ServicePort, clusterIP bool, external bool, annotations map[string]string, z *v1beta1.ZookeeperCluster) *v1.Service {
+func makeService(name string, ports []v1.ServicePort, clusterIP bool, external bool, annotations map[string]string, z *zookeeperv1.ZookeeperCluster) *v1.Service {
 	var dnsName string
 	var annotationMap = copyMap(annotations)
 	if !clusterIP && z.Spec.DomainName != "" {
@@ -355,7 +536,7 @@ func makeService(name string, ports []v1.ServicePort, clusterIP bool, external b
 }
 
 // MakePodDisruptionBudget returns a pdb for the zookeeper cluster
-func MakePodDisruptionBudget(z *v1beta1.ZookeeperCluster) *policyv1.PodDisruptionBudget {
+func MakePodDisruptionBudget(z *zookeeperv1.ZookeeperCluster) *policyv1.PodDisruptionBudget {
 	pdbCount := intstr.FromInt(int(z.Spec.MaxUnavailableReplicas))
 	return &policyv1.PodDisruptionBudget{
 		TypeMeta: metav1.TypeMeta{
@@ -379,7 +560,7 @@ func MakePodDisruptionBudget(z *v1beta1.ZookeeperCluster) *policyv1.PodDisruptio
 }
 
 // MakeServiceAccount returns the service account for zookeeper Cluster
-func MakeServiceAccount(z *v1beta1.ZookeeperCluster) *v1.ServiceAccount {
+func MakeServiceAccount(z *zookeeperv1.ZookeeperCluster) *v1.ServiceAccount {
 	return &v1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      z.Spec.Pod.ServiceAccountName,
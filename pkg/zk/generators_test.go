@@ -13,15 +13,17 @@ package zk_test
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	policyv1 "k8s.io/api/policy/v1"
 
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
-	"github.com/pravega/zookeeper-operator/api/v1beta1"
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
 	"github.com/pravega/zookeeper-operator/pkg/utils"
 	"github.com/pravega/zookeeper-operator/pkg/zk"
 
@@ -38,16 +40,16 @@ var _ = Describe("Generators Spec", func() {
 			var cfg string
 
 			BeforeEach(func() {
-				z := &v1beta1.ZookeeperCluster{
+				z := &zookeeperv1.ZookeeperCluster{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "example",
 						Namespace: "default",
 					},
-					Spec: v1beta1.ZookeeperClusterSpec{
+					Spec: zookeeperv1.ZookeeperClusterSpec{
 						Labels: map[string]string{
 							"exampleLabel": "exampleValue",
 						},
-						Conf: v1beta1.ZookeeperConfig{
+						Conf: zookeeperv1.ZookeeperConfig{
 							AdditionalConfig: map[string]string{
 								"tcpKeepAlive": "true",
 							},
@@ -149,12 +151,12 @@ var _ = Describe("Generators Spec", func() {
 			var cfg string
 
 			BeforeEach(func() {
-				z := &v1beta1.ZookeeperCluster{
+				z := &zookeeperv1.ZookeeperCluster{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "example",
 						Namespace: "default",
 					},
-					Spec: v1beta1.ZookeeperClusterSpec{
+					Spec: zookeeperv1.ZookeeperClusterSpec{
 						KubernetesClusterDomain: "foo.bar",
 					},
 				}
@@ -183,12 +185,12 @@ var _ = Describe("Generators Spec", func() {
 		Context("with defaults", func() {
 
 			BeforeEach(func() {
-				z := &v1beta1.ZookeeperCluster{
+				z := &zookeeperv1.ZookeeperCluster{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "example",
 						Namespace: "default",
 					},
-					Spec: v1beta1.ZookeeperClusterSpec{
+					Spec: zookeeperv1.ZookeeperClusterSpec{
 						Labels: map[string]string{
 							"exampleLabel": "exampleValue",
 						},
@@ -213,18 +215,22 @@ var _ = Describe("Generators Spec", func() {
 			It("should have blank topologySpreadConstraints", func() {
 				Ω(sts.Spec.Template.Spec.TopologySpreadConstraints).To(HaveLen(0))
 			})
+
+			It("should leave the restarts to the operator", func() {
+				Ω(sts.Spec.UpdateStrategy.Type).To(Equal(appsv1.OnDeleteStatefulSetStrategyType))
+			})
 		})
 
 		Context("with pod policy annotations", func() {
 
 			BeforeEach(func() {
-				z := &v1beta1.ZookeeperCluster{
+				z := &zookeeperv1.ZookeeperCluster{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "example",
 						Namespace: "default",
 					},
-					Spec: v1beta1.ZookeeperClusterSpec{
-						Pod: v1beta1.PodPolicy{
+					Spec: zookeeperv1.ZookeeperClusterSpec{
+						Pod: zookeeperv1.PodPolicy{
 							Annotations: map[string]string{
 								"example-annotation": "example-value",
 							},
@@ -249,15 +255,17 @@ var _ = Describe("Generators Spec", func() {
 		Context("with defaults", func() {
 
 			BeforeEach(func() {
-				z := &v1beta1.ZookeeperCluster{
+				z := &zookeeperv1.ZookeeperCluster{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "example",
 						Namespace: "default",
 					},
-					Spec: v1beta1.ZookeeperClusterSpec{},
+					Spec: zookeeperv1.ZookeeperClusterSpec{},
 				}
-				z.Spec = v1beta1.ZookeeperClusterSpec{
-					StorageType: "ephemeral",
+				z.Spec = zookeeperv1.ZookeeperClusterSpec{
+					Storage: zookeeperv1.Storage{
+						Ephemeral: &zookeeperv1.Ephemeral{},
+					},
 				}
 				z.WithDefaults()
 				sts = zk.MakeStatefulSet(z)
@@ -273,12 +281,12 @@ var _ = Describe("Generators Spec", func() {
 		Context("with defaults", func() {
 
 			BeforeEach(func() {
-				z := &v1beta1.ZookeeperCluster{
+				z := &zookeeperv1.ZookeeperCluster{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "example",
 						Namespace: "default",
 					},
-					Spec: v1beta1.ZookeeperClusterSpec{},
+					Spec: zookeeperv1.ZookeeperClusterSpec{},
 				}
 				z.Spec.Pod.ServiceAccountName = "zookeeper"
 				z.WithDefaults()
@@ -297,12 +305,12 @@ var _ = Describe("Generators Spec", func() {
 		Context("with defaults", func() {
 
 			BeforeEach(func() {
-				z := &v1beta1.ZookeeperCluster{
+				z := &zookeeperv1.ZookeeperCluster{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "example",
 						Namespace: "default",
 					},
-					Spec: v1beta1.ZookeeperClusterSpec{},
+					Spec: zookeeperv1.ZookeeperClusterSpec{},
 				}
 				z.WithDefaults()
 				z.Spec.InitContainers = []v1.Container{
@@ -322,23 +330,113 @@ var _ = Describe("Generators Spec", func() {
 		})
 	})
 
+	Context("#MakeObserverStatefulSet", func() {
+		var (
+			z   *zookeeperv1.ZookeeperCluster
+			sts *appsv1.StatefulSet
+		)
+
+		zookeeperContainer := func() v1.Container {
+			containers := sts.Spec.Template.Spec.Containers
+			return containers[len(containers)-1]
+		}
+
+		BeforeEach(func() {
+			z = &zookeeperv1.ZookeeperCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "default",
+				},
+				Spec: zookeeperv1.ZookeeperClusterSpec{
+					Pod: zookeeperv1.PodPolicy{
+						NodeSelector: map[string]string{"pool": "zookeeper"},
+						Resources: v1.ResourceRequirements{
+							Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+						},
+					},
+					Observers: &zookeeperv1.ObserverPolicy{
+						Replicas: 2,
+						Resources: &v1.ResourceRequirements{
+							Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")},
+						},
+						Tolerations: []v1.Toleration{{Key: "reads", Operator: v1.TolerationOpExists}},
+					},
+				},
+			}
+			z.WithDefaults()
+		})
+
+		JustBeforeEach(func() {
+			sts = zk.MakeObserverStatefulSet(z)
+		})
+
+		It("should run the observers next to the voting members", func() {
+			Ω(sts.Name).To(Equal("example-observer"))
+			Ω(*sts.Spec.Replicas).To(BeEquivalentTo(2))
+			Ω(sts.Spec.ServiceName).To(Equal("example-headless"))
+			Ω(sts.Spec.Selector.MatchLabels).To(Equal(map[string]string{"app": "example", "kind": zk.ObserverKind}))
+			Ω(sts.Spec.Template.Labels).To(HaveKeyWithValue("kind", zk.ObserverKind))
+			Ω(sts.Spec.VolumeClaimTemplates[0].Labels).To(HaveKeyWithValue("kind", zk.ObserverKind))
+		})
+
+		It("should roll the observers out with the stateful set", func() {
+			Ω(sts.Spec.UpdateStrategy.Type).To(Equal(appsv1.RollingUpdateStatefulSetStrategyType))
+		})
+
+		It("should offset the server ids", func() {
+			Ω(zookeeperContainer().Env).To(ContainElement(v1.EnvVar{Name: "ZK_SERVER_ID_OFFSET", Value: "1000"}))
+		})
+
+		It("should use the resources and scheduling of the pool", func() {
+			Ω(zookeeperContainer().Resources.Limits).To(HaveKeyWithValue(v1.ResourceCPU, resource.MustParse("500m")))
+			Ω(sts.Spec.Template.Spec.Tolerations).To(HaveLen(1))
+			Ω(sts.Spec.Template.Spec.NodeSelector).To(HaveKeyWithValue("pool", "zookeeper"))
+		})
+
+		It("should leave the voting members as they are", func() {
+			members := zk.MakeStatefulSet(z)
+			Ω(members.Spec.Selector.MatchLabels).To(Equal(map[string]string{"app": "example"}))
+			Ω(members.Spec.Template.Labels).To(HaveKeyWithValue("kind", "ZookeeperMember"))
+			Ω(members.Spec.Template.Spec.Containers[0].Resources.Limits).To(HaveKeyWithValue(v1.ResourceCPU, resource.MustParse("2")))
+		})
+
+		Context("with an observer master", func() {
+			BeforeEach(func() {
+				z.Spec.Observers.ObserverMasterPort = 2191
+			})
+
+			It("should let the followers serve the observers", func() {
+				Ω(zookeeperContainer().Ports).To(ContainElement(v1.ContainerPort{Name: "observer-master", ContainerPort: 2191}))
+				Ω(zk.MakeConfigMap(z).Data["zoo.cfg"]).To(ContainSubstring("observerMasterPort=2191\n"))
+				Ω(zk.MakeHeadlessService(z).Spec.Ports).To(ContainElement(HaveField("Name", "tcp-observer-master")))
+			})
+		})
+
+		It("should register the observers in the dynamic config", func() {
+			server := zk.MakeObserverServerConfig(z, 1)
+			Ω(server.String()).To(Equal("server.1002=example-observer-1.example-headless.default.svc.cluster.local:2888:3888:observer;2181"))
+			Ω(zk.IsObserverServerID(server.ID)).To(BeTrue())
+			Ω(zk.IsObserverServerID(7)).To(BeFalse())
+		})
+	})
+
 	Context("#MakeClientService", func() {
 		var s *v1.Service
 		var domainName string
 
 		BeforeEach(func() {
 			domainName = "zk.com."
-			z := &v1beta1.ZookeeperCluster{
+			z := &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "example",
 					Namespace: "default",
 				},
-				Spec: v1beta1.ZookeeperClusterSpec{
+				Spec: zookeeperv1.ZookeeperClusterSpec{
 					DomainName: domainName,
 					Labels: map[string]string{
 						"exampleLabel": "exampleValue",
 					},
-					ClientService: v1beta1.ClientServicePolicy{
+					ClientService: zookeeperv1.ClientServicePolicy{
 						Annotations: map[string]string{
 							"exampleAnnotation": "exampleValue",
 						},
@@ -386,17 +484,17 @@ var _ = Describe("Generators Spec", func() {
 
 		BeforeEach(func() {
 			domainName = "zk.com."
-			z := &v1beta1.ZookeeperCluster{
+			z := &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "example",
 					Namespace: "default",
 				},
-				Spec: v1beta1.ZookeeperClusterSpec{
+				Spec: zookeeperv1.ZookeeperClusterSpec{
 					DomainName: domainName,
 					Labels: map[string]string{
 						"exampleLabel": "exampleValue",
 					},
-					HeadlessService: v1beta1.HeadlessServicePolicy{
+					HeadlessService: zookeeperv1.HeadlessServicePolicy{
 						Annotations: map[string]string{
 							"exampleAnnotation": "exampleValue",
 						},
@@ -464,12 +562,12 @@ var _ = Describe("Generators Spec", func() {
 
 		BeforeEach(func() {
 			domainName = "zkcom"
-			z := &v1beta1.ZookeeperCluster{
+			z := &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "example",
 					Namespace: "default",
 				},
-				Spec: v1beta1.ZookeeperClusterSpec{
+				Spec: zookeeperv1.ZookeeperClusterSpec{
 					DomainName: domainName,
 				},
 			}
@@ -488,16 +586,16 @@ var _ = Describe("Generators Spec", func() {
 		var s *v1.Service
 
 		BeforeEach(func() {
-			z := &v1beta1.ZookeeperCluster{
+			z := &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "example",
 					Namespace: "default",
 				},
-				Spec: v1beta1.ZookeeperClusterSpec{
+				Spec: zookeeperv1.ZookeeperClusterSpec{
 					Labels: map[string]string{
 						"exampleLabel": "exampleValue",
 					},
-					AdminServerService: v1beta1.AdminServerServicePolicy{
+					AdminServerService: zookeeperv1.AdminServerServicePolicy{
 						Annotations: map[string]string{
 							"exampleAnnotation": "exampleValue",
 						},
@@ -543,13 +641,13 @@ var _ = Describe("Generators Spec", func() {
 		var s *v1.Service
 
 		BeforeEach(func() {
-			z := &v1beta1.ZookeeperCluster{
+			z := &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "example",
 					Namespace: "default",
 				},
-				Spec: v1beta1.ZookeeperClusterSpec{
-					AdminServerService: v1beta1.AdminServerServicePolicy{
+				Spec: zookeeperv1.ZookeeperClusterSpec{
+					AdminServerService: zookeeperv1.AdminServerServicePolicy{
 						External: true,
 					},
 				},
@@ -570,12 +668,12 @@ var _ = Describe("Generators Spec", func() {
 
 		BeforeEach(func() {
 			domainName = "zk.com."
-			z := &v1beta1.ZookeeperCluster{
+			z := &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "example",
 					Namespace: "default",
 				},
-				Spec: v1beta1.ZookeeperClusterSpec{
+				Spec: zookeeperv1.ZookeeperClusterSpec{
 					DomainName: domainName,
 					Labels: map[string]string{
 						"exampleLabel": "exampleValue",
@@ -601,4 +699,565 @@ var _ = Describe("Generators Spec", func() {
 				"exampleValue"))
 		})
 	})
+
+	Context("with client TLS", func() {
+		var z *zookeeperv1.ZookeeperCluster
+
+		BeforeEach(func() {
+			z = &zookeeperv1.ZookeeperCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "default",
+				},
+				Spec: zookeeperv1.ZookeeperClusterSpec{
+					TLS: &zookeeperv1.TLSPolicy{
+						Client: &zookeeperv1.ClientTLS{
+							TLSSecret: zookeeperv1.TLSSecret{SecretName: "example-tls"},
+						},
+					},
+				},
+			}
+		})
+
+		Context("in PEM format", func() {
+			var sts *appsv1.StatefulSet
+			var cfg string
+
+			BeforeEach(func() {
+				z.WithDefaults()
+				sts = zk.MakeStatefulSet(z)
+				cfg = zk.MakeConfigMap(z).Data["zoo.cfg"]
+			})
+
+			It("should open the secure client port", func() {
+				Ω(cfg).To(ContainSubstring("secureClientPort=2281\n"))
+				Ω(cfg).To(ContainSubstring("serverCnxnFactory=org.apache.zookeeper.server.NettyServerCnxnFactory\n"))
+				Ω(cfg).To(HaveSuffix("dynamicConfigFile=/data/zoo.cfg.dynamic\n"))
+			})
+
+			It("should use the keystore assembled from the secret", func() {
+				Ω(cfg).To(ContainSubstring("ssl.keyStore.location=/tls/client-keystore/keystore.pem\n"))
+				Ω(cfg).To(ContainSubstring("ssl.keyStore.type=PEM\n"))
+				Ω(cfg).To(ContainSubstring("ssl.trustStore.location=/tls/client/ca.crt\n"))
+				Ω(cfg).NotTo(ContainSubstring("ssl.clientAuth"))
+
+				initContainers := sts.Spec.Template.Spec.InitContainers
+				Ω(initContainers).To(HaveLen(1))
+				Ω(initContainers[0].Command[2]).To(Equal(
+					"cat /tls/client/tls.key /tls/client/tls.crt > /tls/client-keystore/keystore.pem"))
+			})
+
+			It("should mount the secret into the zookeeper container", func() {
+				Ω(sts.Spec.Template.Spec.Volumes).To(ContainElement(v1.Volume{
+					Name: "client-tls",
+					VolumeSource: v1.VolumeSource{
+						Secret: &v1.SecretVolumeSource{SecretName: "example-tls"},
+					},
+				}))
+				container := sts.Spec.Template.Spec.Containers[0]
+				Ω(container.VolumeMounts).To(ContainElement(v1.VolumeMount{
+					Name: "client-tls", MountPath: "/tls/client", ReadOnly: true,
+				}))
+				Ω(container.Ports).To(ContainElement(v1.ContainerPort{Name: "secure-client", ContainerPort: 2281}))
+			})
+
+			It("should expose the secure client port on the services", func() {
+				for _, s := range []*v1.Service{zk.MakeClientService(z), zk.MakeHeadlessService(z)} {
+					p, err := utils.ServicePortByName(s.Spec.Ports, "tcp-secure-client")
+					Ω(err).To(BeNil())
+					Ω(p.Port).To(BeEquivalentTo(2281))
+				}
+			})
+
+			It("should only serve plaintext clients on the loopback interface", func() {
+				for _, s := range []*v1.Service{zk.MakeClientService(z), zk.MakeHeadlessService(z)} {
+					_, err := utils.ServicePortByName(s.Spec.Ports, "tcp-client")
+					Ω(err).NotTo(BeNil())
+				}
+				Ω(zk.MakeServerConfig(z, 0, zk.RoleParticipant).ClientAddress).To(Equal("127.0.0.1:2181"))
+				env := zk.MakeConfigMap(z).Data["env.sh"]
+				Ω(env).To(ContainSubstring("CLIENT_ADDRESS=127.0.0.1:2181\n"))
+				Ω(env).To(ContainSubstring("SECURE_CLIENT_PORT=2281\n"))
+				Ω(env).To(ContainSubstring("-Dzookeeper.client.secure=true"))
+				Ω(env).To(ContainSubstring("-Dzookeeper.ssl.keyStore.location=/tls/client-keystore/keystore.pem"))
+			})
+
+			Context("allowing plaintext clients", func() {
+				BeforeEach(func() {
+					z.Spec.TLS.Client.AllowPlaintext = true
+				})
+
+				It("should keep serving them on the plaintext client port", func() {
+					for _, s := range []*v1.Service{zk.MakeClientService(z), zk.MakeHeadlessService(z)} {
+						p, err := utils.ServicePortByName(s.Spec.Ports, "tcp-client")
+						Ω(err).To(BeNil())
+						Ω(p.Port).To(BeEquivalentTo(2181))
+					}
+					Ω(zk.MakeServerConfig(z, 0, zk.RoleParticipant).ClientAddress).To(Equal("2181"))
+					Ω(zk.MakeConfigMap(z).Data["env.sh"]).NotTo(ContainSubstring("SECURE_CLIENT_PORT"))
+				})
+			})
+		})
+
+		Context("in PKCS12 format", func() {
+			var sts *appsv1.StatefulSet
+			var cfg string
+
+			BeforeEach(func() {
+				z.Spec.TLS.Client.Format = zookeeperv1.TLSFormatPKCS12
+				z.Spec.TLS.Client.ClientAuth = "want"
+				z.Spec.Pod.Env = []v1.EnvVar{{Name: "SERVER_JVMFLAGS", Value: "-Xms512m"}}
+				z.WithDefaults()
+				sts = zk.MakeStatefulSet(z)
+				cfg = zk.MakeConfigMap(z).Data["zoo.cfg"]
+			})
+
+			It("should use the keystores of the secret", func() {
+				Ω(cfg).To(ContainSubstring("ssl.keyStore.location=/tls/client/keystore.p12\n"))
+				Ω(cfg).To(ContainSubstring("ssl.trustStore.location=/tls/client/truststore.p12\n"))
+				Ω(cfg).To(ContainSubstring("ssl.trustStore.type=PKCS12\n"))
+				Ω(cfg).To(ContainSubstring("ssl.clientAuth=want\n"))
+				Ω(sts.Spec.Template.Spec.InitContainers).To(BeEmpty())
+			})
+
+			It("should pass the password as a system property", func() {
+				Ω(cfg).NotTo(ContainSubstring("password"))
+				var flags []string
+				for _, env := range sts.Spec.Template.Spec.Containers[0].Env {
+					if env.Name == "SERVER_JVMFLAGS" {
+						flags = append(flags, env.Value)
+					}
+				}
+				Ω(flags).To(Equal([]string{"-Xms512m " +
+					"-Dzookeeper.ssl.keyStore.password=$(ZK_CLIENT_TLS_PASSWORD) " +
+					"-Dzookeeper.ssl.trustStore.password=$(ZK_CLIENT_TLS_PASSWORD)"}))
+			})
+		})
+	})
+
+	Context("with quorum TLS", func() {
+		var z *zookeeperv1.ZookeeperCluster
+
+		config := func(phase zookeeperv1.QuorumTLSPhase) string {
+			z.Status.QuorumTLS = &zookeeperv1.QuorumTLSStatus{Phase: phase}
+			return zk.MakeConfigMap(z).Data["zoo.cfg"]
+		}
+
+		BeforeEach(func() {
+			z = &zookeeperv1.ZookeeperCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "default",
+				},
+				Spec: zookeeperv1.ZookeeperClusterSpec{
+					TLS: &zookeeperv1.TLSPolicy{
+						Quorum: &zookeeperv1.TLSSecret{SecretName: "example-tls", Format: zookeeperv1.TLSFormatPKCS12},
+					},
+				},
+			}
+			z.WithDefaults()
+		})
+
+		It("should turn on port unification before sslQuorum", func() {
+			cfg := config(zookeeperv1.QuorumTLSPortUnification)
+			Ω(cfg).To(ContainSubstring("ssl.quorum.keyStore.location=/tls/quorum/keystore.p12\n"))
+			Ω(cfg).To(ContainSubstring("sslQuorum=false\n"))
+			Ω(cfg).To(ContainSubstring("portUnification=true\n"))
+
+			cfg = config(zookeeperv1.QuorumTLSWithPortUnification)
+			Ω(cfg).To(ContainSubstring("sslQuorum=true\n"))
+			Ω(cfg).To(ContainSubstring("portUnification=true\n"))
+
+			cfg = config(zookeeperv1.QuorumTLSEnabled)
+			Ω(cfg).To(ContainSubstring("sslQuorum=true\n"))
+			Ω(cfg).To(ContainSubstring("portUnification=false\n"))
+			Ω(cfg).NotTo(ContainSubstring("secureClientPort"))
+		})
+
+		It("should leave the quorum in plaintext while disabled", func() {
+			cfg := config(zookeeperv1.QuorumTLSDisabled)
+			Ω(cfg).NotTo(ContainSubstring("ssl.quorum"))
+			Ω(cfg).NotTo(ContainSubstring("sslQuorum"))
+		})
+
+		It("should restart the members for every phase", func() {
+			z.Status.QuorumTLS = &zookeeperv1.QuorumTLSStatus{Phase: zookeeperv1.QuorumTLSWithPortUnification}
+			sts := zk.MakeStatefulSet(z)
+			Ω(sts.Spec.Template.Annotations).To(HaveKeyWithValue(zk.QuorumTLSPhaseAnnotation, "TLSWithPortUnification"))
+			Ω(z.Spec.Pod.Annotations).NotTo(HaveKey(zk.QuorumTLSPhaseAnnotation))
+
+			z.Status.QuorumTLS = nil
+			z.Spec.TLS = nil
+			sts = zk.MakeStatefulSet(z)
+			Ω(sts.Spec.Template.Annotations).NotTo(HaveKey(zk.QuorumTLSPhaseAnnotation))
+		})
+
+		It("should keep the key material of the status while quorum TLS is being disabled", func() {
+			z.Spec.TLS = nil
+			z.Status.QuorumTLS = &zookeeperv1.QuorumTLSStatus{
+				Phase:  zookeeperv1.QuorumTLSPortUnification,
+				Secret: &zookeeperv1.TLSSecret{SecretName: "old-tls", Format: zookeeperv1.TLSFormatPEM},
+			}
+			sts := zk.MakeStatefulSet(z)
+			Ω(sts.Spec.Template.Spec.Volumes).To(ContainElement(v1.Volume{
+				Name: "quorum-tls",
+				VolumeSource: v1.VolumeSource{
+					Secret: &v1.SecretVolumeSource{SecretName: "old-tls"},
+				},
+			}))
+			Ω(sts.Spec.Template.Spec.InitContainers[0].Name).To(Equal("quorum-keystore"))
+			cfg := zk.MakeConfigMap(z).Data["zoo.cfg"]
+			Ω(cfg).To(ContainSubstring("ssl.quorum.keyStore.location=/tls/quorum-keystore/keystore.pem\n"))
+		})
+
+		It("should pass the passwords of both keystores", func() {
+			z.Spec.TLS.Client = &zookeeperv1.ClientTLS{
+				TLSSecret: zookeeperv1.TLSSecret{SecretName: "client-tls", Format: zookeeperv1.TLSFormatPKCS12},
+			}
+			z.WithDefaults()
+			env := zk.MakeStatefulSet(z).Spec.Template.Spec.Containers[0].Env
+			last := env[len(env)-1]
+			Ω(last.Name).To(Equal("SERVER_JVMFLAGS"))
+			Ω(last.Value).To(ContainSubstring("-Dzookeeper.ssl.keyStore.password=$(ZK_CLIENT_TLS_PASSWORD)"))
+			Ω(last.Value).To(ContainSubstring("-Dzookeeper.ssl.quorum.keyStore.password=$(ZK_QUORUM_TLS_PASSWORD)"))
+		})
+	})
+
+	Context("with auth", func() {
+		var z *zookeeperv1.ZookeeperCluster
+
+		BeforeEach(func() {
+			z = &zookeeperv1.ZookeeperCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "default",
+				},
+				Spec: zookeeperv1.ZookeeperClusterSpec{
+					Auth: &zookeeperv1.AuthPolicy{
+						Digest:            &zookeeperv1.DigestAuth{SecretName: "example-users"},
+						RequireClientAuth: true,
+						Quorum:            true,
+					},
+				},
+			}
+			z.WithDefaults()
+		})
+
+		It("should write the JAAS file from the digest users", func() {
+			spec := zk.MakeStatefulSet(z).Spec.Template.Spec
+			Ω(spec.Volumes).To(ContainElement(v1.Volume{
+				Name: "digest-users",
+				VolumeSource: v1.VolumeSource{
+					Secret: &v1.SecretVolumeSource{SecretName: "example-users"},
+				},
+			}))
+			Ω(spec.InitContainers).To(HaveLen(1))
+			script := spec.InitContainers[0].Command[2]
+			Ω(script).To(ContainSubstring("MEMBER_PASSWORD=$(cat /auth/digest/zookeeper)"))
+			Ω(script).To(ContainSubstring("Server {\n  org.apache.zookeeper.server.auth.DigestLoginModule required\n$DIGEST_USERS;\n};"))
+			Ω(script).To(ContainSubstring("QuorumServer {\n  org.apache.zookeeper.server.auth.DigestLoginModule required\n    user_zookeeper=\"$MEMBER_PASSWORD\";\n};"))
+			Ω(script).To(ContainSubstring("QuorumLearner {\n  org.apache.zookeeper.server.auth.DigestLoginModule required\n    username=\"zookeeper\""))
+			zkContainer := spec.Containers[0]
+			Ω(zkContainer.VolumeMounts).To(ContainElement(v1.VolumeMount{Name: "jaas", MountPath: "/auth/jaas", ReadOnly: true}))
+			Ω(zkContainer.VolumeMounts).NotTo(ContainElement(HaveField("Name", "digest-users")))
+		})
+
+		It("should enable SASL in zoo.cfg", func() {
+			cfg := zk.MakeConfigMap(z).Data["zoo.cfg"]
+			Ω(cfg).To(ContainSubstring("authProvider.sasl=org.apache.zookeeper.server.auth.SASLAuthenticationProvider\n"))
+			Ω(cfg).To(ContainSubstring("requireClientAuthScheme=sasl\n"))
+			Ω(cfg).To(ContainSubstring("enforce.auth.schemes=sasl\n"))
+			Ω(cfg).To(ContainSubstring("quorum.auth.enableSasl=true\n"))
+			Ω(cfg).NotTo(ContainSubstring("quorum.auth.kerberos.servicePrincipal"))
+			Ω(strings.HasSuffix(cfg, "dynamicConfigFile=/data/zoo.cfg.dynamic\n")).To(BeTrue())
+		})
+
+		It("should accept the digest credentials of the operator", func() {
+			z.Spec.OperatorClient = &zookeeperv1.OperatorClientPolicy{
+				Auth: &zookeeperv1.ClientCredentials{SecretName: "operator-credentials"},
+			}
+			z.WithDefaults()
+			Ω(zk.MakeConfigMap(z).Data["zoo.cfg"]).To(ContainSubstring("enforce.auth.schemes=sasl,digest\n"))
+		})
+
+		It("should point the JVMs to the JAAS file", func() {
+			env := zk.MakeConfigMap(z).Data["env.sh"]
+			Ω(env).To(ContainSubstring("AUTH_JVMFLAGS=\"-Djava.security.auth.login.config=/auth/jaas/jaas.conf\"\n"))
+			Ω(env).To(ContainSubstring("export SERVER_JVMFLAGS=\"$AUTH_JVMFLAGS $SERVER_JVMFLAGS\"\n"))
+			Ω(env).To(ContainSubstring("export CLIENT_JVMFLAGS=\"$AUTH_JVMFLAGS $CLIENT_JVMFLAGS\"\n"))
+		})
+
+		Context("through Kerberos", func() {
+			BeforeEach(func() {
+				z.Spec.Auth.Kerberos = &zookeeperv1.KerberosAuth{
+					KeytabSecretName:  "example-keytab",
+					Principal:         "zookeeper/_HOST@EXAMPLE.COM",
+					Krb5ConfigMapName: "krb5",
+				}
+				z.WithDefaults()
+			})
+
+			It("should template the principal of every member", func() {
+				spec := zk.MakeStatefulSet(z).Spec.Template.Spec
+				script := spec.InitContainers[0].Command[2]
+				Ω(script).To(ContainSubstring("HOST=$(hostname -s).example-headless.default.svc.cluster.local\n"))
+				Ω(script).To(ContainSubstring("PRINCIPAL=$(echo 'zookeeper/_HOST@EXAMPLE.COM' | sed \"s/_HOST/$HOST/\")"))
+				Ω(script).To(ContainSubstring("keyTab=\"/auth/kerberos/krb5.keytab\""))
+				Ω(script).To(ContainSubstring("QuorumLearner {\n  com.sun.security.auth.module.Krb5LoginModule required"))
+				Ω(spec.Containers[0].VolumeMounts).To(ContainElement(v1.VolumeMount{Name: "keytab", MountPath: "/auth/kerberos", ReadOnly: true}))
+				Ω(spec.Containers[0].VolumeMounts).To(ContainElement(v1.VolumeMount{Name: "krb5", MountPath: "/auth/krb5", ReadOnly: true}))
+			})
+
+			It("should use the principal between the members", func() {
+				cfg := zk.MakeConfigMap(z).Data["zoo.cfg"]
+				Ω(cfg).To(ContainSubstring("quorum.auth.kerberos.servicePrincipal=zookeeper/_HOST@EXAMPLE.COM\n"))
+				env := zk.MakeConfigMap(z).Data["env.sh"]
+				Ω(env).To(ContainSubstring("-Djava.security.krb5.conf=/auth/krb5/krb5.conf"))
+			})
+		})
+
+		It("should not configure SASL without auth", func() {
+			z.Spec.Auth = nil
+			Ω(zk.MakeConfigMap(z).Data["zoo.cfg"]).NotTo(ContainSubstring("authProvider"))
+			Ω(zk.MakeConfigMap(z).Data["env.sh"]).NotTo(ContainSubstring("JVMFLAGS"))
+			Ω(zk.MakeStatefulSet(z).Spec.Template.Spec.InitContainers).To(BeEmpty())
+		})
+
+		It("should accept the digest credentials of the super user", func() {
+			z.Spec.ACL = &zookeeperv1.ACLPolicy{Enforce: true, SuperUserSecretName: "example-super"}
+			Ω(zk.MakeConfigMap(z).Data["zoo.cfg"]).To(ContainSubstring("enforce.auth.schemes=sasl,digest\n"))
+		})
+	})
+
+	Context("with ACLs enforced", func() {
+		var z *zookeeperv1.ZookeeperCluster
+
+		BeforeEach(func() {
+			z = &zookeeperv1.ZookeeperCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "default",
+				},
+				Spec: zookeeperv1.ZookeeperClusterSpec{
+					ACL: &zookeeperv1.ACLPolicy{Enforce: true, SuperUserSecretName: "example-super"},
+				},
+			}
+			z.WithDefaults()
+		})
+
+		It("should not skip the ACLs", func() {
+			Ω(zk.MakeConfigMap(z).Data["zoo.cfg"]).To(ContainSubstring("skipACL=no\n"))
+		})
+
+		It("should hand the super user to the zookeeper container", func() {
+			env := zk.MakeStatefulSet(z).Spec.Template.Spec.Containers[0].Env
+			Ω(env).To(ContainElement(v1.EnvVar{
+				Name: "ZK_SUPER_USER",
+				ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: "example-super"},
+					Key:                  "username",
+				}},
+			}))
+			Ω(env).To(ContainElement(HaveField("Name", "ZK_SUPER_PASSWORD")))
+		})
+
+		It("should skip the ACLs unless enforced", func() {
+			z.Spec.ACL.Enforce = false
+			Ω(zk.MakeConfigMap(z).Data["zoo.cfg"]).To(ContainSubstring("skipACL=yes\n"))
+			Ω(zk.MakeStatefulSet(z).Spec.Template.Spec.Containers[0].Env).NotTo(ContainElement(HaveField("Name", "ZK_SUPER_USER")))
+		})
+	})
+
+	Context("#MakeStatefulSet with a restore", func() {
+		var z *zookeeperv1.ZookeeperCluster
+
+		BeforeEach(func() {
+			z = &zookeeperv1.ZookeeperCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "default",
+				},
+				Spec: zookeeperv1.ZookeeperClusterSpec{
+					RestoreFrom: &zookeeperv1.RestoreSource{
+						Storage: zookeeperv1.BackupStorage{
+							S3: &zookeeperv1.S3Storage{
+								Endpoint:              "http://minio:9000",
+								Bucket:                "backups",
+								CredentialsSecretName: "s3-credentials",
+							},
+						},
+						Path:     "default/example/nightly.tar.gz",
+						Checksum: "sha256:0123",
+					},
+				},
+			}
+			z.WithDefaults()
+		})
+
+		It("should download the artifact into the data volume before the member starts", func() {
+			spec := zk.MakeStatefulSet(z).Spec.Template.Spec
+			Ω(spec.InitContainers).To(HaveLen(1))
+			restore := spec.InitContainers[0]
+			Ω(restore.Command).To(Equal([]string{"/usr/local/bin/zookeeperRestore.sh"}))
+			Ω(restore.Env).To(ContainElement(v1.EnvVar{Name: "BACKUP_PATH", Value: "default/example/nightly.tar.gz"}))
+			Ω(restore.Env).To(ContainElement(v1.EnvVar{Name: "RESTORE_CHECKSUM", Value: "sha256:0123"}))
+			Ω(restore.Env).To(ContainElement(v1.EnvVar{Name: "S3_REGION", Value: "us-east-1"}))
+			Ω(restore.VolumeMounts).To(ContainElement(v1.VolumeMount{Name: "data", MountPath: "/data"}))
+			Ω(spec.Volumes).To(ContainElement(v1.Volume{
+				Name:         "restore",
+				VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}},
+			}))
+		})
+
+		It("should read the artifact from the claim", func() {
+			z.Spec.RestoreFrom.Storage = zookeeperv1.BackupStorage{PersistentVolumeClaim: &zookeeperv1.PVCStorage{ClaimName: "backups"}}
+			spec := zk.MakeStatefulSet(z).Spec.Template.Spec
+			Ω(spec.Volumes).To(ContainElement(v1.Volume{
+				Name: "restore",
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "backups", ReadOnly: true},
+				},
+			}))
+			Ω(spec.InitContainers[0].VolumeMounts).To(ContainElement(v1.VolumeMount{Name: "restore", MountPath: "/backup", ReadOnly: true}))
+		})
+	})
+
+	Context("#MakeBackupJob", func() {
+		var (
+			z *zookeeperv1.ZookeeperCluster
+			b *zookeeperv1.ZookeeperBackup
+		)
+
+		BeforeEach(func() {
+			z = &zookeeperv1.ZookeeperCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "example",
+					Namespace: "default",
+				},
+			}
+			z.WithDefaults()
+			b = &zookeeperv1.ZookeeperBackup{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "nightly",
+					Namespace: "default",
+				},
+				Spec: zookeeperv1.ZookeeperBackupSpec{
+					ZookeeperCluster: "example",
+					Storage: zookeeperv1.BackupStorage{
+						S3: &zookeeperv1.S3Storage{
+							Endpoint:              "http://minio:9000",
+							Bucket:                "backups",
+							CredentialsSecretName: "s3-credentials",
+						},
+					},
+				},
+			}
+			b.WithDefaults()
+		})
+
+		It("should run next to the member and mount its data read-only", func() {
+			spec := zk.MakeBackupJob(b, z, "example-1").Spec.Template.Spec
+			Ω(spec.Affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].LabelSelector.MatchLabels).To(
+				HaveKeyWithValue("statefulset.kubernetes.io/pod-name", "example-1"))
+			Ω(spec.Volumes).To(ContainElement(v1.Volume{
+				Name: "data",
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "data-example-1", ReadOnly: true},
+				},
+			}))
+			Ω(spec.Containers[0].Image).To(Equal(z.Spec.Image.ToString()))
+			Ω(spec.Containers[0].Command).To(Equal([]string{"/usr/local/bin/zookeeperBackup.sh"}))
+		})
+
+		It("should upload the archive to the bucket", func() {
+			env := zk.MakeBackupJob(b, z, "example-1").Spec.Template.Spec.Containers[0].Env
+			Ω(env).To(ContainElement(v1.EnvVar{Name: "BACKUP_PATH", Value: "default/example/nightly.tar.gz"}))
+			Ω(env).To(ContainElement(v1.EnvVar{Name: "S3_REGION", Value: "us-east-1"}))
+			Ω(env).To(ContainElement(HaveField("ValueFrom.SecretKeyRef.Key", "secretAccessKey")))
+		})
+
+		It("should write the archive to the volume", func() {
+			b.Spec.Storage = zookeeperv1.BackupStorage{PersistentVolumeClaim: &zookeeperv1.PVCStorage{ClaimName: "backups"}}
+			spec := zk.MakeBackupJob(b, z, "example-1").Spec.Template.Spec
+			Ω(spec.Volumes).To(ContainElement(v1.Volume{
+				Name: "backup",
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "backups"},
+				},
+			}))
+			Ω(spec.Containers[0].Env).NotTo(ContainElement(HaveField("Name", "S3_BUCKET")))
+		})
+
+		It("should delete the artifact from the bucket", func() {
+			job := zk.MakeBackupDeleteJob(b, z)
+			Ω(job.Name).To(Equal("nightly-delete"))
+			container := job.Spec.Template.Spec.Containers[0]
+			Ω(container.Args).To(Equal([]string{"delete"}))
+			Ω(container.Env).To(ContainElement(v1.EnvVar{Name: "S3_BUCKET", Value: "backups"}))
+			Ω(container.VolumeMounts).To(BeEmpty())
+			Ω(job.Spec.Template.Spec.Volumes).To(BeEmpty())
+			Ω(job.Spec.Template.Spec.Affinity).To(BeNil())
+		})
+
+		It("should delete the artifact from the volume", func() {
+			b.Spec.Storage = zookeeperv1.BackupStorage{PersistentVolumeClaim: &zookeeperv1.PVCStorage{ClaimName: "backups"}}
+			spec := zk.MakeBackupDeleteJob(b, z).Spec.Template.Spec
+			Ω(spec.Volumes).To(ConsistOf(HaveField("VolumeSource.PersistentVolumeClaim.ClaimName", "backups")))
+			Ω(spec.Containers[0].VolumeMounts).To(ConsistOf(HaveField("MountPath", "/backup")))
+		})
+	})
+
+	Context("#MakeScheduledBackup", func() {
+		It("should name the backup after the scheduled time", func() {
+			s := &zookeeperv1.ZookeeperBackupSchedule{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "nightly",
+					Namespace: "default",
+				},
+				Spec: zookeeperv1.ZookeeperBackupScheduleSpec{
+					Schedule: "0 2 * * *",
+					Backup: zookeeperv1.ZookeeperBackupSpec{
+						ZookeeperCluster: "example",
+						Storage: zookeeperv1.BackupStorage{
+							PersistentVolumeClaim: &zookeeperv1.PVCStorage{ClaimName: "backups"},
+						},
+					},
+				},
+			}
+			s.WithDefaults()
+			b := zk.MakeScheduledBackup(s, time.Date(2024, time.March, 1, 2, 0, 0, 0, time.UTC))
+			Ω(b.Name).To(Equal("nightly-20240301-0200"))
+			Ω(b.Labels).To(HaveKeyWithValue(zk.BackupScheduleLabel, "nightly"))
+			Ω(b.Spec.ReclaimPolicy).To(Equal(zookeeperv1.VolumeReclaimPolicyDelete))
+			Ω(b.Spec.Storage.PersistentVolumeClaim.ClaimName).To(Equal("backups"))
+		})
+	})
+
+	Context("#MakePreUpgradeBackup", func() {
+		It("should name the backup after the generation of the cluster", func() {
+			z := &zookeeperv1.ZookeeperCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "example",
+					Namespace:  "default",
+					Generation: 7,
+				},
+				Spec: zookeeperv1.ZookeeperClusterSpec{
+					UpgradeStrategy: &zookeeperv1.UpgradeStrategy{
+						PreUpgradeBackup: &zookeeperv1.PreUpgradeBackup{
+							Storage: zookeeperv1.BackupStorage{
+								PersistentVolumeClaim: &zookeeperv1.PVCStorage{ClaimName: "backups"},
+							},
+						},
+					},
+				},
+			}
+			z.WithDefaults()
+			b := zk.MakePreUpgradeBackup(z)
+			Ω(b.Name).To(Equal("example-pre-upgrade-7"))
+			Ω(b.Labels).To(HaveKeyWithValue(zk.PreUpgradeBackupLabel, "true"))
+			Ω(b.Spec.ZookeeperCluster).To(Equal("example"))
+			Ω(b.Spec.ReclaimPolicy).To(Equal(zookeeperv1.VolumeReclaimPolicyRetain))
+			Ω(b.Spec.Storage.PersistentVolumeClaim.ClaimName).To(Equal("backups"))
+		})
+	})
 })
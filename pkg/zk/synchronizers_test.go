@@ -11,7 +11,7 @@
 package zk_test
 
 import (
-	"github.com/pravega/zookeeper-operator/api/v1beta1"
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
 	"github.com/pravega/zookeeper-operator/pkg/zk"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
@@ -28,7 +28,7 @@ var _ = Describe("Synchronizers", func() {
 		var sts1 *appsv1.StatefulSet
 
 		BeforeEach(func() {
-			z := &v1beta1.ZookeeperCluster{
+			z := &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "example",
 					Namespace: "default",
@@ -58,7 +58,7 @@ var _ = Describe("Synchronizers", func() {
 		var value string
 
 		BeforeEach(func() {
-			z := &v1beta1.ZookeeperCluster{
+			z := &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "example",
 					Namespace: "default",
@@ -83,7 +83,7 @@ var _ = Describe("Synchronizers", func() {
 	Context("with a valid update of config map", func() {
 		var value string
 		BeforeEach(func() {
-			z := &v1beta1.ZookeeperCluster{
+			z := &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "example",
 					Namespace: "default",
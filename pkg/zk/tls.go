@@ -0,0 +1,204 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package zk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
+)
+
+const (
+	keystorePEM       = "keystore.pem"
+	serverJVMFlagsEnv = "SERVER_JVMFLAGS"
+)
+
+// tlsMount describes where the key material of a TLS Secret is mounted in
+// the zookeeper container, and which zookeeper properties point to it
+type tlsMount struct {
+	// name is the name of the Secret volume, and the prefix of its paths
+	name string
+	// configPrefix is the prefix of the zookeeper keystore properties
+	configPrefix string
+	// passwordEnv holds the password of PKCS12 keystores
+	passwordEnv string
+}
+
+var (
+	clientTLSMount = tlsMount{name: "client", configPrefix: "ssl", passwordEnv: "ZK_CLIENT_TLS_PASSWORD"}
+	quorumTLSMount = tlsMount{name: "quorum", configPrefix: "ssl.quorum", passwordEnv: "ZK_QUORUM_TLS_PASSWORD"}
+)
+
+func (m tlsMount) secretVolume() string   { return m.name + "-tls" }
+func (m tlsMount) secretPath() string     { return "/tls/" + m.name }
+func (m tlsMount) keystoreVolume() string { return m.name + "-keystore" }
+func (m tlsMount) keystorePath() string   { return "/tls/" + m.name + "-keystore" }
+
+type tlsMountedSecret struct {
+	mount  tlsMount
+	secret *zookeeperv1.TLSSecret
+}
+
+// tlsMounts returns the TLS Secrets the zookeeper container reads key
+// material from. The quorum key material stays mounted until the members
+// have left the last phase of quorum TLS.
+func tlsMounts(z *zookeeperv1.ZookeeperCluster) []tlsMountedSecret {
+	var mounts []tlsMountedSecret
+	if z.Spec.TLS.ClientEnabled() {
+		mounts = append(mounts, tlsMountedSecret{clientTLSMount, &z.Spec.TLS.Client.TLSSecret})
+	}
+	if secret := z.QuorumTLSSecret(); secret != nil && z.QuorumTLSPhase() != zookeeperv1.QuorumTLSDisabled {
+		mounts = append(mounts, tlsMountedSecret{quorumTLSMount, secret})
+	}
+	return mounts
+}
+
+// addTo mounts the TLS Secret into the zookeeper container. A PEM keystore
+// has to hold the private key and the certificate in a single file, which an
+// init container assembles from the Secret, while the password of a PKCS12
+// keystore is handed to zookeeper as a system property so that it never
+// shows up in the config map.
+func (m tlsMount) addTo(z *zookeeperv1.ZookeeperCluster, secret *zookeeperv1.TLSSecret, zkContainer *v1.Container, volumes []v1.Volume) ([]v1.Volume, *v1.Container) {
+	volumes = append(volumes, v1.Volume{
+		Name: m.secretVolume(),
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{SecretName: secret.SecretName},
+		},
+	})
+	secretMount := v1.VolumeMount{Name: m.secretVolume(), MountPath: m.secretPath(), ReadOnly: true}
+	zkContainer.VolumeMounts = append(zkContainer.VolumeMounts, secretMount)
+
+	if secret.Format == zookeeperv1.TLSFormatPKCS12 {
+		zkContainer.Env = append(zkContainer.Env, v1.EnvVar{
+			Name: m.passwordEnv,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: secret.SecretName},
+					Key:                  zookeeperv1.PKCS12PasswordKey,
+				},
+			},
+		})
+		zkContainer.Env = withServerJVMFlags(zkContainer.Env,
+			"-Dzookeeper."+m.configPrefix+".keyStore.password=$("+m.passwordEnv+")",
+			"-Dzookeeper."+m.configPrefix+".trustStore.password=$("+m.passwordEnv+")")
+		return volumes, nil
+	}
+
+	volumes = append(volumes, v1.Volume{
+		Name: m.keystoreVolume(),
+		VolumeSource: v1.VolumeSource{
+			EmptyDir: &v1.EmptyDirVolumeSource{Medium: v1.StorageMediumMemory},
+		},
+	})
+	keystoreMount := v1.VolumeMount{Name: m.keystoreVolume(), MountPath: m.keystorePath()}
+	zkContainer.VolumeMounts = append(zkContainer.VolumeMounts, v1.VolumeMount{
+		Name:      m.keystoreVolume(),
+		MountPath: m.keystorePath(),
+		ReadOnly:  true,
+	})
+	initContainer := &v1.Container{
+		Name:            m.keystoreVolume(),
+		Image:           z.Spec.Image.ToString(),
+		ImagePullPolicy: z.Spec.Image.PullPolicy,
+		Command: []string{"sh", "-c", fmt.Sprintf("cat %s/%s %s/%s > %s/%s",
+			m.secretPath(), zookeeperv1.TLSPrivateKeyKey,
+			m.secretPath(), zookeeperv1.TLSCertKey,
+			m.keystorePath(), keystorePEM)},
+		VolumeMounts: []v1.VolumeMount{secretMount, keystoreMount},
+	}
+	return volumes, initContainer
+}
+
+// config returns the zookeeper properties of the keystore and the truststore
+func (m tlsMount) config(secret *zookeeperv1.TLSSecret) string {
+	if secret.Format == zookeeperv1.TLSFormatPKCS12 {
+		return m.configPrefix + ".keyStore.location=" + m.secretPath() + "/" + zookeeperv1.PKCS12KeystoreKey + "\n" +
+			m.configPrefix + ".keyStore.type=PKCS12\n" +
+			m.configPrefix + ".trustStore.location=" + m.secretPath() + "/" + zookeeperv1.PKCS12TruststoreKey + "\n" +
+			m.configPrefix + ".trustStore.type=PKCS12\n"
+	}
+	return m.configPrefix + ".keyStore.location=" + m.keystorePath() + "/" + keystorePEM + "\n" +
+		m.configPrefix + ".keyStore.type=PEM\n" +
+		m.configPrefix + ".trustStore.location=" + m.secretPath() + "/" + zookeeperv1.TLSCAKey + "\n" +
+		m.configPrefix + ".trustStore.type=PEM\n"
+}
+
+// withServerJVMFlags adds flags to the JVM flags of the zookeeper server,
+// keeping the flags the pod policy already sets
+func withServerJVMFlags(env []v1.EnvVar, flags ...string) []v1.EnvVar {
+	value := strings.Join(flags, " ")
+	for i := range env {
+		if env[i].Name == serverJVMFlagsEnv && env[i].ValueFrom == nil {
+			if env[i].Value != "" {
+				value = env[i].Value + " " + value
+			}
+			result := append([]v1.EnvVar{}, env[:i]...)
+			result = append(result, env[i+1:]...)
+			env = result
+			break
+		}
+	}
+	return append(env, v1.EnvVar{Name: serverJVMFlagsEnv, Value: value})
+}
+
+// makeZkTLSEnvString returns the part of env.sh which lets the clients run by
+// the scripts of the image reach the client service over TLS, once it no
+// longer serves plaintext clients. They present the key material of the
+// member.
+func makeZkTLSEnvString(z *zookeeperv1.ZookeeperCluster) string {
+	if z.Spec.TLS.ServesPlaintextClients() {
+		return ""
+	}
+	secret := &z.Spec.TLS.Client.TLSSecret
+	flags := []string{
+		"-Dzookeeper.client.secure=true",
+		"-Dzookeeper.clientCnxnSocket=org.apache.zookeeper.ClientCnxnSocketNetty",
+	}
+	for _, property := range strings.Split(strings.TrimSpace(clientTLSMount.config(secret)), "\n") {
+		flags = append(flags, "-Dzookeeper."+property)
+	}
+	if secret.Format == zookeeperv1.TLSFormatPKCS12 {
+		flags = append(flags,
+			"-Dzookeeper.ssl.keyStore.password=$"+clientTLSMount.passwordEnv,
+			"-Dzookeeper.ssl.trustStore.password=$"+clientTLSMount.passwordEnv)
+	}
+	return "SECURE_CLIENT_PORT=" + strconv.Itoa(int(z.Spec.Ports.SecureClient)) + "\n" +
+		"CLIENT_TLS_JVMFLAGS=\"" + strings.Join(flags, " ") + "\"\n"
+}
+
+// makeZkTLSConfigString returns the TLS properties of zoo.cfg. The quorum
+// properties follow the phase the members are in: port unification lets a
+// member accept TLS and plaintext peers alike, so that members can switch
+// to TLS one at a time without losing the quorum.
+func makeZkTLSConfigString(z *zookeeperv1.ZookeeperCluster) string {
+	config := ""
+	if z.Spec.TLS.ClientEnabled() {
+		tls := z.Spec.TLS.Client
+		config += "secureClientPort=" + strconv.Itoa(int(z.Spec.Ports.SecureClient)) + "\n" +
+			"serverCnxnFactory=org.apache.zookeeper.server.NettyServerCnxnFactory\n" +
+			clientTLSMount.config(&tls.TLSSecret)
+		if tls.ClientAuth != "" {
+			config += "ssl.clientAuth=" + tls.ClientAuth + "\n"
+		}
+	}
+	phase := z.QuorumTLSPhase()
+	if secret := z.QuorumTLSSecret(); secret != nil && phase != zookeeperv1.QuorumTLSDisabled {
+		sslQuorum := phase == zookeeperv1.QuorumTLSWithPortUnification || phase == zookeeperv1.QuorumTLSEnabled
+		config += quorumTLSMount.config(secret) +
+			"sslQuorum=" + strconv.FormatBool(sslQuorum) + "\n" +
+			"portUnification=" + strconv.FormatBool(phase != zookeeperv1.QuorumTLSEnabled) + "\n"
+	}
+	return config
+}
@@ -10,50 +10,170 @@
 package zk
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/pravega/zookeeper-operator/api/v1beta1"
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
 	"github.com/samuel/go-zookeeper/zk"
 )
 
 type ZookeeperClient interface {
-	Connect(string) error
-	CreateNode(*v1beta1.ZookeeperCluster, string) error
+	Connect(string, *ConnectOptions) error
+	CreateNode(*zookeeperv1.ZookeeperCluster, string) error
 	NodeExists(string) (int32, error)
 	UpdateNode(string, string, int32) error
+	ServerStats(string, *tls.Config) (*ServerStats, error)
+	ServerMetrics(string) (map[string]float64, error)
+	GetConfig() (*EnsembleConfig, error)
+	Reconfig(joining []ServerConfig, leaving []int, version int64) error
 	Close()
 }
 
+// ServerStats is the state of a single zookeeper server as reported by the
+// srvr four letter word
+type ServerStats struct {
+	// Mode is the role of the server, e.g. leader, follower or observer
+	Mode        string
+	Zxid        int64
+	Connections int32
+	Outstanding int64
+	// Latency is the min/avg/max request latency in milliseconds
+	Latency string
+}
+
+// Epoch returns the leader epoch the server is in
+func (s *ServerStats) Epoch() int64 {
+	return s.Zxid >> 32
+}
+
+// serverStatsTimeout bounds the time spent on a single server, so that an
+// unreachable member does not hold up the reconcile loop
+const serverStatsTimeout = 2 * time.Second
+
+// ConnectOptions secure the session of the operator with the ensemble
+type ConnectOptions struct {
+	// TLSConfig negotiates TLS with the server when set
+	TLSConfig *tls.Config
+	// Credentials are added to the session once it is established
+	Credentials []Credentials
+}
+
+// Credentials authenticate a session through a zookeeper auth scheme
+type Credentials struct {
+	Scheme string
+	Auth   []byte
+}
+
 type DefaultZookeeperClient struct {
 	conn *zk.Conn
 }
 
-func (client *DefaultZookeeperClient) Connect(zkUri string) (err error) {
+// Connect opens a session with the server at zkUri. Without options the
+// session is neither encrypted nor authenticated.
+func (client *DefaultZookeeperClient) Connect(zkUri string, opts *ConnectOptions) (err error) {
 	host := []string{zkUri}
-	conn, _, err := zk.Connect(host, time.Second*5)
+	dialer := zk.Dialer(net.DialTimeout)
+	if opts != nil && opts.TLSConfig != nil {
+		dialer = tlsDialer(opts.TLSConfig)
+	}
+	conn, _, err := zk.Connect(host, time.Second*5, zk.WithDialer(dialer))
 	if err != nil {
 		return fmt.Errorf("Failed to connect to zookeeper: %s, Reason: %v", zkUri, err)
 	}
+	if opts != nil {
+		for _, creds := range opts.Credentials {
+			if err := conn.AddAuth(creds.Scheme, creds.Auth); err != nil {
+				conn.Close()
+				return fmt.Errorf("Failed to authenticate with zookeeper: %s, Scheme: %s, Reason: %v", zkUri, creds.Scheme, err)
+			}
+		}
+	}
 	client.conn = conn
 	return nil
 }
 
-func (client *DefaultZookeeperClient) CreateNode(zoo *v1beta1.ZookeeperCluster, zNodePath string) (err error) {
+func tlsDialer(config *tls.Config) zk.Dialer {
+	return func(network, address string, timeout time.Duration) (net.Conn, error) {
+		return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, network, address, config)
+	}
+}
+
+// NewClientTLSConfig returns the TLS config of a client of the members. The
+// members are verified with the PEM encoded CA bundle in the ca.crt key of
+// a Secret, and the client presents the certificate in its tls.crt and
+// tls.key keys, if it holds one.
+func NewClientTLSConfig(serverName string, data map[string][]byte) (*tls.Config, error) {
+	caBundle, ok := data[zookeeperv1.TLSCAKey]
+	if !ok {
+		return nil, fmt.Errorf("no %s key", zookeeperv1.TLSCAKey)
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("no PEM encoded certificate in the %s key", zookeeperv1.TLSCAKey)
+	}
+	config := &tls.Config{
+		ServerName: serverName,
+		RootCAs:    rootCAs,
+		MinVersion: tls.VersionTLS12,
+	}
+	cert, hasCert := data[zookeeperv1.TLSCertKey]
+	key, hasKey := data[zookeeperv1.TLSPrivateKeyKey]
+	if hasCert || hasKey {
+		keyPair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{keyPair}
+	}
+	return config, nil
+}
+
+// NewDigestCredentials returns the digest credentials held by the username
+// and password keys of a Secret
+func NewDigestCredentials(data map[string][]byte) (Credentials, error) {
+	for _, key := range []string{zookeeperv1.DigestUsernameKey, zookeeperv1.DigestPasswordKey} {
+		if len(data[key]) == 0 {
+			return Credentials{}, fmt.Errorf("no %s key", key)
+		}
+	}
+	auth := string(data[zookeeperv1.DigestUsernameKey]) + ":" + string(data[zookeeperv1.DigestPasswordKey])
+	return Credentials{Scheme: zookeeperv1.DigestAuthScheme, Auth: []byte(auth)}, nil
+}
+
+func (client *DefaultZookeeperClient) CreateNode(zoo *zookeeperv1.ZookeeperCluster, zNodePath string) (err error) {
+	acl := zk.WorldACL(zk.PermAll)
+	if zoo.Spec.ACL.Enforced() {
+		// restrict the metadata to the identities of the session, i.e. the
+		// super user
+		acl = zk.AuthACL(zk.PermAll)
+	}
 	paths := strings.Split(zNodePath, "/")
 	pathLength := len(paths)
 	var parentPath string
 	for i := 1; i < pathLength-1; i++ {
 		parentPath += "/" + paths[i]
-		if _, err := client.conn.Create(parentPath, nil, 0, zk.WorldACL(zk.PermAll)); err != nil {
+		// the parents exist in an ensemble restored from a backup
+		if _, err := client.conn.Create(parentPath, nil, 0, acl); err != nil && err != zk.ErrNodeExists {
 			return fmt.Errorf("Error creating parent zkNode: %s: %v", parentPath, err)
 		}
 	}
 	data := "CLUSTER_SIZE=" + strconv.Itoa(int(zoo.Spec.Replicas))
 	childNode := parentPath + "/" + paths[pathLength-1]
-	if _, err := client.conn.Create(childNode, []byte(data), 0, zk.WorldACL(zk.PermAll)); err != nil {
+	_, err = client.conn.Create(childNode, []byte(data), 0, acl)
+	if err == zk.ErrNodeExists {
+		// the node was restored along with the size of the backed up cluster
+		_, err = client.conn.Set(childNode, []byte(data), -1)
+	}
+	if err != nil {
 		return fmt.Errorf("Error creating sub zkNode: %s: %v", childNode, err)
 	}
 	return nil
@@ -74,6 +194,150 @@ func (client *DefaultZookeeperClient) NodeExists(zNodePath string) (version int3
 	return zNodeStat.Version, err
 }
 
+// GetConfig reads the dynamic configuration of the ensemble. It syncs with
+// the leader first, so that the last committed reconfiguration is seen.
+func (client *DefaultZookeeperClient) GetConfig() (*EnsembleConfig, error) {
+	if _, err := client.conn.Sync(ZkConfigPath); err != nil {
+		return nil, fmt.Errorf("Error syncing zkNode %s: %v", ZkConfigPath, err)
+	}
+	data, _, err := client.conn.Get(ZkConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading zkNode %s: %v", ZkConfigPath, err)
+	}
+	return ParseEnsembleConfig(string(data))
+}
+
+// Reconfig adds the joining servers to the ensemble, or changes their role
+// if they are members already, and removes the servers with the leaving ids.
+// The reconfiguration only applies to the config with the version, unless
+// the version is -1.
+func (client *DefaultZookeeperClient) Reconfig(joining []ServerConfig, leaving []int, version int64) error {
+	joiningServers := make([]string, 0, len(joining))
+	for _, s := range joining {
+		joiningServers = append(joiningServers, s.String())
+	}
+	leavingServers := make([]string, 0, len(leaving))
+	for _, id := range leaving {
+		leavingServers = append(leavingServers, strconv.Itoa(id))
+	}
+	if _, err := client.conn.IncrementalReconfig(joiningServers, leavingServers, version); err != nil {
+		return fmt.Errorf("Error reconfiguring the ensemble, joining: %v, leaving: %v: %v", joiningServers, leavingServers, err)
+	}
+	return nil
+}
+
+// ServerStats queries a single zookeeper server without opening a session, so
+// it also answers while the server is not part of a quorum. The query is sent
+// over TLS when a TLS config is given.
+func (client *DefaultZookeeperClient) ServerStats(address string, tlsConfig *tls.Config) (*ServerStats, error) {
+	var conn net.Conn
+	var err error
+	if tlsConfig != nil {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: serverStatsTimeout}, "tcp", address, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", address, serverStatsTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to zookeeper server %s: %v", address, err)
+	}
+	defer conn.Close()
+	if err = conn.SetDeadline(time.Now().Add(serverStatsTimeout)); err != nil {
+		return nil, err
+	}
+	if _, err = conn.Write([]byte("srvr")); err != nil {
+		return nil, fmt.Errorf("Failed to query zookeeper server %s: %v", address, err)
+	}
+	out, err := io.ReadAll(conn)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the stats of zookeeper server %s: %v", address, err)
+	}
+	return parseServerStats(out)
+}
+
+// parseServerStats reads the "Key: value" lines of a srvr response. A server
+// which does not serve requests, or does not allow srvr, answers with a
+// single line of explanation instead.
+func parseServerStats(out []byte) (*ServerStats, error) {
+	stats := &ServerStats{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		var err error
+		switch key {
+		case "Mode":
+			stats.Mode = value
+		case "Zxid":
+			stats.Zxid, err = strconv.ParseInt(value, 0, 64)
+		case "Connections":
+			var connections int64
+			connections, err = strconv.ParseInt(value, 10, 32)
+			stats.Connections = int32(connections)
+		case "Outstanding":
+			stats.Outstanding, err = strconv.ParseInt(value, 10, 64)
+		case "Latency min/avg/max":
+			stats.Latency = value
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Invalid %s in zookeeper server stats: %v", key, err)
+		}
+	}
+	if stats.Mode == "" {
+		return nil, fmt.Errorf("Zookeeper server did not report its mode: %s", strings.TrimSpace(string(out)))
+	}
+	return stats, nil
+}
+
+// ServerMetrics reads the Prometheus metrics of the zookeeper server whose
+// metrics provider listens on the address. The samples of a metric are summed
+// across their labels.
+func (client *DefaultZookeeperClient) ServerMetrics(address string) (map[string]float64, error) {
+	httpClient := &http.Client{Timeout: serverStatsTimeout}
+	resp, err := httpClient.Get("http://" + address + "/metrics")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the metrics of zookeeper server %s: %v", address, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to read the metrics of zookeeper server %s: %s", address, resp.Status)
+	}
+	return parseServerMetrics(resp.Body)
+}
+
+// parseServerMetrics reads the samples of the Prometheus text format, skipping
+// the comments
+func parseServerMetrics(r io.Reader) (map[string]float64, error) {
+	metrics := map[string]float64{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, rest := line, ""
+		if i := strings.IndexAny(line, "{ "); i >= 0 {
+			name, rest = line[:i], line[i:]
+		}
+		if strings.HasPrefix(rest, "{") {
+			rest = rest[strings.LastIndex(rest, "}")+1:]
+		}
+		// an optional timestamp follows the value
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("Invalid metric sample: %s", line)
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value of metric %s: %v", name, err)
+		}
+		metrics[name] += value
+	}
+	return metrics, scanner.Err()
+}
+
 func (client *DefaultZookeeperClient) Close() {
 	client.conn.Close()
 }
@@ -11,9 +11,22 @@
 package zk_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	"github.com/pravega/zookeeper-operator/api/v1beta1"
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
 	"github.com/pravega/zookeeper-operator/pkg/zk"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -23,7 +36,7 @@ var _ = Describe("Zookeeper Client", func() {
 	Context("with a valid update of Service port", func() {
 		var err1, err2, err3, err4, err5 error
 		BeforeEach(func() {
-			z := &v1beta1.ZookeeperCluster{
+			z := &zookeeperv1.ZookeeperCluster{
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      "example",
 					Namespace: "default",
@@ -31,7 +44,7 @@ var _ = Describe("Zookeeper Client", func() {
 			}
 			zkclient := new(zk.DefaultZookeeperClient)
 			z.WithDefaults()
-			err1 = zkclient.Connect("127.0.0.0:2181")
+			err1 = zkclient.Connect("127.0.0.0:2181", nil)
 			err2 = zkclient.CreateNode(z, "temp/tmp/tmp")
 			err5 = zkclient.CreateNode(z, "temp/tmp")
 			err3 = zkclient.UpdateNode("temp/tem/temp", "dasd", 2)
@@ -54,4 +67,170 @@ var _ = Describe("Zookeeper Client", func() {
 			Ω(err5).ShouldNot(BeNil())
 		})
 	})
+
+	Context("#ServerStats", func() {
+		var (
+			listener net.Listener
+			response string
+		)
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			Ω(err).Should(BeNil())
+			go func() {
+				for {
+					conn, err := listener.Accept()
+					if err != nil {
+						return
+					}
+					buf := make([]byte, 4)
+					conn.Read(buf)
+					if string(buf) == "srvr" {
+						conn.Write([]byte(response))
+					}
+					conn.Close()
+				}
+			}()
+		})
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("should parse the stats of a leader", func() {
+			response = "Zookeeper version: 3.6.3--6401e4ad2087061bc6b9f80dec2d69f2e3c8660a, built on 04/08/2021 16:35 GMT\n" +
+				"Latency min/avg/max: 0/0.4167/5\n" +
+				"Received: 120\n" +
+				"Sent: 119\n" +
+				"Connections: 3\n" +
+				"Outstanding: 1\n" +
+				"Zxid: 0x20000000a\n" +
+				"Mode: leader\n" +
+				"Node count: 7\n" +
+				"Proposal sizes last/min/max: 48/36/92\n"
+			stats, err := new(zk.DefaultZookeeperClient).ServerStats(listener.Addr().String(), nil)
+			Ω(err).Should(BeNil())
+			Ω(stats.Mode).Should(Equal("leader"))
+			Ω(stats.Zxid).Should(BeEquivalentTo(0x20000000a))
+			Ω(stats.Epoch()).Should(BeEquivalentTo(2))
+			Ω(stats.Connections).Should(BeEquivalentTo(3))
+			Ω(stats.Outstanding).Should(BeEquivalentTo(1))
+			Ω(stats.Latency).Should(Equal("0/0.4167/5"))
+		})
+		It("should fail for a server which is not serving requests", func() {
+			response = "This ZooKeeper instance is not currently serving requests\n"
+			_, err := new(zk.DefaultZookeeperClient).ServerStats(listener.Addr().String(), nil)
+			Ω(err).ShouldNot(BeNil())
+			Ω(err.Error()).Should(ContainSubstring("not currently serving requests"))
+		})
+		It("should fail for an unreachable server", func() {
+			address := listener.Addr().String()
+			listener.Close()
+			_, err := new(zk.DefaultZookeeperClient).ServerStats(address, nil)
+			Ω(err).ShouldNot(BeNil())
+		})
+	})
+
+	Context("#ServerMetrics", func() {
+		var (
+			server   *httptest.Server
+			response string
+		)
+		BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/metrics" {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(response))
+			}))
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("should sum the samples of a metric across their labels", func() {
+			response = "# HELP unsuccessful_handshake unsuccessful_handshake\n" +
+				"# TYPE unsuccessful_handshake counter\n" +
+				"unsuccessful_handshake 3.0\n" +
+				"# TYPE digest_mismatches_count counter\n" +
+				"digest_mismatches_count 0.0\n" +
+				"# TYPE jvm_threads_state gauge\n" +
+				"jvm_threads_state{state=\"RUNNABLE\",} 12.0\n" +
+				"jvm_threads_state{state=\"BLOCKED\",} 1.0 1697000000000\n"
+			metrics, err := new(zk.DefaultZookeeperClient).ServerMetrics(strings.TrimPrefix(server.URL, "http://"))
+			Ω(err).Should(BeNil())
+			Ω(metrics).Should(HaveKeyWithValue("unsuccessful_handshake", 3.0))
+			Ω(metrics).Should(HaveKeyWithValue("digest_mismatches_count", 0.0))
+			Ω(metrics).Should(HaveKeyWithValue("jvm_threads_state", 13.0))
+		})
+		It("should fail for an invalid sample", func() {
+			response = "unsuccessful_handshake three\n"
+			_, err := new(zk.DefaultZookeeperClient).ServerMetrics(strings.TrimPrefix(server.URL, "http://"))
+			Ω(err).ShouldNot(BeNil())
+		})
+		It("should fail for an unreachable server", func() {
+			address := strings.TrimPrefix(server.URL, "http://")
+			server.Close()
+			_, err := new(zk.DefaultZookeeperClient).ServerMetrics(address)
+			Ω(err).ShouldNot(BeNil())
+		})
+	})
+
+	Context("#NewClientTLSConfig", func() {
+		var cert, key []byte
+		BeforeEach(func() {
+			privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Ω(err).Should(BeNil())
+			template := &x509.Certificate{
+				SerialNumber:          big.NewInt(1),
+				Subject:               pkix.Name{CommonName: "example-client"},
+				NotBefore:             time.Now(),
+				NotAfter:              time.Now().Add(time.Hour),
+				IsCA:                  true,
+				BasicConstraintsValid: true,
+				KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+			}
+			der, err := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+			Ω(err).Should(BeNil())
+			keyDer, err := x509.MarshalECPrivateKey(privateKey)
+			Ω(err).Should(BeNil())
+			cert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+			key = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+		})
+		It("should verify the server and present the client certificate", func() {
+			config, err := zk.NewClientTLSConfig("example-client", map[string][]byte{"ca.crt": cert, "tls.crt": cert, "tls.key": key})
+			Ω(err).Should(BeNil())
+			Ω(config.ServerName).Should(Equal("example-client"))
+			Ω(config.RootCAs).ShouldNot(BeNil())
+			Ω(config.Certificates).Should(HaveLen(1))
+		})
+		It("should not present a certificate without one", func() {
+			config, err := zk.NewClientTLSConfig("example-client", map[string][]byte{"ca.crt": cert})
+			Ω(err).Should(BeNil())
+			Ω(config.Certificates).Should(BeEmpty())
+		})
+		It("should fail without a CA bundle", func() {
+			_, err := zk.NewClientTLSConfig("example-client", map[string][]byte{"tls.crt": cert, "tls.key": key})
+			Ω(err).ShouldNot(BeNil())
+			Ω(err.Error()).Should(ContainSubstring("no ca.crt key"))
+		})
+		It("should fail with a certificate but no key", func() {
+			_, err := zk.NewClientTLSConfig("example-client", map[string][]byte{"ca.crt": cert, "tls.crt": cert})
+			Ω(err).ShouldNot(BeNil())
+		})
+	})
+
+	Context("#NewDigestCredentials", func() {
+		It("should join the username and the password", func() {
+			creds, err := zk.NewDigestCredentials(map[string][]byte{"username": []byte("operator"), "password": []byte("secret")})
+			Ω(err).Should(BeNil())
+			Ω(creds.Scheme).Should(Equal("digest"))
+			Ω(string(creds.Auth)).Should(Equal("operator:secret"))
+		})
+		It("should fail without a password", func() {
+			_, err := zk.NewDigestCredentials(map[string][]byte{"username": []byte("operator")})
+			Ω(err).ShouldNot(BeNil())
+			Ω(err.Error()).Should(ContainSubstring("no password key"))
+		})
+	})
 })
@@ -0,0 +1,308 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+// Package znodedump takes logical dumps of znode trees and loads them into
+// other ensembles, under the same or another root.
+package znodedump
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/samuel/go-zookeeper/zk"
+)
+
+// Format is the serialization of a dump
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+// ConflictPolicy is what happens to a znode of the dump which exists in the
+// target ensemble already
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the existing znode as it is
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the data and the ACL of the existing znode
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictFail stops the import at the first existing znode
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// systemRoot holds the quotas and the configuration of an ensemble, which
+// are not part of its data
+const systemRoot = "/zookeeper"
+
+// sequentialSuffix is the counter zookeeper appends to sequential znodes
+var sequentialSuffix = regexp.MustCompile(`\d{10}$`)
+
+// Conn is the part of a zookeeper connection a dump is taken and loaded
+// through, which *zk.Conn implements
+type Conn interface {
+	Children(path string) ([]string, *zk.Stat, error)
+	Get(path string) ([]byte, *zk.Stat, error)
+	GetACL(path string) ([]zk.ACL, *zk.Stat, error)
+	Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error)
+	Set(path string, data []byte, version int32) (*zk.Stat, error)
+	SetACL(path string, acl []zk.ACL, version int32) (*zk.Stat, error)
+}
+
+// Dump is a logical dump of a znode tree
+type Dump struct {
+	// Root is the path the tree was dumped from
+	Root string `json:"root"`
+	// Nodes are the znodes of the tree, parents first. Their paths are
+	// relative to the root, which is dumped as /.
+	Nodes []Node `json:"nodes"`
+}
+
+// Node is a znode of a dump
+type Node struct {
+	Path string `json:"path"`
+	// Data is base64 encoded when serialized
+	Data []byte `json:"data,omitempty"`
+	ACL  []ACL  `json:"acl,omitempty"`
+	// Ephemeral znodes belong to the session of a client of the dumped
+	// ensemble
+	Ephemeral bool `json:"ephemeral,omitempty"`
+	// Sequential znodes carry the counter of a sequential creation in their
+	// name. Zookeeper does not record the flag, it is derived from the name.
+	Sequential bool `json:"sequential,omitempty"`
+}
+
+// ACL is an entry of the access control list of a znode
+type ACL struct {
+	Scheme string `json:"scheme"`
+	ID     string `json:"id"`
+	// Perms are the permissions granted, a combination of c, d, r, w and a
+	// as in the zookeeper CLI
+	Perms string `json:"perms"`
+}
+
+// ImportOptions tunes how a dump is loaded
+type ImportOptions struct {
+	// Root is the path the root of the dump is loaded at, the root of the
+	// dump itself by default
+	Root string
+	// Conflict is the policy for znodes which exist already, skip by default
+	Conflict ConflictPolicy
+	// Ephemerals loads the ephemeral znodes of the dump as persistent ones.
+	// They are left out by default.
+	Ephemerals bool
+	// WorldACL loads every znode with the open ACL instead of its own
+	WorldACL bool
+}
+
+// ImportResult counts what happened to the znodes of a dump
+type ImportResult struct {
+	Created     int
+	Overwritten int
+	Skipped     int
+}
+
+// Export dumps the tree under root, leaving out the system znodes under
+// /zookeeper
+func Export(conn Conn, root string) (*Dump, error) {
+	root = path.Clean("/" + root)
+	dump := &Dump{Root: root}
+	if err := exportNode(conn, dump, root, "/"); err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+func exportNode(conn Conn, dump *Dump, root string, relative string) error {
+	absolute := path.Join(root, relative)
+	data, stat, err := conn.Get(absolute)
+	if err == zk.ErrNoNode && relative != "/" {
+		// deleted while the tree is walked
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Error reading znode %s: %v", absolute, err)
+	}
+	acl, _, err := conn.GetACL(absolute)
+	if err != nil {
+		return fmt.Errorf("Error reading the ACL of znode %s: %v", absolute, err)
+	}
+	dump.Nodes = append(dump.Nodes, Node{
+		Path:       relative,
+		Data:       data,
+		ACL:        fromZkACL(acl),
+		Ephemeral:  stat.EphemeralOwner != 0,
+		Sequential: relative != "/" && sequentialSuffix.MatchString(path.Base(relative)),
+	})
+
+	children, _, err := conn.Children(absolute)
+	if err == zk.ErrNoNode {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Error listing the children of znode %s: %v", absolute, err)
+	}
+	sort.Strings(children)
+	for _, child := range children {
+		if path.Join(absolute, child) == systemRoot {
+			continue
+		}
+		if err = exportNode(conn, dump, root, path.Join(relative, child)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Import loads the dump, creating the parents of its root as needed
+func Import(conn Conn, dump *Dump, opts ImportOptions) (*ImportResult, error) {
+	root := opts.Root
+	if root == "" {
+		root = dump.Root
+	}
+	root = path.Clean("/" + root)
+	conflict := opts.Conflict
+	if conflict == "" {
+		conflict = ConflictSkip
+	}
+
+	if err := createParents(conn, root); err != nil {
+		return nil, err
+	}
+	result := &ImportResult{}
+	for _, node := range dump.Nodes {
+		if node.Ephemeral && !opts.Ephemerals {
+			continue
+		}
+		target := path.Join(root, node.Path)
+		if target == systemRoot || strings.HasPrefix(target, systemRoot+"/") {
+			return result, fmt.Errorf("Error importing znode %s: the system znodes cannot be written", target)
+		}
+		acl := zk.WorldACL(zk.PermAll)
+		if !opts.WorldACL && len(node.ACL) > 0 {
+			var err error
+			if acl, err = toZkACL(node.ACL); err != nil {
+				return result, fmt.Errorf("Error importing znode %s: %v", target, err)
+			}
+		}
+		// sequential znodes keep their name, they are not created again
+		// with a new counter
+		_, err := conn.Create(target, node.Data, 0, acl)
+		if err == nil {
+			result.Created++
+			continue
+		} else if err != zk.ErrNodeExists {
+			return result, fmt.Errorf("Error creating znode %s: %v", target, err)
+		}
+		switch conflict {
+		case ConflictSkip:
+			result.Skipped++
+		case ConflictOverwrite:
+			if _, err = conn.Set(target, node.Data, -1); err != nil {
+				return result, fmt.Errorf("Error updating znode %s: %v", target, err)
+			}
+			if _, err = conn.SetACL(target, acl, -1); err != nil {
+				return result, fmt.Errorf("Error updating the ACL of znode %s: %v", target, err)
+			}
+			result.Overwritten++
+		default:
+			return result, fmt.Errorf("Error importing znode %s: it exists already", target)
+		}
+	}
+	return result, nil
+}
+
+// createParents creates the missing parents of a path as empty, open
+// znodes
+func createParents(conn Conn, p string) error {
+	parent := ""
+	for _, name := range strings.Split(strings.Trim(path.Dir(p), "/"), "/") {
+		if name == "" {
+			continue
+		}
+		parent += "/" + name
+		if _, err := conn.Create(parent, nil, 0, zk.WorldACL(zk.PermAll)); err != nil && err != zk.ErrNodeExists {
+			return fmt.Errorf("Error creating parent znode %s: %v", parent, err)
+		}
+	}
+	return nil
+}
+
+// Marshal serializes the dump in the format
+func Marshal(dump *Dump, format Format) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(dump, "", "  ")
+	case FormatYAML:
+		return yaml.Marshal(dump)
+	}
+	return nil, fmt.Errorf("unknown format %q, expected json or yaml", format)
+}
+
+// Unmarshal reads a dump serialized in either format
+func Unmarshal(data []byte) (*Dump, error) {
+	dump := &Dump{}
+	// JSON is read as YAML as well
+	if err := yaml.Unmarshal(data, dump); err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+var permissions = []struct {
+	flag int32
+	name byte
+}{
+	{zk.PermCreate, 'c'},
+	{zk.PermDelete, 'd'},
+	{zk.PermRead, 'r'},
+	{zk.PermWrite, 'w'},
+	{zk.PermAdmin, 'a'},
+}
+
+func fromZkACL(acl []zk.ACL) []ACL {
+	result := make([]ACL, 0, len(acl))
+	for _, entry := range acl {
+		perms := []byte{}
+		for _, p := range permissions {
+			if entry.Perms&p.flag != 0 {
+				perms = append(perms, p.name)
+			}
+		}
+		result = append(result, ACL{Scheme: entry.Scheme, ID: entry.ID, Perms: string(perms)})
+	}
+	return result
+}
+
+func toZkACL(acl []ACL) ([]zk.ACL, error) {
+	result := make([]zk.ACL, 0, len(acl))
+	for _, entry := range acl {
+		perms := int32(0)
+		for i := range entry.Perms {
+			found := false
+			for _, p := range permissions {
+				if entry.Perms[i] == p.name {
+					perms |= p.flag
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unknown permission %q in ACL %s:%s", entry.Perms[i], entry.Scheme, entry.ID)
+			}
+		}
+		result = append(result, zk.ACL{Perms: perms, Scheme: entry.Scheme, ID: entry.ID})
+	}
+	return result, nil
+}
@@ -0,0 +1,282 @@
+/**
+ * Copyright (c) 2018 Dell Inc., or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ */
+
+package znodedump
+
+import (
+	"path"
+
+	"github.com/samuel/go-zookeeper/zk"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeNode struct {
+	data      []byte
+	acl       []zk.ACL
+	ephemeral bool
+}
+
+// fakeConn is an in-memory znode tree
+type fakeConn struct {
+	nodes map[string]*fakeNode
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{nodes: map[string]*fakeNode{
+		"/":                 {acl: zk.WorldACL(zk.PermAll)},
+		"/zookeeper":        {acl: zk.WorldACL(zk.PermAll)},
+		"/zookeeper/config": {acl: zk.WorldACL(zk.PermRead)},
+		"/zookeeper/quota":  {acl: zk.WorldACL(zk.PermAll)},
+	}}
+}
+
+func (c *fakeConn) Children(p string) ([]string, *zk.Stat, error) {
+	if _, ok := c.nodes[p]; !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	children := []string{}
+	for other := range c.nodes {
+		if other != "/" && path.Dir(other) == p {
+			children = append(children, path.Base(other))
+		}
+	}
+	return children, &zk.Stat{}, nil
+}
+
+func (c *fakeConn) Get(p string) ([]byte, *zk.Stat, error) {
+	n, ok := c.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	stat := &zk.Stat{}
+	if n.ephemeral {
+		stat.EphemeralOwner = 1
+	}
+	return n.data, stat, nil
+}
+
+func (c *fakeConn) GetACL(p string) ([]zk.ACL, *zk.Stat, error) {
+	n, ok := c.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return n.acl, &zk.Stat{}, nil
+}
+
+func (c *fakeConn) Create(p string, data []byte, flags int32, acl []zk.ACL) (string, error) {
+	if _, ok := c.nodes[p]; ok {
+		return "", zk.ErrNodeExists
+	}
+	if _, ok := c.nodes[path.Dir(p)]; !ok {
+		return "", zk.ErrNoNode
+	}
+	c.nodes[p] = &fakeNode{data: data, acl: acl, ephemeral: flags&zk.FlagEphemeral != 0}
+	return p, nil
+}
+
+func (c *fakeConn) Set(p string, data []byte, version int32) (*zk.Stat, error) {
+	n, ok := c.nodes[p]
+	if !ok {
+		return nil, zk.ErrNoNode
+	}
+	n.data = data
+	return &zk.Stat{}, nil
+}
+
+func (c *fakeConn) SetACL(p string, acl []zk.ACL, version int32) (*zk.Stat, error) {
+	n, ok := c.nodes[p]
+	if !ok {
+		return nil, zk.ErrNoNode
+	}
+	n.acl = acl
+	return &zk.Stat{}, nil
+}
+
+var _ = Describe("Znode Dump", func() {
+	var source *fakeConn
+
+	digestACL := []zk.ACL{{Perms: zk.PermRead | zk.PermAdmin, Scheme: "digest", ID: "admin:hash"}}
+
+	BeforeEach(func() {
+		source = newFakeConn()
+		source.nodes["/app"] = &fakeNode{data: []byte("root"), acl: zk.WorldACL(zk.PermAll)}
+		source.nodes["/app/config"] = &fakeNode{data: []byte{0, 1, 2}, acl: digestACL}
+		source.nodes["/app/locks"] = &fakeNode{acl: zk.WorldACL(zk.PermAll)}
+		source.nodes["/app/locks/lock-0000000007"] = &fakeNode{acl: zk.WorldACL(zk.PermAll), ephemeral: true}
+	})
+
+	Context("Export", func() {
+		It("should walk the tree parents first", func() {
+			dump, err := Export(source, "/app")
+			Ω(err).To(BeNil())
+			Ω(dump.Root).To(Equal("/app"))
+			Ω(dump.Nodes).To(HaveLen(4))
+			Ω(dump.Nodes[0]).To(Equal(Node{Path: "/", Data: []byte("root"), ACL: []ACL{{Scheme: "world", ID: "anyone", Perms: "cdrwa"}}}))
+			Ω(dump.Nodes[1].Path).To(Equal("/config"))
+			Ω(dump.Nodes[1].ACL).To(Equal([]ACL{{Scheme: "digest", ID: "admin:hash", Perms: "ra"}}))
+			Ω(dump.Nodes[2].Path).To(Equal("/locks"))
+			Ω(dump.Nodes[3].Path).To(Equal("/locks/lock-0000000007"))
+			Ω(dump.Nodes[3].Ephemeral).To(BeTrue())
+			Ω(dump.Nodes[3].Sequential).To(BeTrue())
+		})
+
+		It("should leave out the system znodes", func() {
+			dump, err := Export(source, "/")
+			Ω(err).To(BeNil())
+			for _, node := range dump.Nodes {
+				Ω(node.Path).NotTo(HavePrefix("/zookeeper"))
+			}
+			Ω(dump.Nodes).To(HaveLen(5))
+		})
+
+		It("should fail on a missing root", func() {
+			_, err := Export(source, "/missing")
+			Ω(err).NotTo(BeNil())
+		})
+	})
+
+	Context("Marshal", func() {
+		It("should read both formats back", func() {
+			dump, err := Export(source, "/app")
+			Ω(err).To(BeNil())
+			for _, format := range []Format{FormatJSON, FormatYAML} {
+				data, err := Marshal(dump, format)
+				Ω(err).To(BeNil())
+				Ω(string(data)).To(ContainSubstring("AAEC"))
+				read, err := Unmarshal(data)
+				Ω(err).To(BeNil())
+				Ω(read).To(Equal(dump))
+			}
+		})
+
+		It("should reject an unknown format", func() {
+			_, err := Marshal(&Dump{}, "xml")
+			Ω(err).NotTo(BeNil())
+		})
+	})
+
+	Context("Import", func() {
+		var (
+			target *fakeConn
+			dump   *Dump
+			opts   ImportOptions
+			result *ImportResult
+			err    error
+		)
+
+		BeforeEach(func() {
+			target = newFakeConn()
+			dump, err = Export(source, "/app")
+			Ω(err).To(BeNil())
+			opts = ImportOptions{}
+		})
+
+		JustBeforeEach(func() {
+			result, err = Import(target, dump, opts)
+		})
+
+		It("should load the tree at its root", func() {
+			Ω(err).To(BeNil())
+			Ω(result.Created).To(Equal(3))
+			Ω(target.nodes["/app/config"].data).To(Equal([]byte{0, 1, 2}))
+			Ω(target.nodes["/app/config"].acl).To(Equal(digestACL))
+			Ω(target.nodes).NotTo(HaveKey("/app/locks/lock-0000000007"))
+		})
+
+		Context("under another root", func() {
+			BeforeEach(func() {
+				opts.Root = "/copies/app"
+			})
+
+			It("should create the parents of the root", func() {
+				Ω(err).To(BeNil())
+				Ω(target.nodes).To(HaveKey("/copies"))
+				Ω(target.nodes["/copies/app"].data).To(Equal([]byte("root")))
+				Ω(target.nodes).To(HaveKey("/copies/app/config"))
+			})
+		})
+
+		Context("with ephemeral znodes and without ACLs", func() {
+			BeforeEach(func() {
+				opts.Ephemerals = true
+				opts.WorldACL = true
+			})
+
+			It("should create them as persistent, open znodes", func() {
+				Ω(err).To(BeNil())
+				Ω(result.Created).To(Equal(4))
+				Ω(target.nodes["/app/locks/lock-0000000007"].ephemeral).To(BeFalse())
+				Ω(target.nodes["/app/config"].acl).To(Equal(zk.WorldACL(zk.PermAll)))
+			})
+		})
+
+		Context("when znodes exist", func() {
+			BeforeEach(func() {
+				target.nodes["/app"] = &fakeNode{data: []byte("old"), acl: zk.WorldACL(zk.PermRead)}
+			})
+
+			It("should skip them by default", func() {
+				Ω(err).To(BeNil())
+				Ω(result.Skipped).To(Equal(1))
+				Ω(result.Created).To(Equal(2))
+				Ω(target.nodes["/app"].data).To(Equal([]byte("old")))
+			})
+
+			Context("and they are overwritten", func() {
+				BeforeEach(func() {
+					opts.Conflict = ConflictOverwrite
+				})
+
+				It("should replace their data and ACL", func() {
+					Ω(err).To(BeNil())
+					Ω(result.Overwritten).To(Equal(1))
+					Ω(target.nodes["/app"].data).To(Equal([]byte("root")))
+					Ω(target.nodes["/app"].acl).To(Equal(zk.WorldACL(zk.PermAll)))
+				})
+			})
+
+			Context("and the import fails on conflicts", func() {
+				BeforeEach(func() {
+					opts.Conflict = ConflictFail
+				})
+
+				It("should stop at the first one", func() {
+					Ω(err).NotTo(BeNil())
+					Ω(err.Error()).To(ContainSubstring("/app"))
+					Ω(target.nodes).NotTo(HaveKey("/app/config"))
+				})
+			})
+		})
+
+		Context("into the system znodes", func() {
+			BeforeEach(func() {
+				opts.Root = "/zookeeper/app"
+			})
+
+			It("should refuse the import", func() {
+				Ω(err).NotTo(BeNil())
+				Ω(err.Error()).To(ContainSubstring("system znodes"))
+			})
+		})
+
+		Context("with an invalid ACL", func() {
+			BeforeEach(func() {
+				dump.Nodes[1].ACL[0].Perms = "rx"
+			})
+
+			It("should report it", func() {
+				Ω(err).NotTo(BeNil())
+				Ω(err.Error()).To(ContainSubstring("unknown permission"))
+			})
+		})
+	})
+})
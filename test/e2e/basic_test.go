@@ -24,7 +24,7 @@ var _ = Describe("Basic test controller", func() {
 			defaultCluster := zk_e2eutil.NewDefaultCluster(testNamespace)
 			defaultCluster.WithDefaults()
 			defaultCluster.Status.Init()
-			defaultCluster.Spec.Persistence.VolumeReclaimPolicy = "Delete"
+			defaultCluster.Spec.Storage.Persistence.VolumeReclaimPolicy = "Delete"
 
 			zk, err := zk_e2eutil.CreateCluster(logger, k8sClient, defaultCluster)
 			Expect(err).NotTo(HaveOccurred())
@@ -41,7 +41,7 @@ var _ = Describe("Basic test controller", func() {
 			defaultCluster = zk_e2eutil.NewDefaultCluster(testNamespace)
 			defaultCluster.WithDefaults()
 			defaultCluster.Status.Init()
-			defaultCluster.Spec.Persistence.VolumeReclaimPolicy = "Delete"
+			defaultCluster.Spec.Storage.Persistence.VolumeReclaimPolicy = "Delete"
 
 			zk, err = zk_e2eutil.CreateCluster(logger, k8sClient, defaultCluster)
 			Expect(err).NotTo(HaveOccurred())
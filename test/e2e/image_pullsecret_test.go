@@ -25,7 +25,7 @@ var _ = Describe("Image pull secret check", func() {
 			defaultCluster := zk_e2eutil.NewDefaultCluster(testNamespace)
 			defaultCluster.WithDefaults()
 			defaultCluster.Status.Init()
-			defaultCluster.Spec.Persistence.VolumeReclaimPolicy = "Delete"
+			defaultCluster.Spec.Storage.Persistence.VolumeReclaimPolicy = "Delete"
 			defaultCluster.Spec.Image.Repository = "testanisha/zookeeper"
 			defaultCluster.Spec.Image.Tag = "checksecret_1"
 			defaultCluster.Spec.Pod.ImagePullSecrets = []v1.LocalObjectReference{
@@ -13,7 +13,7 @@ package e2e
 import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	api "github.com/pravega/zookeeper-operator/api/v1beta1"
+	api "github.com/pravega/zookeeper-operator/api/v1"
 	zk_e2eutil "github.com/pravega/zookeeper-operator/pkg/test/e2e/e2eutil"
 	"time"
 )
@@ -28,7 +28,7 @@ var _ = Describe("Operations with multiple cluster", func() {
 			defaultCluster.WithDefaults()
 			defaultCluster.Status.Init()
 			defaultCluster.ObjectMeta.Name = "zk1"
-			defaultCluster.Spec.Persistence.VolumeReclaimPolicy = "Delete"
+			defaultCluster.Spec.Storage.Persistence.VolumeReclaimPolicy = "Delete"
 
 			zk1, err := zk_e2eutil.CreateCluster(logger, k8sClient, defaultCluster)
 			Expect(err).NotTo(HaveOccurred())
@@ -41,7 +41,7 @@ var _ = Describe("Operations with multiple cluster", func() {
 
 			defaultCluster.WithDefaults()
 			defaultCluster.Status.Init()
-			defaultCluster.Spec.Persistence.VolumeReclaimPolicy = "Delete"
+			defaultCluster.Spec.Storage.Persistence.VolumeReclaimPolicy = "Delete"
 			defaultCluster.ObjectMeta.Name = "zk2"
 			initialVersion := "0.2.7"
 			upgradeVersion := "0.2.9"
@@ -64,7 +64,7 @@ var _ = Describe("Operations with multiple cluster", func() {
 			defaultCluster.WithDefaults()
 			defaultCluster.Status.Init()
 			defaultCluster.ObjectMeta.Name = "zk3"
-			defaultCluster.Spec.Persistence.VolumeReclaimPolicy = "Delete"
+			defaultCluster.Spec.Storage.Persistence.VolumeReclaimPolicy = "Delete"
 
 			zk3, err := zk_e2eutil.CreateCluster(logger, k8sClient, defaultCluster)
 			Expect(err).NotTo(HaveOccurred())
@@ -139,7 +139,7 @@ var _ = Describe("Operations with multiple cluster", func() {
 			defaultCluster.WithDefaults()
 			defaultCluster.Status.Init()
 			defaultCluster.ObjectMeta.Name = "zk1"
-			defaultCluster.Spec.Persistence.VolumeReclaimPolicy = "Delete"
+			defaultCluster.Spec.Storage.Persistence.VolumeReclaimPolicy = "Delete"
 
 			zk1, err = zk_e2eutil.CreateCluster(logger, k8sClient, defaultCluster)
 			Expect(err).NotTo(HaveOccurred())
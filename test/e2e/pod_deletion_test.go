@@ -26,7 +26,7 @@ var _ = Describe("Delete pods in zk clusters", func() {
 
 			defaultCluster.WithDefaults()
 			defaultCluster.Status.Init()
-			defaultCluster.Spec.Persistence.VolumeReclaimPolicy = "Delete"
+			defaultCluster.Spec.Storage.Persistence.VolumeReclaimPolicy = "Delete"
 			By("create zk cluster")
 			zk, err := zk_e2eutil.CreateCluster(logger, k8sClient, defaultCluster)
 			Expect(err).NotTo(HaveOccurred())
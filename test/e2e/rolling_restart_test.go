@@ -24,7 +24,7 @@ var _ = Describe("Perform rolling restart on zk cluster", func() {
 
 			cluster.WithDefaults()
 			cluster.Status.Init()
-			cluster.Spec.Persistence.VolumeReclaimPolicy = "Delete"
+			cluster.Spec.Storage.Persistence.VolumeReclaimPolicy = "Delete"
 
 			zk, err := zk_e2eutil.CreateCluster(logger, k8sClient, cluster)
 			Expect(err).NotTo(HaveOccurred())
@@ -46,7 +46,7 @@ var _ = Describe("Perform rolling restart on zk cluster", func() {
 			Expect(zk.GetTriggerRollingRestart()).To(Equal(false))
 
 			// Trigger a rolling restart
-			zk.Spec.TriggerRollingRestart = true
+			zk.SetTriggerRollingRestart(true)
 			err = zk_e2eutil.UpdateCluster(logger, k8sClient, zk)
 			// zk_e2eutil.WaitForClusterToBecomeReady(...) will return as soon as any pod is restarted as the cluster is briefly reported to be healthy even though the restart is not completed. this method is hence called after a sleep to ensure that the restart has completed before asserting the test cases.
 			time.Sleep(time.Duration(clusterCreateDuration) * 2 * time.Second)
@@ -64,7 +64,7 @@ var _ = Describe("Perform rolling restart on zk cluster", func() {
 			Expect(zk.GetTriggerRollingRestart()).To(Equal(false))
 
 			// Trigger a rolling restart again
-			zk.Spec.TriggerRollingRestart = true
+			zk.SetTriggerRollingRestart(true)
 			err = zk_e2eutil.UpdateCluster(logger, k8sClient, zk)
 			// zk_e2eutil.WaitForClusterToBecomeReady(...) will return as soon as any pod is restarted as the cluster is briefly reported to be healthy even though the complete restart is not completed. this method is hence called after a sleep to ensure that the restart has completed before asserting the test cases.
 			time.Sleep(time.Duration(clusterCreateDuration) * 2 * time.Second)
@@ -24,7 +24,7 @@ var _ = Describe("Perform scale for cluster upgrade", func() {
 			defaultCluster.WithDefaults()
 
 			defaultCluster.Status.Init()
-			defaultCluster.Spec.Persistence.VolumeReclaimPolicy = "Delete"
+			defaultCluster.Spec.Storage.Persistence.VolumeReclaimPolicy = "Delete"
 
 			zk, err := zk_e2eutil.CreateCluster(logger, k8sClient, defaultCluster)
 
@@ -13,8 +13,8 @@ package e2e
 import (
 	"context"
 	"github.com/go-logr/logr"
-	api "github.com/pravega/zookeeper-operator/api/v1beta1"
-	zookeeperv1beta1 "github.com/pravega/zookeeper-operator/api/v1beta1"
+	api "github.com/pravega/zookeeper-operator/api/v1"
+	zookeeperv1 "github.com/pravega/zookeeper-operator/api/v1"
 	zookeepercontroller "github.com/pravega/zookeeper-operator/controllers"
 	zkClient "github.com/pravega/zookeeper-operator/pkg/zk"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -67,7 +67,7 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	Expect(cfg).NotTo(BeNil())
 
-	err = zookeeperv1beta1.AddToScheme(scheme.Scheme)
+	err = zookeeperv1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
 	/*
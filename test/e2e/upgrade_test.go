@@ -13,7 +13,7 @@ package e2e
 import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
-	api "github.com/pravega/zookeeper-operator/api/v1beta1"
+	api "github.com/pravega/zookeeper-operator/api/v1"
 	zk_e2eutil "github.com/pravega/zookeeper-operator/pkg/test/e2e/e2eutil"
 )
 
@@ -25,7 +25,7 @@ var _ = Describe("Perform zk cluster upgrade", func() {
 
 			cluster.WithDefaults()
 			cluster.Status.Init()
-			cluster.Spec.Persistence.VolumeReclaimPolicy = "Delete"
+			cluster.Spec.Storage.Persistence.VolumeReclaimPolicy = "Delete"
 			initialVersion := "0.2.7"
 			upgradeVersion := "0.2.9"
 			cluster.Spec.Image = api.ContainerImage{